@@ -0,0 +1,179 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_demo/internal/config"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// config 命令参数
+var (
+	configNoSecrets bool   // 不解析密钥引用
+	configStrict    bool   // 未知配置项视为错误
+	configFormat    string // 输出格式
+)
+
+// configCmd 配置管理子命令
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "校验、查看和比较配置文件",
+	Long: `校验、查看和比较配置文件，无需启动服务。
+
+示例：
+  go_demo config validate --config ./configs/config.yaml   # 校验配置，CI 中可加 --no-secrets
+  go_demo config validate configs/*.yaml                   # 校验多个配置文件
+  go_demo config print --format json                       # 输出合并默认值和环境变量后的配置（已脱敏）
+  go_demo config diff dev prod                             # 比较两个环境的配置
+  go_demo config schema > configs/config.schema.json       # 生成 JSON Schema，供编辑器补全和校验`,
+}
+
+// configValidateCmd 校验配置文件
+var configValidateCmd = &cobra.Command{
+	Use:   "validate [file...]",
+	Short: "校验配置文件，未指定文件时校验 --config",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		files := args
+		if len(files) == 0 {
+			files = []string{configFile}
+		}
+
+		out := cmd.OutOrStdout()
+		failed := 0
+		for _, file := range files {
+			_, err := config.Parse(file, config.ParseOptions{Strict: configStrict, SkipSecrets: configNoSecrets})
+			if err == nil {
+				fmt.Fprintf(out, "✓ %s\n", file)
+				continue
+			}
+
+			failed++
+			fmt.Fprintf(out, "✗ %s\n", file)
+			var verr *config.ValidationError
+			if errors.As(err, &verr) {
+				for _, fe := range verr.Errors {
+					fmt.Fprintf(out, "    %s: %s\n", fe.Path, fe.Message)
+				}
+			} else {
+				fmt.Fprintf(out, "    %v\n", err)
+			}
+		}
+
+		if failed > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("%d 个配置文件校验失败", failed)
+		}
+		return nil
+	},
+}
+
+// configPrintCmd 输出生效的配置
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "输出合并默认值和环境变量后的生效配置，敏感项已脱敏",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := config.Parse(configFile, config.ParseOptions{SkipSecrets: configNoSecrets})
+		if err != nil {
+			return err
+		}
+		return writeFormatted(cmd.OutOrStdout(), config.Redacted(cfg))
+	},
+}
+
+// configDiffCmd 比较两份配置
+var configDiffCmd = &cobra.Command{
+	Use:   "diff <env|file> <env|file>",
+	Short: "比较两个环境或配置文件的生效配置",
+	Long: `比较两个环境或配置文件合并默认值后的生效配置，敏感项已脱敏。
+参数为文件路径或环境名称，环境名称对应 configs/config.<env>.yaml，不存在时为 configs/config.yaml。
+不解析密钥引用，比较的是 env://、vault:// 等引用本身。`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var cfgs [2]*config.Config
+		for i, arg := range args {
+			path := arg
+			if _, err := os.Stat(path); err != nil {
+				path = config.PathForEnv(arg)
+			}
+			cfg, err := config.Parse(path, config.ParseOptions{SkipSecrets: true})
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			cfgs[i] = cfg
+		}
+
+		changes := config.Diff(cfgs[0], cfgs[1])
+		if configFormat == "json" {
+			return writeFormatted(cmd.OutOrStdout(), changes)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(changes) == 0 {
+			fmt.Fprintln(out, "配置相同")
+			return nil
+		}
+		for _, c := range changes {
+			fmt.Fprintf(out, "%s:\n  - %s\n  + %s\n", c.Key, formatValue(c.Old), formatValue(c.New))
+		}
+		fmt.Fprintf(out, "共 %d 处不同\n", len(changes))
+		return nil
+	},
+}
+
+// configSchemaCmd 输出 JSON Schema
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "输出配置文件的 JSON Schema",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := json.MarshalIndent(config.Schema(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化 JSON Schema 失败: %w", err)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), string(data))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configValidateCmd, configPrintCmd, configDiffCmd, configSchemaCmd)
+
+	configCmd.PersistentFlags().BoolVar(&configNoSecrets, "no-secrets", false, "不解析 env://、file://、vault:// 密钥引用，用于没有密钥的环境（如 CI）")
+	configValidateCmd.Flags().BoolVar(&configStrict, "strict", true, "配置文件中出现未知配置项时报错")
+	configPrintCmd.Flags().StringVar(&configFormat, "format", "yaml", "输出格式: yaml, json")
+	configDiffCmd.Flags().StringVar(&configFormat, "format", "text", "输出格式: text, json")
+}
+
+// writeFormatted 按 --format 输出 yaml 或 json
+func writeFormatted(w io.Writer, v interface{}) error {
+	if configFormat == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return enc.Close()
+}
+
+// formatValue 以 JSON 形式显示配置值，未设置时显示 <unset>
+func formatValue(v interface{}) string {
+	if v == nil {
+		return "<unset>"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -9,13 +9,17 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"go_demo/internal/admin"
 	"go_demo/internal/config"
 	"go_demo/internal/di"
 	"go_demo/pkg/logger"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -71,10 +75,21 @@ func startServer() {
 
 	// 创建并启动HTTP服务器
 	srv := createHTTPServer(app)
-	startHTTPServer(srv)
+	startHTTPServer(srv, app)
+
+	// 启动运维管理监听（可选）
+	adminSrv := startAdminServer(app)
+
+	// 监听配置变化
+	stopWatch := watchConfig()
+	defer stopWatch()
 
 	// 等待关闭信号
-	waitForShutdown(srv)
+	waitForShutdown(srv, app)
+
+	if adminSrv != nil {
+		shutdownAdminServer(adminSrv)
+	}
 }
 
 // initServerApp 初始化服务器应用，带重试机制
@@ -94,6 +109,11 @@ func initServerApp() *di.ServerApp {
 			return app
 		}
 
+		// 配置错误重试无法恢复，直接退出
+		if errors.Is(err, config.ErrInvalidConfig) {
+			logger.Fatal("配置无效，请使用 go_demo config validate 检查", logger.Err(err))
+		}
+
 		if i < maxRetries-1 {
 			logger.Error("服务器初始化失败，正在重试...",
 				logger.Err(err),
@@ -156,21 +176,100 @@ func getMaxHeaderBytes(mb, defaultMB int) int {
 	return defaultMB << 20
 }
 
-// startHTTPServer 启动HTTP服务器（非阻塞）
-func startHTTPServer(srv *http.Server) {
+// startHTTPServer 启动HTTP服务器（非阻塞），监听成功后标记启动完成
+func startHTTPServer(srv *http.Server, app *di.ServerApp) {
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		logger.Fatal("服务器监听失败", logger.Err(err), logger.String("addr", srv.Addr))
+	}
+
 	go func() {
 		logger.Info("HTTP服务器启动",
 			logger.String("addr", srv.Addr),
 			logger.String("config", configFile))
 
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
 			logger.Fatal("服务器运行失败", logger.Err(err))
 		}
 	}()
+
+	if app.Health != nil {
+		app.Health.MarkStarted()
+	}
+}
+
+// startAdminServer 启动运维管理监听，未启用时返回 nil
+func startAdminServer(app *di.ServerApp) *http.Server {
+	cfg := config.GetConfig()
+	if cfg == nil || !cfg.Admin.Enabled {
+		return nil
+	}
+
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(cfg.Admin.Host, strconv.Itoa(cfg.Admin.Port)),
+		Handler:           admin.NewHandler(config.GetConfig, app.Engine),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		logger.Info("运维管理监听启动", logger.String("addr", srv.Addr))
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			// 管理接口不可用不影响业务
+			logger.Error("运维管理监听失败", logger.Err(err), logger.String("addr", srv.Addr))
+		}
+	}()
+	return srv
+}
+
+// shutdownAdminServer 关闭运维管理监听
+func shutdownAdminServer(srv *http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("运维管理监听关闭失败", logger.Err(err))
+	}
+}
+
+// watchConfig 监听配置文件变化和 SIGHUP 信号并热更新配置，返回停止函数
+func watchConfig() func() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+				logger.Info("收到 SIGHUP，重新加载配置", logger.String("config", configFile))
+				if _, err := config.Reload(configFile); err != nil {
+					logger.Error("配置热更新失败，继续使用当前配置", logger.Err(err))
+				}
+			}
+		}
+	}()
+
+	var watcher *config.Watcher
+	if cfg := config.GetConfig(); cfg != nil && cfg.Server.HotReload {
+		w, err := config.Watch(configFile)
+		if err != nil {
+			logger.Warn("配置文件监听启动失败，可通过 SIGHUP 手动重新加载", logger.Err(err))
+		} else {
+			watcher = w
+			logger.Info("配置文件监听已启动", logger.String("config", configFile))
+		}
+	}
+
+	return func() {
+		signal.Stop(hup)
+		close(done)
+		if watcher != nil {
+			_ = watcher.Close()
+		}
+	}
 }
 
 // waitForShutdown 等待关闭信号并优雅关闭服务器
-func waitForShutdown(srv *http.Server) {
+func waitForShutdown(srv *http.Server, app *di.ServerApp) {
 	// 等待中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -178,10 +277,27 @@ func waitForShutdown(srv *http.Server) {
 
 	logger.Info("收到关闭信号", logger.String("signal", sig.String()))
 
+	// 就绪探针先失败，等待负载均衡摘除流量
+	drainTraffic(app)
+
 	// 优雅关闭
 	gracefulShutdown(srv)
 }
 
+// drainTraffic 标记服务正在关闭，并按配置等待负载均衡摘除流量
+func drainTraffic(app *di.ServerApp) {
+	if app.Health == nil {
+		return
+	}
+	app.Health.SetShuttingDown()
+
+	delay := time.Duration(config.GetServerConfig().ShutdownDelay) * time.Second
+	if delay > 0 {
+		logger.Info("就绪探针已标记为失败，等待流量摘除", logger.Duration("delay", delay))
+		time.Sleep(delay)
+	}
+}
+
 // gracefulShutdown 优雅关闭HTTP服务器
 func gracefulShutdown(srv *http.Server) {
 	const shutdownTimeout = 30 * time.Second
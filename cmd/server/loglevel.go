@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go_demo/internal/config"
+	"go_demo/internal/models"
+	"go_demo/internal/utils"
+	"go_demo/pkg/logger"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// log-level 命令参数
+var (
+	adminAddr   string // 管理接口地址
+	levelValue  string // 全局日志级别
+	levelsValue string // 按名称设置的日志级别
+	levelTTL    string // 自动恢复时间
+	levelReset  bool   // 恢复为配置文件中的级别
+)
+
+// logLevelCmd 日志级别管理子命令
+var logLevelCmd = &cobra.Command{
+	Use:   "log-level",
+	Short: "查看或修改运行中服务的日志级别",
+	Long: `通过运维管理端口（admin.host:admin.port）查看或修改运行中服务的日志级别，无需重启，需要启用 admin.enabled。
+
+示例：
+  go_demo log-level                                  # 查看当前级别
+  go_demo log-level --level debug --ttl 10m          # 全局调整为 debug，10分钟后自动恢复
+  go_demo log-level --levels "repository=debug"      # 仅调整 repository 的级别
+  go_demo log-level --reset                          # 恢复为配置文件中的级别`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		method := http.MethodGet
+		var body []byte
+		switch {
+		case levelReset:
+			method = http.MethodDelete
+		case cmd.Flags().Changed("level") || cmd.Flags().Changed("levels") || levelTTL != "":
+			method = http.MethodPut
+			req := models.LogLevelRequest{Level: levelValue, TTL: levelTTL}
+			if cmd.Flags().Changed("levels") {
+				req.Levels = &levelsValue
+			}
+			body, _ = json.Marshal(req)
+		}
+
+		status, err := callLogLevelAPI(method, resolveAdminAddr()+"/log/level", body)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("level:  %s\n", status.Level)
+		fmt.Printf("levels: %s\n", logger.FormatLevels(status.Levels))
+		if status.RevertAt != nil {
+			fmt.Printf("revert: %s\n", status.RevertAt.Format(time.RFC3339))
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(logLevelCmd)
+
+	logLevelCmd.Flags().StringVar(&adminAddr, "addr", "", "管理接口地址（默认 http://<admin.host>:<admin.port>）")
+	logLevelCmd.Flags().StringVar(&levelValue, "level", "", "全局日志级别: debug, info, warn, error")
+	logLevelCmd.Flags().StringVar(&levelsValue, "levels", "", `按名称设置的级别，如 "repository=debug,webhook=info"，为空时清除`)
+	logLevelCmd.Flags().StringVar(&levelTTL, "ttl", "", "自动恢复时间，如 10m、1h")
+	logLevelCmd.Flags().BoolVar(&levelReset, "reset", false, "恢复为配置文件中的级别")
+}
+
+// resolveAdminAddr 确定管理接口地址：命令行参数优先，否则使用配置文件中的运维管理端口
+func resolveAdminAddr() string {
+	if adminAddr != "" {
+		return adminAddr
+	}
+	host, port := "127.0.0.1", 6060
+	if cfg, err := config.Load(configFile); err == nil {
+		if cfg.Admin.Host != "" && cfg.Admin.Host != "0.0.0.0" && cfg.Admin.Host != "::" {
+			host = cfg.Admin.Host
+		}
+		if cfg.Admin.Port > 0 {
+			port = cfg.Admin.Port
+		}
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// callLogLevelAPI 调用日志级别管理接口
+func callLogLevelAPI(method, url string, body []byte) (*logger.LevelStatus, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求管理接口失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var status logger.LevelStatus
+	result := utils.Response{Data: &status}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("修改日志级别失败(%d): %s", resp.StatusCode, result.Message)
+	}
+	return &status, nil
+}
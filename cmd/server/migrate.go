@@ -0,0 +1,248 @@
+package server
+
+import (
+	"fmt"
+	"go_demo/internal/config"
+	"go_demo/internal/migrations"
+	"go_demo/pkg/database"
+	"go_demo/pkg/logger"
+	"go_demo/pkg/migrate"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// migrationNamePattern 新建迁移的名称格式
+var migrationNamePattern = regexp.MustCompile(`^[a-z0-9]+(_[a-z0-9]+)*$`)
+
+// migrateCmd 数据库迁移子命令
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "执行数据库迁移",
+	Long: `执行版本化的数据库迁移，迁移记录保存在 schema_migrations 表中。
+执行期间持有数据库锁，多个副本同时执行时不会重复迁移；已执行的迁移被修改时拒绝继续。
+
+示例：
+  go_demo migrate up                  # 执行全部待执行的迁移
+  go_demo migrate status              # 查看迁移状态
+  go_demo migrate down 2              # 回滚最近2个迁移
+  go_demo migrate to 1                # 迁移到版本1（升级或回滚）
+  go_demo migrate redo                # 回滚并重新执行最近一个迁移，开发时使用
+  go_demo migrate create add_orders   # 在 internal/migrations/sql/<数据库> 下创建新的 SQL 迁移
+  go_demo migrate seed                # 创建开发和测试用的种子数据`,
+}
+
+// migrateUpCmd 执行全部待执行的迁移
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "执行全部待执行的迁移",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(cmd, func(m *migrate.Migrator, _ *gorm.DB) error {
+			done, err := m.Up(cmd.Context())
+			return printMigrations(cmd.OutOrStdout(), "已执行", done, err)
+		})
+	},
+}
+
+// migrateDownCmd 回滚迁移
+var migrateDownCmd = &cobra.Command{
+	Use:   "down [n]",
+	Short: "回滚最近执行的 n 个迁移，默认为1",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		steps := 1
+		if len(args) == 1 {
+			n, err := strconv.Atoi(args[0])
+			if err != nil || n <= 0 {
+				return fmt.Errorf("回滚数量必须是正整数: %s", args[0])
+			}
+			steps = n
+		}
+		return withMigrator(cmd, func(m *migrate.Migrator, _ *gorm.DB) error {
+			done, err := m.Down(cmd.Context(), steps)
+			return printMigrations(cmd.OutOrStdout(), "已回滚", done, err)
+		})
+	},
+}
+
+// migrateStatusCmd 查看迁移状态
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "查看迁移状态",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(cmd, func(m *migrate.Migrator, _ *gorm.DB) error {
+			statuses, err := m.Status(cmd.Context())
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tNAME\tSOURCE\tSTATE\tAPPLIED AT")
+			for _, s := range statuses {
+				appliedAt := "-"
+				if s.AppliedAt != nil {
+					appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", s.Version, s.Name, s.Source, s.State, appliedAt)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+// migrateToCmd 迁移到指定版本
+var migrateToCmd = &cobra.Command{
+	Use:   "to <version>",
+	Short: "迁移到指定版本，高于该版本的迁移将被回滚，0 表示回滚全部",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("版本号必须是非负整数: %s", args[0])
+		}
+		return withMigrator(cmd, func(m *migrate.Migrator, _ *gorm.DB) error {
+			done, err := m.To(cmd.Context(), version)
+			return printMigrations(cmd.OutOrStdout(), "已迁移", done, err)
+		})
+	},
+}
+
+// migrateRedoCmd 重新执行最近一个迁移
+var migrateRedoCmd = &cobra.Command{
+	Use:   "redo",
+	Short: "回滚并重新执行最近一个迁移",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(cmd, func(m *migrate.Migrator, _ *gorm.DB) error {
+			redone, err := m.Redo(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return printMigrations(cmd.OutOrStdout(), "已重新执行", []*migrate.Migration{redone}, nil)
+		})
+	},
+}
+
+// migrateCreateCmd 创建 SQL 迁移文件
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "在 internal/migrations/sql 下为每个数据库创建下一个版本的 up/down SQL 文件",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if !migrationNamePattern.MatchString(name) {
+			return fmt.Errorf("迁移名称只能包含小写字母、数字和下划线: %s", name)
+		}
+
+		version, err := nextMigrationVersion()
+		if err != nil {
+			return err
+		}
+		for _, dialect := range migrations.Dialects {
+			for _, direction := range []string{"up", "down"} {
+				file := filepath.Join(migrations.Dir, dialect, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+				content := fmt.Sprintf("-- %04d_%s %s (%s)\n", version, name, direction, dialect)
+				if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+					return fmt.Errorf("创建迁移文件失败: %w", err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), file)
+			}
+		}
+		return nil
+	},
+}
+
+// migrateSeedCmd 创建种子数据
+var migrateSeedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "创建开发和测试用的种子数据，已存在的数据跳过",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withMigrator(cmd, func(_ *migrate.Migrator, db *gorm.DB) error {
+			return migrations.Seed(db.WithContext(cmd.Context()))
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migrateToCmd,
+		migrateRedoCmd, migrateCreateCmd, migrateSeedCmd)
+}
+
+// withMigrator 加载配置、连接数据库并创建迁移执行器
+func withMigrator(cmd *cobra.Command, fn func(m *migrate.Migrator, db *gorm.DB) error) error {
+	cmd.SilenceUsage = true
+
+	cfg, err := config.Load(configFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %w", err)
+	}
+	if err := logger.Init(cfg.Log); err != nil {
+		return fmt.Errorf("初始化日志失败: %w", err)
+	}
+	defer logger.Sync()
+
+	// 迁移只使用主库，建索引等语句耗时较长，不设置查询超时
+	dbConfig := cfg.Database
+	dbConfig.Replication.Replicas = nil
+	dbConfig.QueryTimeout = 0
+	db, err := database.Open(dbConfig)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	all, err := migrations.All(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	m, err := migrate.New(db, all, migrate.DefaultConfig())
+	if err != nil {
+		return err
+	}
+	return fn(m, db)
+}
+
+// nextMigrationVersion 下一个迁移版本号，取各数据库源码目录和已编译迁移中的最大版本号加1
+func nextMigrationVersion() (int64, error) {
+	if _, err := os.Stat(migrations.Dir); err != nil {
+		return 0, fmt.Errorf("请在项目根目录执行: %w", err)
+	}
+
+	var latest int64
+	for _, dialect := range migrations.Dialects {
+		onDisk, err := migrate.LoadFS(os.DirFS(filepath.Join(migrations.Dir, dialect)), ".")
+		if err != nil {
+			return 0, err
+		}
+		compiled, err := migrations.All(dialect)
+		if err != nil {
+			return 0, err
+		}
+		for _, m := range append(onDisk, compiled...) {
+			if m.Version > latest {
+				latest = m.Version
+			}
+		}
+	}
+	return latest + 1, nil
+}
+
+// printMigrations 输出本次执行的迁移，出错时已执行的部分仍然输出，返回 err
+func printMigrations(w io.Writer, action string, done []*migrate.Migration, err error) error {
+	for _, m := range done {
+		fmt.Fprintf(w, "%s %d_%s\n", action, m.Version, m.Name)
+	}
+	if len(done) == 0 && err == nil {
+		fmt.Fprintln(w, "没有需要执行的迁移")
+	}
+	return err
+}
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"bufio"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go_demo/internal/config"
+	"go_demo/pkg/secrets"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// secrets 命令参数
+var (
+	vaultPath    string // 密钥库文件路径
+	vaultKeyFile string // 主密钥文件路径
+)
+
+// secretsCmd 本地加密密钥库管理子命令
+var secretsCmd = &cobra.Command{
+	Use:   "secrets",
+	Short: "管理本地加密密钥库（vault:// 引用）",
+	Long: `管理配置中 vault:// 引用的本地加密密钥库，条目使用 AES-256-GCM 加密。
+主密钥读取顺序：环境变量 GO_DEMO_VAULT_KEY，其次为 --key-file（默认读取配置文件 secrets.key_file）。
+
+示例：
+  go_demo secrets init                              # 生成主密钥文件
+  go_demo secrets set jwt_secret_key                # 从标准输入读取值，避免留在 shell 历史中
+  go_demo secrets get jwt_secret_key
+  go_demo secrets list
+  go_demo secrets rotate                            # 更换主密钥并重新加密全部条目`,
+}
+
+// secretsInitCmd 生成主密钥
+var secretsInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "生成主密钥文件",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, keyFile, err := resolveVaultPaths()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(keyFile); err == nil {
+			return fmt.Errorf("主密钥文件已存在: %s", keyFile)
+		}
+		key, err := secrets.GenerateKey()
+		if err != nil {
+			return err
+		}
+		if err := secrets.WriteKeyFile(keyFile, key); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "已生成主密钥: %s，请妥善备份，不要提交到代码仓库\n", keyFile)
+		return nil
+	},
+}
+
+// secretsSetCmd 设置条目
+var secretsSetCmd = &cobra.Command{
+	Use:   "set <name> [value]",
+	Short: "设置密钥，未提供 value 或为 - 时从标准输入读取",
+	Args:  cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var value string
+		if len(args) == 2 && args[1] != "-" {
+			value = args[1]
+		} else {
+			var err error
+			if value, err = readSecretValue(cmd.InOrStdin()); err != nil {
+				return err
+			}
+		}
+
+		vault, err := openVault()
+		if err != nil {
+			return err
+		}
+		if err := vault.Set(args[0], value); err != nil {
+			return err
+		}
+		if err := vault.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "已保存 %s\n", args[0])
+		return nil
+	},
+}
+
+// secretsGetCmd 读取条目
+var secretsGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "读取并输出密钥",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		vault, err := openVault()
+		if err != nil {
+			return err
+		}
+		value, err := vault.Get(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), value)
+		return nil
+	},
+}
+
+// secretsListCmd 列出条目名称
+var secretsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出密钥名称",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		vault, err := openVault()
+		if err != nil {
+			return err
+		}
+		for _, name := range vault.Names() {
+			fmt.Fprintln(cmd.OutOrStdout(), name)
+		}
+		return nil
+	},
+}
+
+// secretsDeleteCmd 删除条目
+var secretsDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "删除密钥",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		vault, err := openVault()
+		if err != nil {
+			return err
+		}
+		if err := vault.Delete(args[0]); err != nil {
+			return err
+		}
+		return vault.Save()
+	},
+}
+
+// secretsRotateCmd 更换主密钥
+var secretsRotateCmd = &cobra.Command{
+	Use:   "rotate",
+	Short: "生成新的主密钥并重新加密全部条目",
+	Long: `生成新的主密钥并重新加密全部条目。
+使用主密钥文件时，新密钥先写入 <key-file>.new，密钥库保存成功后再替换原文件；
+若中途失败，密钥库对应的主密钥以 .new 文件为准。
+使用 GO_DEMO_VAULT_KEY 环境变量时，新密钥输出到标准输出，需自行更新环境变量。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, keyFile, err := resolveVaultPaths()
+		if err != nil {
+			return err
+		}
+		vault, err := openVault()
+		if err != nil {
+			return err
+		}
+		newKey, err := secrets.GenerateKey()
+		if err != nil {
+			return err
+		}
+		if err := vault.Rotate(newKey); err != nil {
+			return err
+		}
+
+		if os.Getenv(secrets.KeyEnv) != "" {
+			if err := vault.Save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "主密钥已更换，请将 %s 更新为：\n", secrets.KeyEnv)
+			fmt.Fprintln(cmd.OutOrStdout(), base64.StdEncoding.EncodeToString(newKey))
+			return nil
+		}
+
+		pending := keyFile + ".new"
+		if err := secrets.WriteKeyFile(pending, newKey); err != nil {
+			return err
+		}
+		if err := vault.Save(); err != nil {
+			_ = os.Remove(pending)
+			return err
+		}
+		if err := os.Rename(pending, keyFile); err != nil {
+			return fmt.Errorf("密钥库已使用新主密钥保存，但替换主密钥文件失败，请手动将 %s 重命名为 %s: %w", pending, keyFile, err)
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "主密钥已更换，共重新加密 %d 个条目\n", len(vault.Names()))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(secretsCmd)
+	secretsCmd.AddCommand(secretsInitCmd, secretsSetCmd, secretsGetCmd, secretsListCmd, secretsDeleteCmd, secretsRotateCmd)
+
+	secretsCmd.PersistentFlags().StringVar(&vaultPath, "vault", "", "密钥库文件路径（默认读取配置文件 secrets.vault_path）")
+	secretsCmd.PersistentFlags().StringVar(&vaultKeyFile, "key-file", "", "主密钥文件路径（默认读取配置文件 secrets.key_file）")
+}
+
+// resolveVaultPaths 确定密钥库和主密钥文件路径：命令行参数优先，否则使用配置文件中的 secrets 配置
+func resolveVaultPaths() (string, string, error) {
+	path, keyFile := vaultPath, vaultKeyFile
+	if path == "" || keyFile == "" {
+		cfg, err := config.LoadSecretsConfig(configFile)
+		if err != nil {
+			return "", "", err
+		}
+		if path == "" {
+			path = cfg.VaultPath
+		}
+		if keyFile == "" {
+			keyFile = cfg.KeyFile
+		}
+	}
+	return path, keyFile, nil
+}
+
+// openVault 加载主密钥并打开密钥库
+func openVault() (*secrets.Vault, error) {
+	path, keyFile, err := resolveVaultPaths()
+	if err != nil {
+		return nil, err
+	}
+	key, err := secrets.LoadKey(keyFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("主密钥文件不存在，请先执行 go_demo secrets init: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secrets.OpenVault(path, key)
+}
+
+// readSecretValue 从标准输入读取一行作为密钥值
+func readSecretValue(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("读取标准输入失败: %w", err)
+	}
+	value := strings.TrimRight(line, "\r\n")
+	if value == "" {
+		return "", fmt.Errorf("密钥值不能为空")
+	}
+	return value, nil
+}
@@ -0,0 +1,144 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go_demo/internal/config"
+	"go_demo/internal/di"
+	"go_demo/internal/models"
+	"go_demo/internal/scheduler"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// taskHistoryLimit 查询的执行记录数量
+var taskHistoryLimit int
+
+// taskCmd 定时任务子命令
+var taskCmd = &cobra.Command{
+	Use:   "task",
+	Short: "查看和手动执行定时任务",
+	Long: `查看已注册的定时任务、手动执行任务和查询执行记录。
+任务的执行计划来自配置文件的 maintenance 配置，由 server 或 worker 进程按计划执行（scheduler.enabled）。
+手动执行与按计划执行使用同一个分布式锁，任务正在其他实例执行时直接返回。
+
+示例：
+  go_demo task list                                 # 查看全部任务和下次执行时间
+  go_demo task run purge_deleted_users              # 立即执行任务
+  go_demo task history                              # 查看最近的执行记录
+  go_demo task history compact_audit_logs --limit 5 # 查看指定任务的执行记录`,
+}
+
+// taskListCmd 查看全部任务
+var taskListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "查看全部定时任务和下次执行时间",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withScheduler(cmd, func(s *scheduler.Scheduler) error {
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tSCHEDULE\tNEXT RUN\tDESCRIPTION")
+			for _, task := range s.Tasks() {
+				schedule, next := "-", "-"
+				if task.Schedule != "" {
+					schedule = task.Schedule
+				}
+				if !task.Next.IsZero() {
+					next = task.Next.Format("2006-01-02 15:04:05")
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", task.Name, schedule, next, task.Description)
+			}
+			return w.Flush()
+		})
+	},
+}
+
+// taskRunCmd 立即执行任务
+var taskRunCmd = &cobra.Command{
+	Use:   "run <name>",
+	Short: "立即执行定时任务并输出执行结果",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return withScheduler(cmd, func(s *scheduler.Scheduler) error {
+			run, err := s.RunNow(cmd.Context(), args[0])
+			if run != nil {
+				printTaskRuns(cmd.OutOrStdout(), []models.TaskRun{*run})
+			}
+			if errors.Is(err, scheduler.ErrUnknownTask) {
+				return fmt.Errorf("%w，使用 go_demo task list 查看全部任务", err)
+			}
+			return err
+		})
+	},
+}
+
+// taskHistoryCmd 查询执行记录
+var taskHistoryCmd = &cobra.Command{
+	Use:   "history [name]",
+	Short: "按开始时间倒序查看执行记录，不指定任务时查看全部任务",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if taskHistoryLimit <= 0 {
+			return fmt.Errorf("--limit 必须是正整数: %d", taskHistoryLimit)
+		}
+		var name string
+		if len(args) == 1 {
+			name = args[0]
+		}
+		return withScheduler(cmd, func(s *scheduler.Scheduler) error {
+			runs, err := s.History(cmd.Context(), name, taskHistoryLimit)
+			if err != nil {
+				return fmt.Errorf("查询执行记录失败: %w", err)
+			}
+			if len(runs) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "没有执行记录")
+				return nil
+			}
+			printTaskRuns(cmd.OutOrStdout(), runs)
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(taskCmd)
+	taskCmd.AddCommand(taskListCmd, taskRunCmd, taskHistoryCmd)
+	taskHistoryCmd.Flags().IntVar(&taskHistoryLimit, "limit", 20, "输出的执行记录数量")
+}
+
+// withScheduler 初始化应用依赖并获取定时任务调度器，不启动按计划执行
+func withScheduler(cmd *cobra.Command, fn func(s *scheduler.Scheduler) error) error {
+	cmd.SilenceUsage = true
+
+	app, err := di.InitializeWorkerApp(configFile)
+	if err != nil {
+		if errors.Is(err, config.ErrInvalidConfig) {
+			return fmt.Errorf("配置无效，请使用 go_demo config validate 检查: %w", err)
+		}
+		return fmt.Errorf("应用初始化失败: %w", err)
+	}
+	defer app.Cleanup()
+	return fn(app.Scheduler)
+}
+
+// printTaskRuns 输出执行记录
+func printTaskRuns(out io.Writer, runs []models.TaskRun) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTASK\tTRIGGER\tSTATUS\tSTARTED AT\tDURATION\tAFFECTED\tHOST\tERROR")
+	for _, run := range runs {
+		duration := "-"
+		if run.FinishedAt != nil {
+			duration = (time.Duration(run.DurationMs) * time.Millisecond).String()
+		}
+		errMsg := run.Error
+		if errMsg == "" {
+			errMsg = "-"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\n", run.ID, run.Task, run.TriggeredBy, run.Status,
+			run.StartedAt.Format("2006-01-02 15:04:05"), duration, run.Affected, run.Host, errMsg)
+	}
+	_ = w.Flush()
+}
@@ -0,0 +1,27 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"go_demo/pkg/buildinfo"
+
+	"github.com/spf13/cobra"
+)
+
+// versionCmd 版本信息子命令
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "查看版本和构建信息",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out, err := json.MarshalIndent(buildinfo.Get(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化构建信息失败: %w", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"go_demo/internal/config"
+	"go_demo/internal/di"
+	"go_demo/pkg/logger"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// workerQueues 只执行指定的队列（覆盖配置文件）
+var workerQueues []string
+
+// workerCmd 后台作业执行子命令
+var workerCmd = &cobra.Command{
+	Use:   "worker",
+	Short: "启动后台作业执行进程",
+	Long: `从作业队列中取出并执行后台作业，与 API 进程分开部署和扩容。
+队列和并发数来自配置文件的 jobs.queues；收到 SIGINT 或 SIGTERM 后停止取出新作业，
+等待正在执行的作业完成，超过 jobs.shutdown_timeout 后中断剩余作业并放回队列。
+scheduler.enabled 为 true 时同时按计划执行定时任务。
+
+示例：
+  go_demo worker                        # 执行全部已配置的队列
+  go_demo worker --queues default,mail  # 只执行指定的队列`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWorker()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(workerCmd)
+	workerCmd.Flags().StringSliceVar(&workerQueues, "queues", nil, "只执行指定的队列，多个队列用逗号分隔")
+}
+
+// runWorker 启动后台作业执行，直到收到关闭信号
+func runWorker() error {
+	app, err := di.InitializeWorkerApp(configFile)
+	if err != nil {
+		if errors.Is(err, config.ErrInvalidConfig) {
+			return fmt.Errorf("配置无效，请使用 go_demo config validate 检查: %w", err)
+		}
+		return fmt.Errorf("后台作业应用初始化失败: %w", err)
+	}
+	defer app.Cleanup()
+
+	if len(workerQueues) > 0 {
+		if err := app.Worker.LimitQueues(workerQueues...); err != nil {
+			return err
+		}
+	}
+
+	app.Worker.Start()
+	logger.Info("后台作业执行已启动",
+		logger.Any("queues", app.Worker.Queues()),
+		logger.Any("types", app.Worker.Types()),
+		logger.String("backend", app.Config.Jobs.Backend),
+		logger.String("config", configFile))
+
+	if app.Config.Scheduler.Enabled {
+		app.Scheduler.Start()
+		logger.Info("定时任务调度已启动", logger.Int("tasks", len(app.Scheduler.Tasks())))
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-quit
+	logger.Info("收到关闭信号，等待正在执行的作业完成",
+		logger.String("signal", sig.String()),
+		logger.Duration("timeout", app.Config.Jobs.ShutdownTimeout))
+	// app.Cleanup 先停止作业执行和定时任务并等待正在执行的作业，再关闭缓存和数据库连接
+	return nil
+}
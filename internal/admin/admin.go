@@ -0,0 +1,175 @@
+// Package admin 运维管理接口，在独立端口上提供 pprof、运行时统计、构建信息、脱敏配置、路由表和日志级别管理
+// 与业务接口分开监听，默认只绑定本机地址
+package admin
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go_demo/internal/config"
+	"go_demo/internal/handler"
+	"go_demo/pkg/buildinfo"
+)
+
+// publishOnce expvar 变量只能发布一次
+var publishOnce sync.Once
+
+// RuntimeStats 运行时统计
+type RuntimeStats struct {
+	Goroutines   int    `json:"goroutines"`
+	NumCPU       int    `json:"num_cpu"`
+	GOMAXPROCS   int    `json:"gomaxprocs"`
+	CgoCalls     int64  `json:"cgo_calls"`
+	HeapAlloc    uint64 `json:"heap_alloc"`    // 已分配的堆内存（字节）
+	HeapInuse    uint64 `json:"heap_inuse"`    // 正在使用的堆内存（字节）
+	HeapObjects  uint64 `json:"heap_objects"`  // 堆对象数量
+	TotalAlloc   uint64 `json:"total_alloc"`   // 累计分配内存（字节）
+	Sys          uint64 `json:"sys"`           // 从系统获取的内存（字节）
+	StackInuse   uint64 `json:"stack_inuse"`   // 栈内存（字节）
+	Mallocs      uint64 `json:"mallocs"`       // 累计分配次数
+	Frees        uint64 `json:"frees"`         // 累计释放次数
+	NumGC        uint32 `json:"num_gc"`        // GC 次数
+	NumForcedGC  uint32 `json:"num_forced_gc"` // 手动触发的 GC 次数
+	PauseTotal   string `json:"pause_total"`   // GC 累计暂停时间
+	LastPause    string `json:"last_pause"`    // 最近一次 GC 暂停时间
+	LastGC       string `json:"last_gc,omitempty"`
+	NextGC       uint64 `json:"next_gc"` // 下次 GC 的堆大小目标（字节）
+	GCCPUPercent string `json:"gc_cpu_percent"`
+}
+
+// Route 路由信息
+type Route struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Handler string `json:"handler"`
+}
+
+// ReadRuntimeStats 读取当前运行时统计
+func ReadRuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		Goroutines:   runtime.NumGoroutine(),
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		CgoCalls:     runtime.NumCgoCall(),
+		HeapAlloc:    m.HeapAlloc,
+		HeapInuse:    m.HeapInuse,
+		HeapObjects:  m.HeapObjects,
+		TotalAlloc:   m.TotalAlloc,
+		Sys:          m.Sys,
+		StackInuse:   m.StackInuse,
+		Mallocs:      m.Mallocs,
+		Frees:        m.Frees,
+		NumGC:        m.NumGC,
+		NumForcedGC:  m.NumForcedGC,
+		PauseTotal:   time.Duration(m.PauseTotalNs).String(),
+		LastPause:    time.Duration(m.PauseNs[(m.NumGC+255)%256]).String(),
+		NextGC:       m.NextGC,
+		GCCPUPercent: fmt.Sprintf("%.4f%%", m.GCCPUFraction*100),
+	}
+	if m.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(m.LastGC)).Format(time.RFC3339)
+	}
+	return stats
+}
+
+// NewHandler 创建运维管理接口
+// configFn 返回当前生效的配置，engine 为业务接口引擎，用于输出路由表
+func NewHandler(configFn func() *config.Config, engine *gin.Engine) http.Handler {
+	publishOnce.Do(func() {
+		expvar.Publish("runtime", expvar.Func(func() interface{} { return ReadRuntimeStats() }))
+		expvar.Publish("build", expvar.Func(func() interface{} { return buildinfo.Get() }))
+	})
+
+	mux := http.NewServeMux()
+
+	// pprof
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	// expvar 格式的全部变量，包含 memstats、cmdline 以及上面发布的运行时统计和构建信息
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	mux.HandleFunc("/runtime", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, ReadRuntimeStats())
+	})
+	mux.HandleFunc("/buildinfo", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, buildinfo.Get())
+	})
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, config.Redacted(configFn()))
+	})
+	mux.HandleFunc("/routes", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, routes(engine))
+	})
+	mux.Handle("/log/level", logLevelHandler())
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, map[string]string{
+			"/debug/pprof/": "pprof 性能分析",
+			"/debug/vars":   "expvar 变量",
+			"/runtime":      "运行时统计",
+			"/buildinfo":    "构建信息",
+			"/config":       "当前生效的配置（已脱敏）",
+			"/routes":       "已注册的路由",
+			"/log/level":    "查看（GET）、修改（PUT）或恢复（DELETE）日志级别",
+		})
+	})
+	return mux
+}
+
+// logLevelHandler 日志级别管理接口，复用业务接口的请求和响应格式
+func logLevelHandler() http.Handler {
+	h := handler.NewLogLevelHandler()
+	engine := gin.New()
+	engine.GET("/log/level", h.GetLevel)
+	engine.PUT("/log/level", h.SetLevel)
+	engine.DELETE("/log/level", h.ResetLevel)
+	return engine
+}
+
+// routes 获取业务接口的路由表，按路径和方法排序
+func routes(engine *gin.Engine) []Route {
+	if engine == nil {
+		return []Route{}
+	}
+	infos := engine.Routes()
+	out := make([]Route, 0, len(infos))
+	for _, info := range infos {
+		out = append(out, Route{Method: info.Method, Path: info.Path, Handler: info.Handler})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+// writeJSON 输出格式化的JSON
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
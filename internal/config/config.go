@@ -2,91 +2,188 @@ package config
 
 import (
 	"fmt"
+	"go_demo/internal/events"
+	"go_demo/internal/maintenance"
+	"go_demo/internal/repository"
+	"go_demo/internal/scheduler"
 	"go_demo/internal/utils"
+	"go_demo/internal/webhook"
+	"go_demo/pkg/cache"
 	"go_demo/pkg/database"
+	"go_demo/pkg/jobs"
 	"go_demo/pkg/logger"
+	"go_demo/pkg/tracing"
 	"os"
+	"reflect"
 	"strings"
+	"sync/atomic"
 
 	"github.com/spf13/viper"
 )
 
 // Config 应用配置结构
 type Config struct {
-	Server   ServerConfig         `mapstructure:"server" yaml:"server"`
-	Database database.MySQLConfig `mapstructure:"database" yaml:"database"`
-	JWT      utils.JWTConfig      `mapstructure:"jwt" yaml:"jwt"`
-	Log      logger.LogConfig     `mapstructure:"log" yaml:"log"`
-	Redis    RedisConfig          `mapstructure:"redis" yaml:"redis"`
+	Server      ServerConfig               `mapstructure:"server" yaml:"server"`
+	Database    database.Config            `mapstructure:"database" yaml:"database"`
+	JWT         utils.JWTConfig            `mapstructure:"jwt" yaml:"jwt"`
+	Log         logger.LogConfig           `mapstructure:"log" yaml:"log"`
+	Redis       RedisConfig                `mapstructure:"redis" yaml:"redis"`
+	Cache       cache.Config               `mapstructure:"cache" yaml:"cache"`
+	UserCache   repository.UserCacheConfig `mapstructure:"user_cache" yaml:"user_cache"`
+	Tracing     tracing.Config             `mapstructure:"tracing" yaml:"tracing"`
+	Events      events.Config              `mapstructure:"events" yaml:"events"`
+	Webhook     webhook.Config             `mapstructure:"webhook" yaml:"webhook"`
+	Jobs        jobs.Config                `mapstructure:"jobs" yaml:"jobs"`
+	Scheduler   scheduler.Config           `mapstructure:"scheduler" yaml:"scheduler"`
+	Maintenance maintenance.Config         `mapstructure:"maintenance" yaml:"maintenance"`
+	Admin       AdminConfig                `mapstructure:"admin" yaml:"admin"`
+	CORS        CORSConfig                 `mapstructure:"cors" yaml:"cors"`             // 支持热更新
+	RateLimit   RateLimitConfig            `mapstructure:"rate_limit" yaml:"rate_limit"` // 支持热更新
+	Secrets     SecretsConfig              `mapstructure:"secrets" yaml:"secrets"`
+
+	secrets secretSet // 从密钥引用解析出的值，脱敏时使用
 }
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Port         int    `mapstructure:"port" yaml:"port"`
-	Mode         string `mapstructure:"mode" yaml:"mode"`                   // debug, release, test
-	ReadTimeout  int    `mapstructure:"read_timeout" yaml:"read_timeout"`   // 秒
-	WriteTimeout int    `mapstructure:"write_timeout" yaml:"write_timeout"` // 秒
-	MaxHeaderMB  int    `mapstructure:"max_header_mb" yaml:"max_header_mb"` // MB
+	Port           int      `mapstructure:"port" yaml:"port" validate:"required,min=1,max=65535"`
+	Mode           string   `mapstructure:"mode" yaml:"mode" validate:"oneof=debug release test"`           // debug, release, test
+	ReadTimeout    int      `mapstructure:"read_timeout" yaml:"read_timeout" validate:"min=0"`              // 秒
+	WriteTimeout   int      `mapstructure:"write_timeout" yaml:"write_timeout" validate:"min=0"`            // 秒
+	MaxHeaderMB    int      `mapstructure:"max_header_mb" yaml:"max_header_mb" validate:"min=0"`            // MB
+	ShutdownDelay  int      `mapstructure:"shutdown_delay" yaml:"shutdown_delay" validate:"min=0"`          // 秒，收到关闭信号后就绪探针先失败，等待负载均衡摘除流量再关闭
+	HotReload      bool     `mapstructure:"hot_reload" yaml:"hot_reload"`                                   // 是否监听配置文件变化并热更新
+	TrustedProxies []string `mapstructure:"trusted_proxies" yaml:"trusted_proxies" validate:"dive,ip|cidr"` // 信任的反向代理地址或网段，只有来自这些地址的请求才按 X-Forwarded-For 等头确定客户端IP；为空时使用连接的远端地址
+	AdminUsers     []string `mapstructure:"admin_users" yaml:"admin_users"`                                 // 管理员用户名，可以访问 Webhook 订阅等管理接口，为空时任何用户都不能访问；支持热更新
+}
+
+// CORSConfig 跨域配置
+type CORSConfig struct {
+	AllowOrigins     []string `mapstructure:"allow_origins" yaml:"allow_origins" validate:"dive,required"` // 允许的来源，"*" 表示全部，支持 "https://*.example.com" 形式的子域名通配
+	AllowCredentials bool     `mapstructure:"allow_credentials" yaml:"allow_credentials"`                  // 是否允许携带凭据
+	MaxAge           int      `mapstructure:"max_age" yaml:"max_age" validate:"min=0"`                     // 预检请求缓存时间（秒），0 表示不设置
+}
+
+// RateLimitConfig 限流配置，按客户端IP做固定窗口计数
+type RateLimitConfig struct {
+	Enabled  bool `mapstructure:"enabled" yaml:"enabled"`                    // 是否启用
+	Requests int  `mapstructure:"requests" yaml:"requests" validate:"min=0"` // 每个窗口允许的请求数
+	Window   int  `mapstructure:"window" yaml:"window" validate:"min=0"`     // 窗口长度（秒）
+}
+
+// AdminConfig 运维管理监听配置，提供 pprof、运行时统计、构建信息等，与业务接口分开监听
+type AdminConfig struct {
+	Enabled bool   `mapstructure:"enabled" yaml:"enabled"`                            // 是否启用
+	Host    string `mapstructure:"host" yaml:"host" validate:"omitempty,ip|hostname"` // 监听地址，默认仅本机访问
+	Port    int    `mapstructure:"port" yaml:"port" validate:"min=0,max=65535"`       // 监听端口
 }
 
 // RedisConfig Redis配置
 type RedisConfig struct {
-	Host         string `mapstructure:"host" yaml:"host"`
-	Port         int    `mapstructure:"port" yaml:"port"`
+	Host         string `mapstructure:"host" yaml:"host" validate:"required"`
+	Port         int    `mapstructure:"port" yaml:"port" validate:"min=1,max=65535"`
 	Password     string `mapstructure:"Password" yaml:"Password"`
-	DB           int    `mapstructure:"db" yaml:"db"`
-	PoolSize     int    `mapstructure:"pool_size" yaml:"pool_size"`
-	MinIdleConns int    `mapstructure:"min_idle_conns" yaml:"min_idle_conns"`
-	MaxRetries   int    `mapstructure:"max_retries" yaml:"max_retries"`
+	DB           int    `mapstructure:"db" yaml:"db" validate:"min=0"`
+	PoolSize     int    `mapstructure:"pool_size" yaml:"pool_size" validate:"min=0"`
+	MinIdleConns int    `mapstructure:"min_idle_conns" yaml:"min_idle_conns" validate:"min=0"`
+	MaxRetries   int    `mapstructure:"max_retries" yaml:"max_retries" validate:"min=0"`
 }
 
-// 全局配置实例
-var GlobalConfig *Config
+// current 当前生效的配置，热更新时整体原子替换，读取方不应修改返回的配置
+var current atomic.Pointer[Config]
 
-// Load 加载配置文件
+// Load 加载配置文件并设置为当前生效的配置
 func Load(configPath string) (*Config, error) {
-	viper.SetConfigFile(configPath)
-	viper.SetConfigType("yaml")
+	config, err := parse(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 设置全局配置
+	current.Store(config)
+
+	return config, nil
+}
+
+// ParseOptions 解析配置的选项
+type ParseOptions struct {
+	Strict      bool // 配置文件中出现未知配置项时报错，用于发现拼写错误
+	SkipSecrets bool // 不解析密钥引用，用于在没有密钥的环境（如 CI）中校验配置
+}
+
+// Parse 读取并验证配置文件，不影响当前生效的配置
+// 返回的错误均包装 ErrInvalidConfig，验证失败时包含 *ValidationError
+func Parse(configPath string, opts ParseOptions) (*Config, error) {
+	config, err := decode(configPath, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+	return config, nil
+}
+
+// parse 读取并验证配置文件，见 Parse
+func parse(configPath string) (*Config, error) {
+	return Parse(configPath, ParseOptions{})
+}
+
+// decode 读取、解析并验证配置文件
+// 每次使用独立的 viper 实例，避免热更新时与其他读取方竞争
+func decode(configPath string, opts ParseOptions) (*Config, error) {
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	v.SetConfigType("yaml")
 
 	// 设置默认值
-	setDefaults()
+	setDefaults(v)
 
 	// 读取配置文件
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("读取配置文件失败: %w", err)
 	}
 
 	// 设置环境变量支持
-	setupEnvBinding()
+	setupEnvBinding(v)
 
 	// 解析配置
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("解析配置失败: %w", err)
 	}
 
-	// 处理环境变量占位符
-	processEnvPlaceholders(&config)
+	// 解析密钥引用
+	if !opts.SkipSecrets {
+		if err := resolveSecrets(&config); err != nil {
+			return nil, err
+		}
+	}
 
 	// 验证配置
-	if err := validateConfig(&config); err != nil {
-		logger.Debug("配置内容", logger.Any("config", config))
+	err := Validate(&config)
+	if opts.Strict {
+		err = withUnknownKeys(err, unknownKeys("", v.AllSettings(), reflect.TypeOf(config)))
+	}
+	if err != nil {
+		logger.Debug("配置内容", logger.Any("config", Redacted(&config)))
 		return nil, fmt.Errorf("配置验证失败: %w", err)
 	}
 
-	// 设置全局配置
-	GlobalConfig = &config
-
 	return &config, nil
 }
 
-// LoadByEnv 根据环境变量自动加载对应的配置文件
+// LoadByEnv 根据 GO_ENV 环境变量加载对应的配置文件，见 PathForEnv
 func LoadByEnv() (*Config, error) {
 	env := os.Getenv("GO_ENV")
 	if env == "" {
 		env = "dev" // 默认开发环境
 	}
 
+	configPath := PathForEnv(env)
+	logger.Info(fmt.Sprintf("加载配置文件: %s (环境: %s)", configPath, env))
+	return Load(configPath)
+}
+
+// PathForEnv 获取环境对应的配置文件路径 configs/config.<env>.yaml，不存在时使用默认配置 configs/config.yaml
+func PathForEnv(env string) string {
 	configPath := fmt.Sprintf("configs/config.%s.yaml", env)
 
 	// 检查配置文件是否存在
@@ -94,232 +191,283 @@ func LoadByEnv() (*Config, error) {
 		// 如果特定环境配置不存在，尝试使用默认配置
 		configPath = "configs/config.yaml"
 	}
-
-	logger.Info(fmt.Sprintf("加载配置文件: %s (环境: %s)", configPath, env))
-	return Load(configPath)
+	return configPath
 }
 
 // setupEnvBinding 设置环境变量绑定
-func setupEnvBinding() {
+func setupEnvBinding(v *viper.Viper) {
 	// 设置环境变量前缀
-	viper.SetEnvPrefix("GO_DEMO")
+	v.SetEnvPrefix("GO_DEMO")
 	// 自动绑定环境变量
-	viper.AutomaticEnv()
+	v.AutomaticEnv()
 	// 将配置键中的 . 替换为 _
-	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 
 	// 手动绑定关键配置项到环境变量
-	_ = viper.BindEnv("server.port", "GO_DEMO_SERVER_PORT")
-	_ = viper.BindEnv("server.mode", "GO_DEMO_SERVER_MODE")
-	_ = viper.BindEnv("database.dsn", "GO_DEMO_DATABASE_DSN")
-	_ = viper.BindEnv("jwt.secret_key", "GO_DEMO_JWT_secret_KEY")
-	_ = viper.BindEnv("redis.host", "GO_DEMO_REDIS_HOST")
-	_ = viper.BindEnv("redis.port", "GO_DEMO_REDIS_PORT")
-	_ = viper.BindEnv("redis.Password", "GO_DEMO_REDIS_Password")
-}
-
-// processEnvPlaceholders 处理配置中的环境变量占位符 ${VAR_NAME}
-func processEnvPlaceholders(config *Config) {
-	// 处理数据库 DSN
-	config.Database.DSN = expandEnvVar(config.Database.DSN)
-
-	// 处理 JWT 密钥
-	config.JWT.SecretKey = expandEnvVar(config.JWT.SecretKey)
-
-	// 处理 Redis 配置
-	config.Redis.Host = expandEnvVar(config.Redis.Host)
-	config.Redis.Password = expandEnvVar(config.Redis.Password)
-}
-
-// expandEnvVar 展开环境变量占位符
-func expandEnvVar(value string) string {
-	if strings.HasPrefix(value, "${") && strings.HasSuffix(value, "}") {
-		envVar := strings.TrimSuffix(strings.TrimPrefix(value, "${"), "}")
-		if envValue := os.Getenv(envVar); envValue != "" {
-			return envValue
-		}
-	}
-	return value
+	_ = v.BindEnv("server.port", "GO_DEMO_SERVER_PORT")
+	_ = v.BindEnv("server.mode", "GO_DEMO_SERVER_MODE")
+	_ = v.BindEnv("database.dsn", "GO_DEMO_DATABASE_DSN")
+	_ = v.BindEnv("jwt.secret_key", "GO_DEMO_JWT_secret_KEY")
+	_ = v.BindEnv("redis.host", "GO_DEMO_REDIS_HOST")
+	_ = v.BindEnv("redis.port", "GO_DEMO_REDIS_PORT")
+	_ = v.BindEnv("redis.Password", "GO_DEMO_REDIS_Password")
 }
 
 // setDefaults 设置默认配置值
-func setDefaults() {
+func setDefaults(v *viper.Viper) {
 	// 服务器默认配置
-	viper.SetDefault("server.port", 8080)
-	viper.SetDefault("server.mode", "debug")
-	viper.SetDefault("server.read_timeout", 60)
-	viper.SetDefault("server.write_timeout", 60)
-	viper.SetDefault("server.max_header_mb", 1)
+	v.SetDefault("server.port", 8080)
+	v.SetDefault("server.mode", "debug")
+	v.SetDefault("server.read_timeout", 60)
+	v.SetDefault("server.write_timeout", 60)
+	v.SetDefault("server.max_header_mb", 1)
+	v.SetDefault("server.shutdown_delay", 0)
+	v.SetDefault("server.hot_reload", true)
 
 	// 数据库默认配置
-	viper.SetDefault("database.driver", "mysql")
-	viper.SetDefault("database.max_open_conns", 100)
-	viper.SetDefault("database.max_idle_conns", 10)
-	viper.SetDefault("database.conn_max_lifetime", 3600)
-	viper.SetDefault("database.conn_max_idle_time", 1800)
-	viper.SetDefault("database.log_mode", true)
-	viper.SetDefault("database.slow_threshold", 200)
+	v.SetDefault("database.driver", "mysql")
+	v.SetDefault("database.max_open_conns", 100)
+	v.SetDefault("database.max_idle_conns", 10)
+	v.SetDefault("database.conn_max_lifetime", 3600)
+	v.SetDefault("database.conn_max_idle_time", 1800)
+	v.SetDefault("database.log_mode", true)
+	v.SetDefault("database.slow_threshold", 200)
+	v.SetDefault("database.auto_migrate", false)
+	v.SetDefault("database.query_timeout", "5s")
+	v.SetDefault("database.replication.policy", "round_robin")
+	v.SetDefault("database.replication.sticky_window", "5s")
+	v.SetDefault("database.replication.max_lag", "10s")
+	v.SetDefault("database.replication.health_check_interval", "5s")
 
 	// JWT默认配置
-	viper.SetDefault("jwt.secret_key", "wVvHx4e0ZvJ8a9d6r5t3yu0i9o7p6y4t3r2e1w2q3s4d5f6")
-	viper.SetDefault("jwt.access_expire", 3600)    // 1小时
-	viper.SetDefault("jwt.refresh_expire", 604800) // 7天
-	viper.SetDefault("jwt.issuer", "go_demo")
+	v.SetDefault("jwt.access_expire", 3600)    // 1小时
+	v.SetDefault("jwt.refresh_expire", 604800) // 7天
+	v.SetDefault("jwt.issuer", "go_demo")
 
 	// 日志默认配置
-	viper.SetDefault("log.level", "info")
-	viper.SetDefault("log.format", "json")
-	viper.SetDefault("log.output_path", "./logs/app.log")
-	viper.SetDefault("log.req_log_path", "./logs/request.log")
-	viper.SetDefault("log.max_size", 100)
-	viper.SetDefault("log.max_backup", 10)
-	viper.SetDefault("log.max_age", 30)
-	viper.SetDefault("log.compress", true)
+	v.SetDefault("log.level", "info")
+	v.SetDefault("log.format", "json")
+	v.SetDefault("log.output_path", "./logs/app.log")
+	v.SetDefault("log.req_log_path", "./logs/request.log")
+	v.SetDefault("log.max_size", 100)
+	v.SetDefault("log.max_backup", 10)
+	v.SetDefault("log.max_age", 30)
+	v.SetDefault("log.compress", true)
+	v.SetDefault("log.redact.max_body_size", 4096)
+	v.SetDefault("log.req_log.level", "info")
+	v.SetDefault("log.req_log.compress", true)
+	v.SetDefault("log.req_log.sample_rate", 1.0)
+	v.SetDefault("log.access_log.enabled", false)
+	v.SetDefault("log.access_log.path", "./logs/access.log")
+	v.SetDefault("log.access_log.format", "json")
+	v.SetDefault("log.access_log.compress", true)
+
+	// 链路追踪默认配置
+	v.SetDefault("tracing.enabled", false)
+	v.SetDefault("tracing.service_name", "go_demo")
+	v.SetDefault("tracing.exporter", "otlp")
+	v.SetDefault("tracing.endpoint", "localhost:4318")
+	v.SetDefault("tracing.insecure", true)
+	v.SetDefault("tracing.sample_ratio", 1.0)
+
+	// 领域事件分发默认配置
+	v.SetDefault("events.enabled", events.DefaultConfig().Enabled)
+	v.SetDefault("events.poll_interval", events.DefaultConfig().PollInterval)
+	v.SetDefault("events.batch_size", events.DefaultConfig().BatchSize)
+	v.SetDefault("events.workers", events.DefaultConfig().Workers)
+	v.SetDefault("events.max_attempts", events.DefaultConfig().MaxAttempts)
+	v.SetDefault("events.retry_backoff", events.DefaultConfig().RetryBackoff)
+	v.SetDefault("events.max_retry_backoff", events.DefaultConfig().MaxRetryBackoff)
+	v.SetDefault("events.handler_timeout", events.DefaultConfig().HandlerTimeout)
+	v.SetDefault("events.retention", events.DefaultConfig().Retention)
+	v.SetDefault("events.lock_ttl", events.DefaultConfig().LockTTL)
+	v.SetDefault("events.audit_log", events.DefaultConfig().AuditLog)
+
+	// Webhook 投递默认配置
+	v.SetDefault("webhook.enabled", webhook.DefaultConfig().Enabled)
+	v.SetDefault("webhook.poll_interval", webhook.DefaultConfig().PollInterval)
+	v.SetDefault("webhook.batch_size", webhook.DefaultConfig().BatchSize)
+	v.SetDefault("webhook.workers", webhook.DefaultConfig().Workers)
+	v.SetDefault("webhook.timeout", webhook.DefaultConfig().Timeout)
+	v.SetDefault("webhook.max_attempts", webhook.DefaultConfig().MaxAttempts)
+	v.SetDefault("webhook.retry_backoff", webhook.DefaultConfig().RetryBackoff)
+	v.SetDefault("webhook.max_retry_backoff", webhook.DefaultConfig().MaxRetryBackoff)
+	v.SetDefault("webhook.allow_private_targets", webhook.DefaultConfig().AllowPrivateTargets)
+
+	// 后台作业默认配置
+	v.SetDefault("jobs.backend", jobs.DefaultConfig().Backend)
+	v.SetDefault("jobs.prefix", jobs.DefaultConfig().Prefix)
+	v.SetDefault("jobs.embedded", jobs.DefaultConfig().Embedded)
+	v.SetDefault("jobs.queues", jobs.DefaultConfig().Queues)
+	v.SetDefault("jobs.poll_interval", jobs.DefaultConfig().PollInterval)
+	v.SetDefault("jobs.visibility_timeout", jobs.DefaultConfig().VisibilityTimeout)
+	v.SetDefault("jobs.max_attempts", jobs.DefaultConfig().MaxAttempts)
+	v.SetDefault("jobs.retry_backoff", jobs.DefaultConfig().RetryBackoff)
+	v.SetDefault("jobs.max_retry_backoff", jobs.DefaultConfig().MaxRetryBackoff)
+	v.SetDefault("jobs.dead_letter_limit", jobs.DefaultConfig().DeadLetterLimit)
+	v.SetDefault("jobs.shutdown_timeout", jobs.DefaultConfig().ShutdownTimeout)
+
+	// 用户缓存默认配置
+	v.SetDefault("user_cache.enabled", repository.DefaultUserCacheConfig().Enabled)
+	v.SetDefault("user_cache.prefix", repository.DefaultUserCacheConfig().Prefix)
+	v.SetDefault("user_cache.ttl", repository.DefaultUserCacheConfig().TTL)
+	v.SetDefault("user_cache.negative_ttl", repository.DefaultUserCacheConfig().NegativeTTL)
+	v.SetDefault("user_cache.jitter", repository.DefaultUserCacheConfig().Jitter)
+
+	// 定时任务默认配置
+	v.SetDefault("scheduler.enabled", scheduler.DefaultConfig().Enabled)
+	v.SetDefault("scheduler.lock_ttl", scheduler.DefaultConfig().LockTTL)
+	v.SetDefault("maintenance.batch_size", maintenance.DefaultConfig().BatchSize)
+	v.SetDefault("maintenance.purge_deleted_users.schedule", maintenance.DefaultConfig().PurgeDeletedUsers.Schedule)
+	v.SetDefault("maintenance.purge_deleted_users.retention", maintenance.DefaultConfig().PurgeDeletedUsers.Retention)
+	v.SetDefault("maintenance.disable_inactive_users.schedule", maintenance.DefaultConfig().DisableInactiveUsers.Schedule)
+	v.SetDefault("maintenance.disable_inactive_users.inactive_days", maintenance.DefaultConfig().DisableInactiveUsers.InactiveDays)
+	v.SetDefault("maintenance.expire_unactivated_users.schedule", maintenance.DefaultConfig().ExpireUnactivatedUsers.Schedule)
+	v.SetDefault("maintenance.expire_unactivated_users.retention", maintenance.DefaultConfig().ExpireUnactivatedUsers.Retention)
+	v.SetDefault("maintenance.compact_audit_logs.schedule", maintenance.DefaultConfig().CompactAuditLogs.Schedule)
+	v.SetDefault("maintenance.compact_audit_logs.retention", maintenance.DefaultConfig().CompactAuditLogs.Retention)
+
+	// 跨域和限流默认配置
+	v.SetDefault("cors.allow_origins", []string{"*"})
+	v.SetDefault("cors.allow_credentials", true)
+	v.SetDefault("rate_limit.enabled", false)
+	v.SetDefault("rate_limit.requests", 100)
+	v.SetDefault("rate_limit.window", 60)
+
+	// 运维管理监听默认配置
+	v.SetDefault("admin.enabled", false)
+	v.SetDefault("admin.host", "127.0.0.1")
+	v.SetDefault("admin.port", 6060)
+
+	// 本地加密密钥库默认配置
+	v.SetDefault("secrets.vault_path", "./configs/secrets.vault")
+	v.SetDefault("secrets.key_file", "./configs/vault.key")
 
 	// Redis默认配置
-	viper.SetDefault("redis.host", "localhost")
-	viper.SetDefault("redis.port", 6379)
-	viper.SetDefault("redis.db", 0)
-	viper.SetDefault("redis.pool_size", 10)
-	viper.SetDefault("redis.min_idle_conns", 5)
-	viper.SetDefault("redis.max_retries", 3)
-
-}
-
-// validateConfig 验证配置
-func validateConfig(config *Config) error {
-	// 验证服务器配置
-	if config.Server.Port <= 0 || config.Server.Port > 65535 {
-		return fmt.Errorf("无效的服务器端口: %d", config.Server.Port)
-	}
-
-	if config.Server.Mode != "debug" && config.Server.Mode != "release" && config.Server.Mode != "test" {
-		return fmt.Errorf("无效的服务器模式: %s", config.Server.Mode)
-	}
-
-	// 验证数据库配置
-	if config.Database.DSN == "" {
-		return fmt.Errorf("数据库DSN不能为空")
-	}
-
-	// 验证JWT配置
-	if config.JWT.SecretKey == "" {
-		return fmt.Errorf("JWT密钥不能为空")
-	}
+	v.SetDefault("redis.host", "localhost")
+	v.SetDefault("redis.port", 6379)
+	v.SetDefault("redis.db", 0)
+	v.SetDefault("redis.pool_size", 10)
+	v.SetDefault("redis.min_idle_conns", 5)
+	v.SetDefault("redis.max_retries", 3)
+
+	// 缓存默认配置
+	v.SetDefault("cache.backend", cache.DefaultConfig().Backend)
+	v.SetDefault("cache.codec", cache.DefaultConfig().Codec)
+	v.SetDefault("cache.max_entries", cache.DefaultConfig().MaxEntries)
+	v.SetDefault("cache.local_ttl", cache.DefaultConfig().LocalTTL)
+	v.SetDefault("cache.channel", cache.DefaultConfig().Channel)
 
-	if config.JWT.AccessExpire <= 0 {
-		return fmt.Errorf("JWT访问token过期时间必须大于0")
-	}
-
-	// 验证日志配置
-	if config.Log.OutputPath == "" {
-		return fmt.Errorf("日志输出路径不能为空")
-	}
-
-	return nil
 }
 
 // GetConfig 获取全局配置
 func GetConfig() *Config {
-	return GlobalConfig
+	return current.Load()
 }
 
 // GetServerConfig 获取服务器配置
 func GetServerConfig() ServerConfig {
-	if GlobalConfig == nil {
+	config := current.Load()
+	if config == nil {
 		return ServerConfig{}
 	}
-	return GlobalConfig.Server
+	return config.Server
 }
 
 // GetDatabaseConfig 获取数据库配置
-func GetDatabaseConfig() database.MySQLConfig {
-	if GlobalConfig == nil {
-		return database.MySQLConfig{}
+func GetDatabaseConfig() database.Config {
+	config := current.Load()
+	if config == nil {
+		return database.Config{}
 	}
-	return GlobalConfig.Database
+	return config.Database
 }
 
 // GetJWTConfig 获取JWT配置
 func GetJWTConfig() utils.JWTConfig {
-	if GlobalConfig == nil {
+	config := current.Load()
+	if config == nil {
 		return utils.JWTConfig{}
 	}
-	return GlobalConfig.JWT
+	return config.JWT
 }
 
 // GetLogConfig 获取日志配置
 func GetLogConfig() logger.LogConfig {
-	if GlobalConfig == nil {
+	config := current.Load()
+	if config == nil {
 		return logger.LogConfig{}
 	}
-	return GlobalConfig.Log
+	return config.Log
 }
 
 // GetRedisConfig 获取Redis配置
 func GetRedisConfig() RedisConfig {
-	if GlobalConfig == nil {
+	config := current.Load()
+	if config == nil {
 		return RedisConfig{}
 	}
-	return GlobalConfig.Redis
+	return config.Redis
 }
 
 // IsProduction 判断是否为生产环境
 func IsProduction() bool {
-	if GlobalConfig == nil {
+	config := current.Load()
+	if config == nil {
 		return false
 	}
-	return GlobalConfig.Server.Mode == "release"
+	return config.Server.Mode == "release"
 }
 
 // IsDevelopment 判断是否为开发环境
 func IsDevelopment() bool {
-	if GlobalConfig == nil {
+	config := current.Load()
+	if config == nil {
 		return true
 	}
-	return GlobalConfig.Server.Mode == "debug"
+	return config.Server.Mode == "debug"
 }
 
 // IsTest 判断是否为测试环境
 func IsTest() bool {
-	if GlobalConfig == nil {
+	config := current.Load()
+	if config == nil {
 		return false
 	}
-	return GlobalConfig.Server.Mode == "test"
+	return config.Server.Mode == "test"
 }
 
 // LoadFromEnv 从环境变量加载配置（用于容器化部署）
 func LoadFromEnv() (*Config, error) {
-	viper.AutomaticEnv()
-	viper.SetEnvPrefix("GO_DEMO")
+	v := viper.New()
+	v.AutomaticEnv()
+	v.SetEnvPrefix("GO_DEMO")
 
 	// 设置默认值
-	setDefaults()
+	setDefaults(v)
 
 	// 解析配置
 	var config Config
-	if err := viper.Unmarshal(&config); err != nil {
+	if err := v.Unmarshal(&config); err != nil {
 		return nil, fmt.Errorf("从环境变量解析配置失败: %w", err)
 	}
+	if err := resolveSecrets(&config); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
 
 	// 验证配置
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("配置验证失败Env: %w", err)
+	if err := Validate(&config); err != nil {
+		return nil, fmt.Errorf("%w: 配置验证失败Env: %w", ErrInvalidConfig, err)
 	}
 
 	// 设置全局配置
-	GlobalConfig = &config
+	current.Store(&config)
 
 	return &config, nil
 }
 
-// ReloadConfig 重新加载配置（热更新）
+// ReloadConfig 重新加载配置（热更新），见 Reload
 func ReloadConfig(configPath string) error {
-	newConfig, err := Load(configPath)
-	if err != nil {
-		return err
-	}
-
-	GlobalConfig = newConfig
-	return nil
+	_, err := Reload(configPath)
+	return err
 }
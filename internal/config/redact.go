@@ -0,0 +1,117 @@
+package config
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// redactedValue 脱敏后的占位值
+const redactedValue = "******"
+
+// secretKeys 值需要整体脱敏的配置项名称片段
+var secretKeys = []string{"password", "secret", "token"}
+
+// credentialKeys 值中可能包含账号密码的配置项名称，只脱敏其中的密码部分
+var credentialKeys = []string{"dsn", "url", "endpoint", "address"}
+
+// credentialPattern 匹配 user:password@ 形式的凭据，如 DSN 和 URL
+var credentialPattern = regexp.MustCompile(`([^:/@\s]+):([^@\s/]+)@`)
+
+// passwordParamPattern 匹配 password=xxx 形式的凭据，如 PostgreSQL 的 key=value DSN
+var passwordParamPattern = regexp.MustCompile(`(?i)\b(password=)('[^']*'|[^\s&]+)`)
+
+// Redacted 将配置转换为按 mapstructure 名称组织的 map，并脱敏密码、密钥等敏感项
+// 通过 env://、file://、vault:// 解析出的值无论位于哪个配置项都会被脱敏
+func Redacted(cfg *Config) map[string]interface{} {
+	return toMap(cfg, true)
+}
+
+// RedactDSN 隐藏 DSN、URL 中 user:password@ 和 password=xxx 形式凭据的密码部分，用于日志输出
+func RedactDSN(dsn string) string {
+	dsn = credentialPattern.ReplaceAllString(dsn, "${1}:"+redactedValue+"@")
+	return passwordParamPattern.ReplaceAllString(dsn, "${1}"+redactedValue)
+}
+
+// toMap 将配置转换为按 mapstructure 名称组织的 map，redact 为 true 时脱敏敏感项
+func toMap(cfg *Config, redact bool) map[string]interface{} {
+	if cfg == nil {
+		return nil
+	}
+	c := converter{redact: redact, secrets: cfg.secrets}
+	out, _ := c.convert("", reflect.ValueOf(*cfg)).(map[string]interface{})
+	return out
+}
+
+// converter 配置转换器
+type converter struct {
+	redact  bool
+	secrets secretSet // 已解析的密钥值
+}
+
+// convert 递归转换配置值，key 为所在配置项名称
+func (c converter) convert(key string, v reflect.Value) interface{} {
+	lowerKey := strings.ToLower(key)
+	if !c.redact {
+		lowerKey = ""
+	}
+	for _, s := range secretKeys {
+		if strings.Contains(lowerKey, s) {
+			if v.IsZero() {
+				return v.Interface()
+			}
+			return redactedValue
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := fieldName(field)
+			out[name] = c.convert(name, v.Field(i))
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = c.convert(key, v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			name := iter.Key().String()
+			// 附加请求头中通常包含认证信息，值全部脱敏
+			if strings.Contains(lowerKey, "headers") {
+				out[name] = redactedValue
+				continue
+			}
+			out[name] = c.convert(name, iter.Value())
+		}
+		return out
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return c.convert(key, v.Elem())
+	case reflect.String:
+		if c.redact && c.secrets.contains(v.String()) {
+			return redactedValue
+		}
+		for _, s := range credentialKeys {
+			if strings.Contains(lowerKey, s) {
+				return RedactDSN(v.String())
+			}
+		}
+		return v.String()
+	default:
+		return v.Interface()
+	}
+}
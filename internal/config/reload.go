@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"go_demo/pkg/logger"
+)
+
+// reloadDebounce 配置文件连续变化的合并间隔，编辑器保存时通常会产生多个事件
+const reloadDebounce = 200 * time.Millisecond
+
+// Subscriber 配置变更订阅者，old 为变更前的配置，new 为新生效的配置
+type Subscriber func(old, new *Config) error
+
+// subscriber 已注册的订阅者
+type subscriber struct {
+	name string
+	fn   Subscriber
+}
+
+var (
+	reloadMu    sync.Mutex // 串行化热更新
+	subMu       sync.RWMutex
+	subscribers []subscriber
+)
+
+// Change 配置项变更，值已脱敏
+type Change struct {
+	Key string      `json:"key"`
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// Subscribe 注册配置变更订阅者，同名订阅者会被替换
+// 订阅者在配置替换后按注册顺序同步调用，只会收到可热更新的配置项变化
+func Subscribe(name string, fn Subscriber) {
+	subMu.Lock()
+	defer subMu.Unlock()
+	for i, s := range subscribers {
+		if s.name == name {
+			subscribers[i].fn = fn
+			return
+		}
+	}
+	subscribers = append(subscribers, subscriber{name: name, fn: fn})
+}
+
+// Unsubscribe 注销配置变更订阅者
+func Unsubscribe(name string) {
+	subMu.Lock()
+	defer subMu.Unlock()
+	for i, s := range subscribers {
+		if s.name == name {
+			subscribers = append(subscribers[:i], subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Reload 重新读取并验证配置文件，原子替换当前配置后通知订阅者，返回生效的变更
+// 验证失败时保留当前配置；数据库、端口等不能在运行时修改的配置项保持原值并输出警告，重启后生效
+func Reload(configPath string) ([]Change, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	next, err := parse(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("重新加载配置失败: %w", err)
+	}
+
+	old := current.Load()
+	if old == nil {
+		current.Store(next)
+		return nil, nil
+	}
+
+	effective := applyReloadable(old, next)
+	if pending := Diff(effective, next); len(pending) > 0 {
+		logger.Warn("部分配置项不支持热更新，需重启后生效，本次未应用",
+			logger.Any("keys", changeKeys(pending)))
+	}
+
+	changes := Diff(old, effective)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	current.Store(effective)
+	notify(old, effective)
+
+	logger.Info("配置已热更新", logger.Any("keys", changeKeys(changes)))
+	return changes, nil
+}
+
+// applyReloadable 以当前配置为基础，只应用新配置中可热更新的配置项
+func applyReloadable(old, next *Config) *Config {
+	effective := *old
+
+	effective.Server.ShutdownDelay = next.Server.ShutdownDelay
+	effective.Server.HotReload = next.Server.HotReload
+	effective.Server.AdminUsers = next.Server.AdminUsers
+	effective.Log.Level = next.Log.Level
+	effective.Log.Levels = next.Log.Levels
+	effective.JWT.AccessExpire = next.JWT.AccessExpire
+	effective.JWT.RefreshExpire = next.JWT.RefreshExpire
+	effective.CORS = next.CORS
+	effective.RateLimit = next.RateLimit
+	// 两份配置解析出的密钥都需要脱敏
+	effective.secrets = old.secrets.union(next.secrets)
+
+	return &effective
+}
+
+// notify 通知订阅者，单个订阅者失败不影响其他订阅者
+func notify(old, next *Config) {
+	subMu.RLock()
+	subs := append([]subscriber(nil), subscribers...)
+	subMu.RUnlock()
+
+	for _, s := range subs {
+		if err := s.fn(old, next); err != nil {
+			logger.Error("配置变更订阅者处理失败", logger.String("subscriber", s.name), logger.Err(err))
+		}
+	}
+}
+
+// Diff 比较两份配置，返回按配置项名称排序的变更，值已脱敏
+func Diff(old, next *Config) []Change {
+	oldRaw, newRaw := flatten(toMap(old, false)), flatten(toMap(next, false))
+	oldRedacted, newRedacted := flatten(toMap(old, true)), flatten(toMap(next, true))
+
+	keys := make(map[string]struct{}, len(newRaw))
+	for k := range oldRaw {
+		keys[k] = struct{}{}
+	}
+	for k := range newRaw {
+		keys[k] = struct{}{}
+	}
+
+	var changes []Change
+	for k := range keys {
+		if reflect.DeepEqual(oldRaw[k], newRaw[k]) {
+			continue
+		}
+		changes = append(changes, Change{Key: k, Old: oldRedacted[k], New: newRedacted[k]})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+// flatten 将嵌套的 map 展开为以 . 连接的配置项名称，列表作为整体比较
+func flatten(m map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{})
+	var walk func(prefix string, v interface{})
+	walk = func(prefix string, v interface{}) {
+		child, ok := v.(map[string]interface{})
+		if !ok {
+			out[prefix] = v
+			return
+		}
+		for k, val := range child {
+			key := k
+			if prefix != "" {
+				key = prefix + "." + k
+			}
+			walk(key, val)
+		}
+	}
+	walk("", m)
+	return out
+}
+
+// changeKeys 获取变更的配置项名称
+func changeKeys(changes []Change) []string {
+	keys := make([]string, len(changes))
+	for i, c := range changes {
+		keys[i] = c.Key
+	}
+	return keys
+}
+
+// Watcher 配置文件监听器
+type Watcher struct {
+	path    string
+	watcher *fsnotify.Watcher
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// Watch 监听配置文件变化并自动热更新
+// 监听文件所在目录，以兼容编辑器的重命名保存和 Kubernetes ConfigMap 的符号链接替换
+func Watch(configPath string) (*Watcher, error) {
+	path, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("解析配置文件路径失败: %w", err)
+	}
+
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("创建配置文件监听失败: %w", err)
+	}
+	if err := fw.Add(filepath.Dir(path)); err != nil {
+		_ = fw.Close()
+		return nil, fmt.Errorf("监听配置目录失败: %w", err)
+	}
+
+	w := &Watcher{path: path, watcher: fw, done: make(chan struct{})}
+	w.wg.Add(1)
+	go w.loop()
+	return w, nil
+}
+
+// loop 处理文件事件，合并短时间内的连续变化后执行热更新
+func (w *Watcher) loop() {
+	defer w.wg.Done()
+
+	realPath, _ := filepath.EvalSymlinks(w.path)
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	reload := func() {
+		if _, err := Reload(w.path); err != nil {
+			logger.Error("配置热更新失败，继续使用当前配置", logger.String("path", w.path), logger.Err(err))
+		}
+	}
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			// 配置文件本身变化，或其指向的实际文件被替换（ConfigMap 更新）
+			newRealPath, _ := filepath.EvalSymlinks(w.path)
+			changed := filepath.Clean(event.Name) == w.path && event.Op&(fsnotify.Write|fsnotify.Create) != 0
+			if newRealPath != "" && newRealPath != realPath {
+				realPath = newRealPath
+				changed = true
+			}
+			if !changed {
+				continue
+			}
+			if timer == nil {
+				timer = time.AfterFunc(reloadDebounce, reload)
+			} else {
+				timer.Reset(reloadDebounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Warn("配置文件监听出错", logger.Err(err))
+		}
+	}
+}
+
+// Close 停止监听
+func (w *Watcher) Close() error {
+	close(w.done)
+	err := w.watcher.Close()
+	w.wg.Wait()
+	return err
+}
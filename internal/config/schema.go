@@ -0,0 +1,160 @@
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// schemaDraft 生成的 JSON Schema 版本
+const schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// logLevels 日志级别取值，与 zapcore.ParseLevel 一致
+var logLevels = []interface{}{"debug", "info", "warn", "error", "dpanic", "panic", "fatal"}
+
+// durationType time.Duration 类型
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Schema 生成配置文件的 JSON Schema，供编辑器补全和校验使用
+// 类型、取值范围和枚举来自配置结构的 validate 规则，默认值来自 setDefaults
+func Schema() map[string]interface{} {
+	defaults := viper.New()
+	setDefaults(defaults)
+
+	schema := schemaFor("", reflect.TypeOf(Config{}), "", defaults)
+	schema["$schema"] = schemaDraft
+	schema["title"] = "go_demo 配置文件"
+	return schema
+}
+
+// schemaFor 生成单个配置项的 Schema，path 为配置项路径，rules 为 validate 规则
+func schemaFor(path string, t reflect.Type, rules string, defaults *viper.Viper) map[string]interface{} {
+	own, dive := splitDive(rules)
+
+	schema := map[string]interface{}{}
+	switch {
+	case t == durationType:
+		schema["type"] = []interface{}{"string", "integer"}
+		schema["description"] = "时长，如 500ms、1s，整数表示纳秒"
+	case t.Kind() == reflect.Struct:
+		properties := map[string]interface{}{}
+		var required []interface{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := fieldName(field)
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+			fieldRules := field.Tag.Get("validate")
+			properties[name] = schemaFor(fieldPath, field.Type, fieldRules, defaults)
+			// 有默认值的配置项在配置文件中可以省略
+			if hasRule(fieldRules, "required") && (defaults == nil || defaults.Get(fieldPath) == nil) {
+				required = append(required, name)
+			}
+		}
+		schema["type"] = "object"
+		schema["properties"] = properties
+		schema["additionalProperties"] = false
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		schema["type"] = "array"
+		schema["items"] = schemaFor("", t.Elem(), dive, nil)
+	case t.Kind() == reflect.Map:
+		schema["type"] = "object"
+		schema["additionalProperties"] = schemaFor("", t.Elem(), dive, nil)
+	case t.Kind() == reflect.String:
+		schema["type"] = "string"
+	case t.Kind() == reflect.Bool:
+		schema["type"] = "boolean"
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema["type"] = "integer"
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema["type"] = "number"
+	}
+
+	applyRules(schema, t, own)
+	if path != "" && defaults != nil && t.Kind() != reflect.Struct {
+		if value := defaults.Get(path); value != nil {
+			schema["default"] = value
+		}
+	}
+	return schema
+}
+
+// applyRules 将 validate 规则转换为 Schema 约束
+func applyRules(schema map[string]interface{}, t reflect.Type, rules string) {
+	if rules == "" {
+		return
+	}
+	kind := t.Kind()
+	numeric := kind != reflect.String && kind != reflect.Slice && kind != reflect.Map && t != durationType
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			if kind == reflect.String {
+				schema["minLength"] = 1
+			}
+		case "min", "max", "gt":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil || t == durationType {
+				continue
+			}
+			switch {
+			case numeric && name == "min":
+				schema["minimum"] = n
+			case numeric && name == "max":
+				schema["maximum"] = n
+			case numeric && name == "gt":
+				schema["exclusiveMinimum"] = n
+			case kind == reflect.String && name == "min":
+				schema["minLength"] = n
+			case kind == reflect.String && name == "max":
+				schema["maxLength"] = n
+			case kind == reflect.Slice && name == "min":
+				schema["minItems"] = n
+			}
+		case "oneof":
+			var values []interface{}
+			for _, v := range strings.Fields(param) {
+				values = append(values, v)
+			}
+			schema["enum"] = values
+		case "loglevel":
+			schema["enum"] = logLevels
+		case "regexp":
+			schema["format"] = "regex"
+		}
+	}
+}
+
+// splitDive 拆分 validate 规则为配置项自身的规则和 dive 之后的元素规则
+func splitDive(rules string) (own, elem string) {
+	parts := strings.Split(rules, ",")
+	for i, rule := range parts {
+		if rule == "dive" {
+			return strings.Join(parts[:i], ","), strings.Join(parts[i+1:], ",")
+		}
+	}
+	return rules, ""
+}
+
+// hasRule 判断 validate 规则中是否包含指定规则（不含 dive 之后的元素规则）
+func hasRule(rules, name string) bool {
+	own, _ := splitDive(rules)
+	for _, rule := range strings.Split(own, ",") {
+		if rule == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"go_demo/pkg/secrets"
+)
+
+// SecretsConfig 本地加密密钥库配置，供 vault:// 引用使用
+type SecretsConfig struct {
+	VaultPath string `mapstructure:"vault_path" yaml:"vault_path"` // 密钥库文件路径
+	KeyFile   string `mapstructure:"key_file" yaml:"key_file"`     // 主密钥文件路径，设置 GO_DEMO_VAULT_KEY 环境变量时忽略
+}
+
+// secretSet 已解析的密钥值集合，脱敏时据此隐藏任意配置项中的密钥
+type secretSet map[string]struct{}
+
+// contains 判断值是否为已解析的密钥
+func (s secretSet) contains(value string) bool {
+	if value == "" {
+		return false
+	}
+	_, ok := s[value]
+	return ok
+}
+
+// union 合并两个集合，返回新集合
+func (s secretSet) union(other secretSet) secretSet {
+	out := make(secretSet, len(s)+len(other))
+	for v := range s {
+		out[v] = struct{}{}
+	}
+	for v := range other {
+		out[v] = struct{}{}
+	}
+	return out
+}
+
+// resolveSecrets 解析配置中全部字符串配置项的密钥引用（env://、file://、vault:// 及 ${VAR}）
+// 先解析 secrets 配置本身，再用其创建 vault:// 提供者解析其余配置项
+// 错误信息只包含配置项名称和引用，不包含密钥内容
+func resolveSecrets(cfg *Config) error {
+	w := &secretWalker{resolver: secrets.NewResolver(), resolved: make(secretSet)}
+
+	if err := w.walk("secrets", reflect.ValueOf(&cfg.Secrets).Elem()); err != nil {
+		return err
+	}
+	w.resolver.Register("vault", secrets.VaultProvider(cfg.Secrets.VaultPath, cfg.Secrets.KeyFile))
+
+	v := reflect.ValueOf(cfg).Elem()
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Name == "Secrets" {
+			continue
+		}
+		if err := w.walk(fieldName(field), v.Field(i)); err != nil {
+			return err
+		}
+	}
+
+	cfg.secrets = w.resolved
+	return nil
+}
+
+// secretWalker 递归遍历配置并替换密钥引用
+type secretWalker struct {
+	resolver *secrets.Resolver
+	resolved secretSet
+}
+
+// walk 遍历配置值，path 为配置项名称
+func (w *secretWalker) walk(path string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.String:
+		value, ok, err := w.resolve(path, v.String())
+		if err != nil {
+			return err
+		}
+		if ok && v.CanSet() {
+			v.SetString(value)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if err := w.walk(path+"."+fieldName(field), v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := w.walk(fmt.Sprintf("%s[%d]", path, i), v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// map 的值不可寻址，字符串值解析后重新写回
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			value, ok, err := w.resolve(path+"."+fmt.Sprint(key.Interface()), iter.Value().String())
+			if err != nil {
+				return err
+			}
+			if ok {
+				v.SetMapIndex(key, reflect.ValueOf(value).Convert(v.Type().Elem()))
+			}
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return w.walk(path, v.Elem())
+		}
+	}
+	return nil
+}
+
+// resolve 解析单个值并记录解析出的密钥
+func (w *secretWalker) resolve(path, value string) (string, bool, error) {
+	resolved, ok, err := w.resolver.Resolve(value)
+	if err != nil {
+		return "", false, fmt.Errorf("配置项 %s: %w", path, err)
+	}
+	if ok && resolved != "" {
+		w.resolved[resolved] = struct{}{}
+	}
+	return resolved, ok, nil
+}
+
+// fieldName 获取字段的 mapstructure 名称
+func fieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+	if name == "" || name == "-" {
+		name = strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// LoadSecretsConfig 仅读取配置文件中的 secrets 配置，不解析其余配置项的密钥引用
+// 供 secrets 命令在密钥库尚未创建时使用
+func LoadSecretsConfig(configPath string) (SecretsConfig, error) {
+	v := viper.New()
+	v.SetConfigFile(configPath)
+	v.SetConfigType("yaml")
+	setDefaults(v)
+	if err := v.ReadInConfig(); err != nil {
+		return SecretsConfig{}, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+
+	var cfg SecretsConfig
+	if err := v.UnmarshalKey("secrets", &cfg); err != nil {
+		return SecretsConfig{}, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	w := &secretWalker{resolver: secrets.NewResolver(), resolved: make(secretSet)}
+	if err := w.walk("secrets", reflect.ValueOf(&cfg).Elem()); err != nil {
+		return SecretsConfig{}, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+
+	"go_demo/pkg/cron"
+	"go_demo/pkg/logger"
+)
+
+// ErrInvalidConfig 配置文件读取、解析、密钥引用解析或验证失败，重试无法恢复
+var ErrInvalidConfig = errors.New("配置无效")
+
+// FieldError 单个配置项的验证错误
+type FieldError struct {
+	Path    string `json:"path"`    // 配置项路径，如 log.sinks[0].type
+	Message string `json:"message"` // 错误说明
+}
+
+// ValidationError 配置验证错误，包含全部不合法的配置项
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// Error 实现 error，每个配置项一行
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		lines[i] = fe.Path + ": " + fe.Message
+	}
+	return strings.Join(lines, "; ")
+}
+
+var (
+	validateOnce sync.Once
+	validate     *validator.Validate
+)
+
+// configValidator 获取配置验证器，字段名称使用 mapstructure 名称，错误路径与配置文件一致
+func configValidator() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+		validate.RegisterTagNameFunc(fieldName)
+		_ = validate.RegisterValidation("loglevel", func(fl validator.FieldLevel) bool {
+			_, err := logger.ParseLevel(fl.Field().String())
+			return err == nil
+		})
+		_ = validate.RegisterValidation("loglevels", func(fl validator.FieldLevel) bool {
+			_, err := logger.ParseLevels(fl.Field().String())
+			return err == nil
+		})
+		_ = validate.RegisterValidation("regexp", func(fl validator.FieldLevel) bool {
+			_, err := regexp.Compile(fl.Field().String())
+			return err == nil
+		})
+		_ = validate.RegisterValidation("cron", func(fl validator.FieldLevel) bool {
+			return cron.Validate(fl.Field().String()) == nil
+		})
+	})
+	return validate
+}
+
+// Validate 按配置结构上的 validate 规则验证全部配置项，返回 *ValidationError
+// 规则与 Schema 生成的 JSON Schema 一致，另外检查跨配置项的约束
+func Validate(cfg *Config) error {
+	var fieldErrors []FieldError
+
+	var verrs validator.ValidationErrors
+	if err := configValidator().Struct(cfg); errors.As(err, &verrs) {
+		for _, fe := range verrs {
+			fieldErrors = append(fieldErrors, FieldError{Path: errorPath(fe), Message: errorMessage(fe)})
+		}
+	} else if err != nil {
+		return fmt.Errorf("验证配置失败: %w", err)
+	}
+
+	// 跨配置项的约束
+	if cfg.Admin.Enabled {
+		if cfg.Admin.Port <= 0 {
+			fieldErrors = append(fieldErrors, FieldError{Path: "admin.port", Message: "启用管理监听时必须指定端口"})
+		} else if cfg.Admin.Port == cfg.Server.Port && cfg.Admin.Port <= 65535 {
+			fieldErrors = append(fieldErrors, FieldError{Path: "admin.port", Message: fmt.Sprintf("不能与服务器端口相同: %d", cfg.Admin.Port)})
+		}
+	}
+
+	if len(fieldErrors) == 0 {
+		return nil
+	}
+	sortFieldErrors(fieldErrors)
+	return &ValidationError{Errors: fieldErrors}
+}
+
+// errorPath 去掉验证器错误路径中的顶层结构名称
+func errorPath(fe validator.FieldError) string {
+	_, path, _ := strings.Cut(fe.Namespace(), ".")
+	return path
+}
+
+// errorMessage 将验证规则转换为错误说明
+func errorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if":
+		return "不能为空"
+	case "min":
+		if fe.Kind() == reflect.Slice || fe.Kind() == reflect.String {
+			return fmt.Sprintf("长度不能小于 %s", fe.Param())
+		}
+		return fmt.Sprintf("不能小于 %s，当前值 %v", fe.Param(), fe.Value())
+	case "max":
+		if fe.Kind() == reflect.Slice || fe.Kind() == reflect.String {
+			return fmt.Sprintf("长度不能大于 %s", fe.Param())
+		}
+		return fmt.Sprintf("不能大于 %s，当前值 %v", fe.Param(), fe.Value())
+	case "gt":
+		return fmt.Sprintf("必须大于 %s，当前值 %v", fe.Param(), fe.Value())
+	case "oneof":
+		return fmt.Sprintf("必须为 %s 之一，当前值 %q", strings.Join(strings.Fields(fe.Param()), ", "), fe.Value())
+	case "loglevel":
+		return fmt.Sprintf("无效的日志级别 %q", fe.Value())
+	case "loglevels":
+		_, err := logger.ParseLevels(fmt.Sprint(fe.Value()))
+		return err.Error()
+	case "regexp":
+		_, err := regexp.Compile(fmt.Sprint(fe.Value()))
+		return fmt.Sprintf("无效的正则表达式: %v", err)
+	case "cron":
+		return fmt.Sprintf("无效的 cron 表达式: %v", cron.Validate(fmt.Sprint(fe.Value())))
+	case "ip|hostname":
+		return fmt.Sprintf("无效的地址 %q", fe.Value())
+	default:
+		return fmt.Sprintf("不满足规则 %s", fe.Tag())
+	}
+}
+
+// sortFieldErrors 按配置项路径排序
+func sortFieldErrors(errs []FieldError) {
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+}
+
+// withUnknownKeys 将未知配置项合并到验证错误中
+func withUnknownKeys(err error, unknown []FieldError) error {
+	if len(unknown) == 0 {
+		return err
+	}
+	verr := &ValidationError{}
+	if err != nil && !errors.As(err, &verr) {
+		return err
+	}
+	verr.Errors = append(verr.Errors, unknown...)
+	sortFieldErrors(verr.Errors)
+	return verr
+}
+
+// unknownKeys 检查配置文件中无法对应到配置结构的配置项，通常是拼写错误
+// settings 为 viper 读取的原始配置，键名已转为小写；map 类型的配置项（如请求头）不检查
+func unknownKeys(prefix string, settings map[string]interface{}, t reflect.Type) []FieldError {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() {
+			fields[strings.ToLower(fieldName(field))] = field.Type
+		}
+	}
+
+	var errs []FieldError
+	for key, value := range settings {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		ft, ok := fields[strings.ToLower(key)]
+		if !ok {
+			errs = append(errs, FieldError{Path: path, Message: "未知的配置项"})
+			continue
+		}
+		errs = append(errs, unknownKeysIn(path, value, ft)...)
+	}
+	return errs
+}
+
+// unknownKeysIn 递归检查嵌套结构和结构列表
+func unknownKeysIn(path string, value interface{}, t reflect.Type) []FieldError {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if m, ok := value.(map[string]interface{}); ok {
+			return unknownKeys(path, m, t)
+		}
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		var errs []FieldError
+		for i, item := range items {
+			errs = append(errs, unknownKeysIn(fmt.Sprintf("%s[%d]", path, i), item, t.Elem())...)
+		}
+		return errs
+	}
+	return nil
+}
@@ -3,11 +3,16 @@ package di
 
 import (
 	"go_demo/internal/config"
+	"go_demo/internal/events"
 	"go_demo/internal/handler"
 	"go_demo/internal/repository"
+	"go_demo/internal/scheduler"
 	"go_demo/internal/service"
+	"go_demo/internal/webhook"
 	"go_demo/pkg/cache"
 	"go_demo/pkg/captcha"
+	"go_demo/pkg/health"
+	"go_demo/pkg/jobs"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,15 +21,30 @@ import (
 // ServerApp 服务器应用包装器，包含引擎和清理函数
 type ServerApp struct {
 	Engine  *gin.Engine
+	Health  *health.Registry // 健康检查注册表，用于标记启动完成和正在关闭
 	Cleanup func()
 }
 
+// WorkerApp 后台作业应用包装器，包含作业执行器、定时任务调度器和清理函数
+type WorkerApp struct {
+	Config    *config.Config
+	Worker    *jobs.Worker
+	Scheduler *scheduler.Scheduler
+	Cleanup   func()
+}
+
 // AppDependencies 应用依赖聚合器 // di.AppDependencies
 type AppDependencies struct {
 	Config     *config.Config         // di.AppDependencies.Config
 	DB         *gorm.DB               // di.AppDependencies.DB
 	Cache      cache.CacheInterface   // di.AppDependencies.Cache
 	Captcha    captcha.CaptchaService // di.AppDependencies.Captcha
+	Health     *health.Registry       // di.AppDependencies.Health
+	Events     *events.Dispatcher     // di.AppDependencies.Events
+	Webhooks   *webhook.Deliverer     // di.AppDependencies.Webhooks
+	Jobs       *jobs.Client           // di.AppDependencies.Jobs
+	Worker     *jobs.Worker           // di.AppDependencies.Worker
+	Scheduler  *scheduler.Scheduler   // di.AppDependencies.Scheduler
 	Repository *Repository            // di.AppDependencies.Repository
 	Services   *Services              // di.AppDependencies.Services
 	Handlers   *Handlers              // di.AppDependencies.Handlers
@@ -32,13 +52,18 @@ type AppDependencies struct {
 
 // Repository 仓储层聚合器 // di.Repository
 type Repository struct {
-	User repository.UserRepository // di.Repository.User
+	User    repository.UserRepository    // di.Repository.User
+	Outbox  repository.OutboxRepository  // di.Repository.Outbox
+	Webhook repository.WebhookRepository // di.Repository.Webhook
+	TaskRun repository.TaskRunRepository // di.Repository.TaskRun
+	Tx      repository.TxManager         // di.Repository.Tx
 }
 
 // Services 服务层聚合器 // di.Services
 type Services struct {
-	Auth service.AuthService // di.Services.Auth
-	User service.UserService // di.Services.User
+	Auth    service.AuthService    // di.Services.Auth
+	User    service.UserService    // di.Services.User
+	Webhook service.WebhookService // di.Services.Webhook
 }
 
 // Handlers 处理器层聚合器 // di.Handlers
@@ -46,28 +71,38 @@ type Handlers struct {
 	Auth    *handler.AuthHandler    // di.Handlers.Auth
 	User    *handler.UserHandler    // di.Handlers.User
 	Captcha *handler.CaptchaHandler // di.Handlers.Captcha
+	Health  *handler.HealthHandler  // di.Handlers.Health
+	Webhook *handler.WebhookHandler // di.Handlers.Webhook
 }
 
 // NewRepository 创建仓储聚合器 // di.NewRepository()
 func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{
-		User: repository.NewUserRepository(db),
+		User:    repository.NewUserRepository(db),
+		Outbox:  repository.NewOutboxRepository(db),
+		Webhook: repository.NewWebhookRepository(db),
+		TaskRun: repository.NewTaskRunRepository(db),
+		Tx:      repository.NewTxManager(db),
 	}
 }
 
 // NewServices 创建服务聚合器 // di.NewServices()
-func NewServices(repo *Repository) *Services {
+func NewServices(repo *Repository, deliverer *webhook.Deliverer) *Services {
+	publisher := events.NewOutboxPublisher(repo.Outbox)
 	return &Services{
-		Auth: service.NewAuthService(repo.User),
-		User: service.NewUserService(repo.User),
+		Auth:    service.NewAuthService(repo.User, repo.Tx, publisher),
+		User:    service.NewUserService(repo.User, repo.Tx, publisher),
+		Webhook: service.NewWebhookService(repo.Webhook, deliverer),
 	}
 }
 
 // NewHandlers 创建处理器聚合器 // di.NewHandlers()
-func NewHandlers(services *Services, captchaService captcha.CaptchaService) *Handlers {
+func NewHandlers(services *Services, captchaService captcha.CaptchaService, registry *health.Registry) *Handlers {
 	return &Handlers{
 		Auth:    handler.NewAuthHandler(services.Auth, services.User, captchaService),
 		User:    handler.NewUserHandler(services.User),
 		Captcha: handler.NewCaptchaHandler(captchaService),
+		Health:  handler.NewHealthHandler(registry),
+		Webhook: handler.NewWebhookHandler(services.Webhook),
 	}
 }
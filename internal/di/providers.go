@@ -6,17 +6,33 @@
 package di
 
 import (
+	"context"
 	"fmt"
 	"go_demo/internal/config"
+	"go_demo/internal/events"
+	"go_demo/internal/maintenance"
+	"go_demo/internal/middleware"
+	"go_demo/internal/migrations"
+	"go_demo/internal/repository"
 	"go_demo/internal/router"
+	"go_demo/internal/scheduler"
 	"go_demo/internal/utils"
+	"go_demo/internal/webhook"
 	"go_demo/pkg/cache"
 	"go_demo/pkg/captcha"
 	"go_demo/pkg/database"
+	"go_demo/pkg/health"
+	"go_demo/pkg/jobs"
+	"go_demo/pkg/lock"
 	"go_demo/pkg/logger"
+	"go_demo/pkg/metrics"
+	"go_demo/pkg/migrate"
+	"go_demo/pkg/tracing"
 	"go_demo/pkg/validator"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"gorm.io/gorm"
 )
 
@@ -42,9 +58,22 @@ func ProvideAppInit(cfg *config.Config) (AppInit, error) {
 		return AppInit{}, fmt.Errorf("日志初始化失败: %w", err)
 	}
 
+	// 初始化链路追踪
+	if err := tracing.Init(cfg.Tracing); err != nil {
+		return AppInit{}, fmt.Errorf("链路追踪初始化失败: %w", err)
+	}
+
 	// 初始化JWT
 	utils.InitJWT(cfg.JWT)
 
+	// 初始化跨域、限流和管理员配置
+	middleware.SetCORSConfig(corsConfig(cfg.CORS))
+	middleware.SetRateLimitConfig(rateLimitConfig(cfg.RateLimit))
+	middleware.SetAdminUsers(cfg.Server.AdminUsers)
+
+	// 注册配置热更新订阅者
+	subscribeConfigChanges()
+
 	// 初始化验证器
 	if err := validator.Init(); err != nil {
 		return AppInit{}, fmt.Errorf("验证器初始化失败: %w", err)
@@ -58,18 +87,125 @@ func ProvideAppInit(cfg *config.Config) (AppInit, error) {
 	return AppInit{}, nil
 }
 
+// subscribeConfigChanges 注册可热更新配置项的订阅者 // di.subscribeConfigChanges()
+func subscribeConfigChanges() {
+	config.Subscribe("logger", func(old, new *config.Config) error {
+		if old.Log.Level == new.Log.Level && old.Log.Levels == new.Log.Levels {
+			return nil
+		}
+		return logger.ApplyConfigLevels(new.Log.Level, new.Log.Levels)
+	})
+	config.Subscribe("jwt", func(old, new *config.Config) error {
+		if old.JWT != new.JWT {
+			utils.InitJWT(new.JWT)
+		}
+		return nil
+	})
+	config.Subscribe("cors", func(_, new *config.Config) error {
+		middleware.SetCORSConfig(corsConfig(new.CORS))
+		return nil
+	})
+	config.Subscribe("rate_limit", func(_, new *config.Config) error {
+		middleware.SetRateLimitConfig(rateLimitConfig(new.RateLimit))
+		return nil
+	})
+	config.Subscribe("admin_users", func(_, new *config.Config) error {
+		middleware.SetAdminUsers(new.Server.AdminUsers)
+		return nil
+	})
+}
+
+// corsConfig 转换为跨域中间件的配置 // di.corsConfig()
+func corsConfig(cfg config.CORSConfig) middleware.CORSConfig {
+	return middleware.CORSConfig{
+		AllowOrigins:     cfg.AllowOrigins,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+}
+
+// rateLimitConfig 转换为限流中间件的配置 // di.rateLimitConfig()
+func rateLimitConfig(cfg config.RateLimitConfig) middleware.RateLimitConfig {
+	return middleware.RateLimitConfig{
+		Enabled:  cfg.Enabled,
+		Requests: cfg.Requests,
+		Window:   cfg.Window,
+	}
+}
+
 // ProvideDB 初始化数据库 // di.ProvideDB()
 func ProvideDB(cfg *config.Config) (*gorm.DB, error) {
-	db, err := database.NewMySQL(cfg.Database)
+	db, err := database.Open(cfg.Database)
 	if err != nil {
 		return nil, fmt.Errorf("数据库初始化失败: %w", err)
 	}
-	logger.Info("MySQL数据库初始化成功", logger.String("addr", cfg.Database.DSN))
+	logger.Info("数据库初始化成功",
+		logger.String("driver", cfg.Database.Driver),
+		logger.String("addr", config.RedactDSN(cfg.Database.DSN)))
+
+	// 注册数据库指标和链路插件
+	if err := db.Use(metrics.NewGormPlugin()); err != nil {
+		return nil, fmt.Errorf("注册数据库指标插件失败: %w", err)
+	}
+	if err := db.Use(tracing.NewGormPlugin(tracing.DBSystem(cfg.Database.Driver))); err != nil {
+		return nil, fmt.Errorf("注册数据库链路插件失败: %w", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		if err := metrics.RegisterDBStats("main", sqlDB); err != nil {
+			logger.Warn("注册数据库连接池指标失败", logger.Err(err))
+		}
+	}
+	if replication := database.ReplicationOf(db); replication != nil {
+		for name, sqlDB := range replication.DBs() {
+			if err := metrics.RegisterDBStats(name, sqlDB); err != nil {
+				logger.Warn("注册只读副本连接池指标失败", logger.String("replica", name), logger.Err(err))
+			}
+		}
+		logger.Info("读写分离已启用",
+			logger.Int("replicas", len(cfg.Database.Replication.Replicas)),
+			logger.String("policy", cfg.Database.Replication.Policy))
+	}
+
+	if cfg.Database.AutoMigrate {
+		if err := runMigrations(db); err != nil {
+			_ = database.Close(db)
+			return nil, err
+		}
+	}
 	return db, nil
 }
 
-// ProvideCache 初始化缓存 // di.ProvideCache()
+// runMigrations 执行待执行的数据库迁移，多个副本同时启动时通过数据库锁串行执行
+func runMigrations(db *gorm.DB) error {
+	all, err := migrations.All(db.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	m, err := migrate.New(db, all, migrate.DefaultConfig())
+	if err != nil {
+		return err
+	}
+	// 迁移语句不受 database.query_timeout 限制
+	done, err := m.Up(database.WithQueryTimeout(context.Background(), 0))
+	if err != nil {
+		return fmt.Errorf("数据库迁移失败: %w", err)
+	}
+	logger.Info("数据库迁移完成", logger.Int("applied", len(done)))
+	return nil
+}
+
+// ProvideCache 根据 cache.backend 初始化缓存 // di.ProvideCache()
 func ProvideCache(cfg *config.Config) (cache.CacheInterface, error) {
+	codec, err := cache.CodecByName(cfg.Cache.Codec)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Cache.Backend == "memory" {
+		logger.Warn("缓存使用进程内实现，数据只在当前进程内可见，重启后丢失")
+		return cache.NewMemoryCache(cfg.Cache.MaxEntries, codec), nil
+	}
+
 	redisCfg := cache.RedisConfig{
 		Host:         cfg.Redis.Host,
 		Port:         cfg.Redis.Port,
@@ -80,13 +216,87 @@ func ProvideCache(cfg *config.Config) (cache.CacheInterface, error) {
 		MaxRetries:   cfg.Redis.MaxRetries,
 	}
 
-	redisCache, err := cache.NewRedisCache(redisCfg)
+	redisCache, err := cache.NewRedisCache(redisCfg, codec)
 	if err != nil {
 		return nil, fmt.Errorf("redis缓存初始化失败: %w", err)
 	}
 
-	logger.Info("Redis缓存初始化成功", logger.String("addr", fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)))
-	return redisCache, nil
+	logger.Info("Redis缓存初始化成功",
+		logger.String("addr", fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port)),
+		logger.String("codec", codec.Name()),
+	)
+
+	// 注册Redis链路钩子和连接池指标
+	redisCache.GetClient().AddHook(tracing.NewRedisHook())
+	if err := metrics.RegisterRedisPoolStats("main", redisCache.GetClient()); err != nil {
+		logger.Warn("注册Redis连接池指标失败", logger.Err(err))
+	}
+
+	if cfg.Cache.Backend != "tiered" {
+		return redisCache, nil
+	}
+	tiered, err := cache.NewTieredCache(redisCache, cache.NewRedisBus(redisCache.GetClient(), cfg.Cache.Channel), cfg.Cache)
+	if err != nil {
+		_ = redisCache.Close()
+		return nil, fmt.Errorf("二级缓存初始化失败: %w", err)
+	}
+	logger.Info("二级缓存初始化成功", logger.Int("max_entries", cfg.Cache.MaxEntries), logger.Duration("local_ttl", cfg.Cache.LocalTTL))
+	return tiered, nil
+}
+
+// redisClient 获取缓存使用的 Redis 客户端，二级缓存取二级的客户端
+func redisClient(cacheService cache.CacheInterface) (*redis.Client, bool) {
+	if tiered, ok := cacheService.(interface{ Remote() cache.CacheInterface }); ok {
+		cacheService = tiered.Remote()
+	}
+	redisCache, ok := cacheService.(interface{ GetClient() *redis.Client })
+	if !ok {
+		return nil, false
+	}
+	return redisCache.GetClient(), true
+}
+
+// ProvideJobBackend 初始化后台作业队列存储 // di.ProvideJobBackend()
+func ProvideJobBackend(cfg *config.Config, cacheService cache.CacheInterface) (jobs.Backend, error) {
+	if cfg.Jobs.Backend == "memory" {
+		logger.Warn("后台作业使用内存队列，作业只在当前进程内执行，重启后丢失")
+		return jobs.NewMemoryBackend(cfg.Jobs.DeadLetterLimit), nil
+	}
+	client, ok := redisClient(cacheService)
+	if !ok {
+		return nil, fmt.Errorf("后台作业需要 Redis 缓存，当前缓存类型为 %T", cacheService)
+	}
+	return jobs.NewRedisBackend(client, cfg.Jobs.Prefix, cfg.Jobs.DeadLetterLimit), nil
+}
+
+// ProvideJobClient 初始化后台作业提交客户端 // di.ProvideJobClient()
+func ProvideJobClient(cfg *config.Config, backend jobs.Backend) *jobs.Client {
+	return jobs.NewClient(backend, cfg.Jobs)
+}
+
+// ProvideLocker 初始化分布式锁，没有 Redis 缓存时使用进程内的锁 // di.ProvideLocker()
+func ProvideLocker(cacheService cache.CacheInterface) lock.Locker {
+	client, ok := redisClient(cacheService)
+	if !ok {
+		logger.Warn("分布式锁使用进程内实现，多实例部署时定时任务可能重复执行")
+		return lock.NewMemoryLocker()
+	}
+	return lock.NewRedisLocker(client, "lock:")
+}
+
+// ProvideHealthRegistry 初始化健康检查注册表，注册数据库和Redis检查 // di.ProvideHealthRegistry()
+func ProvideHealthRegistry(db *gorm.DB, cacheService cache.CacheInterface) (*health.Registry, error) {
+	registry := health.NewRegistry()
+	checks := []health.Check{
+		{Name: "database", Check: health.GormCheck(db), Timeout: 2 * time.Second, Critical: true, CacheTTL: time.Second},
+		{Name: "redis", Check: health.PingCheck(cacheService), Timeout: time.Second, Critical: true, CacheTTL: time.Second},
+	}
+	for _, check := range checks {
+		if err := registry.Register(check); err != nil {
+			return nil, fmt.Errorf("注册健康检查失败: %w", err)
+		}
+	}
+	return registry, nil
 }
 
 // ===== 验证码服务 =====
@@ -98,19 +308,55 @@ func ProvideCaptcha() captcha.CaptchaService {
 
 // ===== 业务层聚合 =====
 
-// ProvideRepository 初始化仓储层 // di.ProvideRepository()
-func ProvideRepository(db *gorm.DB) *Repository {
-	return NewRepository(db)
+// ProvideRepository 初始化仓储层，启用用户缓存时为用户仓储增加缓存 // di.ProvideRepository()
+func ProvideRepository(cfg *config.Config, db *gorm.DB, cacheService cache.CacheInterface) *Repository {
+	repo := NewRepository(db)
+	if cfg.UserCache.Enabled {
+		repo.User = repository.NewCachedUserRepository(repo.User, cacheService, cfg.UserCache)
+	}
+	return repo
 }
 
 // ProvideServices 初始化服务层聚合器 // di.ProvideServices()
-func ProvideServices(repo *Repository) *Services {
-	return NewServices(repo)
+func ProvideServices(repo *Repository, deliverer *webhook.Deliverer) *Services {
+	return NewServices(repo, deliverer)
+}
+
+// ProvideWebhookDeliverer 初始化 Webhook 投递器，启动服务时根据配置决定是否运行 // di.ProvideWebhookDeliverer()
+func ProvideWebhookDeliverer(cfg *config.Config, repo *Repository) *webhook.Deliverer {
+	return webhook.NewDeliverer(repo.Webhook, cfg.Webhook)
+}
+
+// ProvideEventDispatcher 初始化领域事件分发器，启动服务时根据配置决定是否运行 // di.ProvideEventDispatcher()
+func ProvideEventDispatcher(cfg *config.Config, repo *Repository, deliverer *webhook.Deliverer, locker lock.Locker) *events.Dispatcher {
+	dispatcher := events.NewDispatcher(repo.Outbox, locker, cfg.Events)
+	if cfg.Events.AuditLog {
+		dispatcher.Subscribe(events.AllEvents, "audit_log", events.LogHandler)
+	}
+	dispatcher.Subscribe(events.AllEvents, "webhooks", deliverer.HandleEvent)
+	return dispatcher
+}
+
+// ProvideJobWorker 初始化后台作业执行器，由 go_demo worker 或配置了 jobs.embedded 的 server 运行 // di.ProvideJobWorker()
+func ProvideJobWorker(cfg *config.Config, backend jobs.Backend) *jobs.Worker {
+	return jobs.NewWorker(backend, cfg.Jobs)
+}
+
+// ProvideScheduler 初始化定时任务调度器并注册数据维护任务 // di.ProvideScheduler()
+func ProvideScheduler(cfg *config.Config, repo *Repository, locker lock.Locker) (*scheduler.Scheduler, error) {
+	s := scheduler.New(repo.TaskRun, locker, cfg.Scheduler)
+	maintainer := maintenance.New(repo.User, repo.Outbox, repo.Webhook, repo.TaskRun, repo.Tx, cfg.Maintenance)
+	for _, task := range maintainer.Tasks() {
+		if err := s.Register(task); err != nil {
+			return nil, fmt.Errorf("注册定时任务失败: %w", err)
+		}
+	}
+	return s, nil
 }
 
 // ProvideHandlers 初始化处理器层聚合器 // di.ProvideHandlers()
-func ProvideHandlers(services *Services, captchaService captcha.CaptchaService) *Handlers {
-	return NewHandlers(services, captchaService)
+func ProvideHandlers(services *Services, captchaService captcha.CaptchaService, registry *health.Registry) *Handlers {
+	return NewHandlers(services, captchaService, registry)
 }
 
 // ProvideAppDependencies 初始化应用依赖聚合器 // di.ProvideAppDependencies()
@@ -119,6 +365,12 @@ func ProvideAppDependencies(
 	db *gorm.DB,
 	cacheService cache.CacheInterface,
 	captchaService captcha.CaptchaService,
+	registry *health.Registry,
+	dispatcher *events.Dispatcher,
+	deliverer *webhook.Deliverer,
+	jobClient *jobs.Client,
+	worker *jobs.Worker,
+	taskScheduler *scheduler.Scheduler,
 	repo *Repository,
 	services *Services,
 	handlers *Handlers,
@@ -128,6 +380,12 @@ func ProvideAppDependencies(
 		DB:         db,
 		Cache:      cacheService,
 		Captcha:    captchaService,
+		Health:     registry,
+		Events:     dispatcher,
+		Webhooks:   deliverer,
+		Jobs:       jobClient,
+		Worker:     worker,
+		Scheduler:  taskScheduler,
 		Repository: repo,
 		Services:   services,
 		Handlers:   handlers,
@@ -137,18 +395,54 @@ func ProvideAppDependencies(
 // ===== 路由层 =====
 
 // ProvideRouter 初始化路由器 // di.ProvideRouter()
-func ProvideRouter(handlers *Handlers) *router.Router {
-	return router.NewRouter(handlers.Auth, handlers.User, handlers.Captcha)
+func ProvideRouter(handlers *Handlers, cacheService cache.CacheInterface) *router.Router {
+	return router.NewRouter(handlers.Auth, handlers.User, handlers.Captcha, handlers.Health, handlers.Webhook, cacheService)
 }
 
 // ProvideGinEngine 初始化Gin引擎 // di.ProvideGinEngine()
-func ProvideGinEngine(_ AppInit, r *router.Router) *gin.Engine {
-	return r.Setup()
+func ProvideGinEngine(_ AppInit, cfg *config.Config, r *router.Router) (*gin.Engine, error) {
+	engine := r.Setup()
+	// 只信任配置的代理，否则客户端可以通过 X-Forwarded-For 伪造IP绕过限流
+	if err := engine.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("server.trusted_proxies 无效: %w", err)
+	}
+	return engine, nil
 }
 
 // ProvideServerApp 初始化完整的ServerApp（包含清理函数）// di.ProvideServerApp()
 func ProvideServerApp(engine *gin.Engine, deps *AppDependencies) *ServerApp {
+	// 启动领域事件分发，多实例部署时通过投递锁保证同一时刻只有一个实例投递
+	if deps.Events != nil && deps.Config.Events.Enabled {
+		deps.Events.Start()
+		logger.Info("领域事件分发已启动",
+			logger.Duration("poll_interval", deps.Config.Events.PollInterval),
+			logger.Int("workers", deps.Config.Events.Workers))
+	}
+	// 启动 Webhook 投递，各实例通过占用投递记录避免重复发送
+	if deps.Webhooks != nil && deps.Config.Webhook.Enabled {
+		deps.Webhooks.Start()
+		logger.Info("Webhook 投递已启动",
+			logger.Duration("poll_interval", deps.Config.Webhook.PollInterval),
+			logger.Int("workers", deps.Config.Webhook.Workers))
+	}
+	// 在 server 进程内执行后台作业，默认由 go_demo worker 单独执行
+	if deps.Worker != nil && deps.Config.Jobs.Embedded {
+		deps.Worker.Start()
+		logger.Info("后台作业执行已启动", logger.Any("queues", deps.Worker.Queues()))
+	}
+	// 按计划执行定时任务，多实例通过分布式锁保证每个任务只有一个实例执行
+	if deps.Scheduler != nil && deps.Config.Scheduler.Enabled {
+		deps.Scheduler.Start()
+		logger.Info("定时任务调度已启动", logger.Int("tasks", len(deps.Scheduler.Tasks())))
+	}
+
 	cleanup := func() {
+		// 停止领域事件分发、Webhook 投递、后台作业和定时任务，等待正在进行的投递和作业结束
+		stopEventDispatcher(deps.Events)
+		stopWebhookDeliverer(deps.Webhooks)
+		stopJobWorker(deps.Worker, deps.Config.Jobs.ShutdownTimeout)
+		stopScheduler(deps.Scheduler)
+
 		// 关闭缓存连接
 		if deps.Cache != nil {
 			if closer, ok := deps.Cache.(interface{ Close() error }); ok {
@@ -169,21 +463,46 @@ func ProvideServerApp(engine *gin.Engine, deps *AppDependencies) *ServerApp {
 			}
 		}
 
-		// 同步日志
+		// 导出剩余的链路数据
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := tracing.Shutdown(shutdownCtx); err != nil {
+			logger.Error("关闭链路追踪失败", logger.Err(err))
+		}
+		cancel()
+
+		// 同步日志并关闭附加日志输出
 		logger.Sync()
+		logger.CloseSinks()
 	}
 
 	return &ServerApp{
 		Engine:  engine,
+		Health:  deps.Health,
 		Cleanup: cleanup,
 	}
 }
 
+// ProvideWorkerApp 初始化后台作业应用，由 go_demo worker 启动作业执行 // di.ProvideWorkerApp()
+func ProvideWorkerApp(_ AppInit, deps *AppDependencies) *WorkerApp {
+	return &WorkerApp{
+		Config:    deps.Config,
+		Worker:    deps.Worker,
+		Scheduler: deps.Scheduler,
+		Cleanup:   ProvideCleanup(deps),
+	}
+}
+
 // ===== 资源清理 =====
 
 // ProvideCleanup 提供资源清理函数 // di.ProvideCleanup()
 func ProvideCleanup(deps *AppDependencies) func() {
 	return func() {
+		// 停止领域事件分发、Webhook 投递、后台作业和定时任务
+		stopEventDispatcher(deps.Events)
+		stopWebhookDeliverer(deps.Webhooks)
+		stopJobWorker(deps.Worker, deps.Config.Jobs.ShutdownTimeout)
+		stopScheduler(deps.Scheduler)
+
 		// 关闭缓存连接
 		if deps.Cache != nil {
 			if closer, ok := deps.Cache.(interface{ Close() error }); ok {
@@ -200,7 +519,66 @@ func ProvideCleanup(deps *AppDependencies) func() {
 			}
 		}
 
-		// 同步日志
+		// 导出剩余的链路数据
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := tracing.Shutdown(shutdownCtx); err != nil {
+			logger.Error("关闭链路追踪失败", logger.Err(err))
+		}
+		cancel()
+
+		// 同步日志并关闭附加日志输出
 		logger.Sync()
+		logger.CloseSinks()
+	}
+}
+
+// stopEventDispatcher 停止领域事件分发，最多等待5秒，未投递完的消息由下次启动后继续投递
+func stopEventDispatcher(dispatcher *events.Dispatcher) {
+	if dispatcher == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := dispatcher.Stop(ctx); err != nil {
+		logger.Error("停止领域事件分发失败", logger.Err(err))
+	}
+}
+
+// stopWebhookDeliverer 停止 Webhook 投递，最多等待5秒，未完成的投递在占用到期后重新发送
+func stopWebhookDeliverer(deliverer *webhook.Deliverer) {
+	if deliverer == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := deliverer.Stop(ctx); err != nil {
+		logger.Error("停止 Webhook 投递失败", logger.Err(err))
+	}
+}
+
+// stopJobWorker 停止后台作业执行，等待正在执行的作业完成，超时后中断剩余作业并放回队列
+func stopJobWorker(worker *jobs.Worker, timeout time.Duration) {
+	if worker == nil {
+		return
+	}
+	if timeout <= 0 {
+		timeout = jobs.DefaultConfig().ShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := worker.Stop(ctx); err != nil {
+		logger.Error("停止后台作业执行失败", logger.Err(err))
+	}
+}
+
+// stopScheduler 停止定时任务调度，取消正在执行的任务并最多等待5秒，执行记录保持 running 状态
+func stopScheduler(s *scheduler.Scheduler) {
+	if s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		logger.Error("停止定时任务调度失败", logger.Err(err))
 	}
 }
@@ -18,12 +18,21 @@ var infrastructureSet = wire.NewSet(
 	ProvideAppInit,
 	ProvideDB,
 	ProvideCache,
+	ProvideJobBackend,
+	ProvideJobClient,
+	ProvideLocker,
+	ProvideHealthRegistry,
+	ProvideCaptcha,
 )
 
 // 业务逻辑集合
 var businessSet = wire.NewSet(
 	ProvideRepository,
+	ProvideWebhookDeliverer,
 	ProvideServices,
+	ProvideEventDispatcher,
+	ProvideJobWorker,
+	ProvideScheduler,
 	ProvideHandlers,
 	ProvideAppDependencies,
 )
@@ -57,6 +66,17 @@ func InitializeServerApp(configPath string) (*ServerApp, error) { // di.Initiali
 	return nil, fmt.Errorf("wire build failed") // 实际由 wire 生成替换
 }
 
+// InitializeWorkerApp 使用 Wire 构建后台作业应用（包含清理函数）
+func InitializeWorkerApp(configPath string) (*WorkerApp, error) { // di.InitializeWorkerApp()
+	wire.Build(
+		infrastructureSet,
+		businessSet,
+		ProvideWorkerApp,
+	)
+
+	return nil, fmt.Errorf("wire build failed") // 实际由 wire 生成替换
+}
+
 // InitializeApp 初始化完整应用依赖（可选，用于测试或其他场景）
 func InitializeApp(configPath string) (*AppDependencies, error) { // di.InitializeApp()
 	wire.Build(
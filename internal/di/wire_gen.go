@@ -27,12 +27,24 @@ func InitializeServer(configPath string) (*gin.Engine, error) {
 	if err != nil {
 		return nil, err
 	}
-	repository := ProvideRepository(db)
-	services := ProvideServices(repository)
+	cacheInterface, err := ProvideCache(config)
+	if err != nil {
+		return nil, err
+	}
+	repository := ProvideRepository(config, db, cacheInterface)
+	deliverer := ProvideWebhookDeliverer(config, repository)
+	services := ProvideServices(repository, deliverer)
 	captchaService := ProvideCaptcha()
-	handlers := ProvideHandlers(services, captchaService)
-	router := ProvideRouter(handlers)
-	engine := ProvideGinEngine(appInit, router)
+	registry, err := ProvideHealthRegistry(db, cacheInterface)
+	if err != nil {
+		return nil, err
+	}
+	handlers := ProvideHandlers(services, captchaService, registry)
+	router := ProvideRouter(handlers, cacheInterface)
+	engine, err := ProvideGinEngine(appInit, config, router)
+	if err != nil {
+		return nil, err
+	}
 	return engine, nil
 }
 
@@ -50,21 +62,85 @@ func InitializeServerApp(configPath string) (*ServerApp, error) {
 	if err != nil {
 		return nil, err
 	}
-	repository := ProvideRepository(db)
-	services := ProvideServices(repository)
-	captchaService := ProvideCaptcha()
-	handlers := ProvideHandlers(services, captchaService)
-	router := ProvideRouter(handlers)
-	engine := ProvideGinEngine(appInit, router)
 	cacheInterface, err := ProvideCache(config)
 	if err != nil {
 		return nil, err
 	}
-	appDependencies := ProvideAppDependencies(config, db, cacheInterface, captchaService, repository, services, handlers)
+	repository := ProvideRepository(config, db, cacheInterface)
+	deliverer := ProvideWebhookDeliverer(config, repository)
+	services := ProvideServices(repository, deliverer)
+	captchaService := ProvideCaptcha()
+	registry, err := ProvideHealthRegistry(db, cacheInterface)
+	if err != nil {
+		return nil, err
+	}
+	handlers := ProvideHandlers(services, captchaService, registry)
+	router := ProvideRouter(handlers, cacheInterface)
+	engine, err := ProvideGinEngine(appInit, config, router)
+	if err != nil {
+		return nil, err
+	}
+	locker := ProvideLocker(cacheInterface)
+	dispatcher := ProvideEventDispatcher(config, repository, deliverer, locker)
+	backend, err := ProvideJobBackend(config, cacheInterface)
+	if err != nil {
+		return nil, err
+	}
+	client := ProvideJobClient(config, backend)
+	worker := ProvideJobWorker(config, backend)
+	scheduler, err := ProvideScheduler(config, repository, locker)
+	if err != nil {
+		return nil, err
+	}
+	appDependencies := ProvideAppDependencies(config, db, cacheInterface, captchaService, registry, dispatcher, deliverer, client, worker, scheduler, repository, services, handlers)
 	serverApp := ProvideServerApp(engine, appDependencies)
 	return serverApp, nil
 }
 
+// InitializeWorkerApp 使用 Wire 构建后台作业应用（包含清理函数）
+func InitializeWorkerApp(configPath string) (*WorkerApp, error) {
+	config, err := ProvideConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	appInit, err := ProvideAppInit(config)
+	if err != nil {
+		return nil, err
+	}
+	db, err := ProvideDB(config)
+	if err != nil {
+		return nil, err
+	}
+	cacheInterface, err := ProvideCache(config)
+	if err != nil {
+		return nil, err
+	}
+	captchaService := ProvideCaptcha()
+	registry, err := ProvideHealthRegistry(db, cacheInterface)
+	if err != nil {
+		return nil, err
+	}
+	repository := ProvideRepository(config, db, cacheInterface)
+	deliverer := ProvideWebhookDeliverer(config, repository)
+	locker := ProvideLocker(cacheInterface)
+	dispatcher := ProvideEventDispatcher(config, repository, deliverer, locker)
+	backend, err := ProvideJobBackend(config, cacheInterface)
+	if err != nil {
+		return nil, err
+	}
+	client := ProvideJobClient(config, backend)
+	worker := ProvideJobWorker(config, backend)
+	scheduler, err := ProvideScheduler(config, repository, locker)
+	if err != nil {
+		return nil, err
+	}
+	services := ProvideServices(repository, deliverer)
+	handlers := ProvideHandlers(services, captchaService, registry)
+	appDependencies := ProvideAppDependencies(config, db, cacheInterface, captchaService, registry, dispatcher, deliverer, client, worker, scheduler, repository, services, handlers)
+	workerApp := ProvideWorkerApp(appInit, appDependencies)
+	return workerApp, nil
+}
+
 // InitializeApp 初始化完整应用依赖（可选，用于测试或其他场景）
 func InitializeApp(configPath string) (*AppDependencies, error) {
 	config, err := ProvideConfig(configPath)
@@ -80,10 +156,27 @@ func InitializeApp(configPath string) (*AppDependencies, error) {
 		return nil, err
 	}
 	captchaService := ProvideCaptcha()
-	repository := ProvideRepository(db)
-	services := ProvideServices(repository)
-	handlers := ProvideHandlers(services, captchaService)
-	appDependencies := ProvideAppDependencies(config, db, cacheInterface, captchaService, repository, services, handlers)
+	registry, err := ProvideHealthRegistry(db, cacheInterface)
+	if err != nil {
+		return nil, err
+	}
+	repository := ProvideRepository(config, db, cacheInterface)
+	deliverer := ProvideWebhookDeliverer(config, repository)
+	locker := ProvideLocker(cacheInterface)
+	dispatcher := ProvideEventDispatcher(config, repository, deliverer, locker)
+	backend, err := ProvideJobBackend(config, cacheInterface)
+	if err != nil {
+		return nil, err
+	}
+	client := ProvideJobClient(config, backend)
+	worker := ProvideJobWorker(config, backend)
+	scheduler, err := ProvideScheduler(config, repository, locker)
+	if err != nil {
+		return nil, err
+	}
+	services := ProvideServices(repository, deliverer)
+	handlers := ProvideHandlers(services, captchaService, registry)
+	appDependencies := ProvideAppDependencies(config, db, cacheInterface, captchaService, registry, dispatcher, deliverer, client, worker, scheduler, repository, services, handlers)
 	return appDependencies, nil
 }
 
@@ -95,13 +188,21 @@ var infrastructureSet = wire.NewSet(
 	ProvideAppInit,
 	ProvideDB,
 	ProvideCache,
+	ProvideJobBackend,
+	ProvideJobClient,
+	ProvideLocker,
+	ProvideHealthRegistry,
 	ProvideCaptcha,
 )
 
 // 业务逻辑集合
 var businessSet = wire.NewSet(
 	ProvideRepository,
+	ProvideWebhookDeliverer,
 	ProvideServices,
+	ProvideEventDispatcher,
+	ProvideJobWorker,
+	ProvideScheduler,
 	ProvideHandlers,
 	ProvideAppDependencies,
 )
@@ -0,0 +1,455 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"go_demo/pkg/lock"
+	"go_demo/pkg/logger"
+	"go_demo/pkg/metrics"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// eventsLoggerName 事件分发日志使用的logger名称
+const eventsLoggerName = "events"
+
+func init() {
+	logger.RegisterNames(eventsLoggerName)
+}
+
+// AllEvents 订阅全部事件类型
+const AllEvents = "*"
+
+// cleanupInterval 清理已投递消息的间隔
+const cleanupInterval = time.Hour
+
+// dispatchLockKey 投递锁的键，多实例部署时同一时刻只有一个实例读取发件箱
+const dispatchLockKey = "events:dispatcher"
+
+// Config 事件分发配置
+type Config struct {
+	Enabled         bool          `mapstructure:"enabled" yaml:"enabled"`                                      // 是否在本实例投递发件箱中的事件
+	PollInterval    time.Duration `mapstructure:"poll_interval" yaml:"poll_interval" validate:"min=0"`         // 轮询发件箱的间隔，默认 1s
+	BatchSize       int           `mapstructure:"batch_size" yaml:"batch_size" validate:"min=0"`               // 每次轮询读取的消息数，默认 100
+	Workers         int           `mapstructure:"workers" yaml:"workers" validate:"min=0"`                     // 并发投递的聚合数，默认 4
+	MaxAttempts     int           `mapstructure:"max_attempts" yaml:"max_attempts" validate:"min=0"`           // 最大投递次数，超过后进入死信，默认 10
+	RetryBackoff    time.Duration `mapstructure:"retry_backoff" yaml:"retry_backoff" validate:"min=0"`         // 首次重试的等待时间，之后每次翻倍
+	MaxRetryBackoff time.Duration `mapstructure:"max_retry_backoff" yaml:"max_retry_backoff" validate:"min=0"` // 重试等待时间上限
+	HandlerTimeout  time.Duration `mapstructure:"handler_timeout" yaml:"handler_timeout" validate:"min=0"`     // 单个订阅者处理一条事件的超时时间，0 表示不限制
+	Retention       time.Duration `mapstructure:"retention" yaml:"retention" validate:"min=0"`                 // 已投递消息的保留时间，0 表示不清理
+	LockTTL         time.Duration `mapstructure:"lock_ttl" yaml:"lock_ttl" validate:"min=0"`                   // 投递锁的过期时间，投递期间自动续期，默认 30s
+	AuditLog        bool          `mapstructure:"audit_log" yaml:"audit_log"`                                  // 是否把事件ID、类型和聚合写入 events 日志，不记录事件内容
+}
+
+// DefaultConfig 默认事件分发配置
+func DefaultConfig() Config {
+	return Config{
+		Enabled:         true,
+		PollInterval:    time.Second,
+		BatchSize:       100,
+		Workers:         4,
+		MaxAttempts:     10,
+		RetryBackoff:    time.Second,
+		MaxRetryBackoff: 5 * time.Minute,
+		HandlerTimeout:  10 * time.Second,
+		Retention:       7 * 24 * time.Hour,
+		LockTTL:         30 * time.Second,
+	}
+}
+
+// Message 投递给订阅者的事件
+type Message struct {
+	ID            uint64          // 发件箱消息ID，重复投递时不变，可用于去重
+	Type          string          // 事件类型
+	AggregateType string          // 聚合类型
+	AggregateID   string          // 聚合ID
+	Payload       json.RawMessage // JSON 格式的事件内容
+	OccurredAt    time.Time       // 事件发生时间
+	Attempt       int             // 第几次投递，从1开始
+}
+
+// Decode 把事件内容解析到 v，如 *events.UserRegistered
+func (m Message) Decode(v interface{}) error {
+	return json.Unmarshal(m.Payload, v)
+}
+
+// Handler 事件处理函数，返回错误时事件稍后重新投递给全部订阅者
+type Handler func(ctx context.Context, msg Message) error
+
+// subscriber 订阅者
+type subscriber struct {
+	name    string
+	handler Handler
+}
+
+// Dispatcher 事件分发器，轮询发件箱并投递给订阅者
+// 后台轮询前先获取投递锁，多实例部署时同一时刻只有一个实例投递，避免重复投递和打乱同一聚合的顺序
+type Dispatcher struct {
+	outbox repository.OutboxRepository
+	locker lock.Locker
+	config Config
+
+	mu          sync.RWMutex
+	subscribers map[string][]subscriber
+
+	runMu       sync.Mutex
+	lastCleanup time.Time
+	stop        chan struct{}
+	done        chan struct{}
+}
+
+// NewDispatcher 创建事件分发器，未设置的配置项使用默认值
+// locker 为 nil 时不获取投递锁，只适用于单实例部署
+func NewDispatcher(outbox repository.OutboxRepository, locker lock.Locker, config Config) *Dispatcher {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.MaxRetryBackoff <= 0 {
+		config.MaxRetryBackoff = defaults.MaxRetryBackoff
+	}
+	if config.LockTTL <= 0 {
+		config.LockTTL = defaults.LockTTL
+	}
+	return &Dispatcher{
+		outbox:      outbox,
+		locker:      locker,
+		config:      config,
+		subscribers: make(map[string][]subscriber),
+		lastCleanup: time.Now(),
+	}
+}
+
+// Subscribe 订阅事件，eventType 为 AllEvents 时订阅全部事件；name 用于日志
+func (d *Dispatcher) Subscribe(eventType, name string, handler Handler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subscribers[eventType] = append(d.subscribers[eventType], subscriber{name: name, handler: handler})
+}
+
+// Start 在后台定期投递事件，重复调用无效
+func (d *Dispatcher) Start() {
+	d.runMu.Lock()
+	defer d.runMu.Unlock()
+	if d.stop != nil {
+		return
+	}
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	go d.run(d.stop, d.done)
+}
+
+// Stop 停止后台投递，等待正在进行的投递结束或 ctx 超时
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.runMu.Lock()
+	stop, done := d.stop, d.done
+	d.stop, d.done = nil, nil
+	d.runMu.Unlock()
+	if stop == nil {
+		return nil
+	}
+
+	close(stop)
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 轮询循环
+func (d *Dispatcher) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			d.poll(ctx)
+		}
+	}
+}
+
+// poll 获取投递锁后投递发件箱中的消息，锁被其他实例持有时跳过本轮
+func (d *Dispatcher) poll(ctx context.Context) {
+	if d.locker != nil {
+		lk, ok, err := d.locker.TryLock(ctx, dispatchLockKey, d.config.LockTTL)
+		if err != nil {
+			if ctx.Err() == nil {
+				eventsLogger().Error("获取事件投递锁失败", logger.Err(err))
+			}
+			return
+		}
+		if !ok {
+			return
+		}
+		defer func() {
+			if err := lk.Release(context.WithoutCancel(ctx)); err != nil {
+				eventsLogger().Warn("释放事件投递锁失败", logger.Err(err))
+			}
+		}()
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		renewed := make(chan struct{})
+		defer func() {
+			cancel()
+			<-renewed
+		}()
+		go func() {
+			defer close(renewed)
+			d.keepAlive(ctx, cancel, lk)
+		}()
+	}
+
+	// 一批消息投递完后立即读取下一批，直到发件箱中没有可投递的消息
+	for {
+		n, err := d.DispatchOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			eventsLogger().Error("投递领域事件失败", logger.Err(err))
+		}
+		if err != nil || n < d.config.BatchSize {
+			break
+		}
+	}
+	d.cleanup(ctx)
+}
+
+// keepAlive 定期续期投递锁，续期失败时取消投递，由持有锁的实例继续投递
+func (d *Dispatcher) keepAlive(ctx context.Context, cancel context.CancelFunc, lk lock.Lock) {
+	ticker := time.NewTicker(d.config.LockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := lk.Refresh(ctx, d.config.LockTTL); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			eventsLogger().Error("事件投递锁续期失败，停止本轮投递", logger.Err(err))
+			cancel()
+			return
+		}
+	}
+}
+
+// DispatchOnce 读取一批待投递的消息并投递，返回本次处理（投递成功、失败或进入死信）的消息数
+// 不获取投递锁，多实例部署时由调用方保证同一时刻只有一个实例调用
+// 不同聚合的消息并发投递，同一聚合的消息按顺序投递，遇到失败或未到重试时间的消息时停止投递该聚合后续的消息
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	pending, err := d.outbox.Pending(ctx, d.config.BatchSize, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("读取发件箱失败: %w", err)
+	}
+	if len(pending) == 0 {
+		return 0, nil
+	}
+
+	// 按聚合分组，保持写入顺序
+	var groups [][]models.OutboxMessage
+	index := make(map[string]int)
+	for _, msg := range pending {
+		key := msg.AggregateType + ":" + msg.AggregateID
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], msg)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		processed int
+		errs      []error
+		sem       = make(chan struct{}, d.config.Workers)
+	)
+	for _, group := range groups {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(group []models.OutboxMessage) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			n, err := d.dispatchAggregate(ctx, group)
+			mu.Lock()
+			processed += n
+			if err != nil {
+				errs = append(errs, err)
+			}
+			mu.Unlock()
+		}(group)
+	}
+	wg.Wait()
+	return processed, errors.Join(errs...)
+}
+
+// dispatchAggregate 按顺序投递同一聚合的消息
+func (d *Dispatcher) dispatchAggregate(ctx context.Context, group []models.OutboxMessage) (int, error) {
+	processed := 0
+	for i := range group {
+		msg := &group[i]
+		if ctx.Err() != nil || time.Now().Before(msg.NextAttemptAt) {
+			return processed, nil
+		}
+
+		attempt := msg.Attempts + 1
+		deliverErr := d.deliver(ctx, msg, attempt)
+		if deliverErr == nil {
+			metrics.EventDeliveriesTotal.WithLabelValues(msg.EventType, metrics.ResultSuccess).Inc()
+			if err := d.outbox.MarkProcessed(ctx, msg.ID, attempt, time.Now()); err != nil {
+				// 状态未保存时消息会再次投递，符合至少一次的语义
+				return processed, fmt.Errorf("更新事件 %d 状态失败: %w", msg.ID, err)
+			}
+			processed++
+			continue
+		}
+		if ctx.Err() != nil {
+			// 停止时被取消的投递不计入重试次数
+			return processed, nil
+		}
+
+		status, result := models.OutboxStatusPending, metrics.ResultFailed
+		if attempt >= d.config.MaxAttempts {
+			status, result = models.OutboxStatusDead, metrics.ResultDead
+		}
+		metrics.EventDeliveriesTotal.WithLabelValues(msg.EventType, result).Inc()
+		next := time.Now().Add(d.backoff(attempt))
+		if err := d.outbox.MarkFailed(ctx, msg.ID, status, attempt, next, deliverErr.Error()); err != nil {
+			return processed, fmt.Errorf("更新事件 %d 状态失败: %w", msg.ID, err)
+		}
+		processed++
+
+		fields := []logger.Field{
+			logger.Int64("message_id", int64(msg.ID)),
+			logger.String("event_type", msg.EventType),
+			logger.String("aggregate_id", msg.AggregateID),
+			logger.Int("attempt", attempt),
+			logger.Err(deliverErr),
+		}
+		if status == models.OutboxStatusDead {
+			// 进入死信后继续投递同一聚合的后续事件，避免一个事件阻塞整个聚合
+			eventsLogger().Error("领域事件超过最大投递次数，已进入死信", fields...)
+			continue
+		}
+		eventsLogger().Warn("领域事件投递失败，稍后重试", append(fields, logger.Any("next_attempt_at", next))...)
+		return processed, nil
+	}
+	return processed, nil
+}
+
+// deliver 把消息投递给全部订阅者，任一订阅者失败时返回错误
+func (d *Dispatcher) deliver(ctx context.Context, msg *models.OutboxMessage, attempt int) error {
+	d.mu.RLock()
+	subs := append(append([]subscriber(nil), d.subscribers[msg.EventType]...), d.subscribers[AllEvents]...)
+	d.mu.RUnlock()
+
+	message := Message{
+		ID:            msg.ID,
+		Type:          msg.EventType,
+		AggregateType: msg.AggregateType,
+		AggregateID:   msg.AggregateID,
+		Payload:       json.RawMessage(msg.Payload),
+		OccurredAt:    msg.OccurredAt,
+		Attempt:       attempt,
+	}
+	var errs []error
+	for _, sub := range subs {
+		if err := d.invoke(ctx, sub, message); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sub.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// invoke 调用单个订阅者，panic 视为处理失败
+func (d *Dispatcher) invoke(ctx context.Context, sub subscriber, msg Message) (err error) {
+	if d.config.HandlerTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d.config.HandlerTimeout)
+		defer cancel()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return sub.handler(ctx, msg)
+}
+
+// backoff 第 attempt 次投递失败后的等待时间，指数增长，不超过 MaxRetryBackoff
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.config.RetryBackoff
+	for i := 1; i < attempt && delay < d.config.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxRetryBackoff {
+		delay = d.config.MaxRetryBackoff
+	}
+	return delay
+}
+
+// cleanup 定期删除超过保留时间的已投递消息
+func (d *Dispatcher) cleanup(ctx context.Context) {
+	if d.config.Retention <= 0 || time.Since(d.lastCleanup) < cleanupInterval {
+		return
+	}
+	d.lastCleanup = time.Now()
+	deleted, err := d.outbox.DeleteProcessed(ctx, time.Now().Add(-d.config.Retention))
+	if err != nil {
+		eventsLogger().Warn("清理已投递的领域事件失败", logger.Err(err))
+		return
+	}
+	if deleted > 0 {
+		eventsLogger().Info("已清理投递完成的领域事件", logger.Int64("deleted", deleted))
+	}
+}
+
+// LogHandler 把事件ID、类型和聚合写入名为 events 的日志，作为审计记录
+// 事件内容可能包含邮箱、手机号等个人信息，不写入日志
+func LogHandler(_ context.Context, msg Message) error {
+	eventsLogger().Info("领域事件",
+		logger.Int64("message_id", int64(msg.ID)),
+		logger.String("event_type", msg.Type),
+		logger.String("aggregate_type", msg.AggregateType),
+		logger.String("aggregate_id", msg.AggregateID),
+	)
+	return nil
+}
+
+// eventsLogger 获取事件分发的logger
+func eventsLogger() *zap.Logger {
+	return logger.Named(eventsLoggerName)
+}
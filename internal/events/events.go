@@ -0,0 +1,102 @@
+// Package events 领域事件
+//
+// 服务层在业务事务中通过 Publisher 把事件写入发件箱（outbox_messages 表），事件与业务数据一起提交或回滚；
+// Dispatcher 轮询发件箱，把事件投递给进程内的订阅者，失败时按退避时间重试。
+// 投递语义为至少一次：订阅者可能收到重复的事件，应使用 Message.ID 去重或保证处理幂等；
+// 同一聚合（如同一个用户）的事件按写入顺序投递，前一个事件投递成功或进入死信后才投递下一个。
+package events
+
+import "strconv"
+
+// 聚合类型
+const (
+	AggregateUser = "user"
+)
+
+// 事件类型
+const (
+	TypeUserRegistered    = "user.registered"
+	TypeUserLoggedIn      = "user.logged_in"
+	TypePasswordChanged   = "user.password_changed"
+	TypeUserStatusChanged = "user.status_changed"
+	TypeUserDeleted       = "user.deleted"
+)
+
+// Types 全部事件类型，用于校验订阅的事件类型
+var Types = []string{
+	TypeUserRegistered,
+	TypeUserLoggedIn,
+	TypePasswordChanged,
+	TypeUserStatusChanged,
+	TypeUserDeleted,
+}
+
+// Event 领域事件，序列化为 JSON 后写入发件箱
+type Event interface {
+	EventType() string
+	AggregateType() string
+	AggregateID() string
+}
+
+// UserEvent 用户聚合的事件
+type UserEvent struct {
+	UserID uint `json:"user_id"`
+}
+
+// AggregateType 聚合类型
+func (e UserEvent) AggregateType() string {
+	return AggregateUser
+}
+
+// AggregateID 聚合ID，即用户ID
+func (e UserEvent) AggregateID() string {
+	return strconv.FormatUint(uint64(e.UserID), 10)
+}
+
+// UserRegistered 用户已注册，包括管理员创建的用户
+type UserRegistered struct {
+	UserEvent
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Mobile   string `json:"mobile"`
+	Name     string `json:"name"`
+}
+
+// EventType 事件类型
+func (UserRegistered) EventType() string { return TypeUserRegistered }
+
+// UserLoggedIn 用户已登录
+type UserLoggedIn struct {
+	UserEvent
+	Username string `json:"username"`
+}
+
+// EventType 事件类型
+func (UserLoggedIn) EventType() string { return TypeUserLoggedIn }
+
+// PasswordChanged 用户已修改密码
+type PasswordChanged struct {
+	UserEvent
+}
+
+// EventType 事件类型
+func (PasswordChanged) EventType() string { return TypePasswordChanged }
+
+// UserStatusChanged 用户状态已变更，status 0=禁用，1=启用
+type UserStatusChanged struct {
+	UserEvent
+	OldStatus int `json:"old_status"`
+	NewStatus int `json:"new_status"`
+}
+
+// EventType 事件类型
+func (UserStatusChanged) EventType() string { return TypeUserStatusChanged }
+
+// UserDeleted 用户已删除
+type UserDeleted struct {
+	UserEvent
+	Username string `json:"username"`
+}
+
+// EventType 事件类型
+func (UserDeleted) EventType() string { return TypeUserDeleted }
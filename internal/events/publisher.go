@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"time"
+)
+
+// Publisher 发布领域事件
+type Publisher interface {
+	// Publish 把事件写入发件箱，应在 TxManager.WithinTransaction 中调用，使事件与业务数据一起提交
+	Publish(ctx context.Context, events ...Event) error
+}
+
+// outboxPublisher 写入发件箱的发布者
+type outboxPublisher struct {
+	outbox repository.OutboxRepository
+}
+
+// NewOutboxPublisher 创建写入发件箱的发布者
+func NewOutboxPublisher(outbox repository.OutboxRepository) Publisher {
+	return &outboxPublisher{outbox: outbox}
+}
+
+// Publish 把事件写入发件箱
+func (p *outboxPublisher) Publish(ctx context.Context, events ...Event) error {
+	now := time.Now()
+	messages := make([]*models.OutboxMessage, 0, len(events))
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("序列化事件 %s 失败: %w", event.EventType(), err)
+		}
+		messages = append(messages, &models.OutboxMessage{
+			AggregateType: event.AggregateType(),
+			AggregateID:   event.AggregateID(),
+			EventType:     event.EventType(),
+			Payload:       string(payload),
+			Status:        models.OutboxStatusPending,
+			NextAttemptAt: now,
+			OccurredAt:    now,
+		})
+	}
+	if err := p.outbox.Add(ctx, messages...); err != nil {
+		return fmt.Errorf("写入发件箱失败: %w", err)
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	"go_demo/pkg/captcha"
 	"go_demo/pkg/errors"
 	"go_demo/pkg/logger"
+	"go_demo/pkg/metrics"
 	"net/http"
 	"strings"
 
@@ -15,10 +16,9 @@ import (
 )
 
 // handleServiceError 统一处理服务层返回的错误
-func handleServiceError(c *gin.Context, err error, requestID string) {
+func handleServiceError(c *gin.Context, err error) {
 	// 记录错误日志
-	logger.Warn("服务调用失败",
-		logger.String("request_id", requestID),
+	logger.WarnCtx(c, "服务调用失败",
 		logger.Err(err),
 	)
 
@@ -62,8 +62,6 @@ func NewAuthHandler(authService service.AuthService, userService service.UserSer
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/auth/login [post]
 func (h *AuthHandler) Login(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 绑定并验证请求参数
 	var req models.LoginRequest
 	if !middleware.ValidateAndBind(c, &req) {
@@ -72,8 +70,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	// 验证验证码
 	if !h.captchaService.Verify(req.CaptchaID, req.Captcha) {
-		logger.Warn("验证码验证失败",
-			logger.String("request_id", requestID),
+		metrics.CaptchaFailuresTotal.WithLabelValues("login").Inc()
+		logger.WarnCtx(c, "验证码验证失败",
 			logger.String("captcha_id", req.CaptchaID),
 		)
 		utils.ResponseError(c, http.StatusBadRequest, "验证码错误或已过期")
@@ -81,17 +79,17 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// 调用服务层进行登录
-	response, err := h.authService.Login(c, req)
+	response, err := h.authService.Login(c.Request.Context(), req)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		metrics.LoginsTotal.WithLabelValues(metrics.ResultFailed).Inc()
+		handleServiceError(c, err)
 		return
 	}
+	metrics.LoginsTotal.WithLabelValues(metrics.ResultSuccess).Inc()
 
-	// logger.Info("用户登录成功",
-	// 	logger.String("request_id", requestID),
+	// logger.InfoCtx(c, "用户登录成功",
 	// 	logger.String("username", req.Username),
 	// 	logger.Int("user_id", int(response.User.ID)),
-	// 	logger.String("client_ip", c.ClientIP()),
 	// )
 
 	utils.ResponseSuccess(c, "登录成功", response)
@@ -104,14 +102,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param request body models.RegisterRequest true "注册请求"
+// @Param Idempotency-Key header string false "幂等键，重复请求将回放首次响应"
 // @Success 200 {object} utils.Response{data=models.UserResponse} "注册成功"
 // @Failure 400 {object} utils.Response "请求参数错误"
 // @Failure 409 {object} utils.Response "用户已存在"
 // @Failure 500 {object} utils.Response "服务器内部错误"
+// @Failure 422 {object} utils.Response "幂等键已被用于不同的请求"
 // @Router /api/v1/auth/register [post]
 func (h *AuthHandler) Register(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 绑定并验证请求参数
 	var req models.RegisterRequest
 	if !middleware.ValidateAndBind(c, &req) {
@@ -120,8 +118,8 @@ func (h *AuthHandler) Register(c *gin.Context) {
 
 	// 验证验证码
 	if !h.captchaService.Verify(req.CaptchaID, req.Captcha) {
-		logger.Warn("验证码验证失败",
-			logger.String("request_id", requestID),
+		metrics.CaptchaFailuresTotal.WithLabelValues("register").Inc()
+		logger.WarnCtx(c, "验证码验证失败",
 			logger.String("captcha_id", req.CaptchaID),
 		)
 		utils.ResponseError(c, http.StatusBadRequest, "验证码错误或已过期")
@@ -129,11 +127,13 @@ func (h *AuthHandler) Register(c *gin.Context) {
 	}
 
 	// 调用服务层进行注册
-	user, err := h.authService.Register(c, req)
+	user, err := h.authService.Register(c.Request.Context(), req)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		metrics.RegistrationsTotal.WithLabelValues(metrics.ResultFailed).Inc()
+		handleServiceError(c, err)
 		return
 	}
+	metrics.RegistrationsTotal.WithLabelValues(metrics.ResultSuccess).Inc()
 
 	utils.ResponseSuccess(c, "注册成功", user)
 }
@@ -150,8 +150,6 @@ func (h *AuthHandler) Register(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/auth/logout [post]
 func (h *AuthHandler) Logout(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 从Authorization头获取token
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
@@ -170,22 +168,16 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	logger.Info("用户登出请求",
-		logger.String("request_id", requestID),
-		logger.String("client_ip", c.ClientIP()),
-	)
+	logger.InfoCtx(c, "用户登出请求")
 
 	// 调用服务层进行登出
-	err := h.authService.Logout(tokenString)
+	err := h.authService.Logout(c.Request.Context(), tokenString)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
-	logger.Info("用户登出成功",
-		logger.String("request_id", requestID),
-		logger.String("client_ip", c.ClientIP()),
-	)
+	logger.InfoCtx(c, "用户登出成功")
 
 	utils.ResponseSuccess(c, "登出成功", nil)
 }
@@ -202,8 +194,6 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/auth/profile [get]
 func (h *AuthHandler) GetProfile(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 从上下文获取用户ID（由认证中间件设置）
 	userIDInterface, exists := c.Get("user_id")
 	if !exists {
@@ -212,9 +202,9 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	}
 	userID := userIDInterface.(int64)
 	// 调用用户服务获取用户信息
-	user, err := h.userService.GetUserByID(int(userID))
+	user, err := h.userService.GetUserByID(c.Request.Context(), int(userID))
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
@@ -234,8 +224,6 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 获取Authorization头
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
@@ -251,14 +239,14 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	// 验证刷新令牌并生成新的访问令牌
 	claims, err := utils.ValidateRefreshToken(refreshToken)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
 	// 生成新的访问令牌
 	newAccessToken, err := utils.GenerateAccessToken(claims.UserID, claims.Username)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
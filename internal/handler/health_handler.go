@@ -5,203 +5,89 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
-	"go_demo/pkg/cache"
-	"go_demo/pkg/database"
+	"go_demo/pkg/health"
 )
 
 // HealthHandler 健康检查处理器
 type HealthHandler struct {
-	db    *gorm.DB
-	cache *cache.RedisCache
+	registry *health.Registry
 }
 
 // NewHealthHandler 创建健康检查处理器
-func NewHealthHandler(db *gorm.DB, cache *cache.RedisCache) *HealthHandler {
+func NewHealthHandler(registry *health.Registry) *HealthHandler {
 	return &HealthHandler{
-		db:    db,
-		cache: cache,
+		registry: registry,
 	}
 }
 
-// HealthResponse 健康检查响应
-type HealthResponse struct {
-	Status    string                 `json:"status"`
-	Timestamp string                 `json:"timestamp"`
-	Services  map[string]ServiceInfo `json:"services"`
-	Version   string                 `json:"version,omitempty"`
-}
-
-// ServiceInfo 服务信息
-type ServiceInfo struct {
-	Status  string                 `json:"status"`
-	Message string                 `json:"message,omitempty"`
-	Details map[string]interface{} `json:"details,omitempty"`
-}
-
-// Health 基础健康检查
+// Health 基础健康检查，不检查依赖
 // @Summary 健康检查
 // @Description 检查服务是否正常运行
 // @Tags 健康检查
 // @Produce json
-// @Success 200 {object} HealthResponse
+// @Success 200 {object} map[string]string
 // @Router /health [get]
 func (h *HealthHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status":    "ok",
-		"timestamp": time.Now().Format(time.RFC3339),
+		"status": "ok",
+		"time":   time.Now().Format(time.RFC3339),
 	})
 }
 
 // HealthCheck 详细健康检查
 // @Summary 详细健康检查
-// @Description 检查服务及其依赖的健康状态
+// @Description 执行全部已注册的健康检查并返回各项结果
 // @Tags 健康检查
 // @Produce json
-// @Success 200 {object} HealthResponse
-// @Failure 503 {object} HealthResponse
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
 // @Router /health/check [get]
 func (h *HealthHandler) HealthCheck(c *gin.Context) {
-	response := HealthResponse{
-		Status:    "healthy",
-		Timestamp: time.Now().Format(time.RFC3339),
-		Services:  make(map[string]ServiceInfo),
-		Version:   "1.0.0",
-	}
-
-	allHealthy := true
-
-	// 检查数据库
-	dbInfo := h.checkDatabase()
-	response.Services["database"] = dbInfo
-	if dbInfo.Status != "healthy" {
-		allHealthy = false
-	}
-
-	// 检查Redis
-	redisInfo := h.checkRedis()
-	response.Services["redis"] = redisInfo
-	if redisInfo.Status != "healthy" {
-		allHealthy = false
-	}
-
-	// 设置整体状态
-	if !allHealthy {
-		response.Status = "unhealthy"
-		c.JSON(http.StatusServiceUnavailable, response)
-		return
-	}
-
-	c.JSON(http.StatusOK, response)
-}
-
-// checkDatabase 检查数据库健康状态
-func (h *HealthHandler) checkDatabase() ServiceInfo {
-	if h.db == nil {
-		return ServiceInfo{
-			Status:  "unhealthy",
-			Message: "数据库连接未初始化",
-		}
-	}
-
-	err := database.HealthCheck(h.db)
-	if err != nil {
-		return ServiceInfo{
-			Status:  "unhealthy",
-			Message: err.Error(),
-		}
-	}
-
-	// 获取数据库统计信息
-	stats, err := database.GetStats(h.db)
-	if err != nil {
-		return ServiceInfo{
-			Status:  "healthy",
-			Message: "数据库连接正常，但无法获取统计信息",
-		}
-	}
-
-	return ServiceInfo{
-		Status:  "healthy",
-		Message: "数据库连接正常",
-		Details: stats,
-	}
-}
-
-// checkRedis 检查Redis健康状态
-func (h *HealthHandler) checkRedis() ServiceInfo {
-	if h.cache == nil {
-		return ServiceInfo{
-			Status:  "unhealthy",
-			Message: "Redis连接未初始化",
-		}
-	}
-
-	err := h.cache.HealthCheck()
-	if err != nil {
-		return ServiceInfo{
-			Status:  "unhealthy",
-			Message: err.Error(),
-		}
-	}
-
-	// 获取Redis统计信息
-	stats, err := h.cache.GetStats()
-	if err != nil {
-		return ServiceInfo{
-			Status:  "healthy",
-			Message: "Redis连接正常，但无法获取统计信息",
-		}
-	}
-
-	return ServiceInfo{
-		Status:  "healthy",
-		Message: "Redis连接正常",
-		Details: stats,
-	}
+	h.respond(c, h.registry.Ready(c.Request.Context()))
 }
 
 // Readiness 就绪检查（用于Kubernetes）
 // @Summary 就绪检查
-// @Description 检查服务是否准备好接收流量
+// @Description 检查服务是否准备好接收流量，关键依赖不可用或服务正在关闭时返回 503
 // @Tags 健康检查
 // @Produce json
-// @Success 200 {object} map[string]string
-// @Failure 503 {object} map[string]string
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
 // @Router /health/ready [get]
 func (h *HealthHandler) Readiness(c *gin.Context) {
-	// 检查关键依赖
-	if h.db == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "not ready",
-			"message": "数据库未初始化",
-		})
-		return
-	}
-
-	if err := database.HealthCheck(h.db); err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "not ready",
-			"message": "数据库连接失败",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"status": "ready",
-	})
+	h.respond(c, h.registry.Ready(c.Request.Context()))
 }
 
 // Liveness 存活检查（用于Kubernetes）
 // @Summary 存活检查
-// @Description 检查服务是否存活
+// @Description 检查服务进程是否存活，不检查外部依赖
 // @Tags 健康检查
 // @Produce json
-// @Success 200 {object} map[string]string
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
 // @Router /health/live [get]
 func (h *HealthHandler) Liveness(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status": "alive",
-	})
+	h.respond(c, h.registry.Live(c.Request.Context()))
+}
+
+// Startup 启动检查（用于Kubernetes）
+// @Summary 启动检查
+// @Description 检查服务是否完成启动，通过后保持成功
+// @Tags 健康检查
+// @Produce json
+// @Success 200 {object} health.Report
+// @Failure 503 {object} health.Report
+// @Router /health/startup [get]
+func (h *HealthHandler) Startup(c *gin.Context) {
+	h.respond(c, h.registry.Startup(c.Request.Context()))
+}
+
+// respond 根据探针结果返回状态码
+func (h *HealthHandler) respond(c *gin.Context, report health.Report) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
 }
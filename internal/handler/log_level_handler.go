@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"go_demo/internal/models"
+	"go_demo/internal/utils"
+	"go_demo/pkg/logger"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogLevelHandler 日志级别管理处理器，挂载在运维管理端口上，不属于业务接口
+type LogLevelHandler struct{}
+
+// NewLogLevelHandler 创建日志级别管理处理器实例
+func NewLogLevelHandler() *LogLevelHandler {
+	return &LogLevelHandler{}
+}
+
+// GetLevel 获取当前日志级别
+func (h *LogLevelHandler) GetLevel(c *gin.Context) {
+	utils.ResponseSuccess(c, "获取成功", logger.GetLevelStatus())
+}
+
+// SetLevel 运行时修改日志级别
+func (h *LogLevelHandler) SetLevel(c *gin.Context) {
+	var req models.LogLevelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ResponseError(c, http.StatusBadRequest, "请求参数错误")
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		parsed, err := time.ParseDuration(req.TTL)
+		if err != nil || parsed < 0 {
+			utils.ResponseError(c, http.StatusBadRequest, "ttl 格式错误")
+			return
+		}
+		ttl = parsed
+	}
+
+	var names map[string]zapcore.Level
+	if req.Levels != nil {
+		parsed, err := logger.ParseLevels(*req.Levels)
+		if err != nil {
+			utils.ResponseError(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		names = parsed
+	}
+
+	if err := logger.SetLevels(req.Level, names, ttl); err != nil {
+		utils.ResponseError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := logger.GetLevelStatus()
+	logger.WarnCtx(c, "日志级别已修改",
+		logger.String("level", status.Level),
+		logger.String("levels", logger.FormatLevels(status.Levels)),
+		logger.Duration("ttl", ttl),
+	)
+
+	utils.ResponseSuccess(c, "修改成功", status)
+}
+
+// ResetLevel 恢复为配置文件中的日志级别
+func (h *LogLevelHandler) ResetLevel(c *gin.Context) {
+	logger.ResetLevels()
+	utils.ResponseSuccess(c, "恢复成功", logger.GetLevelStatus())
+}
@@ -34,13 +34,12 @@ type UserListResponse struct {
 
 func (h *UserHandler) GetUserlist(c *gin.Context) {
 	// 获取请求参数
-	requestID := middleware.GetTraceID(c)
 	page, _ := strconv.Atoi(c.DefaultPostForm("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultPostForm("size", "10"))
 
-	userList, total, err := h.userService.GetUserlist(page, pageSize)
+	userList, total, err := h.userService.GetUserlist(c.Request.Context(), page, pageSize)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
@@ -68,23 +67,19 @@ func (h *UserHandler) GetUserlist(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 获取分页参数
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
 
-	logger.Info("获取用户列表请求",
-		logger.String("request_id", requestID),
+	logger.InfoCtx(c, "获取用户列表请求",
 		logger.Int("page", page),
 		logger.Int("size", size),
-		logger.String("client_ip", c.ClientIP()),
 	)
 
 	// 调用服务层获取用户列表
-	users, total, err := h.userService.GetUsers(page, size)
+	users, total, err := h.userService.GetUsers(c.Request.Context(), page, size)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
@@ -96,8 +91,7 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 		Size:  size,
 	}
 
-	logger.Info("获取用户列表成功",
-		logger.String("request_id", requestID),
+	logger.InfoCtx(c, "获取用户列表成功",
 		logger.Int64("total", total),
 	)
 
@@ -119,8 +113,6 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/users/{id} [get]
 func (h *UserHandler) GetUser(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 获取用户ID参数
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -129,22 +121,19 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	logger.Info("获取用户详情请求",
-		logger.String("request_id", requestID),
-		logger.Int("user_id", id),
-		logger.String("client_ip", c.ClientIP()),
+	logger.InfoCtx(c, "获取用户详情请求",
+		logger.Int("target_user_id", id),
 	)
 
 	// 调用服务层获取用户信息
-	user, err := h.userService.GetUserByID(id)
+	user, err := h.userService.GetUserByID(c.Request.Context(), id)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
-	logger.Info("获取用户详情成功",
-		logger.String("request_id", requestID),
-		logger.Int("user_id", id),
+	logger.InfoCtx(c, "获取用户详情成功",
+		logger.Int("target_user_id", id),
 	)
 
 	utils.ResponseSuccess(c, "获取成功", user)
@@ -158,40 +147,36 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param request body models.UserCreateRequest true "创建用户请求"
+// @Param Idempotency-Key header string false "幂等键，重复请求将回放首次响应"
 // @Success 200 {object} utils.Response{data=models.UserResponse} "创建成功"
 // @Failure 400 {object} utils.Response "请求参数错误"
 // @Failure 401 {object} utils.Response "未认证"
 // @Failure 403 {object} utils.Response "权限不足"
 // @Failure 409 {object} utils.Response "用户已存在"
 // @Failure 500 {object} utils.Response "服务器内部错误"
+// @Failure 422 {object} utils.Response "幂等键已被用于不同的请求"
 // @Router /api/v1/users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 绑定并验证请求参数
 	var req models.UserCreateRequest
 	if !middleware.ValidateAndBind(c, &req) {
 		return
 	}
 
-	logger.Info("创建用户请求",
-		logger.String("request_id", requestID),
+	logger.InfoCtx(c, "创建用户请求",
 		logger.String("username", req.Username),
-		logger.String("email", req.Email),
-		logger.String("client_ip", c.ClientIP()),
 	)
 
 	// 调用服务层创建用户
-	user, err := h.userService.CreateUser(req)
+	user, err := h.userService.CreateUser(c.Request.Context(), req)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
-	logger.Info("创建用户成功",
-		logger.String("request_id", requestID),
+	logger.InfoCtx(c, "创建用户成功",
 		logger.String("username", req.Username),
-		logger.Int("user_id", int(user.ID)),
+		logger.Int("target_user_id", int(user.ID)),
 	)
 
 	utils.ResponseSuccess(c, "创建成功", user)
@@ -213,8 +198,6 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 获取用户ID参数
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -229,22 +212,19 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	logger.Info("更新用户请求",
-		logger.String("request_id", requestID),
-		logger.Int("user_id", id),
-		logger.String("client_ip", c.ClientIP()),
+	logger.InfoCtx(c, "更新用户请求",
+		logger.Int("target_user_id", id),
 	)
 
 	// 调用服务层更新用户
-	user, err := h.userService.UpdateUser(id, req)
+	user, err := h.userService.UpdateUser(c.Request.Context(), id, req)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
-	logger.Info("更新用户成功",
-		logger.String("request_id", requestID),
-		logger.Int("user_id", id),
+	logger.InfoCtx(c, "更新用户成功",
+		logger.Int("target_user_id", id),
 	)
 
 	utils.ResponseSuccess(c, "更新成功", user)
@@ -265,8 +245,6 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 获取用户ID参数
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
@@ -275,22 +253,19 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	logger.Info("删除用户请求",
-		logger.String("request_id", requestID),
-		logger.Int("user_id", id),
-		logger.String("client_ip", c.ClientIP()),
+	logger.InfoCtx(c, "删除用户请求",
+		logger.Int("target_user_id", id),
 	)
 
 	// 调用服务层删除用户
-	err = h.userService.DeleteUser(id)
+	err = h.userService.DeleteUser(c.Request.Context(), id)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
-	logger.Info("删除用户成功",
-		logger.String("request_id", requestID),
-		logger.Int("user_id", id),
+	logger.InfoCtx(c, "删除用户成功",
+		logger.Int("target_user_id", id),
 	)
 
 	utils.ResponseSuccess(c, "删除成功", nil)
@@ -310,8 +285,6 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/users/profile [put]
 func (h *UserHandler) UpdateProfile(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 从上下文获取用户ID（由认证中间件设置）
 	userIDInterface, exists := c.Get("user_id")
 	if !exists {
@@ -331,21 +304,18 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	logger.Info("更新用户资料请求",
-		logger.String("request_id", requestID),
+	logger.InfoCtx(c, "更新用户资料请求",
 		logger.Int64("user_id", userID),
-		logger.String("client_ip", c.ClientIP()),
 	)
 
 	// 调用服务层更新用户资料
-	user, err := h.userService.UpdateUserProfile(int(userID), req)
+	user, err := h.userService.UpdateUserProfile(c.Request.Context(), int(userID), req)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
-	logger.Info("更新用户资料成功",
-		logger.String("request_id", requestID),
+	logger.InfoCtx(c, "更新用户资料成功",
 		logger.Int64("user_id", userID),
 	)
 
@@ -366,8 +336,6 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/users/Password [put]
 func (h *UserHandler) ChangePassword(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
 	// 从上下文获取用户ID（由认证中间件设置）
 	userIDInterface, exists := c.Get("user_id")
 	if !exists {
@@ -387,21 +355,18 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	logger.Info("修改密码请求",
-		logger.String("request_id", requestID),
+	logger.InfoCtx(c, "修改密码请求",
 		logger.Int64("user_id", userID),
-		logger.String("client_ip", c.ClientIP()),
 	)
 
 	// 调用服务层修改密码
-	err := h.userService.ChangePassword(int(userID), req)
+	err := h.userService.ChangePassword(c.Request.Context(), int(userID), req)
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
-	logger.Info("修改密码成功",
-		logger.String("request_id", requestID),
+	logger.InfoCtx(c, "修改密码成功",
 		logger.Int64("user_id", userID),
 	)
 
@@ -420,23 +385,16 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 // @Failure 500 {object} utils.Response "服务器内部错误"
 // @Router /api/v1/users/stats [get]
 func (h *UserHandler) GetUserStats(c *gin.Context) {
-	requestID := middleware.GetTraceID(c)
-
-	logger.Info("获取用户统计信息请求",
-		logger.String("request_id", requestID),
-		logger.String("client_ip", c.ClientIP()),
-	)
+	logger.InfoCtx(c, "获取用户统计信息请求")
 
 	// 调用服务层获取统计信息
-	stats, err := h.userService.GetUserStats()
+	stats, err := h.userService.GetUserStats(c.Request.Context())
 	if err != nil {
-		handleServiceError(c, err, requestID)
+		handleServiceError(c, err)
 		return
 	}
 
-	logger.Info("获取用户统计信息成功",
-		logger.String("request_id", requestID),
-	)
+	logger.InfoCtx(c, "获取用户统计信息成功")
 
 	utils.ResponseSuccess(c, "获取成功", stats)
 }
@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"go_demo/internal/middleware"
+	"go_demo/internal/models"
+	"go_demo/internal/service"
+	"go_demo/internal/utils"
+	"go_demo/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler Webhook 订阅处理器
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+// NewWebhookHandler 创建 Webhook 订阅处理器实例
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// ListWebhooks 获取 Webhook 订阅列表
+// @Summary 获取 Webhook 订阅列表
+// @Description 获取全部 Webhook 订阅，不返回签名密钥
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=[]models.WebhookResponse} "获取成功"
+// @Failure 401 {object} utils.Response "未认证"
+// @Failure 403 {object} utils.Response "非管理员"
+// @Failure 500 {object} utils.Response "服务器内部错误"
+// @Router /api/v1/webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.ListWebhooks(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	utils.ResponseSuccess(c, "获取成功", webhooks)
+}
+
+// CreateWebhook 创建 Webhook 订阅
+// @Summary 创建 Webhook 订阅
+// @Description 创建 Webhook 订阅，未指定密钥时自动生成，密钥只在创建时返回
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.WebhookCreateRequest true "创建订阅请求"
+// @Success 200 {object} utils.Response{data=models.WebhookResponse} "创建成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 401 {object} utils.Response "未认证"
+// @Failure 403 {object} utils.Response "非管理员"
+// @Failure 500 {object} utils.Response "服务器内部错误"
+// @Router /api/v1/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.WebhookCreateRequest
+	if !middleware.ValidateAndBind(c, &req) {
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(c.Request.Context(), req)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	logger.InfoCtx(c, "创建 webhook 订阅成功",
+		logger.Int("webhook_id", int(webhook.ID)),
+	)
+
+	utils.ResponseSuccess(c, "创建成功", webhook)
+}
+
+// GetWebhook 获取 Webhook 订阅详情
+// @Summary 获取 Webhook 订阅详情
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订阅ID"
+// @Success 200 {object} utils.Response{data=models.WebhookResponse} "获取成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 401 {object} utils.Response "未认证"
+// @Failure 403 {object} utils.Response "非管理员"
+// @Failure 404 {object} utils.Response "订阅不存在"
+// @Router /api/v1/webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(c.Request.Context(), id)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	utils.ResponseSuccess(c, "获取成功", webhook)
+}
+
+// UpdateWebhook 更新 Webhook 订阅
+// @Summary 更新 Webhook 订阅
+// @Description 更新 Webhook 订阅，未提供的字段不修改；修改密钥时在响应中返回新密钥
+// @Tags Webhook
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订阅ID"
+// @Param request body models.WebhookUpdateRequest true "更新订阅请求"
+// @Success 200 {object} utils.Response{data=models.WebhookResponse} "更新成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 401 {object} utils.Response "未认证"
+// @Failure 403 {object} utils.Response "非管理员"
+// @Failure 404 {object} utils.Response "订阅不存在"
+// @Router /api/v1/webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	var req models.WebhookUpdateRequest
+	if !middleware.ValidateAndBind(c, &req) {
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(c.Request.Context(), id, req)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	logger.InfoCtx(c, "更新 webhook 订阅成功",
+		logger.Int("webhook_id", int(id)),
+	)
+
+	utils.ResponseSuccess(c, "更新成功", webhook)
+}
+
+// DeleteWebhook 删除 Webhook 订阅
+// @Summary 删除 Webhook 订阅
+// @Description 删除 Webhook 订阅，保留投递记录，未完成的投递不再发送
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订阅ID"
+// @Success 200 {object} utils.Response "删除成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 401 {object} utils.Response "未认证"
+// @Failure 403 {object} utils.Response "非管理员"
+// @Failure 404 {object} utils.Response "订阅不存在"
+// @Router /api/v1/webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), id); err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	logger.InfoCtx(c, "删除 webhook 订阅成功",
+		logger.Int("webhook_id", int(id)),
+	)
+
+	utils.ResponseSuccess(c, "删除成功", nil)
+}
+
+// ListDeliveries 获取 Webhook 投递记录
+// @Summary 获取 Webhook 投递记录
+// @Description 分页获取订阅的投递记录，最新的在前
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订阅ID"
+// @Param status query string false "投递状态" Enums(pending, succeeded, dead)
+// @Param page query int false "页码" default(1)
+// @Param size query int false "每页数量" default(10)
+// @Success 200 {object} utils.Response{data=models.WebhookDeliveryListResponse} "获取成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 401 {object} utils.Response "未认证"
+// @Failure 403 {object} utils.Response "非管理员"
+// @Failure 404 {object} utils.Response "订阅不存在"
+// @Router /api/v1/webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	size, _ := strconv.Atoi(c.DefaultQuery("size", "10"))
+
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Request.Context(), id, c.Query("status"), page, size)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+
+	utils.ResponseSuccess(c, "获取成功", models.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       page,
+		Size:       size,
+	})
+}
+
+// GetDelivery 获取 Webhook 投递详情
+// @Summary 获取 Webhook 投递详情
+// @Description 获取投递记录的请求体和每一次请求的结果
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订阅ID"
+// @Param delivery_id path int true "投递记录ID"
+// @Success 200 {object} utils.Response{data=models.WebhookDeliveryResponse} "获取成功"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 401 {object} utils.Response "未认证"
+// @Failure 403 {object} utils.Response "非管理员"
+// @Failure 404 {object} utils.Response "投递记录不存在"
+// @Router /api/v1/webhooks/{id}/deliveries/{delivery_id} [get]
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	id, deliveryID, ok := webhookDeliveryID(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	utils.ResponseSuccess(c, "获取成功", delivery)
+}
+
+// Redeliver 重新投递 Webhook
+// @Summary 重新投递 Webhook
+// @Description 立即重新发送一次请求并清零投递次数，失败时按退避时间继续重试
+// @Tags Webhook
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "订阅ID"
+// @Param delivery_id path int true "投递记录ID"
+// @Success 200 {object} utils.Response{data=models.WebhookDeliveryResponse} "已重新投递，结果见 status"
+// @Failure 400 {object} utils.Response "请求参数错误"
+// @Failure 401 {object} utils.Response "未认证"
+// @Failure 403 {object} utils.Response "非管理员"
+// @Failure 404 {object} utils.Response "投递记录不存在"
+// @Failure 409 {object} utils.Response "订阅已停用"
+// @Router /api/v1/webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, deliveryID, ok := webhookDeliveryID(c)
+	if !ok {
+		return
+	}
+
+	logger.InfoCtx(c, "重新投递 webhook 请求",
+		logger.Int("webhook_id", int(id)),
+		logger.Int64("delivery_id", int64(deliveryID)),
+	)
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		handleServiceError(c, err)
+		return
+	}
+	utils.ResponseSuccess(c, "已重新投递", delivery)
+}
+
+// webhookID 解析路径中的订阅ID，无效时返回 400
+func webhookID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ResponseError(c, http.StatusBadRequest, "无效的订阅ID")
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// webhookDeliveryID 解析路径中的订阅ID和投递记录ID，无效时返回 400
+func webhookDeliveryID(c *gin.Context) (uint, uint64, bool) {
+	id, ok := webhookID(c)
+	if !ok {
+		return 0, 0, false
+	}
+	deliveryID, err := strconv.ParseUint(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		utils.ResponseError(c, http.StatusBadRequest, "无效的投递记录ID")
+		return 0, 0, false
+	}
+	return id, deliveryID, true
+}
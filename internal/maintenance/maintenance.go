@@ -0,0 +1,253 @@
+// Package maintenance 提供数据维护的定时任务
+//
+// 任务由 scheduler 按配置的 cron 表达式执行，也可以通过 go_demo task run <name> 手动执行：
+//
+//	purge_deleted_users       永久删除软删除超过保留期的用户
+//	disable_inactive_users    禁用超过指定天数未登录的用户，从未登录的按注册时间计算
+//	expire_unactivated_users  删除注册后超过有效期仍未激活的用户
+//	compact_audit_logs        删除超过保留期的已投递事件、已结束的 Webhook 投递记录和定时任务执行记录
+//
+// 每个任务分批处理，每批在一个事务中完成，变更用户状态时在同一事务中发布领域事件。
+package maintenance
+
+import (
+	"context"
+	"fmt"
+	"go_demo/internal/events"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"go_demo/internal/scheduler"
+	"time"
+)
+
+// 任务名称
+const (
+	TaskPurgeDeletedUsers      = "purge_deleted_users"
+	TaskDisableInactiveUsers   = "disable_inactive_users"
+	TaskExpireUnactivatedUsers = "expire_unactivated_users"
+	TaskCompactAuditLogs       = "compact_audit_logs"
+)
+
+// RetentionTask 按保留时间清理数据的任务配置
+type RetentionTask struct {
+	Schedule  string        `mapstructure:"schedule" yaml:"schedule" validate:"omitempty,cron"` // cron 表达式，为空时只能手动执行
+	Retention time.Duration `mapstructure:"retention" yaml:"retention" validate:"min=0"`        // 保留时间
+}
+
+// InactiveUsersTask 禁用长期未登录用户的任务配置
+type InactiveUsersTask struct {
+	Schedule     string `mapstructure:"schedule" yaml:"schedule" validate:"omitempty,cron"`  // cron 表达式，为空时只能手动执行
+	InactiveDays int    `mapstructure:"inactive_days" yaml:"inactive_days" validate:"min=0"` // 超过多少天未登录的用户被禁用
+}
+
+// Config 数据维护任务配置
+type Config struct {
+	BatchSize              int               `mapstructure:"batch_size" yaml:"batch_size" validate:"min=0"`            // 每批处理的记录数，默认 500
+	PurgeDeletedUsers      RetentionTask     `mapstructure:"purge_deleted_users" yaml:"purge_deleted_users"`           // 软删除用户的保留时间，默认 30 天
+	DisableInactiveUsers   InactiveUsersTask `mapstructure:"disable_inactive_users" yaml:"disable_inactive_users"`     // 默认禁用 90 天未登录的用户
+	ExpireUnactivatedUsers RetentionTask     `mapstructure:"expire_unactivated_users" yaml:"expire_unactivated_users"` // 未激活用户的有效期，默认 72 小时
+	CompactAuditLogs       RetentionTask     `mapstructure:"compact_audit_logs" yaml:"compact_audit_logs"`             // 事件和投递记录的保留时间，默认 90 天
+}
+
+// DefaultConfig 默认数据维护任务配置
+func DefaultConfig() Config {
+	return Config{
+		BatchSize:              500,
+		PurgeDeletedUsers:      RetentionTask{Schedule: "30 3 * * *", Retention: 30 * 24 * time.Hour},
+		DisableInactiveUsers:   InactiveUsersTask{Schedule: "0 4 * * *", InactiveDays: 90},
+		ExpireUnactivatedUsers: RetentionTask{Schedule: "15 * * * *", Retention: 72 * time.Hour},
+		CompactAuditLogs:       RetentionTask{Schedule: "0 5 * * *", Retention: 90 * 24 * time.Hour},
+	}
+}
+
+// Maintainer 数据维护任务
+type Maintainer struct {
+	users     repository.UserRepository
+	outbox    repository.OutboxRepository
+	webhooks  repository.WebhookRepository
+	taskRuns  repository.TaskRunRepository
+	tx        repository.TxManager
+	publisher events.Publisher
+	config    Config
+}
+
+// New 创建数据维护任务，未设置的配置项使用默认值
+func New(
+	users repository.UserRepository,
+	outbox repository.OutboxRepository,
+	webhooks repository.WebhookRepository,
+	taskRuns repository.TaskRunRepository,
+	tx repository.TxManager,
+	config Config,
+) *Maintainer {
+	defaults := DefaultConfig()
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.PurgeDeletedUsers.Retention <= 0 {
+		config.PurgeDeletedUsers.Retention = defaults.PurgeDeletedUsers.Retention
+	}
+	if config.DisableInactiveUsers.InactiveDays <= 0 {
+		config.DisableInactiveUsers.InactiveDays = defaults.DisableInactiveUsers.InactiveDays
+	}
+	if config.ExpireUnactivatedUsers.Retention <= 0 {
+		config.ExpireUnactivatedUsers.Retention = defaults.ExpireUnactivatedUsers.Retention
+	}
+	if config.CompactAuditLogs.Retention <= 0 {
+		config.CompactAuditLogs.Retention = defaults.CompactAuditLogs.Retention
+	}
+	return &Maintainer{
+		users:     users,
+		outbox:    outbox,
+		webhooks:  webhooks,
+		taskRuns:  taskRuns,
+		tx:        tx,
+		publisher: events.NewOutboxPublisher(outbox),
+		config:    config,
+	}
+}
+
+// Tasks 返回全部数据维护任务，用于注册到调度器
+func (m *Maintainer) Tasks() []scheduler.Task {
+	return []scheduler.Task{
+		{
+			Name:        TaskPurgeDeletedUsers,
+			Description: "永久删除软删除超过保留期的用户",
+			Schedule:    m.config.PurgeDeletedUsers.Schedule,
+			Run:         m.PurgeDeletedUsers,
+		},
+		{
+			Name:        TaskDisableInactiveUsers,
+			Description: "禁用长期未登录的用户",
+			Schedule:    m.config.DisableInactiveUsers.Schedule,
+			Run:         m.DisableInactiveUsers,
+		},
+		{
+			Name:        TaskExpireUnactivatedUsers,
+			Description: "删除超过有效期仍未激活的用户",
+			Schedule:    m.config.ExpireUnactivatedUsers.Schedule,
+			Run:         m.ExpireUnactivatedUsers,
+		},
+		{
+			Name:        TaskCompactAuditLogs,
+			Description: "清理历史事件、Webhook 投递记录和定时任务执行记录",
+			Schedule:    m.config.CompactAuditLogs.Schedule,
+			Run:         m.CompactAuditLogs,
+		},
+	}
+}
+
+// PurgeDeletedUsers 永久删除软删除超过保留期的用户，删除事件已在软删除时发布
+func (m *Maintainer) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	before := time.Now().Add(-m.config.PurgeDeletedUsers.Retention)
+	return m.batches(ctx, func(ctx context.Context) (int, int64, error) {
+		users, err := m.users.FindDeletedBefore(ctx, before, m.config.BatchSize)
+		if err != nil || len(users) == 0 {
+			return 0, 0, err
+		}
+		n, err := m.users.Purge(ctx, userIDs(users))
+		return len(users), n, err
+	})
+}
+
+// DisableInactiveUsers 禁用长期未登录的用户
+func (m *Maintainer) DisableInactiveUsers(ctx context.Context) (int64, error) {
+	before := time.Now().AddDate(0, 0, -m.config.DisableInactiveUsers.InactiveDays)
+	return m.batches(ctx, func(ctx context.Context) (int, int64, error) {
+		users, err := m.users.FindInactiveSince(ctx, before, m.config.BatchSize)
+		if err != nil || len(users) == 0 {
+			return 0, 0, err
+		}
+		ids := make([]int, len(users))
+		changes := make([]events.Event, len(users))
+		for i, user := range users {
+			ids[i] = int(user.ID)
+			changes[i] = events.UserStatusChanged{
+				UserEvent: events.UserEvent{UserID: user.ID},
+				OldStatus: user.Status,
+				NewStatus: 0,
+			}
+		}
+		if err := m.users.BatchUpdateStatus(ctx, ids, 0); err != nil {
+			return 0, 0, err
+		}
+		return len(users), int64(len(users)), m.publisher.Publish(ctx, changes...)
+	})
+}
+
+// ExpireUnactivatedUsers 永久删除超过有效期仍未激活的用户，释放其用户名和邮箱
+func (m *Maintainer) ExpireUnactivatedUsers(ctx context.Context) (int64, error) {
+	before := time.Now().Add(-m.config.ExpireUnactivatedUsers.Retention)
+	return m.batches(ctx, func(ctx context.Context) (int, int64, error) {
+		users, err := m.users.FindUnactivatedBefore(ctx, before, m.config.BatchSize)
+		if err != nil || len(users) == 0 {
+			return 0, 0, err
+		}
+		deleted := make([]events.Event, len(users))
+		for i, user := range users {
+			deleted[i] = events.UserDeleted{UserEvent: events.UserEvent{UserID: user.ID}, Username: user.Username}
+		}
+		n, err := m.users.Purge(ctx, userIDs(users))
+		if err != nil {
+			return 0, 0, err
+		}
+		return len(users), n, m.publisher.Publish(ctx, deleted...)
+	})
+}
+
+// CompactAuditLogs 删除超过保留期的已投递事件、已结束的 Webhook 投递记录和定时任务执行记录
+func (m *Maintainer) CompactAuditLogs(ctx context.Context) (int64, error) {
+	before := time.Now().Add(-m.config.CompactAuditLogs.Retention)
+	total, err := m.outbox.DeleteProcessed(ctx, before)
+	if err != nil {
+		return total, fmt.Errorf("清理已投递事件失败: %w", err)
+	}
+	deliveries, err := m.batches(ctx, func(ctx context.Context) (int, int64, error) {
+		n, err := m.webhooks.DeleteFinishedDeliveries(ctx, before, m.config.BatchSize)
+		return int(n), n, err
+	})
+	total += deliveries
+	if err != nil {
+		return total, fmt.Errorf("清理 Webhook 投递记录失败: %w", err)
+	}
+	runs, err := m.taskRuns.DeleteBefore(ctx, before)
+	total += runs
+	if err != nil {
+		return total, fmt.Errorf("清理定时任务执行记录失败: %w", err)
+	}
+	return total, nil
+}
+
+// batches 在事务中反复执行 batch，直到一批读取的记录数少于 BatchSize，返回处理的记录总数
+// batch 返回本批读取的记录数和处理的记录数
+func (m *Maintainer) batches(ctx context.Context, batch func(ctx context.Context) (int, int64, error)) (int64, error) {
+	var total int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		var read int
+		var affected int64
+		err := m.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+			var err error
+			read, affected, err = batch(ctx)
+			return err
+		})
+		if err != nil {
+			return total, err
+		}
+		total += affected
+		if read < m.config.BatchSize {
+			return total, nil
+		}
+	}
+}
+
+// userIDs 提取用户ID
+func userIDs(users []models.User) []uint {
+	ids := make([]uint, len(users))
+	for i, user := range users {
+		ids[i] = user.ID
+	}
+	return ids
+}
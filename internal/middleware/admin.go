@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+
+	"go_demo/internal/utils"
+	"go_demo/pkg/logger"
+)
+
+// adminUsers 当前生效的管理员用户名
+var adminUsers atomic.Pointer[map[string]struct{}]
+
+func init() {
+	SetAdminUsers(nil)
+}
+
+// SetAdminUsers 设置管理员用户名，对已注册的管理员中间件立即生效
+func SetAdminUsers(usernames []string) {
+	users := make(map[string]struct{}, len(usernames))
+	for _, username := range usernames {
+		users[username] = struct{}{}
+	}
+	adminUsers.Store(&users)
+}
+
+// AdminOnly 管理员权限中间件，需在 JWTAuthMiddleware 之后使用
+// 用户名不在管理员列表（server.admin_users）中时返回 403，列表为空时拒绝全部请求
+func AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.GetString("username")
+		if _, ok := (*adminUsers.Load())[username]; !ok || username == "" {
+			logger.WarnCtx(c, "权限不足：非管理员访问管理接口",
+				logger.String("username", username),
+				logger.String("path", c.Request.URL.Path),
+			)
+			utils.ResponseError(c, http.StatusForbidden, "权限不足")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
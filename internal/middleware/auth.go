@@ -2,11 +2,13 @@ package middleware
 
 import (
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 
 	"go_demo/internal/utils"
+	"go_demo/pkg/database"
 	"go_demo/pkg/logger"
 )
 
@@ -63,6 +65,9 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 
 		c.Set("user_id", userID)
 		c.Set("username", username)
+		// 读写分离时，该用户写入后的短时间内读主库，保证读到自己的写入
+		ctx := database.WithStickyKey(c.Request.Context(), "user:"+strconv.FormatInt(userID, 10))
+		c.Request = c.Request.WithContext(logger.WithContext(ctx, logger.Int64("user_id", userID)))
 
 		logger.Debug("JWT认证通过",
 			logger.String("request_id", requestID),
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig 跨域配置，支持热更新
+type CORSConfig struct {
+	AllowOrigins     []string // 允许的来源，"*" 表示全部，支持 "https://*.example.com" 形式的子域名通配
+	AllowCredentials bool     // 是否允许携带凭据
+	MaxAge           int      // 预检请求缓存时间（秒），0 表示不设置
+}
+
+// DefaultCORSConfig 默认跨域配置，允许所有来源
+func DefaultCORSConfig() CORSConfig {
+	return CORSConfig{
+		AllowOrigins:     []string{"*"},
+		AllowCredentials: true,
+	}
+}
+
+// corsConfig 当前生效的跨域配置
+var corsConfig atomic.Pointer[CORSConfig]
+
+func init() {
+	SetCORSConfig(DefaultCORSConfig())
+}
+
+// SetCORSConfig 设置跨域配置，对已注册的 CORS 中间件立即生效
+func SetCORSConfig(config CORSConfig) {
+	if len(config.AllowOrigins) == 0 {
+		config.AllowOrigins = DefaultCORSConfig().AllowOrigins
+	}
+	corsConfig.Store(&config)
+}
+
+// CORS CORS中间件，每个请求读取当前生效的跨域配置
+func CORS() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		config := corsConfig.Load()
+
+		if origin, ok := allowedOrigin(config.AllowOrigins, c.GetHeader("Origin")); ok {
+			c.Header("Access-Control-Allow-Origin", origin)
+			if origin != "*" {
+				c.Header("Vary", "Origin")
+			}
+			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Request-ID, Idempotency-Key")
+			c.Header("Access-Control-Expose-Headers", "Content-Length, X-Request-ID, Idempotent-Replayed, Retry-After")
+			if config.AllowCredentials {
+				c.Header("Access-Control-Allow-Credentials", "true")
+			}
+			if config.MaxAge > 0 {
+				c.Header("Access-Control-Max-Age", strconv.Itoa(config.MaxAge))
+			}
+		}
+
+		if c.Request.Method == "OPTIONS" {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// allowedOrigin 判断来源是否允许，返回 Access-Control-Allow-Origin 的值
+func allowedOrigin(allowed []string, origin string) (string, bool) {
+	for _, pattern := range allowed {
+		if pattern == "*" {
+			return "*", true
+		}
+		if origin == "" {
+			continue
+		}
+		if strings.EqualFold(pattern, origin) {
+			return origin, true
+		}
+		// 子域名通配，如 https://*.example.com
+		if i := strings.Index(pattern, "*."); i >= 0 {
+			prefix, suffix := strings.ToLower(pattern[:i]), strings.ToLower(pattern[i+1:])
+			lower := strings.ToLower(origin)
+			if strings.HasPrefix(lower, prefix) && strings.HasSuffix(lower, suffix) && len(lower) > len(prefix)+len(suffix) {
+				return origin, true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,220 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go_demo/internal/utils"
+	"go_demo/pkg/cache"
+	"go_demo/pkg/logger"
+)
+
+// 幂等记录状态
+const (
+	idempotencyStatusProcessing = "processing" // 首个请求仍在处理中
+	idempotencyStatusCompleted  = "completed"  // 首个请求已完成，响应可回放
+)
+
+// IdempotencyConfig 幂等中间件配置
+type IdempotencyConfig struct {
+	HeaderName string        // 幂等键请求头名称
+	KeyPrefix  string        // 缓存键前缀
+	TTL        time.Duration // 已完成响应的保留时间
+	LockTTL    time.Duration // 处理中标记的保留时间，防止进程崩溃后键被永久占用
+	MaxKeyLen  int           // 幂等键最大长度
+}
+
+// DefaultIdempotencyConfig 默认幂等中间件配置
+func DefaultIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		HeaderName: "Idempotency-Key",
+		KeyPrefix:  "idempotency:",
+		TTL:        24 * time.Hour,
+		LockTTL:    30 * time.Second,
+		MaxKeyLen:  255,
+	}
+}
+
+// idempotencyRecord 缓存中保存的幂等记录
+type idempotencyRecord struct {
+	Status      string              `json:"status"`
+	Fingerprint string              `json:"fingerprint"`
+	StatusCode  int                 `json:"status_code,omitempty"`
+	Headers     map[string][]string `json:"headers,omitempty"`
+	Body        []byte              `json:"body,omitempty"`
+}
+
+// Idempotency 幂等中间件，使用默认配置
+func Idempotency(store cache.CacheInterface) gin.HandlerFunc {
+	return IdempotencyWithConfig(store, DefaultIdempotencyConfig())
+}
+
+// IdempotencyWithConfig 带配置的幂等中间件
+// 根据 Idempotency-Key 请求头保存首次请求的响应（状态码、响应头、响应体），
+// 重复请求直接回放；并发的重复请求返回 409；相同键但请求体不同返回 422。
+// 缓存不可用时放行请求，不影响正常业务。
+func IdempotencyWithConfig(store cache.CacheInterface, config IdempotencyConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idemKey := c.GetHeader(config.HeaderName)
+		if store == nil || idemKey == "" {
+			c.Next()
+			return
+		}
+
+		requestID := utils.GetRequestID(c)
+
+		if config.MaxKeyLen > 0 && len(idemKey) > config.MaxKeyLen {
+			utils.ResponseError(c, http.StatusBadRequest, fmt.Sprintf("%s长度不能超过%d", config.HeaderName, config.MaxKeyLen))
+			c.Abort()
+			return
+		}
+
+		// 读取请求体计算指纹
+		var requestBody []byte
+		if c.Request.Body != nil {
+			requestBody, _ = io.ReadAll(c.Request.Body)
+			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+		}
+		fingerprint := requestFingerprint(c.Request.Method, c.FullPath(), requestBody)
+		cacheKey := idempotencyCacheKey(c, config.KeyPrefix, idemKey)
+
+		// 抢占处理权
+		ctx := c.Request.Context()
+		acquired, err := store.SetNX(ctx, cacheKey, idempotencyRecord{
+			Status:      idempotencyStatusProcessing,
+			Fingerprint: fingerprint,
+		}, config.LockTTL)
+		if err != nil {
+			logger.Warn("幂等键加锁失败，跳过幂等处理",
+				logger.String("request_id", requestID),
+				logger.String("idempotency_key", idemKey),
+				logger.Err(err),
+			)
+			c.Next()
+			return
+		}
+
+		if !acquired {
+			var record idempotencyRecord
+			if err := store.GetObject(ctx, cacheKey, &record); err != nil {
+				// 记录恰好过期或被删除，视为并发冲突，由客户端重试
+				utils.ResponseError(c, http.StatusConflict, "相同幂等键的请求正在处理中")
+				c.Abort()
+				return
+			}
+			replayIdempotentResponse(c, &record, fingerprint)
+			return
+		}
+
+		// 包装ResponseWriter以捕获响应内容
+		blw := &responseWriter{
+			ResponseWriter: c.Writer,
+			body:           bytes.NewBufferString(""),
+		}
+		c.Writer = blw
+
+		c.Next()
+
+		statusCode := c.Writer.Status()
+		// 客户端断开后仍需保存结果或释放幂等键
+		ctx = context.WithoutCancel(ctx)
+
+		// 服务端错误不缓存，允许客户端使用相同的键重试
+		if statusCode >= http.StatusInternalServerError {
+			if err := store.Delete(ctx, cacheKey); err != nil {
+				logger.Warn("释放幂等键失败",
+					logger.String("request_id", requestID),
+					logger.String("idempotency_key", idemKey),
+					logger.Err(err),
+				)
+			}
+			return
+		}
+
+		record := idempotencyRecord{
+			Status:      idempotencyStatusCompleted,
+			Fingerprint: fingerprint,
+			StatusCode:  statusCode,
+			Headers:     replayableHeaders(c.Writer.Header()),
+			Body:        blw.body.Bytes(),
+		}
+		if err := store.Set(ctx, cacheKey, record, config.TTL); err != nil {
+			logger.Warn("保存幂等响应失败",
+				logger.String("request_id", requestID),
+				logger.String("idempotency_key", idemKey),
+				logger.Err(err),
+			)
+		}
+	}
+}
+
+// replayIdempotentResponse 根据已有记录回放响应或返回冲突错误
+func replayIdempotentResponse(c *gin.Context, record *idempotencyRecord, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		utils.ResponseError(c, http.StatusUnprocessableEntity, "幂等键已被用于不同的请求")
+		c.Abort()
+		return
+	}
+
+	if record.Status != idempotencyStatusCompleted {
+		utils.ResponseError(c, http.StatusConflict, "相同幂等键的请求正在处理中")
+		c.Abort()
+		return
+	}
+
+	for name, values := range record.Headers {
+		for _, v := range values {
+			c.Writer.Header().Add(name, v)
+		}
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.StatusCode, c.Writer.Header().Get("Content-Type"), record.Body)
+	c.Abort()
+}
+
+// idempotencyCacheKey 生成幂等缓存键，按用户隔离，未登录请求统一归为 anonymous
+func idempotencyCacheKey(c *gin.Context, prefix, idemKey string) string {
+	owner := "anonymous"
+	if userID, exists := c.Get("user_id"); exists {
+		owner = fmt.Sprintf("%v", userID)
+	}
+	return fmt.Sprintf("%s%s:%s", prefix, owner, idemKey)
+}
+
+// requestFingerprint 计算请求指纹（方法 + 路由 + 请求体）
+func requestFingerprint(method, route string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(route))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayableHeaders 过滤出可以回放的响应头，与单次请求相关的头由当前请求重新生成
+func replayableHeaders(header http.Header) map[string][]string {
+	skip := map[string]bool{
+		"X-Request-Id":   true,
+		"X-Trace-Id":     true,
+		"Content-Length": true,
+		"Date":           true,
+	}
+	headers := make(map[string][]string, len(header))
+	for name, values := range header {
+		if skip[http.CanonicalHeaderKey(name)] || strings.HasPrefix(name, "Access-Control-") {
+			continue
+		}
+		headers[name] = append([]string(nil), values...)
+	}
+	return headers
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"go_demo/internal/utils"
+	"go_demo/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LogContext 日志上下文中间件
+// 将 request_id、trace_id、span_id、route、client_ip 写入请求上下文，后续通过 logger.FromContext 记录日志时自动携带。
+// 需注册在 RequestID 和 Trace 之后；user_id 由认证中间件在认证通过后补充。
+func LogContext() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fields := make([]logger.Field, 0, 6)
+		if requestID := utils.GetRequestID(c); requestID != "" {
+			fields = append(fields, logger.String("request_id", requestID))
+		}
+		if traceID := GetTraceID(c); traceID != "" {
+			fields = append(fields, logger.String("trace_id", traceID))
+		}
+		if spanID := c.GetString("span_id"); spanID != "" {
+			fields = append(fields, logger.String("span_id", spanID))
+		}
+		if route := c.FullPath(); route != "" {
+			fields = append(fields, logger.String("route", route))
+		}
+		if clientIP := utils.GetClientIP(c); clientIP != "" {
+			fields = append(fields, logger.String("client_ip", clientIP))
+		}
+		if userID, ok := c.Get("user_id"); ok {
+			if id, ok := userID.(int64); ok {
+				fields = append(fields, logger.Int64("user_id", id))
+			}
+		}
+
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), fields...))
+		c.Next()
+	}
+}
@@ -3,7 +3,11 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net/url"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -25,68 +29,9 @@ func (w responseWriter) Write(b []byte) (int, error) {
 }
 
 // RequestLog 请求日志中间件 - 记录请求参数和返回值
+// 敏感字段、请求头按 logger.GetRedactor() 的规则脱敏
 func RequestLog() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		startTime := time.Now()
-		requestID := utils.GetRequestID(c)
-		traceId := c.GetString("trace_id")
-
-		// 读取请求体
-		var requestBody []byte
-		if c.Request.Body != nil {
-			requestBody, _ = io.ReadAll(c.Request.Body)
-			// 重新设置请求体，以便后续处理器可以读取
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
-		}
-
-		// 获取查询参数
-		queryParams := c.Request.URL.Query()
-
-		// 获取路径参数
-		pathParams := make(map[string]string)
-		for _, param := range c.Params {
-			pathParams[param.Key] = param.Value
-		}
-
-		// 包装ResponseWriter以捕获响应内容
-		blw := &responseWriter{
-			ResponseWriter: c.Writer,
-			body:           bytes.NewBufferString(""),
-		}
-		c.Writer = blw
-
-		// 处理请求
-		c.Next()
-
-		// 计算处理时间
-		duration := time.Since(startTime)
-
-		// 获取响应内容
-		responseBody := blw.body.String()
-
-		// 尝试解析响应为JSON以便更好的日志记录
-		var responseData interface{}
-		if err := json.Unmarshal([]byte(responseBody), &responseData); err != nil {
-			// 如果不是JSON，直接记录字符串
-			responseData = responseBody
-		}
-
-		// 记录完整的请求和响应信息
-		logger.ReqInfo("req",
-			logger.String("trace_id", traceId),
-			logger.String("request_id", requestID),
-			logger.String("method", c.Request.Method),
-			logger.String("path", c.Request.URL.Path),
-			logger.String("client_ip", c.ClientIP()),
-			logger.String("user_agent", c.Request.UserAgent()),
-			logger.Any("query_params", queryParams),
-			logger.Any("path_params", pathParams),
-			logger.String("request_body", string(requestBody)),
-			logger.Int("status_code", c.Writer.Status()),
-			logger.String("duration", duration.String()),
-			logger.Any("response_body", responseData),
-		)
-	}
+	return RequestLogWithConfig(DefaultRequestLogConfig())
 }
 
 // RequestLogWithConfig 带配置的请求日志中间件
@@ -100,10 +45,16 @@ func RequestLogWithConfig(config RequestLogConfig) gin.HandlerFunc {
 		startTime := time.Now()
 		// 获取请求ID，用于请求追踪
 		requestID := utils.GetRequestID(c)
+		// 获取脱敏器
+		redactor := config.Redactor
+		if redactor == nil {
+			redactor = logger.GetRedactor()
+		}
+		requestContentType := c.ContentType()
 
-		// 读取请求体
+		// 读取请求体（不在白名单内的Content-Type不读取，避免缓冲大文件）
 		var requestBody []byte
-		if c.Request.Body != nil && config.LogRequestBody {
+		if c.Request.Body != nil && config.LogRequestBody && redactor.AllowContentType(requestContentType) {
 			requestBody, _ = io.ReadAll(c.Request.Body)
 			// 重新设置请求体，以便后续处理器可以读取
 			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
@@ -112,7 +63,7 @@ func RequestLogWithConfig(config RequestLogConfig) gin.HandlerFunc {
 		// 获取查询参数
 		var queryParams map[string][]string
 		if config.LogQueryParams {
-			queryParams = c.Request.URL.Query()
+			queryParams = redactor.RedactValues(c.Request.URL.Query())
 		}
 
 		// 获取路径参数
@@ -127,7 +78,7 @@ func RequestLogWithConfig(config RequestLogConfig) gin.HandlerFunc {
 		// 获取请求头
 		var headers map[string][]string
 		if config.LogHeaders {
-			headers = c.Request.Header
+			headers = redactor.RedactHeaders(c.Request.Header)
 		}
 
 		// 包装ResponseWriter以捕获响应内容
@@ -146,6 +97,11 @@ func RequestLogWithConfig(config RequestLogConfig) gin.HandlerFunc {
 		// 计算处理时间
 		duration := time.Since(startTime)
 
+		// 按路由采样，未命中采样的请求不记录
+		if !logger.SampleRequest(c.Request.Method, c.FullPath(), c.Writer.Status(), requestID) {
+			return
+		}
+
 		// 构建完整的请求和响应日志字段
 		// 这些是无论如何都会记录的基础字段
 		logFields := []zap.Field{
@@ -171,11 +127,15 @@ func RequestLogWithConfig(config RequestLogConfig) gin.HandlerFunc {
 		if config.LogHeaders && headers != nil {
 			logFields = append(logFields, logger.Any("headers", headers))
 		}
-		if config.LogRequestBody && len(requestBody) > 0 {
-			logFields = append(logFields, logger.String("request_body", string(requestBody)))
+		if config.LogRequestBody && c.Request.ContentLength != 0 {
+			if len(requestBody) > 0 {
+				logFields = append(logFields, logger.String("request_body", redactor.RedactBody(requestContentType, requestBody)))
+			} else if !redactor.AllowContentType(requestContentType) {
+				logFields = append(logFields, logger.String("request_body", fmt.Sprintf("[omitted content-type=%s]", requestContentType)))
+			}
 		}
 		if config.LogResponseBody && blw != nil {
-			responseBody := blw.body.String()
+			responseBody := redactor.RedactBody(c.Writer.Header().Get("Content-Type"), blw.body.Bytes())
 			var responseData interface{}
 			// 尝试将响应体解析为JSON，方便日志查看
 			if err := json.Unmarshal([]byte(responseBody), &responseData); err != nil {
@@ -190,6 +150,75 @@ func RequestLogWithConfig(config RequestLogConfig) gin.HandlerFunc {
 	}
 }
 
+// AccessLog 访问日志中间件 - 按 log.access_log 配置输出 JSON Lines 或 Apache combined 格式
+// 访问日志未启用时直接放行
+func AccessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !logger.AccessLogEnabled() {
+			c.Next()
+			return
+		}
+
+		startTime := time.Now()
+		c.Next()
+
+		requestID := utils.GetRequestID(c)
+		route := c.FullPath()
+		status := c.Writer.Status()
+		if !logger.SampleRequest(c.Request.Method, route, status, requestID) {
+			return
+		}
+
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+
+		// 查询参数同样按脱敏规则处理
+		uri := c.Request.URL.Path
+		if c.Request.URL.RawQuery != "" {
+			uri += "?" + encodeRedactedQuery(logger.GetRedactor().RedactValues(c.Request.URL.Query()))
+		}
+
+		logger.Access(logger.AccessEntry{
+			Time:      startTime,
+			RemoteIP:  c.ClientIP(),
+			User:      c.GetString("username"),
+			Method:    c.Request.Method,
+			URI:       uri,
+			Route:     route,
+			Proto:     c.Request.Proto,
+			Status:    status,
+			Bytes:     size,
+			Referer:   c.Request.Referer(),
+			UserAgent: c.Request.UserAgent(),
+			Duration:  time.Since(startTime),
+			RequestID: requestID,
+			TraceID:   c.GetString("trace_id"),
+		})
+	}
+}
+
+// encodeRedactedQuery 编码脱敏后的查询参数，掩码占位符保持原样便于阅读
+func encodeRedactedQuery(query map[string][]string) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			if v != logger.RedactMask {
+				v = url.QueryEscape(v)
+			}
+			parts = append(parts, url.QueryEscape(k)+"="+v)
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
 // RequestLogConfig 请求日志配置
 type RequestLogConfig struct {
 	LogRequestBody  bool // 是否记录请求体
@@ -198,6 +227,8 @@ type RequestLogConfig struct {
 	LogPathParams   bool // 是否记录路径参数
 	LogHeaders      bool // 是否记录请求头
 	LogUserAgent    bool // 是否记录User-Agent
+
+	Redactor *logger.Redactor // 脱敏器，为空时使用 logger.GetRedactor()
 }
 
 // DefaultRequestLogConfig 默认请求日志配置
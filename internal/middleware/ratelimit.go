@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"go_demo/internal/utils"
+	"go_demo/pkg/cache"
+	"go_demo/pkg/logger"
+	"go_demo/pkg/metrics"
+)
+
+// rateLimitKeyPrefix 限流计数的缓存键前缀
+const rateLimitKeyPrefix = "ratelimit:"
+
+// RateLimitConfig 限流配置，按客户端IP做固定窗口计数，支持热更新
+type RateLimitConfig struct {
+	Enabled  bool // 是否启用
+	Requests int  // 每个窗口允许的请求数
+	Window   int  // 窗口长度（秒）
+}
+
+// DefaultRateLimitConfig 默认限流配置，未启用
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled:  false,
+		Requests: 100,
+		Window:   60,
+	}
+}
+
+// rateLimitConfig 当前生效的限流配置
+var rateLimitConfig atomic.Pointer[RateLimitConfig]
+
+func init() {
+	SetRateLimitConfig(DefaultRateLimitConfig())
+}
+
+// SetRateLimitConfig 设置限流配置，对已注册的限流中间件立即生效
+func SetRateLimitConfig(config RateLimitConfig) {
+	rateLimitConfig.Store(&config)
+}
+
+// RateLimit 限流中间件，每个请求读取当前生效的限流配置
+// 计数保存在缓存中，多实例共享；缓存不可用时放行请求，不影响正常业务
+// 客户端IP由 gin 按引擎信任的代理（server.trusted_proxies）解析，未信任代理时使用连接的远端地址，不能通过伪造 X-Forwarded-For 绕过
+func RateLimit(store cache.CacheInterface) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		config := rateLimitConfig.Load()
+		if store == nil || !config.Enabled || config.Requests <= 0 || config.Window <= 0 {
+			c.Next()
+			return
+		}
+
+		window := time.Duration(config.Window) * time.Second
+		now := time.Now()
+		windowStart := now.Truncate(window)
+		key := fmt.Sprintf("%s%s:%d", rateLimitKeyPrefix, c.ClientIP(), windowStart.Unix())
+
+		count, err := store.Increment(c.Request.Context(), key)
+		if err != nil {
+			logger.WarnCtx(c, "限流计数失败，跳过限流", logger.Err(err))
+			c.Next()
+			return
+		}
+		if count == 1 {
+			if err := store.Expire(c.Request.Context(), key, window); err != nil {
+				logger.WarnCtx(c, "设置限流计数过期时间失败", logger.Err(err))
+			}
+		}
+
+		remaining := int64(config.Requests) - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(config.Requests))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+
+		if count > int64(config.Requests) {
+			metrics.RateLimitTotal.WithLabelValues("rejected").Inc()
+			retryAfter := int(windowStart.Add(window).Sub(now).Seconds()) + 1
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			utils.ResponseError(c, http.StatusTooManyRequests, "请求过于频繁，请稍后再试")
+			c.Abort()
+			return
+		}
+
+		metrics.RateLimitTotal.WithLabelValues("allowed").Inc()
+		c.Next()
+	}
+}
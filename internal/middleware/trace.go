@@ -2,31 +2,76 @@ package middleware
 
 import (
 	"context"
+	"fmt"
+	"go_demo/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TraceIDKey struct{}
 
 // Trace 链路追踪中间件
+// 解析请求头中的 W3C traceparent/tracestate，为每个请求创建服务端span，并在响应头中返回链路信息。
+// 未启用链路追踪且上游未传递 traceparent 时，沿用 X-Trace-ID 或生成 UUID 作为 trace_id。
 func Trace() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// 1. 尝试从请求头获取 trace_id（如果是上游服务传递的）
-		traceID := c.GetHeader("X-Trace-ID")
+		// 1. 从请求头提取上游链路上下文
+		propagator := otel.GetTextMapPropagator()
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		// 2. 创建服务端span，名称使用路由模板避免基数过高
+		route := c.FullPath()
+		spanName := c.Request.Method + " " + route
+		if route == "" {
+			spanName = c.Request.Method
+		}
+		ctx, span := tracing.Tracer().Start(ctx, spanName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", c.Request.URL.Path),
+				attribute.String("client.address", c.ClientIP()),
+			),
+		)
+		defer span.End()
+
+		// 3. 确定 trace_id：优先使用链路中的 trace ID
+		traceID := tracing.TraceID(ctx)
+		if traceID == "" {
+			traceID = c.GetHeader("X-Trace-ID")
+		}
 		if traceID == "" {
-			// 2. 如果请求头中没有，则生成一个新的 trace_id
 			traceID = uuid.New().String()
 		}
-		// 3. 将 trace_id 存储到上下文中
 		c.Set("trace_id", traceID)
-		// 4. 将 trace_id 添加到响应头，方便客户端调试
+		if spanID := tracing.SpanID(ctx); spanID != "" {
+			c.Set("span_id", spanID)
+		}
+
+		// 4. 将链路信息添加到响应头，方便客户端调试
 		c.Header("X-Trace-ID", traceID)
-		//
-		ctx := context.WithValue(c.Request.Context(), TraceIDKey{}, traceID)
+		propagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
+
+		ctx = context.WithValue(ctx, TraceIDKey{}, traceID)
 		c.Request = c.Request.WithContext(ctx)
 		// 继续处理请求
 		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= 500 {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+		if len(c.Errors) > 0 {
+			span.RecordError(c.Errors.Last())
+		}
 	}
 }
 
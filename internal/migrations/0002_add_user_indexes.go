@@ -0,0 +1,44 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// userIndexes 用户表的查询索引: 索引名 -> 列
+// 旧版 init.sql 已经创建了部分索引，因此逐个判断是否存在
+var userIndexes = []struct {
+	name    string
+	columns string
+}{
+	{"idx_users_status_created_at", "status, created_at"},
+	{"idx_users_created_at", "created_at"},
+	{"idx_users_mobile", "mobile"},
+}
+
+// addUserIndexes 创建用户表的查询索引
+func addUserIndexes(tx *gorm.DB) error {
+	for _, idx := range userIndexes {
+		if tx.Migrator().HasIndex("users", idx.name) {
+			continue
+		}
+		if err := tx.Exec(fmt.Sprintf("CREATE INDEX %s ON users (%s)", idx.name, idx.columns)).Error; err != nil {
+			return fmt.Errorf("创建索引 %s 失败: %w", idx.name, err)
+		}
+	}
+	return nil
+}
+
+// dropUserIndexes 删除用户表的查询索引
+func dropUserIndexes(tx *gorm.DB) error {
+	for _, idx := range userIndexes {
+		if !tx.Migrator().HasIndex("users", idx.name) {
+			continue
+		}
+		if err := tx.Migrator().DropIndex("users", idx.name); err != nil {
+			return fmt.Errorf("删除索引 %s 失败: %w", idx.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Package migrations 应用的数据库迁移
+//
+// SQL 迁移按数据库放在 sql/<mysql|postgres|sqlite> 目录，文件名为 <版本号>_<名称>.up.sql / .down.sql，
+// 编译时打包进二进制，各数据库的版本号和名称必须一致；
+// 与数据库无关或需要判断现状、迁移数据时使用 Go 迁移，在 goMigrations 中注册。
+// 新增迁移可执行 go_demo migrate create <名称>，已发布的迁移不应再修改。
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"path"
+
+	"go_demo/pkg/migrate"
+)
+
+// Dir SQL 迁移文件在源码中的目录，migrate create 在其下每个数据库的目录中创建文件
+const Dir = "internal/migrations/sql"
+
+// Dialects 提供了 SQL 迁移的数据库，与 GORM 方言名称一致
+var Dialects = []string{"mysql", "postgres", "sqlite"}
+
+//go:embed sql
+var sqlFiles embed.FS
+
+// goMigrations Go 函数实现的迁移
+var goMigrations = []*migrate.Migration{
+	migrate.Go(2, "add_user_indexes", addUserIndexes, dropUserIndexes),
+}
+
+// All 获取指定数据库的全部迁移，dialect 为 GORM 方言名称，即 db.Dialector.Name()
+func All(dialect string) ([]*migrate.Migration, error) {
+	if !supported(dialect) {
+		return nil, fmt.Errorf("没有数据库 %s 的迁移", dialect)
+	}
+	migrations, err := migrate.LoadFS(sqlFiles, path.Join("sql", dialect))
+	if err != nil {
+		return nil, fmt.Errorf("加载 %s SQL 迁移失败: %w", dialect, err)
+	}
+	migrations = append(migrations, goMigrations...)
+	if err := migrate.Validate(migrations); err != nil {
+		return nil, err
+	}
+	return migrations, nil
+}
+
+// supported 是否提供了该数据库的迁移
+func supported(dialect string) bool {
+	for _, d := range Dialects {
+		if d == dialect {
+			return true
+		}
+	}
+	return false
+}
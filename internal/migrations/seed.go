@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"fmt"
+
+	"go_demo/internal/models"
+	"go_demo/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// seedPassword 种子用户的密码哈希，明文为 password
+const seedPassword = "$2a$10$92IXUNpkjO0rOQ5byMi.Ye4oKoEa3Ro9llC/.og/at2.uheWG/igi"
+
+// seedUsers 开发和测试环境的种子用户
+var seedUsers = []models.User{
+	{Username: "admin", Email: "admin@example.com", Name: "管理员", Mobile: "13800138000", Status: 1},
+	{Username: "testuser", Email: "test@example.com", Name: "测试用户", Mobile: "13800138001", Status: 1},
+}
+
+// Seed 创建种子数据，已存在的用户跳过，可重复执行
+func Seed(db *gorm.DB) error {
+	for _, u := range seedUsers {
+		var count int64
+		if err := db.Model(&models.User{}).Where("username = ?", u.Username).Count(&count).Error; err != nil {
+			return fmt.Errorf("查询用户 %s 失败: %w", u.Username, err)
+		}
+		if count > 0 {
+			logger.Info("种子用户已存在，跳过创建", logger.String("username", u.Username))
+			continue
+		}
+
+		user := u
+		user.Password = seedPassword
+		if err := db.Create(&user).Error; err != nil {
+			return fmt.Errorf("创建用户 %s 失败: %w", u.Username, err)
+		}
+		logger.Info("种子用户创建成功", logger.String("username", u.Username))
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+-- 用户表，与 models.User 一致
+-- 使用 IF NOT EXISTS，已由旧版 init.sql 或 AutoMigrate 创建的表作为基线直接纳入版本管理
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `username` varchar(50) NOT NULL COMMENT '用户名',
+  `email` varchar(100) NOT NULL COMMENT '邮箱',
+  `password` varchar(255) NOT NULL COMMENT '密码哈希',
+  `mobile` varchar(20) DEFAULT NULL COMMENT '手机号',
+  `name` varchar(100) DEFAULT NULL COMMENT '名字',
+  `avatar` varchar(255) DEFAULT NULL COMMENT '头像',
+  `status` bigint DEFAULT 1 COMMENT '状态 1:启用 0:禁用',
+  `is_activated` bigint DEFAULT 1 COMMENT '是否激活',
+  `last_login` datetime(3) DEFAULT NULL COMMENT '最后登录时间',
+  `created_at` datetime(3) DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) DEFAULT NULL COMMENT '更新时间',
+  `deleted_at` datetime(3) DEFAULT NULL COMMENT '删除时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_users_username` (`username`),
+  UNIQUE KEY `idx_users_email` (`email`),
+  KEY `idx_users_deleted_at` (`deleted_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='用户表';
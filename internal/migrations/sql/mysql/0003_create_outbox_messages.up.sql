@@ -0,0 +1,18 @@
+-- 发件箱表，与 models.OutboxMessage 一致
+CREATE TABLE IF NOT EXISTS `outbox_messages` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `aggregate_type` varchar(50) NOT NULL COMMENT '聚合类型',
+  `aggregate_id` varchar(64) NOT NULL COMMENT '聚合ID',
+  `event_type` varchar(100) NOT NULL COMMENT '事件类型',
+  `payload` text NOT NULL COMMENT '事件内容',
+  `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '状态 pending processed dead',
+  `attempts` bigint NOT NULL DEFAULT 0 COMMENT '已投递次数',
+  `last_error` varchar(1000) DEFAULT NULL COMMENT '最近一次投递失败的原因',
+  `next_attempt_at` datetime(3) DEFAULT NULL COMMENT '下次投递时间',
+  `occurred_at` datetime(3) DEFAULT NULL COMMENT '事件发生时间',
+  `processed_at` datetime(3) DEFAULT NULL COMMENT '投递完成时间',
+  `created_at` datetime(3) DEFAULT NULL COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_outbox_status_id` (`status`, `id`),
+  KEY `idx_outbox_aggregate` (`aggregate_type`, `aggregate_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='领域事件发件箱';
@@ -0,0 +1,45 @@
+-- Webhook 订阅、投递记录和投递日志，与 models.WebhookSubscription、WebhookDelivery、WebhookAttempt 一致
+CREATE TABLE IF NOT EXISTS `webhook_subscriptions` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `url` varchar(500) NOT NULL COMMENT '接收地址',
+  `secret` varchar(128) NOT NULL COMMENT '签名密钥',
+  `event_types` varchar(500) NOT NULL COMMENT '订阅的事件类型，逗号分隔',
+  `description` varchar(255) DEFAULT NULL COMMENT '描述',
+  `enabled` tinyint(1) NOT NULL DEFAULT 1 COMMENT '是否启用',
+  `created_at` datetime(3) DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook 订阅';
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `subscription_id` bigint unsigned NOT NULL COMMENT '订阅ID',
+  `event_id` bigint unsigned NOT NULL COMMENT '发件箱消息ID',
+  `event_type` varchar(100) NOT NULL COMMENT '事件类型',
+  `payload` text NOT NULL COMMENT '请求体',
+  `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '状态 pending succeeded dead',
+  `attempts` bigint NOT NULL DEFAULT 0 COMMENT '本轮已投递次数',
+  `next_attempt_at` datetime(3) DEFAULT NULL COMMENT '下次投递时间',
+  `last_status_code` bigint DEFAULT NULL COMMENT '最近一次响应状态码',
+  `last_error` varchar(1000) DEFAULT NULL COMMENT '最近一次失败原因',
+  `delivered_at` datetime(3) DEFAULT NULL COMMENT '投递成功时间',
+  `created_at` datetime(3) DEFAULT NULL COMMENT '创建时间',
+  `updated_at` datetime(3) DEFAULT NULL COMMENT '更新时间',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `idx_webhook_deliveries_event` (`subscription_id`, `event_id`),
+  KEY `idx_webhook_deliveries_due` (`status`, `next_attempt_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook 投递记录';
+
+CREATE TABLE IF NOT EXISTS `webhook_attempts` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `delivery_id` bigint unsigned NOT NULL COMMENT '投递记录ID',
+  `attempt` bigint NOT NULL COMMENT '本轮第几次投递',
+  `triggered_by` varchar(20) NOT NULL COMMENT '触发方式 auto manual',
+  `status_code` bigint DEFAULT NULL COMMENT '响应状态码',
+  `error` varchar(1000) DEFAULT NULL COMMENT '失败原因',
+  `response_body` varchar(1024) DEFAULT NULL COMMENT '响应内容',
+  `duration_ms` bigint DEFAULT NULL COMMENT '请求耗时（毫秒）',
+  `created_at` datetime(3) DEFAULT NULL COMMENT '创建时间',
+  PRIMARY KEY (`id`),
+  KEY `idx_webhook_attempts_delivery_id` (`delivery_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='Webhook 投递日志';
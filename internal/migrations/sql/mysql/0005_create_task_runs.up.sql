@@ -0,0 +1,17 @@
+-- 定时任务执行记录，与 models.TaskRun 一致
+CREATE TABLE IF NOT EXISTS `task_runs` (
+  `id` bigint unsigned NOT NULL AUTO_INCREMENT,
+  `task` varchar(100) NOT NULL COMMENT '任务名称',
+  `triggered_by` varchar(20) NOT NULL COMMENT '触发方式 schedule manual',
+  `scheduled_at` datetime(3) DEFAULT NULL COMMENT '计划执行时间',
+  `host` varchar(255) DEFAULT NULL COMMENT '执行任务的主机名',
+  `status` varchar(20) NOT NULL COMMENT '状态 running succeeded failed',
+  `affected` bigint DEFAULT NULL COMMENT '处理的记录数',
+  `error` varchar(1000) DEFAULT NULL COMMENT '失败原因',
+  `started_at` datetime(3) DEFAULT NULL COMMENT '开始时间',
+  `finished_at` datetime(3) DEFAULT NULL COMMENT '结束时间',
+  `duration_ms` bigint DEFAULT NULL COMMENT '执行耗时（毫秒）',
+  PRIMARY KEY (`id`),
+  KEY `idx_task_runs_task` (`task`, `scheduled_at`),
+  KEY `idx_task_runs_started_at` (`started_at`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci COMMENT='定时任务执行记录';
@@ -0,0 +1,25 @@
+-- 用户表，与 models.User 一致
+-- 使用 IF NOT EXISTS，已由 AutoMigrate 创建的表作为基线直接纳入版本管理
+CREATE TABLE IF NOT EXISTS users (
+  id bigserial PRIMARY KEY,
+  username varchar(50) NOT NULL,
+  email varchar(100) NOT NULL,
+  password varchar(255) NOT NULL,
+  mobile varchar(20),
+  name varchar(100),
+  avatar varchar(255),
+  status bigint DEFAULT 1,
+  is_activated bigint DEFAULT 1,
+  last_login timestamptz,
+  created_at timestamptz,
+  updated_at timestamptz,
+  deleted_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users (username);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+COMMENT ON TABLE users IS '用户表';
+COMMENT ON COLUMN users.status IS '状态 1:启用 0:禁用';
+COMMENT ON COLUMN users.is_activated IS '是否激活';
@@ -0,0 +1,21 @@
+-- 发件箱表，与 models.OutboxMessage 一致
+CREATE TABLE IF NOT EXISTS outbox_messages (
+  id bigserial PRIMARY KEY,
+  aggregate_type varchar(50) NOT NULL,
+  aggregate_id varchar(64) NOT NULL,
+  event_type varchar(100) NOT NULL,
+  payload text NOT NULL,
+  status varchar(20) NOT NULL DEFAULT 'pending',
+  attempts bigint NOT NULL DEFAULT 0,
+  last_error varchar(1000),
+  next_attempt_at timestamptz,
+  occurred_at timestamptz,
+  processed_at timestamptz,
+  created_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_status_id ON outbox_messages (status, id);
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate ON outbox_messages (aggregate_type, aggregate_id);
+
+COMMENT ON TABLE outbox_messages IS '领域事件发件箱';
+COMMENT ON COLUMN outbox_messages.status IS '状态 pending processed dead';
@@ -0,0 +1,48 @@
+-- Webhook 订阅、投递记录和投递日志，与 models.WebhookSubscription、WebhookDelivery、WebhookAttempt 一致
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id bigserial PRIMARY KEY,
+  url varchar(500) NOT NULL,
+  secret varchar(128) NOT NULL,
+  event_types varchar(500) NOT NULL,
+  description varchar(255),
+  enabled boolean NOT NULL DEFAULT true,
+  created_at timestamptz,
+  updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id bigserial PRIMARY KEY,
+  subscription_id bigint NOT NULL,
+  event_id bigint NOT NULL,
+  event_type varchar(100) NOT NULL,
+  payload text NOT NULL,
+  status varchar(20) NOT NULL DEFAULT 'pending',
+  attempts bigint NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz,
+  last_status_code bigint,
+  last_error varchar(1000),
+  delivered_at timestamptz,
+  created_at timestamptz,
+  updated_at timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries (subscription_id, event_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (status, next_attempt_at);
+
+CREATE TABLE IF NOT EXISTS webhook_attempts (
+  id bigserial PRIMARY KEY,
+  delivery_id bigint NOT NULL,
+  attempt bigint NOT NULL,
+  triggered_by varchar(20) NOT NULL,
+  status_code bigint,
+  error varchar(1000),
+  response_body varchar(1024),
+  duration_ms bigint,
+  created_at timestamptz
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_attempts_delivery_id ON webhook_attempts (delivery_id);
+
+COMMENT ON TABLE webhook_subscriptions IS 'Webhook 订阅';
+COMMENT ON TABLE webhook_deliveries IS 'Webhook 投递记录';
+COMMENT ON TABLE webhook_attempts IS 'Webhook 投递日志';
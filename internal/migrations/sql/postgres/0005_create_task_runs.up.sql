@@ -0,0 +1,19 @@
+-- 定时任务执行记录，与 models.TaskRun 一致
+CREATE TABLE IF NOT EXISTS task_runs (
+  id bigserial PRIMARY KEY,
+  task varchar(100) NOT NULL,
+  triggered_by varchar(20) NOT NULL,
+  scheduled_at timestamptz,
+  host varchar(255),
+  status varchar(20) NOT NULL,
+  affected bigint,
+  error varchar(1000),
+  started_at timestamptz,
+  finished_at timestamptz,
+  duration_ms bigint
+);
+
+CREATE INDEX IF NOT EXISTS idx_task_runs_task ON task_runs (task, scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_task_runs_started_at ON task_runs (started_at);
+
+COMMENT ON TABLE task_runs IS '定时任务执行记录';
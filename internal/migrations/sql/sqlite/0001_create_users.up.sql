@@ -0,0 +1,21 @@
+-- 用户表，与 models.User 一致
+-- 使用 IF NOT EXISTS，已由 AutoMigrate 创建的表作为基线直接纳入版本管理
+CREATE TABLE IF NOT EXISTS `users` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `username` text NOT NULL,
+  `email` text NOT NULL,
+  `password` text NOT NULL,
+  `mobile` text,
+  `name` text,
+  `avatar` text,
+  `status` integer DEFAULT 1,
+  `is_activated` integer DEFAULT 1,
+  `last_login` datetime,
+  `created_at` datetime,
+  `updated_at` datetime,
+  `deleted_at` datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_username` ON `users` (`username`);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_users_email` ON `users` (`email`);
+CREATE INDEX IF NOT EXISTS `idx_users_deleted_at` ON `users` (`deleted_at`);
@@ -0,0 +1,18 @@
+-- 发件箱表，与 models.OutboxMessage 一致
+CREATE TABLE IF NOT EXISTS `outbox_messages` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `aggregate_type` text NOT NULL,
+  `aggregate_id` text NOT NULL,
+  `event_type` text NOT NULL,
+  `payload` text NOT NULL,
+  `status` text NOT NULL DEFAULT 'pending',
+  `attempts` integer NOT NULL DEFAULT 0,
+  `last_error` text,
+  `next_attempt_at` datetime,
+  `occurred_at` datetime,
+  `processed_at` datetime,
+  `created_at` datetime
+);
+
+CREATE INDEX IF NOT EXISTS `idx_outbox_status_id` ON `outbox_messages` (`status`, `id`);
+CREATE INDEX IF NOT EXISTS `idx_outbox_aggregate` ON `outbox_messages` (`aggregate_type`, `aggregate_id`);
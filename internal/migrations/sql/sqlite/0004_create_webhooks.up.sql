@@ -0,0 +1,44 @@
+-- Webhook 订阅、投递记录和投递日志，与 models.WebhookSubscription、WebhookDelivery、WebhookAttempt 一致
+CREATE TABLE IF NOT EXISTS `webhook_subscriptions` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `url` text NOT NULL,
+  `secret` text NOT NULL,
+  `event_types` text NOT NULL,
+  `description` text,
+  `enabled` numeric NOT NULL DEFAULT true,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+
+CREATE TABLE IF NOT EXISTS `webhook_deliveries` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `subscription_id` integer NOT NULL,
+  `event_id` integer NOT NULL,
+  `event_type` text NOT NULL,
+  `payload` text NOT NULL,
+  `status` text NOT NULL DEFAULT 'pending',
+  `attempts` integer NOT NULL DEFAULT 0,
+  `next_attempt_at` datetime,
+  `last_status_code` integer,
+  `last_error` text,
+  `delivered_at` datetime,
+  `created_at` datetime,
+  `updated_at` datetime
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_webhook_deliveries_event` ON `webhook_deliveries` (`subscription_id`, `event_id`);
+CREATE INDEX IF NOT EXISTS `idx_webhook_deliveries_due` ON `webhook_deliveries` (`status`, `next_attempt_at`);
+
+CREATE TABLE IF NOT EXISTS `webhook_attempts` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `delivery_id` integer NOT NULL,
+  `attempt` integer NOT NULL,
+  `triggered_by` text NOT NULL,
+  `status_code` integer,
+  `error` text,
+  `response_body` text,
+  `duration_ms` integer,
+  `created_at` datetime
+);
+
+CREATE INDEX IF NOT EXISTS `idx_webhook_attempts_delivery_id` ON `webhook_attempts` (`delivery_id`);
@@ -0,0 +1,17 @@
+-- 定时任务执行记录，与 models.TaskRun 一致
+CREATE TABLE IF NOT EXISTS `task_runs` (
+  `id` integer PRIMARY KEY AUTOINCREMENT,
+  `task` text NOT NULL,
+  `triggered_by` text NOT NULL,
+  `scheduled_at` datetime,
+  `host` text,
+  `status` text NOT NULL,
+  `affected` integer,
+  `error` text,
+  `started_at` datetime,
+  `finished_at` datetime,
+  `duration_ms` integer
+);
+
+CREATE INDEX IF NOT EXISTS `idx_task_runs_task` ON `task_runs` (`task`, `scheduled_at`);
+CREATE INDEX IF NOT EXISTS `idx_task_runs_started_at` ON `task_runs` (`started_at`);
@@ -0,0 +1,8 @@
+package models
+
+// LogLevelRequest 修改日志级别请求结构体
+type LogLevelRequest struct {
+	Level  string  `json:"level" example:"debug"`                       // 全局日志级别，为空时不修改
+	Levels *string `json:"levels,omitempty" example:"repository=debug"` // 按logger名称设置的级别，为空字符串时清除
+	TTL    string  `json:"ttl,omitempty" example:"10m"`                 // 自动恢复时间，如 10m、1h，为空时不自动恢复
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// 发件箱消息状态
+const (
+	OutboxStatusPending   = "pending"   // 等待投递
+	OutboxStatusProcessed = "processed" // 已投递给全部订阅者
+	OutboxStatusDead      = "dead"      // 超过最大重试次数，不再投递
+)
+
+// OutboxMessage 发件箱消息，领域事件与业务数据在同一个事务中写入，由分发器投递给订阅者
+type OutboxMessage struct {
+	ID            uint64    `gorm:"primarykey"`
+	AggregateType string    `gorm:"size:50;not null;index:idx_outbox_aggregate,priority:1"` // 聚合类型，如 user
+	AggregateID   string    `gorm:"size:64;not null;index:idx_outbox_aggregate,priority:2"` // 聚合ID，同一聚合的事件按写入顺序投递
+	EventType     string    `gorm:"size:100;not null"`                                      // 事件类型，如 user.registered
+	Payload       string    `gorm:"type:text;not null"`                                     // JSON 格式的事件内容
+	Status        string    `gorm:"size:20;not null;default:pending;index:idx_outbox_status_id,priority:1"`
+	Attempts      int       `gorm:"not null;default:0"` // 已投递次数
+	LastError     string    `gorm:"size:1000"`          // 最近一次投递失败的原因
+	NextAttemptAt time.Time // 下次投递时间，失败后按退避时间推迟
+	OccurredAt    time.Time // 事件发生时间
+	ProcessedAt   *time.Time
+	CreatedAt     time.Time
+}
+
+// TableName 表名
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
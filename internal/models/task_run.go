@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// 定时任务执行状态
+const (
+	TaskRunRunning   = "running"   // 正在执行，进程异常退出时保持此状态
+	TaskRunSucceeded = "succeeded" // 执行成功
+	TaskRunFailed    = "failed"    // 执行失败
+)
+
+// 定时任务触发方式
+const (
+	TaskTriggerSchedule = "schedule" // 按 cron 表达式触发
+	TaskTriggerManual   = "manual"   // 通过命令行手动触发
+)
+
+// TaskRun 定时任务执行记录
+type TaskRun struct {
+	ID          uint64     `gorm:"primarykey"`
+	Task        string     `gorm:"size:100;not null;index:idx_task_runs_task,priority:1"`
+	TriggeredBy string     `gorm:"size:20;not null"`                    // schedule 或 manual
+	ScheduledAt *time.Time `gorm:"index:idx_task_runs_task,priority:2"` // 计划执行时间，手动触发时为空
+	Host        string     `gorm:"size:255"`                            // 执行任务的主机名
+	Status      string     `gorm:"size:20;not null"`
+	Affected    int64      // 处理的记录数
+	Error       string     `gorm:"size:1000"`
+	StartedAt   time.Time  `gorm:"index"`
+	FinishedAt  *time.Time
+	DurationMs  int64 // 执行耗时（毫秒）
+}
+
+// TableName 表名
+func (TaskRun) TableName() string {
+	return "task_runs"
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // User 用户模型
@@ -18,7 +20,7 @@ type User struct {
 	LastLogin   *time.Time
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
-	DeletedAt   *time.Time `gorm:"index"`
+	DeletedAt   gorm.DeletedAt `gorm:"index"` // 软删除时间，由定时任务在保留期后永久删除
 }
 
 // ToResponse 转换为响应格式
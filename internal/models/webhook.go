@@ -0,0 +1,211 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook 投递状态
+const (
+	WebhookDeliveryPending   = "pending"   // 等待投递或等待重试
+	WebhookDeliverySucceeded = "succeeded" // 接收方返回 2xx
+	WebhookDeliveryDead      = "dead"      // 超过最大投递次数或订阅已停用，不再自动投递
+)
+
+// Webhook 投递触发方式
+const (
+	WebhookTriggerAuto   = "auto"   // 自动投递和重试
+	WebhookTriggerManual = "manual" // 通过接口手动重新投递
+)
+
+// WebhookSubscription Webhook 订阅，事件发生时向 URL 推送签名后的请求
+type WebhookSubscription struct {
+	ID          uint   `gorm:"primarykey"`
+	URL         string `gorm:"size:500;not null"`
+	Secret      string `gorm:"size:128;not null"` // HMAC-SHA256 签名密钥
+	EventTypes  string `gorm:"size:500;not null"` // 订阅的事件类型，逗号分隔，* 表示全部事件
+	Description string `gorm:"size:255"`
+	Enabled     bool   `gorm:"not null"` // 停用后不再生成新的投递，待投递的记录进入死信
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// TableName 表名
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// EventTypeList 订阅的事件类型列表
+func (s *WebhookSubscription) EventTypeList() []string {
+	if s.EventTypes == "" {
+		return nil
+	}
+	return strings.Split(s.EventTypes, ",")
+}
+
+// Subscribes 是否订阅了指定类型的事件
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, t := range s.EventTypeList() {
+		if t == "*" || t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// ToResponse 转换为响应格式，不包含签名密钥
+func (s *WebhookSubscription) ToResponse() *WebhookResponse {
+	return &WebhookResponse{
+		ID:          s.ID,
+		URL:         s.URL,
+		EventTypes:  s.EventTypeList(),
+		Description: s.Description,
+		Enabled:     s.Enabled,
+		CreatedAt:   s.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:   s.UpdatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// WebhookDelivery Webhook 投递记录，每个事件对每个订阅生成一条
+type WebhookDelivery struct {
+	ID             uint64    `gorm:"primarykey"`
+	SubscriptionID uint      `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	EventID        uint64    `gorm:"not null;uniqueIndex:idx_webhook_deliveries_event,priority:2"` // 发件箱消息ID，接收方可用于去重
+	EventType      string    `gorm:"size:100;not null"`
+	Payload        string    `gorm:"type:text;not null"` // 请求体，重新投递时原样发送
+	Status         string    `gorm:"size:20;not null;default:pending;index:idx_webhook_deliveries_due,priority:1"`
+	Attempts       int       `gorm:"not null;default:0"` // 本轮已投递次数，手动重新投递时清零
+	NextAttemptAt  time.Time `gorm:"index:idx_webhook_deliveries_due,priority:2"`
+	LastStatusCode int       // 最近一次响应的状态码，请求失败时为0
+	LastError      string    `gorm:"size:1000"`
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// TableName 表名
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// ToResponse 转换为响应格式
+func (d *WebhookDelivery) ToResponse() *WebhookDeliveryResponse {
+	var deliveredAt string
+	if d.DeliveredAt != nil {
+		deliveredAt = d.DeliveredAt.Format("2006-01-02 15:04:05")
+	}
+	var nextAttemptAt string
+	if d.Status == WebhookDeliveryPending {
+		nextAttemptAt = d.NextAttemptAt.Format("2006-01-02 15:04:05")
+	}
+	return &WebhookDeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  nextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    deliveredAt,
+		CreatedAt:      d.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// WebhookAttempt Webhook 投递日志，记录每一次请求的结果
+type WebhookAttempt struct {
+	ID           uint64 `gorm:"primarykey"`
+	DeliveryID   uint64 `gorm:"not null;index"`
+	Attempt      int    `gorm:"not null"`         // 本轮第几次投递
+	TriggeredBy  string `gorm:"size:20;not null"` // auto 或 manual
+	StatusCode   int    // 响应状态码，请求失败时为0
+	Error        string `gorm:"size:1000"` // 请求失败或非 2xx 响应的原因
+	ResponseBody string `gorm:"size:1024"` // 响应内容，最多保留1KB
+	DurationMs   int64  // 请求耗时（毫秒）
+	CreatedAt    time.Time
+}
+
+// TableName 表名
+func (WebhookAttempt) TableName() string {
+	return "webhook_attempts"
+}
+
+// ToResponse 转换为响应格式
+func (a *WebhookAttempt) ToResponse() *WebhookAttemptResponse {
+	return &WebhookAttemptResponse{
+		Attempt:      a.Attempt,
+		TriggeredBy:  a.TriggeredBy,
+		StatusCode:   a.StatusCode,
+		Error:        a.Error,
+		ResponseBody: a.ResponseBody,
+		DurationMs:   a.DurationMs,
+		CreatedAt:    a.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// WebhookCreateRequest 创建 Webhook 订阅请求
+type WebhookCreateRequest struct {
+	URL         string   `json:"url" validate:"required,url,max=500" example:"https://example.com/webhooks/users"`
+	Secret      string   `json:"secret" validate:"omitempty,min=16,max=128"`                  // 签名密钥，为空时自动生成
+	EventTypes  []string `json:"event_types" validate:"required,min=1,dive,required,max=100"` // 订阅的事件类型，* 表示全部事件
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	Enabled     *bool    `json:"enabled"` // 默认启用
+}
+
+// WebhookUpdateRequest 更新 Webhook 订阅请求，未提供的字段不修改
+type WebhookUpdateRequest struct {
+	URL         string   `json:"url" validate:"omitempty,url,max=500"`
+	Secret      string   `json:"secret" validate:"omitempty,min=16,max=128"`
+	EventTypes  []string `json:"event_types" validate:"omitempty,min=1,dive,required,max=100"`
+	Description *string  `json:"description" validate:"omitempty,max=255"`
+	Enabled     *bool    `json:"enabled"`
+}
+
+// WebhookResponse Webhook 订阅响应格式
+type WebhookResponse struct {
+	ID          uint     `json:"id"`
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret,omitempty"` // 仅在创建和修改密钥时返回
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	CreatedAt   string   `json:"created_at"`
+	UpdatedAt   string   `json:"updated_at"`
+}
+
+// WebhookDeliveryResponse Webhook 投递记录响应格式
+type WebhookDeliveryResponse struct {
+	ID             uint64                    `json:"id"`
+	SubscriptionID uint                      `json:"subscription_id"`
+	EventID        uint64                    `json:"event_id"`
+	EventType      string                    `json:"event_type"`
+	Status         string                    `json:"status"`
+	Attempts       int                       `json:"attempts"`
+	NextAttemptAt  string                    `json:"next_attempt_at,omitempty"`
+	LastStatusCode int                       `json:"last_status_code"`
+	LastError      string                    `json:"last_error,omitempty"`
+	DeliveredAt    string                    `json:"delivered_at,omitempty"`
+	CreatedAt      string                    `json:"created_at"`
+	Payload        string                    `json:"payload,omitempty"` // 仅在详情中返回
+	AttemptLogs    []*WebhookAttemptResponse `json:"attempt_logs,omitempty"`
+}
+
+// WebhookAttemptResponse Webhook 投递日志响应格式
+type WebhookAttemptResponse struct {
+	Attempt      int    `json:"attempt"`
+	TriggeredBy  string `json:"triggered_by"`
+	StatusCode   int    `json:"status_code"`
+	Error        string `json:"error,omitempty"`
+	ResponseBody string `json:"response_body,omitempty"`
+	DurationMs   int64  `json:"duration_ms"`
+	CreatedAt    string `json:"created_at"`
+}
+
+// WebhookDeliveryListResponse Webhook 投递记录列表响应
+type WebhookDeliveryListResponse struct {
+	Deliveries []*WebhookDeliveryResponse `json:"deliveries"`
+	Total      int64                      `json:"total"`
+	Page       int                        `json:"page"`
+	Size       int                        `json:"size"`
+}
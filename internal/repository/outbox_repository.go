@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"go_demo/internal/models"
+	"go_demo/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxRepository 发件箱仓储接口
+type OutboxRepository interface {
+	// Add 写入消息，上下文携带事务时与业务数据一起提交
+	Add(ctx context.Context, messages ...*models.OutboxMessage) error
+	// Pending 按写入顺序获取 now 时已到投递时间的消息
+	// 同一聚合中存在未到重试时间的消息时，不返回该消息及其后续消息，保证同一聚合按顺序投递
+	Pending(ctx context.Context, limit int, now time.Time) ([]models.OutboxMessage, error)
+	// MarkProcessed 标记消息已投递，attempts 为包括本次在内的投递次数
+	MarkProcessed(ctx context.Context, id uint64, attempts int, processedAt time.Time) error
+	// MarkFailed 记录投递失败，status 为 pending 时在 nextAttemptAt 重试，为 dead 时不再投递
+	MarkFailed(ctx context.Context, id uint64, status string, attempts int, nextAttemptAt time.Time, lastError string) error
+	// DeleteProcessed 删除 before 之前已投递的消息，返回删除数量
+	DeleteProcessed(ctx context.Context, before time.Time) (int64, error)
+}
+
+// outboxRepository 发件箱仓储实现
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepository 创建发件箱仓储实例
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// Add 写入消息
+func (r *outboxRepository) Add(ctx context.Context, messages ...*models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(messages).Error
+}
+
+// Pending 获取待投递的消息，读主库，避免副本延迟导致重复投递
+// 排除正在退避的消息，避免较早的失败消息占满批次导致其他聚合的消息无法投递
+func (r *outboxRepository) Pending(ctx context.Context, limit int, now time.Time) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+	err := conn(database.WithPrimary(ctx), r.db).
+		Where("status = ?", models.OutboxStatusPending).
+		Where(`NOT EXISTS (SELECT 1 FROM outbox_messages b WHERE b.aggregate_type = outbox_messages.aggregate_type `+
+			`AND b.aggregate_id = outbox_messages.aggregate_id AND b.status = ? AND b.id <= outbox_messages.id AND b.next_attempt_at > ?)`,
+			models.OutboxStatusPending, now).
+		Order("id").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// MarkProcessed 标记消息已投递
+func (r *outboxRepository) MarkProcessed(ctx context.Context, id uint64, attempts int, processedAt time.Time) error {
+	return conn(ctx, r.db).Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       models.OutboxStatusProcessed,
+		"attempts":     attempts,
+		"last_error":   "",
+		"processed_at": processedAt,
+	}).Error
+}
+
+// MarkFailed 记录投递失败
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uint64, status string, attempts int, nextAttemptAt time.Time, lastError string) error {
+	// last_error 最长1000个字符
+	if runes := []rune(lastError); len(runes) > 1000 {
+		lastError = string(runes[:1000])
+	}
+	return conn(ctx, r.db).Model(&models.OutboxMessage{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          status,
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}).Error
+}
+
+// DeleteProcessed 删除已投递的历史消息
+func (r *outboxRepository) DeleteProcessed(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("status = ? AND processed_at < ?", models.OutboxStatusProcessed, before).
+		Delete(&models.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
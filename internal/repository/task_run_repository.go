@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"go_demo/internal/models"
+	"go_demo/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaskRunRepository 定时任务执行记录仓储接口
+type TaskRunRepository interface {
+	// Create 写入执行记录
+	Create(ctx context.Context, run *models.TaskRun) error
+	// Save 更新执行记录的状态、结果和耗时
+	Save(ctx context.Context, run *models.TaskRun) error
+	// HasScheduledRun 同一任务的同一计划时间是否已有执行记录，用于多实例时钟偏差下避免重复执行
+	HasScheduledRun(ctx context.Context, task string, scheduledAt time.Time) (bool, error)
+	// List 按开始时间倒序获取执行记录，task 为空时获取全部任务
+	List(ctx context.Context, task string, limit int) ([]models.TaskRun, error)
+	// DeleteBefore 删除 before 之前开始的执行记录，返回删除数量
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// taskRunRepository 定时任务执行记录仓储实现
+type taskRunRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskRunRepository 创建定时任务执行记录仓储实例
+func NewTaskRunRepository(db *gorm.DB) TaskRunRepository {
+	return &taskRunRepository{db: db}
+}
+
+// Create 写入执行记录
+func (r *taskRunRepository) Create(ctx context.Context, run *models.TaskRun) error {
+	return conn(ctx, r.db).Create(run).Error
+}
+
+// Save 更新执行记录
+func (r *taskRunRepository) Save(ctx context.Context, run *models.TaskRun) error {
+	return conn(ctx, r.db).Model(&models.TaskRun{}).Where("id = ?", run.ID).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"affected":    run.Affected,
+		"error":       run.Error,
+		"finished_at": run.FinishedAt,
+		"duration_ms": run.DurationMs,
+	}).Error
+}
+
+// HasScheduledRun 读主库，避免副本延迟导致重复执行
+func (r *taskRunRepository) HasScheduledRun(ctx context.Context, task string, scheduledAt time.Time) (bool, error) {
+	var count int64
+	err := conn(database.WithPrimary(ctx), r.db).Model(&models.TaskRun{}).
+		Where("task = ? AND scheduled_at = ?", task, scheduledAt).Count(&count).Error
+	return count > 0, err
+}
+
+// List 获取执行记录
+func (r *taskRunRepository) List(ctx context.Context, task string, limit int) ([]models.TaskRun, error) {
+	var runs []models.TaskRun
+	db := conn(ctx, r.db)
+	if task != "" {
+		db = db.Where("task = ?", task)
+	}
+	err := db.Order("started_at DESC, id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// DeleteBefore 删除历史执行记录
+func (r *taskRunRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("started_at < ?", before).Delete(&models.TaskRun{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// txContextKey 上下文中保存事务的键
+type txContextKey struct{}
+
+// txHooksKey 上下文中保存提交后回调的键
+type txHooksKey struct{}
+
+// txHooks 最外层事务提交后执行的回调
+type txHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// TxManager 事务管理器，在同一个事务中调用多个仓储
+type TxManager interface {
+	// WithinTransaction 在事务中执行 fn，fn 收到的上下文携带事务，仓储方法使用该上下文时自动加入事务
+	// fn 返回错误或 panic 时回滚，否则提交；已在事务中时使用保存点，内层失败只回滚到保存点
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txManager 基于GORM的事务管理器
+type txManager struct {
+	db *gorm.DB
+}
+
+// NewTxManager 创建事务管理器
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db}
+}
+
+// WithinTransaction 在事务中执行 fn
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	var hooks *txHooks
+	if !inTransaction(ctx) {
+		hooks = &txHooks{}
+		ctx = context.WithValue(ctx, txHooksKey{}, hooks)
+	}
+	// 上下文中已有事务时 GORM 创建保存点，出错或 panic 时回滚到保存点
+	err := conn(ctx, m.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txContextKey{}, tx))
+	})
+	if hooks != nil && err == nil {
+		hooks.mu.Lock()
+		fns := hooks.fns
+		hooks.mu.Unlock()
+		for _, f := range fns {
+			f()
+		}
+	}
+	return err
+}
+
+// AfterCommit 在上下文携带的事务提交后执行 fn，没有事务时立即执行
+// 事务回滚时不执行；内层事务回滚到保存点而外层提交时仍会执行，fn 应可以重复执行（如删除缓存）
+func AfterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok || !inTransaction(ctx) {
+		fn()
+		return
+	}
+	hooks.mu.Lock()
+	hooks.fns = append(hooks.fns, fn)
+	hooks.mu.Unlock()
+}
+
+// inTransaction 上下文是否携带事务
+func inTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(txContextKey{}).(*gorm.DB)
+	return ok
+}
+
+// conn 返回上下文中的事务，没有事务时返回绑定了上下文的数据库连接
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txContextKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
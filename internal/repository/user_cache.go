@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go_demo/internal/models"
+	"go_demo/pkg/cache"
+	"go_demo/pkg/database"
+	"go_demo/pkg/metrics"
+	"math/rand/v2"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+// userCacheName 缓存指标中的缓存名称
+const userCacheName = "user"
+
+// credentialsKey 上下文中标记读取密码哈希的键
+type credentialsKey struct{}
+
+// WithCredentials 标记读取的用户需要包含密码哈希（如登录、修改密码），用户缓存不保存密码哈希，带该标记的读取直接查询数据库
+func WithCredentials(ctx context.Context) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, true)
+}
+
+// withCredentials 是否需要读取密码哈希
+func withCredentials(ctx context.Context) bool {
+	v, _ := ctx.Value(credentialsKey{}).(bool)
+	return v
+}
+
+// UserCacheConfig 用户缓存配置
+type UserCacheConfig struct {
+	Enabled     bool          `mapstructure:"enabled" yaml:"enabled"`                            // 是否缓存按 ID、用户名、邮箱和手机号查询的用户
+	Prefix      string        `mapstructure:"prefix" yaml:"prefix"`                              // 缓存键前缀，默认 user
+	TTL         time.Duration `mapstructure:"ttl" yaml:"ttl" validate:"min=0"`                   // 缓存时间，默认 10m
+	NegativeTTL time.Duration `mapstructure:"negative_ttl" yaml:"negative_ttl" validate:"min=0"` // 用户不存在时的缓存时间，默认 30s
+	Jitter      float64       `mapstructure:"jitter" yaml:"jitter" validate:"min=0,max=1"`       // 缓存时间随机增加的比例，避免同时过期，默认 0.1
+}
+
+// DefaultUserCacheConfig 默认用户缓存配置
+func DefaultUserCacheConfig() UserCacheConfig {
+	return UserCacheConfig{
+		Enabled:     true,
+		Prefix:      "user",
+		TTL:         10 * time.Minute,
+		NegativeTTL: 30 * time.Second,
+		Jitter:      0.1,
+	}
+}
+
+// cachedUserRepository 带缓存的用户仓储
+//
+// 用户按 ID 缓存，用户名、邮箱和手机号缓存到用户ID的索引，通过索引读取时校验用户的字段仍然一致。
+// 同一个键的并发未命中只查询一次数据库；用户不存在时缓存 NegativeTTL，避免反复查询不存在的用户，
+// 不存在的用户缓存为零值用户，不存在的索引缓存为用户ID 0，与缓存的编码方式无关。
+// 写操作在执行后和事务提交后各删除一次缓存，事务中的读操作不使用缓存。
+// 缓存和通过缓存读取的用户不包含密码哈希，需要校验密码时使用 WithCredentials 读取数据库。
+type cachedUserRepository struct {
+	UserRepository
+	cache  cache.CacheInterface
+	config UserCacheConfig
+	group  singleflight.Group
+}
+
+// NewCachedUserRepository 为用户仓储增加缓存，未设置的配置项使用默认值
+func NewCachedUserRepository(repo UserRepository, c cache.CacheInterface, config UserCacheConfig) UserRepository {
+	defaults := DefaultUserCacheConfig()
+	if config.Prefix == "" {
+		config.Prefix = defaults.Prefix
+	}
+	if config.TTL <= 0 {
+		config.TTL = defaults.TTL
+	}
+	if config.NegativeTTL <= 0 {
+		config.NegativeTTL = defaults.NegativeTTL
+	}
+	return &cachedUserRepository{UserRepository: repo, cache: c, config: config}
+}
+
+// idKey 用户的缓存键
+func (r *cachedUserRepository) idKey(id uint) string {
+	return r.config.Prefix + ":id:" + strconv.FormatUint(uint64(id), 10)
+}
+
+// indexKey 用户名、邮箱或手机号到用户ID的索引键
+func (r *cachedUserRepository) indexKey(field, value string) string {
+	return r.config.Prefix + ":" + field + ":" + value
+}
+
+// indexKeys 用户的全部索引键
+func (r *cachedUserRepository) indexKeys(user *models.User) []string {
+	keys := []string{r.indexKey("username", user.Username), r.indexKey("email", user.Email)}
+	if user.Mobile != "" {
+		keys = append(keys, r.indexKey("mobile", user.Mobile))
+	}
+	return keys
+}
+
+// ttl 增加随机抖动后的缓存时间
+func (r *cachedUserRepository) ttl(base time.Duration) time.Duration {
+	if r.config.Jitter <= 0 {
+		return base
+	}
+	return base + time.Duration(rand.Int64N(int64(float64(base)*r.config.Jitter)+1))
+}
+
+// GetByID 根据ID获取用户
+func (r *cachedUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	if inTransaction(ctx) || withCredentials(ctx) || id <= 0 {
+		return r.UserRepository.GetByID(ctx, id)
+	}
+	key := r.idKey(uint(id))
+	if user, err := cache.GetAs[models.User](ctx, r.cache, key); err == nil {
+		if user.ID == 0 {
+			metrics.CacheLookupsTotal.WithLabelValues(userCacheName, metrics.CacheNegativeHit).Inc()
+			return nil, gorm.ErrRecordNotFound
+		}
+		metrics.CacheLookupsTotal.WithLabelValues(userCacheName, metrics.CacheHit).Inc()
+		return &user, nil
+	}
+	metrics.CacheLookupsTotal.WithLabelValues(userCacheName, metrics.CacheMiss).Inc()
+	return r.load(ctx, key, models.User{}, func(ctx context.Context) (*models.User, error) {
+		return r.UserRepository.GetByID(ctx, id)
+	})
+}
+
+// GetByUsername 根据用户名获取用户
+func (r *cachedUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	return r.getByIndex(ctx, "username", username, func(u *models.User) string { return u.Username }, r.UserRepository.GetByUsername)
+}
+
+// GetByEmail 根据邮箱获取用户
+func (r *cachedUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.getByIndex(ctx, "email", email, func(u *models.User) string { return u.Email }, r.UserRepository.GetByEmail)
+}
+
+// GetByMobile 根据手机号获取用户
+func (r *cachedUserRepository) GetByMobile(ctx context.Context, mobile string) (*models.User, error) {
+	return r.getByIndex(ctx, "mobile", mobile, func(u *models.User) string { return u.Mobile }, r.UserRepository.GetByMobile)
+}
+
+// getByIndex 通过索引读取用户ID，再按ID读取用户；用户的字段已变更时删除索引并查询数据库
+func (r *cachedUserRepository) getByIndex(
+	ctx context.Context,
+	field, value string,
+	current func(*models.User) string,
+	query func(ctx context.Context, value string) (*models.User, error),
+) (*models.User, error) {
+	if inTransaction(ctx) || withCredentials(ctx) || value == "" {
+		return query(ctx, value)
+	}
+	key := r.indexKey(field, value)
+	if id, err := cache.GetAs[uint](ctx, r.cache, key); err == nil {
+		if id == 0 {
+			metrics.CacheLookupsTotal.WithLabelValues(userCacheName, metrics.CacheNegativeHit).Inc()
+			return nil, gorm.ErrRecordNotFound
+		}
+		user, err := r.GetByID(ctx, int(id))
+		if err == nil && current(user) == value {
+			return user, nil
+		}
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		_ = r.cache.Delete(ctx, key)
+	} else if !errors.Is(err, cache.ErrMiss) {
+		_ = r.cache.Delete(ctx, key)
+	}
+	return r.load(ctx, key, uint(0), func(ctx context.Context) (*models.User, error) {
+		return query(ctx, value)
+	})
+}
+
+// load 查询数据库并写入缓存，同一个键的并发查询共享结果，用户不存在时缓存 negative
+// 查询不受单个调用方取消的影响，调用方取消时不再等待结果
+func (r *cachedUserRepository) load(ctx context.Context, key string, negative interface{}, query func(ctx context.Context) (*models.User, error)) (*models.User, error) {
+	ch := r.group.DoChan(key, func() (interface{}, error) {
+		// 回填缓存的数据读主库，避免副本延迟把旧数据写回缓存并保留整个TTL
+		ctx := database.WithPrimary(context.WithoutCancel(ctx))
+		user, err := query(ctx)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			_ = r.cache.Set(ctx, key, negative, r.ttl(r.config.NegativeTTL))
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.store(ctx, user)
+		return user, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		// 共享的结果复制后返回，调用方修改时互不影响；与缓存命中时一样不包含密码哈希
+		user := *res.Val.(*models.User)
+		user.Password = ""
+		return &user, nil
+	}
+}
+
+// store 写入用户和索引，不写入密码哈希，缓存写入失败时只影响命中率
+func (r *cachedUserRepository) store(ctx context.Context, user *models.User) {
+	ttl := r.ttl(r.config.TTL)
+	cached := *user
+	cached.Password = ""
+	values := map[string]interface{}{r.idKey(user.ID): &cached}
+	for _, key := range r.indexKeys(user) {
+		values[key] = user.ID
+	}
+	_ = r.cache.MSet(ctx, values, ttl)
+}
+
+// invalidate 删除用户及其索引的缓存，users 为写入的新值，用于删除新值上的不存在缓存
+// 在写操作后立即删除一次，上下文携带事务时在提交后再删除一次，避免提交前被并发读取写回旧值
+func (r *cachedUserRepository) invalidate(ctx context.Context, ids []uint, users ...*models.User) {
+	var keys []string
+	for _, id := range ids {
+		key := r.idKey(id)
+		if cached, err := cache.GetAs[models.User](ctx, r.cache, key); err == nil && cached.ID != 0 {
+			keys = append(keys, r.indexKeys(&cached)...)
+		}
+		keys = append(keys, key)
+	}
+	for _, user := range users {
+		keys = append(keys, r.indexKeys(user)...)
+	}
+	if len(keys) == 0 {
+		return
+	}
+	_ = r.cache.Delete(ctx, keys...)
+	if inTransaction(ctx) {
+		ctx := context.WithoutCancel(ctx)
+		AfterCommit(ctx, func() { _ = r.cache.Delete(ctx, keys...) })
+	}
+}
+
+// Create 创建用户，删除用户名、邮箱和手机号上的不存在缓存
+func (r *cachedUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := r.UserRepository.Create(ctx, user); err != nil {
+		return err
+	}
+	r.invalidate(ctx, []uint{user.ID}, user)
+	return nil
+}
+
+// Update 更新用户
+func (r *cachedUserRepository) Update(ctx context.Context, user *models.User) error {
+	if err := r.UserRepository.Update(ctx, user); err != nil {
+		return err
+	}
+	r.invalidate(ctx, []uint{user.ID}, user)
+	return nil
+}
+
+// Delete 软删除用户
+func (r *cachedUserRepository) Delete(ctx context.Context, id int) error {
+	if err := r.UserRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, []uint{uint(id)})
+	return nil
+}
+
+// UpdateStatus 更新用户状态
+func (r *cachedUserRepository) UpdateStatus(ctx context.Context, id int, status int) error {
+	if err := r.UserRepository.UpdateStatus(ctx, id, status); err != nil {
+		return err
+	}
+	r.invalidate(ctx, []uint{uint(id)})
+	return nil
+}
+
+// UpdateLastLogin 更新最后登录时间
+func (r *cachedUserRepository) UpdateLastLogin(ctx context.Context, id uint) error {
+	if err := r.UserRepository.UpdateLastLogin(ctx, id); err != nil {
+		return err
+	}
+	r.invalidate(ctx, []uint{id})
+	return nil
+}
+
+// BatchUpdateStatus 批量更新用户状态
+func (r *cachedUserRepository) BatchUpdateStatus(ctx context.Context, ids []int, status int) error {
+	if err := r.UserRepository.BatchUpdateStatus(ctx, ids, status); err != nil {
+		return err
+	}
+	userIDs := make([]uint, len(ids))
+	for i, id := range ids {
+		userIDs[i] = uint(id)
+	}
+	r.invalidate(ctx, userIDs)
+	return nil
+}
+
+// Purge 永久删除用户
+func (r *cachedUserRepository) Purge(ctx context.Context, ids []uint) (int64, error) {
+	n, err := r.UserRepository.Purge(ctx, ids)
+	if err != nil {
+		return n, err
+	}
+	r.invalidate(ctx, ids)
+	return n, nil
+}
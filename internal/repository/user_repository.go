@@ -1,49 +1,58 @@
 package repository
 
 import (
+	"context"
 	"go_demo/internal/models"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 // UserRepository 用户仓储接口
+// 所有方法在上下文携带事务时（见 TxManager）加入该事务
 type UserRepository interface {
 	// 基础CRUD操作
-	Create(user *models.User) error
-	CreateWithTx(tx *gorm.DB, user *models.User) error
-	GetByID(id int) (*models.User, error)
-	GetByUsername(username string) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetByMobile(mobile string) (*models.User, error)
-	Update(user *models.User) error
-	Delete(id int) error
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByMobile(ctx context.Context, mobile string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id int) error
 
 	// 查询操作
-	List(query *models.UserQuery) ([]models.User, int64, error)
-	Count() (int64, error)
+	List(ctx context.Context, query *models.UserQuery) ([]models.User, int64, error)
+	Count(ctx context.Context) (int64, error)
 
 	// 状态操作
-	UpdateStatus(id int, status int) error
-	UpdateLastLogin(id uint) error
+	UpdateStatus(ctx context.Context, id int, status int) error
+	UpdateLastLogin(ctx context.Context, id uint) error
 
 	// 扩展查询方法
-	SearchUsers(keyword string, limit int) ([]models.User, error)
-	GetActiveUsers() ([]models.User, error)
-	GetRecentUsers(limit int) ([]models.User, error)
+	SearchUsers(ctx context.Context, keyword string, limit int) ([]models.User, error)
+	GetActiveUsers(ctx context.Context) ([]models.User, error)
+	GetRecentUsers(ctx context.Context, limit int) ([]models.User, error)
 
 	// 存在性检查
-	ExistsByUsername(username string) (bool, error)
-	ExistsByEmail(email string) (bool, error)
-	ExistsByMobile(mobile string) (bool, error)
+	ExistsByUsername(ctx context.Context, username string) (bool, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	ExistsByMobile(ctx context.Context, mobile string) (bool, error)
 
 	// 批量操作
-	BatchUpdateStatus(ids []int, status int) error
+	BatchUpdateStatus(ctx context.Context, ids []int, status int) error
 
 	//批量获取用户列表
-	GetUserList(query *models.UserQuery) ([]models.User, int64, error)
-
-	// 事务操作
-	BeginTransaction() *gorm.DB
+	GetUserList(ctx context.Context, query *models.UserQuery) ([]models.User, int64, error)
+
+	// 维护操作，由定时任务分批调用
+	// FindDeletedBefore 获取 before 之前软删除的用户
+	FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.User, error)
+	// FindInactiveSince 获取 before 之后没有登录过的启用用户，从未登录的按注册时间计算
+	FindInactiveSince(ctx context.Context, before time.Time, limit int) ([]models.User, error)
+	// FindUnactivatedBefore 获取 before 之前注册且仍未激活的用户
+	FindUnactivatedBefore(ctx context.Context, before time.Time, limit int) ([]models.User, error)
+	// Purge 永久删除用户，包括已软删除的用户，返回删除数量
+	Purge(ctx context.Context, ids []uint) (int64, error)
 }
 
 // userRepository 用户仓储实现
@@ -58,16 +67,16 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	}
 }
 
-func (r *userRepository) GetUserList(query *models.UserQuery) ([]models.User, int64, error) {
+func (r *userRepository) GetUserList(ctx context.Context, query *models.UserQuery) ([]models.User, int64, error) {
 	var users []models.User
 	var total int64
 	// 分页查询
 	offset := query.GetOffset()
 	limit := query.GetSize()
-	db := r.db.Model(&models.User{})
+	db := conn(ctx, r.db).Model(&models.User{})
 
 	db = db.Where("status=?", 1)
-	db = db.Where("created_at > ?", "2025-12-31 00:00:00")
+	db = db.Where("created_at > ?", time.Date(2025, 12, 31, 0, 0, 0, 0, time.Local))
 
 	// 获取总数
 	if err := db.Count(&total).Error; err != nil {
@@ -82,19 +91,14 @@ func (r *userRepository) GetUserList(query *models.UserQuery) ([]models.User, in
 }
 
 // Create 创建用户
-func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
-}
-
-// CreateWithTx 在事务中创建用户
-func (r *userRepository) CreateWithTx(tx *gorm.DB, user *models.User) error {
-	return tx.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return conn(ctx, r.db).Create(user).Error
 }
 
 // GetByID 根据ID获取用户
-func (r *userRepository) GetByID(id int) (*models.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := conn(ctx, r.db).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -102,9 +106,9 @@ func (r *userRepository) GetByID(id int) (*models.User, error) {
 }
 
 // GetByUsername 根据用户名获取用户
-func (r *userRepository) GetByUsername(username string) (*models.User, error) {
+func (r *userRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := conn(ctx, r.db).Where("username = ?", username).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -112,9 +116,9 @@ func (r *userRepository) GetByUsername(username string) (*models.User, error) {
 }
 
 // GetByEmail 根据邮箱获取用户
-func (r *userRepository) GetByEmail(email string) (*models.User, error) {
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := conn(ctx, r.db).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -122,9 +126,9 @@ func (r *userRepository) GetByEmail(email string) (*models.User, error) {
 }
 
 // GetByMobile 根据手机号获取用户
-func (r *userRepository) GetByMobile(mobile string) (*models.User, error) {
+func (r *userRepository) GetByMobile(ctx context.Context, mobile string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("mobile = ?", mobile).First(&user).Error
+	err := conn(ctx, r.db).Where("mobile = ?", mobile).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -132,22 +136,26 @@ func (r *userRepository) GetByMobile(mobile string) (*models.User, error) {
 }
 
 // Update 更新用户
-func (r *userRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	// 从用户缓存读取的用户不包含密码哈希，为空时不更新密码
+	if user.Password == "" {
+		return conn(ctx, r.db).Omit("Password").Save(user).Error
+	}
+	return conn(ctx, r.db).Save(user).Error
 }
 
 // Delete 删除用户（软删除）
-func (r *userRepository) Delete(id int) error {
-	return r.db.Delete(&models.User{}, id).Error
+func (r *userRepository) Delete(ctx context.Context, id int) error {
+	return conn(ctx, r.db).Delete(&models.User{}, id).Error
 }
 
 // List 获取用户列表
-func (r *userRepository) List(query *models.UserQuery) ([]models.User, int64, error) {
+func (r *userRepository) List(ctx context.Context, query *models.UserQuery) ([]models.User, int64, error) {
 	var users []models.User
 	var total int64
 
 	// 构建查询条件
-	db := r.db.Model(&models.User{})
+	db := conn(ctx, r.db).Model(&models.User{})
 
 	// 添加过滤条件
 	if query.Username != "" {
@@ -178,71 +186,100 @@ func (r *userRepository) List(query *models.UserQuery) ([]models.User, int64, er
 }
 
 // Count 获取用户总数
-func (r *userRepository) Count() (int64, error) {
+func (r *userRepository) Count(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Count(&count).Error
+	err := conn(ctx, r.db).Model(&models.User{}).Count(&count).Error
 	return count, err
 }
 
 // UpdateStatus 更新用户状态
-func (r *userRepository) UpdateStatus(id int, status int) error {
-	return r.db.Model(&models.User{}).Where("id = ?", id).Update("status", status).Error
+func (r *userRepository) UpdateStatus(ctx context.Context, id int, status int) error {
+	return conn(ctx, r.db).Model(&models.User{}).Where("id = ?", id).Update("status", status).Error
 }
 
-// UpdateLastLogin 更新最后登录时间
-func (r *userRepository) UpdateLastLogin(id uint) error {
-	return r.db.Model(&models.User{}).Where("id = ?", id).Update("last_login", gorm.Expr("NOW()")).Error
+// UpdateLastLogin 更新最后登录时间，使用应用时间而不是数据库函数，兼容各数据库
+func (r *userRepository) UpdateLastLogin(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Model(&models.User{}).Where("id = ?", id).Update("last_login", r.db.NowFunc()).Error
 }
 
-// ExistsByUsername 检查用户名是否存在
-func (r *userRepository) ExistsByUsername(username string) (bool, error) {
+// ExistsByUsername 检查用户名是否存在，软删除的用户在永久删除前仍占用用户名
+func (r *userRepository) ExistsByUsername(ctx context.Context, username string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("username = ?", username).Count(&count).Error
+	err := conn(ctx, r.db).Unscoped().Model(&models.User{}).Where("username = ?", username).Count(&count).Error
 	return count > 0, err
 }
 
-// ExistsByEmail 检查邮箱是否存在
-func (r *userRepository) ExistsByEmail(email string) (bool, error) {
+// ExistsByEmail 检查邮箱是否存在，软删除的用户在永久删除前仍占用邮箱
+func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	err := conn(ctx, r.db).Unscoped().Model(&models.User{}).Where("email = ?", email).Count(&count).Error
 	return count > 0, err
 }
 
 // ExistsByMobile 检查手机号是否存在
-func (r *userRepository) ExistsByMobile(mobile string) (bool, error) {
+func (r *userRepository) ExistsByMobile(ctx context.Context, mobile string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Where("mobile = ?", mobile).Count(&count).Error
+	err := conn(ctx, r.db).Model(&models.User{}).Where("mobile = ?", mobile).Count(&count).Error
 	return count > 0, err
 }
 
 // GetActiveUsers 获取活跃用户
-func (r *userRepository) GetActiveUsers() ([]models.User, error) {
+func (r *userRepository) GetActiveUsers(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("status = ?", 1).Find(&users).Error
+	err := conn(ctx, r.db).Where("status = ?", 1).Find(&users).Error
 	return users, err
 }
 
 // BatchUpdateStatus 批量更新用户状态
-func (r *userRepository) BatchUpdateStatus(ids []int, status int) error {
-	return r.db.Model(&models.User{}).Where("id IN ?", ids).Update("status", status).Error
+func (r *userRepository) BatchUpdateStatus(ctx context.Context, ids []int, status int) error {
+	return conn(ctx, r.db).Model(&models.User{}).Where("id IN ?", ids).Update("status", status).Error
 }
 
 // SearchUsers 搜索用户（模糊匹配用户名、邮箱）
-func (r *userRepository) SearchUsers(keyword string, limit int) ([]models.User, error) {
+func (r *userRepository) SearchUsers(ctx context.Context, keyword string, limit int) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("username LIKE ? OR email LIKE ?", "%"+keyword+"%", "%"+keyword+"%").
+	err := conn(ctx, r.db).Where("username LIKE ? OR email LIKE ?", "%"+keyword+"%", "%"+keyword+"%").
 		Limit(limit).Find(&users).Error
 	return users, err
 }
 
 // GetRecentUsers 获取最近注册的用户
-func (r *userRepository) GetRecentUsers(limit int) ([]models.User, error) {
+func (r *userRepository) GetRecentUsers(ctx context.Context, limit int) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Order("created_at DESC").Limit(limit).Find(&users).Error
+	err := conn(ctx, r.db).Order("created_at DESC").Limit(limit).Find(&users).Error
 	return users, err
 }
 
-// BeginTransaction 开始事务
-func (r *userRepository) BeginTransaction() *gorm.DB {
-	return r.db.Begin()
+// FindDeletedBefore 获取 before 之前软删除的用户
+func (r *userRepository) FindDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := conn(ctx, r.db).Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// FindInactiveSince 获取长期未登录的启用用户
+func (r *userRepository) FindInactiveSince(ctx context.Context, before time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := conn(ctx, r.db).Where("status = ? AND is_activated = ?", 1, 1).
+		Where("last_login < ? OR (last_login IS NULL AND created_at < ?)", before, before).
+		Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// FindUnactivatedBefore 获取注册后长期未激活的用户
+func (r *userRepository) FindUnactivatedBefore(ctx context.Context, before time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := conn(ctx, r.db).Where("is_activated = ? AND created_at < ?", 0, before).
+		Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// Purge 永久删除用户
+func (r *userRepository) Purge(ctx context.Context, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := conn(ctx, r.db).Unscoped().Where("id IN ?", ids).Delete(&models.User{})
+	return result.RowsAffected, result.Error
 }
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"go_demo/internal/models"
+	"go_demo/pkg/database"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepository Webhook 订阅和投递记录仓储接口
+type WebhookRepository interface {
+	// CreateSubscription 创建订阅
+	CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	// GetSubscription 根据ID获取订阅
+	GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+	// ListSubscriptions 获取全部订阅
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	// EnabledSubscriptions 获取启用的订阅
+	EnabledSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	// UpdateSubscription 保存订阅
+	UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error
+	// DeleteSubscription 删除订阅，投递记录保留
+	DeleteSubscription(ctx context.Context, id uint) error
+
+	// AddDeliveries 写入投递记录，同一订阅的同一事件已存在时忽略
+	AddDeliveries(ctx context.Context, deliveries ...*models.WebhookDelivery) error
+	// DueDeliveries 获取到达投递时间的待投递记录
+	DueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+	// GetDelivery 根据ID获取投递记录
+	GetDelivery(ctx context.Context, id uint64) (*models.WebhookDelivery, error)
+	// ListDeliveries 分页获取订阅的投递记录，status 为空时不过滤
+	ListDeliveries(ctx context.Context, subscriptionID uint, status string, offset, limit int) ([]models.WebhookDelivery, int64, error)
+	// ClaimDelivery 占用一次投递：attempts 仍为读取时的值时加一，并把下次投递时间推迟到 leaseUntil
+	// 返回 false 表示已被其他实例占用；投递过程中进程退出时，记录在 leaseUntil 之后重新投递
+	ClaimDelivery(ctx context.Context, id uint64, attempts int, leaseUntil time.Time) (bool, error)
+	// SaveDeliveryResult 保存投递结果
+	SaveDeliveryResult(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ResetDelivery 重置投递记录为立即投递，清零投递次数
+	ResetDelivery(ctx context.Context, id uint64, now time.Time) error
+
+	// AddAttempt 写入投递日志
+	AddAttempt(ctx context.Context, attempt *models.WebhookAttempt) error
+	// ListAttempts 按时间顺序获取投递记录的全部投递日志
+	ListAttempts(ctx context.Context, deliveryID uint64) ([]models.WebhookAttempt, error)
+
+	// DeleteFinishedDeliveries 删除 before 之前已结束（成功或死信）的投递记录及其投递日志，每次最多 limit 条，返回删除的投递记录数
+	DeleteFinishedDeliveries(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+// webhookRepository Webhook 仓储实现
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository 创建 Webhook 仓储实例
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+// CreateSubscription 创建订阅
+func (r *webhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return conn(ctx, r.db).Create(sub).Error
+}
+
+// GetSubscription 根据ID获取订阅
+func (r *webhookRepository) GetSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := conn(ctx, r.db).First(&sub, id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions 获取全部订阅
+func (r *webhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := conn(ctx, r.db).Order("id").Find(&subs).Error
+	return subs, err
+}
+
+// EnabledSubscriptions 获取启用的订阅，读主库，刚创建的订阅立即生效
+func (r *webhookRepository) EnabledSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := conn(database.WithPrimary(ctx), r.db).Where("enabled = ?", true).Order("id").Find(&subs).Error
+	return subs, err
+}
+
+// UpdateSubscription 保存订阅
+func (r *webhookRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	return conn(ctx, r.db).Save(sub).Error
+}
+
+// DeleteSubscription 删除订阅
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&models.WebhookSubscription{}, id).Error
+}
+
+// AddDeliveries 写入投递记录，事件重复投递给分发器时不会重复生成
+func (r *webhookRepository) AddDeliveries(ctx context.Context, deliveries ...*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(deliveries).Error
+}
+
+// DueDeliveries 获取到达投递时间的待投递记录，读主库
+func (r *webhookRepository) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := conn(database.WithPrimary(ctx), r.db).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("id").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+// GetDelivery 根据ID获取投递记录
+func (r *webhookRepository) GetDelivery(ctx context.Context, id uint64) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := conn(ctx, r.db).First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries 分页获取订阅的投递记录，最新的在前
+func (r *webhookRepository) ListDeliveries(ctx context.Context, subscriptionID uint, status string, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+	var (
+		deliveries []models.WebhookDelivery
+		total      int64
+	)
+	db := conn(ctx, r.db).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// ClaimDelivery 占用一次投递
+func (r *webhookRepository) ClaimDelivery(ctx context.Context, id uint64, attempts int, leaseUntil time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", id, models.WebhookDeliveryPending, attempts).
+		Updates(map[string]interface{}{
+			"attempts":        attempts + 1,
+			"next_attempt_at": leaseUntil,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// SaveDeliveryResult 保存投递结果
+func (r *webhookRepository) SaveDeliveryResult(ctx context.Context, delivery *models.WebhookDelivery) error {
+	// last_error 最长1000个字符
+	if runes := []rune(delivery.LastError); len(runes) > 1000 {
+		delivery.LastError = string(runes[:1000])
+	}
+	return conn(ctx, r.db).Model(delivery).
+		Select("status", "next_attempt_at", "last_status_code", "last_error", "delivered_at").
+		Updates(delivery).Error
+}
+
+// ResetDelivery 重置投递记录为立即投递
+func (r *webhookRepository) ResetDelivery(ctx context.Context, id uint64, now time.Time) error {
+	return conn(ctx, r.db).Model(&models.WebhookDelivery{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          models.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+	}).Error
+}
+
+// AddAttempt 写入投递日志
+func (r *webhookRepository) AddAttempt(ctx context.Context, attempt *models.WebhookAttempt) error {
+	if runes := []rune(attempt.Error); len(runes) > 1000 {
+		attempt.Error = string(runes[:1000])
+	}
+	return conn(ctx, r.db).Create(attempt).Error
+}
+
+// ListAttempts 获取投递记录的全部投递日志
+func (r *webhookRepository) ListAttempts(ctx context.Context, deliveryID uint64) ([]models.WebhookAttempt, error) {
+	var attempts []models.WebhookAttempt
+	err := conn(ctx, r.db).Where("delivery_id = ?", deliveryID).Order("id").Find(&attempts).Error
+	return attempts, err
+}
+
+// DeleteFinishedDeliveries 删除已结束的历史投递记录及其投递日志
+func (r *webhookRepository) DeleteFinishedDeliveries(ctx context.Context, before time.Time, limit int) (int64, error) {
+	var ids []uint64
+	err := conn(ctx, r.db).Model(&models.WebhookDelivery{}).
+		Where("status IN ? AND updated_at < ?", []string{models.WebhookDeliverySucceeded, models.WebhookDeliveryDead}, before).
+		Order("id").Limit(limit).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+	if err := conn(ctx, r.db).Where("delivery_id IN ?", ids).Delete(&models.WebhookAttempt{}).Error; err != nil {
+		return 0, err
+	}
+	result := conn(ctx, r.db).Where("id IN ?", ids).Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
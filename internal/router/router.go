@@ -4,8 +4,8 @@ import (
 	"go_demo/docs"
 	"go_demo/internal/handler"
 	"go_demo/internal/middleware"
-	"net/http"
-	"time"
+	"go_demo/pkg/cache"
+	"go_demo/pkg/metrics"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -18,20 +18,31 @@ type Router struct {
 	authHandler    *handler.AuthHandler
 	userHandler    *handler.UserHandler
 	captchaHandler *handler.CaptchaHandler
+	healthHandler  *handler.HealthHandler
+	webhookHandler *handler.WebhookHandler
+	cache          cache.CacheInterface
 }
 
 // NewRouter 创建新的路由管理器
-func NewRouter(authHandler *handler.AuthHandler, userHandler *handler.UserHandler, captchaHandler *handler.CaptchaHandler) *Router {
+// cacheService 用于幂等键等需要共享存储的中间件，为 nil 时相关中间件直接放行
+// healthHandler 为 nil 时只注册不检查依赖的 /health
+// webhookHandler 为 nil 时不注册 Webhook 订阅管理接口
+func NewRouter(authHandler *handler.AuthHandler, userHandler *handler.UserHandler, captchaHandler *handler.CaptchaHandler, healthHandler *handler.HealthHandler, webhookHandler *handler.WebhookHandler, cacheService cache.CacheInterface) *Router {
 	return &Router{
 		authHandler:    authHandler,
 		userHandler:    userHandler,
 		captchaHandler: captchaHandler,
+		healthHandler:  healthHandler,
+		webhookHandler: webhookHandler,
+		cache:          cacheService,
 	}
 }
 
 // Setup 设置路由
 func (r *Router) Setup() *gin.Engine {
 	r.engine = gin.New()
+	// 允许 gin.Context 作为 context.Context 使用时读取请求上下文中的值（如日志字段）
+	r.engine.ContextWithFallback = true
 
 	// 注册中间件
 	r.setupMiddleware()
@@ -53,8 +64,11 @@ func (r *Router) setupMiddleware() {
 	// 自定义中间件
 	r.engine.Use(
 		middleware.RequestID(),  // 请求ID，用于追踪请求
+		middleware.Metrics(),    // HTTP指标
 		middleware.CORS(),       // 跨域资源共享支持
 		middleware.Trace(),      // 链路追踪
+		middleware.LogContext(), // 日志上下文字段
+		middleware.AccessLog(),  // 访问日志记录
 		middleware.RequestLog(), // 请求日志记录
 	)
 }
@@ -64,6 +78,9 @@ func (r *Router) setupRoutes() {
 	// 健康检查
 	r.setupHealthRoutes()
 
+	// 指标
+	r.setupMetricsRoutes()
+
 	// Swagger文档路由
 	r.setupSwaggerRoutes()
 
@@ -73,17 +90,31 @@ func (r *Router) setupRoutes() {
 
 // setupHealthRoutes 设置健康检查路由
 func (r *Router) setupHealthRoutes() {
-	// 健康检查处理函数
-	healthHandler := func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"status": "ok",
-			"time":   time.Now().Format(time.RFC3339),
-		})
+	// 基础健康检查，不检查依赖 - 支持 GET 和 HEAD 方法
+	basic := handler.NewHealthHandler(nil).Health
+	r.engine.GET("/health", basic)
+	r.engine.HEAD("/health", basic)
+
+	if r.healthHandler == nil {
+		return
 	}
 
-	// 健康检查路由 - 支持 GET 和 HEAD 方法
-	r.engine.GET("/health", healthHandler)
-	r.engine.HEAD("/health", healthHandler)
+	// Kubernetes 探针
+	probes := map[string]gin.HandlerFunc{
+		"/health/live":    r.healthHandler.Liveness,
+		"/health/ready":   r.healthHandler.Readiness,
+		"/health/startup": r.healthHandler.Startup,
+		"/health/check":   r.healthHandler.HealthCheck,
+	}
+	for path, h := range probes {
+		r.engine.GET(path, h)
+		r.engine.HEAD(path, h)
+	}
+}
+
+// setupMetricsRoutes 设置 Prometheus 指标路由
+func (r *Router) setupMetricsRoutes() {
+	r.engine.GET("/metrics", gin.WrapH(metrics.Handler()))
 }
 
 // RouteGroup 定义路由组接口
@@ -93,8 +124,8 @@ type RouteGroup interface {
 
 // setupAPIRoutes 设置 API 路由
 func (r *Router) setupAPIRoutes() {
-	// API v1 路由组
-	v1 := r.engine.Group("/api/v1")
+	// API v1 路由组，按客户端IP限流
+	v1 := r.engine.Group("/api/v1", middleware.RateLimit(r.cache))
 
 	// 验证码路由（公开）
 	r.setupCaptchaRoutes(v1)
@@ -105,6 +136,9 @@ func (r *Router) setupAPIRoutes() {
 	// 用户路由
 	r.setupUserRoutes(v1)
 
+	// Webhook 订阅路由
+	r.setupWebhookRoutes(v1)
+
 	// 可以在这里添加更多的路由组
 	// 例如：r.setupArticleRoutes(v1) 等
 }
@@ -126,7 +160,7 @@ func (r *Router) setupAuthRoutes(rg *gin.RouterGroup) {
 	{
 		// 公开路由（不需要认证）
 		auth.POST("/login", r.authHandler.Login)
-		auth.POST("/register", r.authHandler.Register)
+		auth.POST("/register", middleware.Idempotency(r.cache), r.authHandler.Register)
 		auth.POST("/refresh", r.authHandler.RefreshToken)
 
 		// 需要认证的路由
@@ -144,7 +178,7 @@ func (r *Router) setupUserRoutes(rg *gin.RouterGroup) {
 	{
 		// 用户管理（需要认证）
 		users.GET("", r.userHandler.GetUsers)
-		users.POST("", r.userHandler.CreateUser)
+		users.POST("", middleware.Idempotency(r.cache), r.userHandler.CreateUser)
 		users.GET("/stats", r.userHandler.GetUserStats)
 
 		// 用户详情和操作（需要认证）
@@ -160,6 +194,31 @@ func (r *Router) setupUserRoutes(rg *gin.RouterGroup) {
 	}
 }
 
+// setupWebhookRoutes 设置 Webhook 订阅路由
+func (r *Router) setupWebhookRoutes(rg *gin.RouterGroup) {
+	if r.webhookHandler == nil {
+		return
+	}
+
+	webhooks := rg.Group("/webhooks")
+	// Webhook 订阅接收全部用户的事件，只允许管理员管理
+	webhooks.Use(middleware.JWTAuthMiddleware(), middleware.AdminOnly())
+
+	{
+		// 订阅管理
+		webhooks.GET("", r.webhookHandler.ListWebhooks)
+		webhooks.POST("", r.webhookHandler.CreateWebhook)
+		webhooks.GET("/:id", r.webhookHandler.GetWebhook)
+		webhooks.PUT("/:id", r.webhookHandler.UpdateWebhook)
+		webhooks.DELETE("/:id", r.webhookHandler.DeleteWebhook)
+
+		// 投递记录和重新投递
+		webhooks.GET("/:id/deliveries", r.webhookHandler.ListDeliveries)
+		webhooks.GET("/:id/deliveries/:delivery_id", r.webhookHandler.GetDelivery)
+		webhooks.POST("/:id/deliveries/:delivery_id/redeliver", r.webhookHandler.Redeliver)
+	}
+}
+
 // setupSwaggerRoutes 设置Swagger文档路由
 func (r *Router) setupSwaggerRoutes() {
 	// 导入docs包以确保它被使用
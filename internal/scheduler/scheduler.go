@@ -0,0 +1,390 @@
+// Package scheduler 按 cron 表达式执行定时任务
+//
+// 每个任务执行前先获取分布式锁（lock.Locker），多实例部署时同一任务同一时刻只有一个实例执行，
+// 执行期间每隔锁过期时间的 1/3 续期一次，续期失败时取消任务的 ctx。
+// 按计划触发的执行以（任务, 计划时间）去重，实例间时钟有偏差时也不会重复执行。
+// 每次执行写入执行记录（task_runs 表），包括触发方式、执行主机、处理的记录数、耗时和错误。
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"go_demo/pkg/cron"
+	"go_demo/pkg/lock"
+	"go_demo/pkg/logger"
+	"go_demo/pkg/metrics"
+	"os"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+// schedulerLoggerName 定时任务日志使用的logger名称
+const schedulerLoggerName = "scheduler"
+
+func init() {
+	logger.RegisterNames(schedulerLoggerName)
+}
+
+// lockKeyPrefix 任务锁的键前缀
+const lockKeyPrefix = "scheduler:"
+
+// errorLimit 执行记录保留的错误信息长度
+const errorLimit = 1000
+
+// 定时任务错误
+var (
+	ErrUnknownTask   = errors.New("定时任务不存在")
+	ErrDuplicateTask = errors.New("定时任务已注册")
+	ErrLocked        = errors.New("定时任务正在其他实例执行")
+)
+
+// Config 定时任务配置
+type Config struct {
+	Enabled bool          `mapstructure:"enabled" yaml:"enabled"`                    // 是否在本实例按计划执行定时任务，多实例可以同时开启
+	LockTTL time.Duration `mapstructure:"lock_ttl" yaml:"lock_ttl" validate:"min=0"` // 任务锁的过期时间，执行期间自动续期，默认 1m
+}
+
+// DefaultConfig 默认定时任务配置
+func DefaultConfig() Config {
+	return Config{
+		Enabled: true,
+		LockTTL: time.Minute,
+	}
+}
+
+// TaskFunc 任务函数，返回处理的记录数
+type TaskFunc func(ctx context.Context) (int64, error)
+
+// Task 定时任务
+type Task struct {
+	Name        string   // 任务名称，用于命令行和执行记录
+	Description string   // 任务说明
+	Schedule    string   // cron 表达式，为空时只能手动执行
+	Run         TaskFunc // 任务函数
+}
+
+// TaskInfo 已注册任务的信息
+type TaskInfo struct {
+	Name        string
+	Description string
+	Schedule    string
+	Next        time.Time // 下次计划执行时间，只能手动执行时为零值
+}
+
+// entry 已注册的任务和解析后的执行计划
+type entry struct {
+	task     Task
+	schedule cron.Schedule
+}
+
+// Scheduler 定时任务调度器
+type Scheduler struct {
+	repo   repository.TaskRunRepository
+	locker lock.Locker
+	config Config
+	host   string
+
+	mu    sync.RWMutex
+	tasks map[string]*entry
+	order []string
+
+	runMu sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+// New 创建定时任务调度器，未设置的配置项使用默认值
+func New(repo repository.TaskRunRepository, locker lock.Locker, config Config) *Scheduler {
+	if config.LockTTL <= 0 {
+		config.LockTTL = DefaultConfig().LockTTL
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return &Scheduler{
+		repo:   repo,
+		locker: locker,
+		config: config,
+		host:   host,
+		tasks:  make(map[string]*entry),
+	}
+}
+
+// Register 注册任务，需要在 Start 之前调用
+func (s *Scheduler) Register(task Task) error {
+	if task.Name == "" || task.Run == nil {
+		return errors.New("定时任务缺少名称或任务函数")
+	}
+	e := &entry{task: task}
+	if task.Schedule != "" {
+		schedule, err := cron.Parse(task.Schedule)
+		if err != nil {
+			return fmt.Errorf("定时任务 %s: %w", task.Name, err)
+		}
+		e.schedule = schedule
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tasks[task.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrDuplicateTask, task.Name)
+	}
+	s.tasks[task.Name] = e
+	s.order = append(s.order, task.Name)
+	return nil
+}
+
+// Tasks 按注册顺序返回全部任务
+func (s *Scheduler) Tasks() []TaskInfo {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := time.Now()
+	infos := make([]TaskInfo, 0, len(s.order))
+	for _, name := range s.order {
+		e := s.tasks[name]
+		info := TaskInfo{Name: name, Description: e.task.Description, Schedule: e.task.Schedule}
+		if e.schedule != nil {
+			info.Next = e.schedule.Next(now)
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// History 按开始时间倒序获取执行记录，name 为空时获取全部任务
+func (s *Scheduler) History(ctx context.Context, name string, limit int) ([]models.TaskRun, error) {
+	return s.repo.List(ctx, name, limit)
+}
+
+// RunNow 立即执行任务，任务正在其他实例执行时返回 ErrLocked；任务失败时同时返回执行记录和错误
+func (s *Scheduler) RunNow(ctx context.Context, name string) (*models.TaskRun, error) {
+	s.mu.RLock()
+	e, ok := s.tasks[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTask, name)
+	}
+	return s.execute(ctx, e, models.TaskTriggerManual, nil)
+}
+
+// Start 按计划执行已注册的任务，重复调用无效
+func (s *Scheduler) Start() {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	s.stop, s.done = stop, done
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	s.mu.RLock()
+	for _, name := range s.order {
+		if e := s.tasks[name]; e.schedule != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.loop(ctx, stop, e)
+			}()
+		}
+	}
+	s.mu.RUnlock()
+
+	go func() {
+		<-stop
+		cancel()
+		wg.Wait()
+		close(done)
+	}()
+}
+
+// Stop 停止调度，取消正在执行的任务并等待其结束或 ctx 超时
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.runMu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.runMu.Unlock()
+	if stop == nil {
+		return nil
+	}
+
+	close(stop)
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop 等待到下次计划时间后执行任务
+func (s *Scheduler) loop(ctx context.Context, stop chan struct{}, e *entry) {
+	for {
+		next := e.schedule.Next(time.Now())
+		if next.IsZero() {
+			schedulerLogger().Warn("定时任务没有下次执行时间", logger.String("task", e.task.Name))
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// 任务函数的错误已在执行记录和日志中，这里只记录未能开始执行的原因
+		run, err := s.execute(ctx, e, models.TaskTriggerSchedule, &next)
+		switch {
+		case err == nil || run != nil || ctx.Err() != nil:
+		case errors.Is(err, ErrLocked):
+			schedulerLogger().Debug("定时任务正在其他实例执行，跳过", logger.String("task", e.task.Name))
+		default:
+			schedulerLogger().Error("定时任务调度失败", logger.String("task", e.task.Name), logger.Err(err))
+		}
+	}
+}
+
+// execute 获取任务锁后执行任务并写入执行记录；按计划触发且该计划时间已执行过时返回 nil
+func (s *Scheduler) execute(ctx context.Context, e *entry, trigger string, scheduledAt *time.Time) (*models.TaskRun, error) {
+	name := e.task.Name
+	lk, ok, err := s.locker.TryLock(ctx, lockKeyPrefix+name, s.config.LockTTL)
+	if err != nil {
+		return nil, fmt.Errorf("获取任务锁失败: %w", err)
+	}
+	if !ok {
+		return nil, ErrLocked
+	}
+	defer func() {
+		if err := lk.Release(context.WithoutCancel(ctx)); err != nil {
+			schedulerLogger().Warn("释放任务锁失败", logger.String("task", name), logger.Err(err))
+		}
+	}()
+
+	if scheduledAt != nil {
+		done, err := s.repo.HasScheduledRun(ctx, name, *scheduledAt)
+		if err != nil {
+			return nil, fmt.Errorf("查询执行记录失败: %w", err)
+		}
+		if done {
+			return nil, nil
+		}
+	}
+
+	run := &models.TaskRun{
+		Task:        name,
+		TriggeredBy: trigger,
+		ScheduledAt: scheduledAt,
+		Host:        s.host,
+		Status:      models.TaskRunRunning,
+		StartedAt:   time.Now(),
+	}
+	if err := s.repo.Create(ctx, run); err != nil {
+		return nil, fmt.Errorf("写入执行记录失败: %w", err)
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	renewed := make(chan struct{})
+	go func() {
+		defer close(renewed)
+		s.keepAlive(runCtx, cancel, lk, name)
+	}()
+	affected, runErr := call(runCtx, e.task.Run)
+	cancel()
+	<-renewed
+
+	s.finish(ctx, run, affected, runErr)
+	return run, runErr
+}
+
+// keepAlive 定期续期任务锁，续期失败时取消任务
+func (s *Scheduler) keepAlive(ctx context.Context, cancel context.CancelFunc, lk lock.Lock, name string) {
+	ticker := time.NewTicker(s.config.LockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := lk.Refresh(ctx, s.config.LockTTL); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			schedulerLogger().Error("任务锁续期失败，取消执行", logger.String("task", name), logger.Err(err))
+			cancel()
+			return
+		}
+	}
+}
+
+// call 执行任务函数，将 panic 转换为错误
+func call(ctx context.Context, fn TaskFunc) (affected int64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("定时任务 panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// finish 保存执行结果并记录指标
+func (s *Scheduler) finish(ctx context.Context, run *models.TaskRun, affected int64, err error) {
+	// 任务已执行，即使正在停止也要保存结果
+	ctx = context.WithoutCancel(ctx)
+	finishedAt := time.Now()
+	elapsed := finishedAt.Sub(run.StartedAt)
+	run.Affected = affected
+	run.FinishedAt = &finishedAt
+	run.DurationMs = elapsed.Milliseconds()
+
+	result := metrics.ResultSuccess
+	run.Status = models.TaskRunSucceeded
+	if err != nil {
+		result = metrics.ResultFailed
+		run.Status = models.TaskRunFailed
+		run.Error = truncate(err.Error(), errorLimit)
+	}
+	metrics.SchedulerRunsTotal.WithLabelValues(run.Task, result).Inc()
+	metrics.SchedulerRunDuration.WithLabelValues(run.Task).Observe(elapsed.Seconds())
+
+	if saveErr := s.repo.Save(ctx, run); saveErr != nil {
+		schedulerLogger().Error("保存执行记录失败", logger.String("task", run.Task), logger.Err(saveErr))
+	}
+	fields := []logger.Field{
+		logger.String("task", run.Task),
+		logger.String("trigger", run.TriggeredBy),
+		logger.Int64("affected", affected),
+		logger.Duration("duration", elapsed),
+	}
+	if err != nil {
+		schedulerLogger().Error("定时任务执行失败", append(fields, logger.Err(err))...)
+		return
+	}
+	schedulerLogger().Info("定时任务执行完成", fields...)
+}
+
+// truncate 按字节截断字符串，不截断多字节字符
+func truncate(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	s = s[:limit]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// schedulerLogger 定时任务日志
+func schedulerLogger() *zap.Logger {
+	return logger.Named(schedulerLoggerName)
+}
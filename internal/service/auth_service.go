@@ -1,8 +1,10 @@
 package service
 
 import (
+	"context"
 	"crypto/md5"
 	"fmt"
+	"go_demo/internal/events"
 	"go_demo/internal/models"
 	"go_demo/internal/repository"
 	"go_demo/internal/utils"
@@ -10,50 +12,52 @@ import (
 	"go_demo/pkg/logger"
 	"time"
 
-	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 // AuthService 认证服务接口
 type AuthService interface {
-	Login(c *gin.Context, req models.LoginRequest) (*models.LoginResponse, error)
-	Register(c *gin.Context, req models.RegisterRequest) (*models.UserResponse, error)
-	ValidateToken(token string) (*models.TokenClaims, error)
-	RefreshToken(refreshToken string) (*models.LoginResponse, error)
-	Logout(token string) error
+	Login(ctx context.Context, req models.LoginRequest) (*models.LoginResponse, error)
+	Register(ctx context.Context, req models.RegisterRequest) (*models.UserResponse, error)
+	ValidateToken(ctx context.Context, token string) (*models.TokenClaims, error)
+	RefreshToken(ctx context.Context, refreshToken string) (*models.LoginResponse, error)
+	Logout(ctx context.Context, token string) error
 }
 
 // authService 认证服务实现
 type authService struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	txManager repository.TxManager
+	publisher events.Publisher
 }
 
 // NewAuthService 创建认证服务实例
-func NewAuthService(userRepo repository.UserRepository) AuthService {
+func NewAuthService(userRepo repository.UserRepository, txManager repository.TxManager, publisher events.Publisher) AuthService {
 	return &authService{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		txManager: txManager,
+		publisher: publisher,
 	}
 }
 
 // Login 用户登录
-func (s *authService) Login(c *gin.Context, req models.LoginRequest) (*models.LoginResponse, error) {
+func (s *authService) Login(ctx context.Context, req models.LoginRequest) (*models.LoginResponse, error) {
 	// 验证参数
 	if req.Username == "" || req.Password == "" {
 		return nil, errors.NewValidationError("用户名或密码不能为空")
 	}
 
-	// 查找用户
-	user, err := s.userRepo.GetByUsername(req.Username)
+	// 查找用户，读取数据库中的密码哈希
+	user, err := s.userRepo.GetByUsername(repository.WithCredentials(ctx), req.Username)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			logger.Info("登录失败：用户不存在",
+			logger.InfoCtx(ctx, "登录失败：用户不存在",
 				logger.String("username", req.Username),
-				logger.String("client_ip", utils.GetClientIP(c)),
 			)
 			return nil, errors.ErrInvalidCredentials
 		}
-		logger.Error("登录失败：查询用户错误",
+		logger.ErrorCtx(ctx, "登录失败：查询用户错误",
 			logger.String("username", req.Username),
 			logger.Err(err),
 		)
@@ -61,22 +65,20 @@ func (s *authService) Login(c *gin.Context, req models.LoginRequest) (*models.Lo
 	}
 
 	// 验证密码
-	if !s.verifyPassword(req.Password, user.Password) {
-		logger.Info("登录失败：密码错误",
+	if !s.verifyPassword(ctx, req.Password, user.Password) {
+		logger.InfoCtx(ctx, "登录失败：密码错误",
 			logger.String("username", req.Username),
 			logger.Int64("user_id", int64(user.ID)),
-			logger.String("client_ip", utils.GetClientIP(c)),
 		)
 		return nil, errors.ErrInvalidCredentials
 	}
 
 	// 检查用户状态
 	if user.Status != 1 {
-		logger.Info("登录失败：用户已被禁用",
+		logger.InfoCtx(ctx, "登录失败：用户已被禁用",
 			logger.String("username", req.Username),
 			logger.Int64("user_id", int64(user.ID)),
 			logger.Int("status", user.Status),
-			logger.String("client_ip", utils.GetClientIP(c)),
 		)
 		return nil, errors.NewForbiddenError("用户已被禁用")
 	}
@@ -84,7 +86,7 @@ func (s *authService) Login(c *gin.Context, req models.LoginRequest) (*models.Lo
 	// 生成JWT token
 	token, err := utils.GenerateAccessToken(int64(user.ID), user.Username)
 	if err != nil {
-		logger.Error("登录失败：生成token错误",
+		logger.ErrorCtx(ctx, "登录失败：生成token错误",
 			logger.String("username", req.Username),
 			logger.Int64("user_id", int64(user.ID)),
 			logger.Err(err),
@@ -95,7 +97,7 @@ func (s *authService) Login(c *gin.Context, req models.LoginRequest) (*models.Lo
 	// 生成刷新token
 	refreshToken, err := utils.GenerateRefreshToken(int64(user.ID))
 	if err != nil {
-		logger.Error("登录失败：生成刷新token错误",
+		logger.ErrorCtx(ctx, "登录失败：生成刷新token错误",
 			logger.String("username", req.Username),
 			logger.Int64("user_id", int64(user.ID)),
 			logger.Err(err),
@@ -103,9 +105,18 @@ func (s *authService) Login(c *gin.Context, req models.LoginRequest) (*models.Lo
 		return nil, errors.NewInternalServerError("生成刷新token失败").WithCause(err)
 	}
 
-	// 更新最后登录时间
-	if err := s.userRepo.UpdateLastLogin(user.ID); err != nil {
-		logger.Warn("更新登录时间失败",
+	// 更新最后登录时间并发布登录事件
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdateLastLogin(ctx, user.ID); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.UserLoggedIn{
+			UserEvent: events.UserEvent{UserID: user.ID},
+			Username:  user.Username,
+		})
+	})
+	if err != nil {
+		logger.WarnCtx(ctx, "更新登录时间失败",
 			logger.String("username", req.Username),
 			logger.Int64("user_id", int64(user.ID)),
 			logger.Err(err),
@@ -130,47 +141,43 @@ func (s *authService) Login(c *gin.Context, req models.LoginRequest) (*models.Lo
 		User:             *user.ToResponse(),
 	}
 
-	logger.Info("用户登录成功",
+	logger.InfoCtx(ctx, "用户登录成功",
 		logger.String("username", req.Username),
 		logger.Int64("user_id", int64(user.ID)),
-		logger.String("client_ip", utils.GetClientIP(c)),
 	)
 
 	return response, nil
 }
 
 // Register 用户注册
-func (s *authService) Register(c *gin.Context, req models.RegisterRequest) (*models.UserResponse, error) {
+func (s *authService) Register(ctx context.Context, req models.RegisterRequest) (*models.UserResponse, error) {
 	// 验证参数
 	if err := req.Validate(); err != nil {
 		return nil, errors.NewValidationError(err.Error())
 	}
 
-	logger.Info("用户注册请求",
-		logger.String("request_id", utils.GetRequestID(c)),
-		logger.String("mobile", req.Mobile),
+	// 手机号和邮箱属于个人信息，不写入日志
+	logger.InfoCtx(ctx, "用户注册请求",
 		logger.String("username", req.Username),
-		logger.String("email", req.Email),
-		logger.String("client_ip", utils.GetClientIP(c)),
 	)
 
-	// 检查用户名是否已存在
-	if _, err := s.userRepo.GetByUsername(req.Username); err == nil {
-		return nil, errors.NewConflictError("用户名已存在")
-	} else if err != gorm.ErrRecordNotFound {
-		logger.Error("注册失败：检查用户名错误",
+	// 检查用户名是否已存在，包括尚未永久删除的用户
+	if exists, err := s.userRepo.ExistsByUsername(ctx, req.Username); err != nil {
+		logger.ErrorCtx(ctx, "注册失败：检查用户名错误",
 			logger.String("username", req.Username),
 			logger.Err(err),
 		)
 		return nil, errors.NewInternalServerError("检查用户名失败").WithCause(err)
+	} else if exists {
+		return nil, errors.NewConflictError("用户名已存在")
 	}
 
 	// 检查电话号是否已存在
-	if _, err := s.userRepo.GetByMobile(req.Mobile); err == nil {
+	if _, err := s.userRepo.GetByMobile(ctx, req.Mobile); err == nil {
 		return nil, errors.NewConflictError("手机号已存在")
 	} else if err != gorm.ErrRecordNotFound {
-		logger.Error("注册失败：检查手机号错误",
-			logger.String("mobile", req.Mobile),
+		logger.ErrorCtx(ctx, "注册失败：检查手机号错误",
+			logger.String("username", req.Username),
 			logger.Err(err),
 		)
 		return nil, errors.NewInternalServerError("检查手机号失败").WithCause(err)
@@ -182,50 +189,37 @@ func (s *authService) Register(c *gin.Context, req models.RegisterRequest) (*mod
 		Username:  req.Username,
 		Email:     req.Email,
 		Name:      req.Name,
-		Password:  s.hashPassword(req.Password),
+		Password:  s.hashPassword(ctx, req.Password),
 		Status:    1,
 		Mobile:    req.Mobile,
 		LastLogin: &now,
 	}
 
-	// 开始事务
-	tx := s.userRepo.BeginTransaction()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
+	// 创建用户和注册事件在同一个事务中提交
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
 		}
-	}()
-
-	// 创建用户
-	if err := s.userRepo.CreateWithTx(tx, user); err != nil {
-		tx.Rollback()
-		logger.Error("注册失败：创建用户错误",
+		return s.publisher.Publish(ctx, userRegistered(user))
+	})
+	if err != nil {
+		logger.ErrorCtx(ctx, "注册失败：创建用户错误",
 			logger.String("username", req.Username),
 			logger.Err(err),
 		)
 		return nil, errors.NewInternalServerError("创建用户失败").WithCause(err)
 	}
 
-	// 提交事务
-	if err := tx.Commit().Error; err != nil {
-		logger.Error("注册失败：提交事务错误",
-			logger.String("username", req.Username),
-			logger.Err(err),
-		)
-		return nil, errors.NewInternalServerError("注册失败").WithCause(err)
-	}
-
-	logger.Info("用户注册成功",
+	logger.InfoCtx(ctx, "用户注册成功",
 		logger.String("username", req.Username),
 		logger.Int64("user_id", int64(user.ID)),
-		logger.String("client_ip", utils.GetClientIP(c)),
 	)
 
 	return user.ToResponse(), nil
 }
 
 // ValidateToken 验证JWT token
-func (s *authService) ValidateToken(token string) (*models.TokenClaims, error) {
+func (s *authService) ValidateToken(ctx context.Context, token string) (*models.TokenClaims, error) {
 	if token == "" {
 		return nil, errors.NewValidationError("token不能为空")
 	}
@@ -233,7 +227,7 @@ func (s *authService) ValidateToken(token string) (*models.TokenClaims, error) {
 	// 使用JWT验证token
 	jwtClaims, err := utils.ValidateToken(token)
 	if err != nil {
-		logger.Debug("token验证失败",
+		logger.DebugCtx(ctx, "token验证失败",
 			logger.Err(err),
 		)
 		return nil, errors.ErrInvalidToken
@@ -249,7 +243,7 @@ func (s *authService) ValidateToken(token string) (*models.TokenClaims, error) {
 }
 
 // RefreshToken 刷新token
-func (s *authService) RefreshToken(refreshToken string) (*models.LoginResponse, error) {
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*models.LoginResponse, error) {
 	if refreshToken == "" {
 		return nil, errors.NewValidationError("刷新token不能为空")
 	}
@@ -257,19 +251,19 @@ func (s *authService) RefreshToken(refreshToken string) (*models.LoginResponse,
 	// 验证刷新token
 	jwtClaims, err := utils.ValidateRefreshToken(refreshToken)
 	if err != nil {
-		logger.Debug("刷新token验证失败",
+		logger.DebugCtx(ctx, "刷新token验证失败",
 			logger.Err(err),
 		)
 		return nil, errors.ErrInvalidToken
 	}
 
 	// 获取用户信息
-	user, err := s.userRepo.GetByID(int(jwtClaims.UserID))
+	user, err := s.userRepo.GetByID(ctx, int(jwtClaims.UserID))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, errors.ErrInvalidToken
 		}
-		logger.Error("刷新token失败：获取用户信息错误",
+		logger.ErrorCtx(ctx, "刷新token失败：获取用户信息错误",
 			logger.Int64("user_id", jwtClaims.UserID),
 			logger.Err(err),
 		)
@@ -278,7 +272,7 @@ func (s *authService) RefreshToken(refreshToken string) (*models.LoginResponse,
 
 	// 检查用户状态
 	if user.Status != 1 {
-		logger.Info("刷新token失败：用户已被禁用",
+		logger.InfoCtx(ctx, "刷新token失败：用户已被禁用",
 			logger.Int64("user_id", int64(user.ID)),
 			logger.String("username", user.Username),
 			logger.Int("status", user.Status),
@@ -289,7 +283,7 @@ func (s *authService) RefreshToken(refreshToken string) (*models.LoginResponse,
 	// 生成新的JWT token
 	token, err := utils.GenerateAccessToken(int64(user.ID), user.Username)
 	if err != nil {
-		logger.Error("刷新token失败：生成新token错误",
+		logger.ErrorCtx(ctx, "刷新token失败：生成新token错误",
 			logger.String("username", user.Username),
 			logger.Int64("user_id", int64(user.ID)),
 			logger.Err(err),
@@ -300,7 +294,7 @@ func (s *authService) RefreshToken(refreshToken string) (*models.LoginResponse,
 	// 生成新的刷新token
 	newRefreshToken, err := utils.GenerateRefreshToken(int64(user.ID))
 	if err != nil {
-		logger.Error("刷新token失败：生成新刷新token错误",
+		logger.ErrorCtx(ctx, "刷新token失败：生成新刷新token错误",
 			logger.String("username", user.Username),
 			logger.Int64("user_id", int64(user.ID)),
 			logger.Err(err),
@@ -321,7 +315,7 @@ func (s *authService) RefreshToken(refreshToken string) (*models.LoginResponse,
 		User:             *user.ToResponse(),
 	}
 
-	logger.Info("token刷新成功",
+	logger.InfoCtx(ctx, "token刷新成功",
 		logger.String("username", user.Username),
 		logger.Int64("user_id", int64(user.ID)),
 	)
@@ -330,7 +324,7 @@ func (s *authService) RefreshToken(refreshToken string) (*models.LoginResponse,
 }
 
 // Logout 用户登出
-func (s *authService) Logout(token string) error {
+func (s *authService) Logout(ctx context.Context, token string) error {
 	if token == "" {
 		return errors.NewValidationError("token不能为空")
 	}
@@ -338,7 +332,7 @@ func (s *authService) Logout(token string) error {
 	// 验证token并获取用户信息
 	claims, err := utils.ValidateToken(token)
 	if err != nil {
-		logger.Debug("登出时token验证失败",
+		logger.DebugCtx(ctx, "登出时token验证失败",
 			logger.Err(err),
 		)
 		return errors.ErrInvalidToken
@@ -346,7 +340,7 @@ func (s *authService) Logout(token string) error {
 
 	// 在实际应用中，可以将token加入黑名单
 	// 这里只是记录日志
-	logger.Info("用户登出",
+	logger.InfoCtx(ctx, "用户登出",
 		logger.String("username", claims.Username),
 		logger.Int64("user_id", claims.UserID),
 	)
@@ -355,12 +349,12 @@ func (s *authService) Logout(token string) error {
 }
 
 // hashPassword 密码哈希 - 使用bcrypt替代MD5
-func (s *authService) hashPassword(password string) string {
+func (s *authService) hashPassword(ctx context.Context, password string) string {
 	// 使用bcrypt进行密码哈希，成本为10
 	hashedBytes, err := bcrypt.GenerateFromPassword([]byte(password), 10)
 	if err != nil {
 		// 如果bcrypt失败，回退到MD5（不推荐用于生产环境）
-		logger.Error("密码哈希失败，回退到MD5",
+		logger.ErrorCtx(ctx, "密码哈希失败，回退到MD5",
 			logger.Err(err),
 		)
 		hash := md5.Sum([]byte(password))
@@ -370,7 +364,7 @@ func (s *authService) hashPassword(password string) string {
 }
 
 // verifyPassword 验证密码 - 支持bcrypt和MD5
-func (s *authService) verifyPassword(password, hashedPassword string) bool {
+func (s *authService) verifyPassword(ctx context.Context, password, hashedPassword string) bool {
 	// 首先尝试bcrypt验证
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
 	if err == nil {
@@ -381,7 +375,7 @@ func (s *authService) verifyPassword(password, hashedPassword string) bool {
 	hash := md5.Sum([]byte(password))
 	md5Hash := fmt.Sprintf("%x", hash)
 	if md5Hash == hashedPassword {
-		logger.Warn("使用MD5密码验证，建议升级到bcrypt",
+		logger.WarnCtx(ctx, "使用MD5密码验证，建议升级到bcrypt",
 			logger.String("password_hash", hashedPassword[:10]+"..."),
 		)
 		return true
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"go_demo/internal/events"
+	"go_demo/internal/models"
+)
+
+// userRegistered 根据新创建的用户生成注册事件
+func userRegistered(user *models.User) events.UserRegistered {
+	return events.UserRegistered{
+		UserEvent: events.UserEvent{UserID: user.ID},
+		Username:  user.Username,
+		Email:     user.Email,
+		Mobile:    user.Mobile,
+		Name:      user.Name,
+	}
+}
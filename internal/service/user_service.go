@@ -1,9 +1,12 @@
 package service
 
 import (
+	"context"
 	"fmt"
+	"go_demo/internal/events"
 	"go_demo/internal/models"
 	"go_demo/internal/repository"
+	"go_demo/pkg/logger"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -12,41 +15,45 @@ import (
 // UserService 用户服务接口
 type UserService interface {
 	// 基础CRUD
-	GetUsers(page, pageSize int) ([]*models.UserResponse, int64, error)
-	GetUserByID(id int) (*models.UserResponse, error)
-	UpdateUser(id int, req models.UpdateUserRequest) (*models.UserResponse, error)
-	DeleteUser(id int) error
+	GetUsers(ctx context.Context, page, pageSize int) ([]*models.UserResponse, int64, error)
+	GetUserByID(ctx context.Context, id int) (*models.UserResponse, error)
+	UpdateUser(ctx context.Context, id int, req models.UpdateUserRequest) (*models.UserResponse, error)
+	DeleteUser(ctx context.Context, id int) error
 
 	// 用户管理
-	CreateUser(req models.UserCreateRequest) (*models.UserResponse, error)
-	UpdateUserProfile(id int, req models.UserProfileUpdateRequest) (*models.UserResponse, error)
-	ChangePassword(id int, req models.ChangePasswordRequest) error
-	UpdateUserStatus(id int, status int) error
+	CreateUser(ctx context.Context, req models.UserCreateRequest) (*models.UserResponse, error)
+	UpdateUserProfile(ctx context.Context, id int, req models.UserProfileUpdateRequest) (*models.UserResponse, error)
+	ChangePassword(ctx context.Context, id int, req models.ChangePasswordRequest) error
+	UpdateUserStatus(ctx context.Context, id int, status int) error
 
 	// 查询方法
-	SearchUsers(keyword string, limit int) ([]*models.UserResponse, error)
-	GetActiveUsers() ([]*models.UserResponse, error)
-	GetRecentUsers(limit int) ([]*models.UserResponse, error)
-	GetUserlist(page, pageSize int) ([]*models.UserResponse, int64, error)
+	SearchUsers(ctx context.Context, keyword string, limit int) ([]*models.UserResponse, error)
+	GetActiveUsers(ctx context.Context) ([]*models.UserResponse, error)
+	GetRecentUsers(ctx context.Context, limit int) ([]*models.UserResponse, error)
+	GetUserlist(ctx context.Context, page, pageSize int) ([]*models.UserResponse, int64, error)
 
 	// 统计方法
-	GetUserCount() (int64, error)
-	GetUserStats() (map[string]interface{}, error)
+	GetUserCount(ctx context.Context) (int64, error)
+	GetUserStats(ctx context.Context) (map[string]interface{}, error)
 }
 
 // userService 用户服务实现
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo  repository.UserRepository
+	txManager repository.TxManager
+	publisher events.Publisher
 }
 
 // NewUserService 创建用户服务实例
-func NewUserService(userRepo repository.UserRepository) UserService {
+func NewUserService(userRepo repository.UserRepository, txManager repository.TxManager, publisher events.Publisher) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:  userRepo,
+		txManager: txManager,
+		publisher: publisher,
 	}
 }
 
-func (s *userService) GetUserlist(page, pageSize int) ([]*models.UserResponse, int64, error) {
+func (s *userService) GetUserlist(ctx context.Context, page, pageSize int) ([]*models.UserResponse, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -61,7 +68,7 @@ func (s *userService) GetUserlist(page, pageSize int) ([]*models.UserResponse, i
 		Size: pageSize,
 	}
 
-	users, total, err := s.userRepo.GetUserList(query)
+	users, total, err := s.userRepo.GetUserList(ctx, query)
 
 	if err != nil {
 		return nil, 0, fmt.Errorf("获取用户列表失败: %w", err)
@@ -78,7 +85,7 @@ func (s *userService) GetUserlist(page, pageSize int) ([]*models.UserResponse, i
 }
 
 // GetUsers 获取用户列表
-func (s *userService) GetUsers(page, pageSize int) ([]*models.UserResponse, int64, error) {
+func (s *userService) GetUsers(ctx context.Context, page, pageSize int) ([]*models.UserResponse, int64, error) {
 	if page <= 0 {
 		page = 1
 	}
@@ -93,7 +100,7 @@ func (s *userService) GetUsers(page, pageSize int) ([]*models.UserResponse, int6
 		Page: page,
 		Size: pageSize,
 	}
-	users, total, err := s.userRepo.List(query)
+	users, total, err := s.userRepo.List(ctx, query)
 	if err != nil {
 		return nil, 0, fmt.Errorf("获取用户列表失败: %w", err)
 	}
@@ -108,12 +115,13 @@ func (s *userService) GetUsers(page, pageSize int) ([]*models.UserResponse, int6
 }
 
 // GetUserByID 根据ID获取用户
-func (s *userService) GetUserByID(id int) (*models.UserResponse, error) {
-	user, err := s.userRepo.GetByID(id)
+func (s *userService) GetUserByID(ctx context.Context, id int) (*models.UserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("用户不存在")
 		}
+		logger.ErrorCtx(ctx, "获取用户失败", logger.Int("user_id", id), logger.Err(err))
 		return nil, fmt.Errorf("获取用户失败: %w", err)
 	}
 
@@ -121,83 +129,126 @@ func (s *userService) GetUserByID(id int) (*models.UserResponse, error) {
 }
 
 // UpdateUser 更新用户
-func (s *userService) UpdateUser(id int, req models.UpdateUserRequest) (*models.UserResponse, error) {
+func (s *userService) UpdateUser(ctx context.Context, id int, req models.UpdateUserRequest) (*models.UserResponse, error) {
 	// 获取用户
-	user, err := s.userRepo.GetByID(id)
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("用户不存在")
 		}
+		logger.ErrorCtx(ctx, "获取用户失败", logger.Int("user_id", id), logger.Err(err))
 		return nil, fmt.Errorf("获取用户失败: %w", err)
 	}
 
-	// 更新字段
+	// 检查邮箱是否已被其他用户使用
 	if req.Email != "" {
-		// 检查邮箱是否已被其他用户使用
-		existingUser, err := s.userRepo.GetByEmail(req.Email)
+		existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
 		if err == nil && int(existingUser.ID) != id {
 			return nil, fmt.Errorf("邮箱已被使用")
 		} else if err != nil && err != gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("检查邮箱失败: %w", err)
 		}
-		user.Email = req.Email
 	}
 
-	// 更新姓名字段
-	if req.Name != "" {
-		user.Name = req.Name
-	}
+	// 在事务中重新读取最新数据后保存，避免缓存中的旧数据覆盖状态、登录时间等字段
+	// 状态变化时发布状态变更事件
+	var oldStatus int
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		user = current
+		oldStatus = user.Status
 
-	if req.Status != nil {
-		user.Status = *req.Status
-	}
+		if req.Email != "" {
+			user.Email = req.Email
+		}
+		if req.Name != "" {
+			user.Name = req.Name
+		}
+		if req.Status != nil {
+			user.Status = *req.Status
+		}
 
-	// 保存更新
-	if err := s.userRepo.Update(user); err != nil {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		if user.Status == oldStatus {
+			return nil
+		}
+		return s.publisher.Publish(ctx, events.UserStatusChanged{
+			UserEvent: events.UserEvent{UserID: user.ID},
+			OldStatus: oldStatus,
+			NewStatus: user.Status,
+		})
+	})
+	if err != nil {
+		logger.ErrorCtx(ctx, "更新用户失败", logger.Int("user_id", id), logger.Err(err))
 		return nil, fmt.Errorf("更新用户失败: %w", err)
 	}
 
+	logger.InfoCtx(ctx, "用户已更新",
+		logger.Int("user_id", id),
+		logger.Int("old_status", oldStatus),
+		logger.Int("status", user.Status),
+	)
 	return user.ToResponse(), nil
 }
 
 // DeleteUser 删除用户
-func (s *userService) DeleteUser(id int) error {
+func (s *userService) DeleteUser(ctx context.Context, id int) error {
 	// 检查用户是否存在
-	_, err := s.userRepo.GetByID(id)
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("用户不存在")
 		}
+		logger.ErrorCtx(ctx, "获取用户失败", logger.Int("user_id", id), logger.Err(err))
 		return fmt.Errorf("获取用户失败: %w", err)
 	}
 
 	// 删除用户
-	if err := s.userRepo.Delete(id); err != nil {
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.UserDeleted{
+			UserEvent: events.UserEvent{UserID: user.ID},
+			Username:  user.Username,
+		})
+	})
+	if err != nil {
+		logger.ErrorCtx(ctx, "删除用户失败", logger.Int("user_id", id), logger.Err(err))
 		return fmt.Errorf("删除用户失败: %w", err)
 	}
 
+	logger.InfoCtx(ctx, "用户已删除",
+		logger.Int("user_id", id),
+		logger.String("username", user.Username),
+	)
 	return nil
 }
 
 // CreateUser 创建用户
-func (s *userService) CreateUser(req models.UserCreateRequest) (*models.UserResponse, error) {
-	// 检查用户名是否已存在
-	if _, err := s.userRepo.GetByUsername(req.Username); err == nil {
-		return nil, fmt.Errorf("用户名已存在")
-	} else if err != gorm.ErrRecordNotFound {
+func (s *userService) CreateUser(ctx context.Context, req models.UserCreateRequest) (*models.UserResponse, error) {
+	// 检查用户名是否已存在，包括尚未永久删除的用户
+	if exists, err := s.userRepo.ExistsByUsername(ctx, req.Username); err != nil {
 		return nil, fmt.Errorf("检查用户名失败: %w", err)
+	} else if exists {
+		return nil, fmt.Errorf("用户名已存在")
 	}
 
 	// 检查邮箱是否已存在
-	if _, err := s.userRepo.GetByEmail(req.Email); err == nil {
-		return nil, fmt.Errorf("邮箱已存在")
-	} else if err != gorm.ErrRecordNotFound {
+	if exists, err := s.userRepo.ExistsByEmail(ctx, req.Email); err != nil {
 		return nil, fmt.Errorf("检查邮箱失败: %w", err)
+	} else if exists {
+		return nil, fmt.Errorf("邮箱已存在")
 	}
 
 	// 检查手机号是否已存在（如果提供）
 	if req.Mobile != "" {
-		if _, err := s.userRepo.GetByMobile(req.Mobile); err == nil {
+		if _, err := s.userRepo.GetByMobile(ctx, req.Mobile); err == nil {
 			return nil, fmt.Errorf("手机号已存在")
 		} else if err != gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("检查手机号失败: %w", err)
@@ -220,84 +271,103 @@ func (s *userService) CreateUser(req models.UserCreateRequest) (*models.UserResp
 		Status:   1,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Create(ctx, user); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, userRegistered(user))
+	})
+	if err != nil {
+		logger.ErrorCtx(ctx, "创建用户失败", logger.String("username", req.Username), logger.Err(err))
 		return nil, fmt.Errorf("创建用户失败: %w", err)
 	}
 
+	logger.InfoCtx(ctx, "用户已创建",
+		logger.String("username", user.Username),
+		logger.Int64("user_id", int64(user.ID)),
+	)
 	return user.ToResponse(), nil
 }
 
 // UpdateUserProfile 更新用户资料
-func (s *userService) UpdateUserProfile(id int, req models.UserProfileUpdateRequest) (*models.UserResponse, error) {
+func (s *userService) UpdateUserProfile(ctx context.Context, id int, req models.UserProfileUpdateRequest) (*models.UserResponse, error) {
 	// 获取用户
-	user, err := s.userRepo.GetByID(id)
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("用户不存在")
 		}
+		logger.ErrorCtx(ctx, "获取用户失败", logger.Int("user_id", id), logger.Err(err))
 		return nil, fmt.Errorf("获取用户失败: %w", err)
 	}
 
-	// 更新字段
+	// 检查邮箱是否已被其他用户使用
 	if req.Email != "" && req.Email != user.Email {
-		// 检查邮箱是否已被其他用户使用
-		existingUser, err := s.userRepo.GetByEmail(req.Email)
+		existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
 		if err == nil && int(existingUser.ID) != id {
 			return nil, fmt.Errorf("邮箱已被使用")
 		} else if err != nil && err != gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("检查邮箱失败: %w", err)
 		}
-		user.Email = req.Email
 	}
 
+	// 检查手机号是否已被其他用户使用
 	if req.Mobile != "" && req.Mobile != user.Mobile {
-		// 检查手机号是否已被其他用户使用
-		existingUser, err := s.userRepo.GetByMobile(req.Mobile)
+		existingUser, err := s.userRepo.GetByMobile(ctx, req.Mobile)
 		if err == nil && int(existingUser.ID) != id {
 			return nil, fmt.Errorf("手机号已被使用")
 		} else if err != nil && err != gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("检查手机号失败: %w", err)
 		}
-		user.Mobile = req.Mobile
-	}
-
-	// 更新姓名
-	if req.Name != "" {
-		user.Name = req.Name
 	}
 
-	// 更新手机号
-	if req.Mobile != "" {
-		user.Mobile = req.Mobile
-	}
-
-	// 更新头像
-	if req.Avatar != "" {
-		user.Avatar = req.Avatar
-	}
+	// 在事务中重新读取最新数据后保存，避免缓存中的旧数据覆盖状态、登录时间等字段
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		user = current
 
-	// 保存更新
-	if err := s.userRepo.Update(user); err != nil {
+		if req.Email != "" {
+			user.Email = req.Email
+		}
+		if req.Name != "" {
+			user.Name = req.Name
+		}
+		if req.Mobile != "" {
+			user.Mobile = req.Mobile
+		}
+		if req.Avatar != "" {
+			user.Avatar = req.Avatar
+		}
+		return s.userRepo.Update(ctx, user)
+	})
+	if err != nil {
+		logger.ErrorCtx(ctx, "更新用户资料失败", logger.Int("user_id", id), logger.Err(err))
 		return nil, fmt.Errorf("更新用户失败: %w", err)
 	}
 
+	logger.InfoCtx(ctx, "用户资料已更新", logger.Int("user_id", id))
 	return user.ToResponse(), nil
 }
 
 // ChangePassword 修改密码
-func (s *userService) ChangePassword(id int, req models.ChangePasswordRequest) error {
-	// 获取用户
-	user, err := s.userRepo.GetByID(id)
+func (s *userService) ChangePassword(ctx context.Context, id int, req models.ChangePasswordRequest) error {
+	// 获取用户，读取数据库中的密码哈希
+	user, err := s.userRepo.GetByID(repository.WithCredentials(ctx), id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("用户不存在")
 		}
+		logger.ErrorCtx(ctx, "获取用户失败", logger.Int("user_id", id), logger.Err(err))
 		return fmt.Errorf("获取用户失败: %w", err)
 	}
 
 	// 验证原密码
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword))
 	if err != nil {
+		logger.InfoCtx(ctx, "修改密码失败：原密码错误", logger.Int("user_id", id))
 		return fmt.Errorf("原密码错误")
 	}
 
@@ -309,34 +379,68 @@ func (s *userService) ChangePassword(id int, req models.ChangePasswordRequest) e
 
 	// 更新密码
 	user.Password = string(hashedBytes)
-	if err := s.userRepo.Update(user); err != nil {
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.PasswordChanged{UserEvent: events.UserEvent{UserID: user.ID}})
+	})
+	if err != nil {
+		logger.ErrorCtx(ctx, "更新密码失败", logger.Int("user_id", id), logger.Err(err))
 		return fmt.Errorf("更新密码失败: %w", err)
 	}
 
+	logger.InfoCtx(ctx, "用户已修改密码", logger.Int("user_id", id))
 	return nil
 }
 
 // UpdateUserStatus 更新用户状态
-func (s *userService) UpdateUserStatus(id int, status int) error {
+func (s *userService) UpdateUserStatus(ctx context.Context, id int, status int) error {
 	// 检查用户是否存在
-	_, err := s.userRepo.GetByID(id)
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("用户不存在")
 		}
+		logger.ErrorCtx(ctx, "获取用户失败", logger.Int("user_id", id), logger.Err(err))
 		return fmt.Errorf("获取用户失败: %w", err)
 	}
 
-	// 更新状态
-	if err := s.userRepo.UpdateStatus(id, status); err != nil {
+	// 更新状态，状态变化时发布状态变更事件
+	// 原状态在事务中重新读取，避免使用缓存中的旧数据
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		current, err := s.userRepo.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		user = current
+		if err := s.userRepo.UpdateStatus(ctx, id, status); err != nil {
+			return err
+		}
+		if user.Status == status {
+			return nil
+		}
+		return s.publisher.Publish(ctx, events.UserStatusChanged{
+			UserEvent: events.UserEvent{UserID: user.ID},
+			OldStatus: user.Status,
+			NewStatus: status,
+		})
+	})
+	if err != nil {
+		logger.ErrorCtx(ctx, "更新用户状态失败", logger.Int("user_id", id), logger.Err(err))
 		return fmt.Errorf("更新用户状态失败: %w", err)
 	}
 
+	logger.InfoCtx(ctx, "用户状态已更新",
+		logger.Int("user_id", id),
+		logger.Int("old_status", user.Status),
+		logger.Int("status", status),
+	)
 	return nil
 }
 
 // SearchUsers 搜索用户
-func (s *userService) SearchUsers(keyword string, limit int) ([]*models.UserResponse, error) {
+func (s *userService) SearchUsers(ctx context.Context, keyword string, limit int) ([]*models.UserResponse, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -344,7 +448,7 @@ func (s *userService) SearchUsers(keyword string, limit int) ([]*models.UserResp
 		limit = 100
 	}
 
-	users, err := s.userRepo.SearchUsers(keyword, limit)
+	users, err := s.userRepo.SearchUsers(ctx, keyword, limit)
 	if err != nil {
 		return nil, fmt.Errorf("搜索用户失败: %w", err)
 	}
@@ -358,8 +462,8 @@ func (s *userService) SearchUsers(keyword string, limit int) ([]*models.UserResp
 }
 
 // GetActiveUsers 获取活跃用户
-func (s *userService) GetActiveUsers() ([]*models.UserResponse, error) {
-	users, err := s.userRepo.GetActiveUsers()
+func (s *userService) GetActiveUsers(ctx context.Context) ([]*models.UserResponse, error) {
+	users, err := s.userRepo.GetActiveUsers(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("获取活跃用户失败: %w", err)
 	}
@@ -373,7 +477,7 @@ func (s *userService) GetActiveUsers() ([]*models.UserResponse, error) {
 }
 
 // GetRecentUsers 获取最近注册的用户
-func (s *userService) GetRecentUsers(limit int) ([]*models.UserResponse, error) {
+func (s *userService) GetRecentUsers(ctx context.Context, limit int) ([]*models.UserResponse, error) {
 	if limit <= 0 {
 		limit = 10
 	}
@@ -381,7 +485,7 @@ func (s *userService) GetRecentUsers(limit int) ([]*models.UserResponse, error)
 		limit = 100
 	}
 
-	users, err := s.userRepo.GetRecentUsers(limit)
+	users, err := s.userRepo.GetRecentUsers(ctx, limit)
 	if err != nil {
 		return nil, fmt.Errorf("获取最近用户失败: %w", err)
 	}
@@ -395,8 +499,8 @@ func (s *userService) GetRecentUsers(limit int) ([]*models.UserResponse, error)
 }
 
 // GetUserCount 获取用户总数
-func (s *userService) GetUserCount() (int64, error) {
-	count, err := s.userRepo.Count()
+func (s *userService) GetUserCount(ctx context.Context) (int64, error) {
+	count, err := s.userRepo.Count(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("获取用户总数失败: %w", err)
 	}
@@ -404,18 +508,18 @@ func (s *userService) GetUserCount() (int64, error) {
 }
 
 // GetUserStats 获取用户统计信息
-func (s *userService) GetUserStats() (map[string]interface{}, error) {
+func (s *userService) GetUserStats(ctx context.Context) (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
 	// 总用户数
-	totalCount, err := s.userRepo.Count()
+	totalCount, err := s.userRepo.Count(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("获取总用户数失败: %w", err)
 	}
 	stats["total_users"] = totalCount
 
 	// 活跃用户数
-	activeUsers, err := s.userRepo.GetActiveUsers()
+	activeUsers, err := s.userRepo.GetActiveUsers(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("获取活跃用户失败: %w", err)
 	}
@@ -431,7 +535,7 @@ func (s *userService) GetUserStats() (map[string]interface{}, error) {
 // GetUserRoles 获取用户角色
 /*
 func (s *userService) GetUserRoles(userID int64) ([]*models.Role, error) {
-	user, err := s.userRepo.GetByID(int(userID))
+	user, err := s.userRepo.GetByID(ctx, int(userID))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("用户不存在")
@@ -453,7 +557,7 @@ func (s *userService) GetUserRoles(userID int64) ([]*models.Role, error) {
 /*
 func (s *userService) AssignRole(userID int64, roleName string) error {
 	// 检查用户是否存在
-	user, err := s.userRepo.GetByID(int(userID))
+	user, err := s.userRepo.GetByID(ctx, int(userID))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("用户不存在")
@@ -484,7 +588,7 @@ func (s *userService) AssignRole(userID int64, roleName string) error {
 /*
 func (s *userService) RevokeRole(userID int64, roleName string) error {
 	// 检查用户是否存在
-	user, err := s.userRepo.GetByID(int(userID))
+	user, err := s.userRepo.GetByID(ctx, int(userID))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return fmt.Errorf("用户不存在")
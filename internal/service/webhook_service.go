@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"go_demo/internal/events"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"go_demo/internal/webhook"
+	"go_demo/pkg/errors"
+	"go_demo/pkg/logger"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// WebhookService Webhook 订阅管理服务接口
+type WebhookService interface {
+	// 订阅管理
+	CreateWebhook(ctx context.Context, req models.WebhookCreateRequest) (*models.WebhookResponse, error)
+	GetWebhook(ctx context.Context, id uint) (*models.WebhookResponse, error)
+	ListWebhooks(ctx context.Context) ([]*models.WebhookResponse, error)
+	UpdateWebhook(ctx context.Context, id uint, req models.WebhookUpdateRequest) (*models.WebhookResponse, error)
+	DeleteWebhook(ctx context.Context, id uint) error
+
+	// 投递记录
+	ListDeliveries(ctx context.Context, id uint, status string, page, size int) ([]*models.WebhookDeliveryResponse, int64, error)
+	GetDelivery(ctx context.Context, id uint, deliveryID uint64) (*models.WebhookDeliveryResponse, error)
+	Redeliver(ctx context.Context, id uint, deliveryID uint64) (*models.WebhookDeliveryResponse, error)
+}
+
+// webhookService Webhook 订阅管理服务实现
+type webhookService struct {
+	webhookRepo repository.WebhookRepository
+	deliverer   *webhook.Deliverer
+}
+
+// NewWebhookService 创建 Webhook 订阅管理服务实例
+func NewWebhookService(webhookRepo repository.WebhookRepository, deliverer *webhook.Deliverer) WebhookService {
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		deliverer:   deliverer,
+	}
+}
+
+// CreateWebhook 创建订阅，未指定密钥时自动生成，密钥只在创建时返回
+func (s *webhookService) CreateWebhook(ctx context.Context, req models.WebhookCreateRequest) (*models.WebhookResponse, error) {
+	if err := s.validateWebhookURL(ctx, req.URL); err != nil {
+		return nil, err
+	}
+	eventTypes, err := normalizeEventTypes(req.EventTypes)
+	if err != nil {
+		return nil, err
+	}
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, errors.NewInternalServerError("生成签名密钥失败").WithCause(err)
+		}
+	}
+
+	sub := &models.WebhookSubscription{
+		URL:         req.URL,
+		Secret:      secret,
+		EventTypes:  eventTypes,
+		Description: req.Description,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	if err := s.webhookRepo.CreateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("创建 webhook 订阅失败: %w", err)
+	}
+
+	logger.InfoCtx(ctx, "创建 webhook 订阅",
+		logger.Int("webhook_id", int(sub.ID)),
+		logger.String("url", sub.URL),
+		logger.String("event_types", sub.EventTypes),
+	)
+
+	resp := sub.ToResponse()
+	resp.Secret = secret
+	return resp, nil
+}
+
+// GetWebhook 获取订阅
+func (s *webhookService) GetWebhook(ctx context.Context, id uint) (*models.WebhookResponse, error) {
+	sub, err := s.getSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return sub.ToResponse(), nil
+}
+
+// ListWebhooks 获取全部订阅
+func (s *webhookService) ListWebhooks(ctx context.Context) ([]*models.WebhookResponse, error) {
+	subs, err := s.webhookRepo.ListSubscriptions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取 webhook 订阅失败: %w", err)
+	}
+	responses := make([]*models.WebhookResponse, len(subs))
+	for i := range subs {
+		responses[i] = subs[i].ToResponse()
+	}
+	return responses, nil
+}
+
+// UpdateWebhook 更新订阅，修改密钥时在响应中返回新密钥
+func (s *webhookService) UpdateWebhook(ctx context.Context, id uint, req models.WebhookUpdateRequest) (*models.WebhookResponse, error) {
+	sub, err := s.getSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != "" {
+		if err := s.validateWebhookURL(ctx, req.URL); err != nil {
+			return nil, err
+		}
+		sub.URL = req.URL
+	}
+	if req.EventTypes != nil {
+		if sub.EventTypes, err = normalizeEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+	}
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+	if req.Description != nil {
+		sub.Description = *req.Description
+	}
+	if req.Enabled != nil {
+		sub.Enabled = *req.Enabled
+	}
+	if err := s.webhookRepo.UpdateSubscription(ctx, sub); err != nil {
+		return nil, fmt.Errorf("更新 webhook 订阅失败: %w", err)
+	}
+
+	logger.InfoCtx(ctx, "更新 webhook 订阅",
+		logger.Int("webhook_id", int(sub.ID)),
+		logger.Any("enabled", sub.Enabled),
+		logger.Any("secret_changed", req.Secret != ""),
+	)
+
+	resp := sub.ToResponse()
+	if req.Secret != "" {
+		resp.Secret = sub.Secret
+	}
+	return resp, nil
+}
+
+// DeleteWebhook 删除订阅，保留投递记录，未完成的投递进入死信
+func (s *webhookService) DeleteWebhook(ctx context.Context, id uint) error {
+	if _, err := s.getSubscription(ctx, id); err != nil {
+		return err
+	}
+	if err := s.webhookRepo.DeleteSubscription(ctx, id); err != nil {
+		return fmt.Errorf("删除 webhook 订阅失败: %w", err)
+	}
+	logger.InfoCtx(ctx, "删除 webhook 订阅", logger.Int("webhook_id", int(id)))
+	return nil
+}
+
+// ListDeliveries 分页获取订阅的投递记录
+func (s *webhookService) ListDeliveries(ctx context.Context, id uint, status string, page, size int) ([]*models.WebhookDeliveryResponse, int64, error) {
+	if _, err := s.getSubscription(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryDead:
+	default:
+		return nil, 0, errors.NewValidationError("无效的投递状态: " + status)
+	}
+
+	if page <= 0 {
+		page = 1
+	}
+	if size <= 0 {
+		size = 10
+	}
+	if size > 100 {
+		size = 100
+	}
+
+	deliveries, total, err := s.webhookRepo.ListDeliveries(ctx, id, status, (page-1)*size, size)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取 webhook 投递记录失败: %w", err)
+	}
+	responses := make([]*models.WebhookDeliveryResponse, len(deliveries))
+	for i := range deliveries {
+		responses[i] = deliveries[i].ToResponse()
+	}
+	return responses, total, nil
+}
+
+// GetDelivery 获取投递记录详情，包括请求体和全部投递日志
+func (s *webhookService) GetDelivery(ctx context.Context, id uint, deliveryID uint64) (*models.WebhookDeliveryResponse, error) {
+	delivery, err := s.getDelivery(ctx, id, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	return s.deliveryDetail(ctx, delivery)
+}
+
+// Redeliver 立即重新投递，返回投递后的记录详情
+func (s *webhookService) Redeliver(ctx context.Context, id uint, deliveryID uint64) (*models.WebhookDeliveryResponse, error) {
+	sub, err := s.getSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.Enabled {
+		return nil, errors.NewConflictError("webhook 订阅已停用")
+	}
+	if _, err := s.getDelivery(ctx, id, deliveryID); err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.deliverer.Redeliver(ctx, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("重新投递 webhook 失败: %w", err)
+	}
+	logger.InfoCtx(ctx, "重新投递 webhook",
+		logger.Int("webhook_id", int(id)),
+		logger.Int64("delivery_id", int64(deliveryID)),
+		logger.String("status", delivery.Status),
+		logger.Int("status_code", delivery.LastStatusCode),
+	)
+	return s.deliveryDetail(ctx, delivery)
+}
+
+// getSubscription 获取订阅，不存在时返回 404 错误
+func (s *webhookService) getSubscription(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	sub, err := s.webhookRepo.GetSubscription(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError("webhook 订阅不存在")
+		}
+		return nil, fmt.Errorf("获取 webhook 订阅失败: %w", err)
+	}
+	return sub, nil
+}
+
+// getDelivery 获取属于订阅的投递记录，不存在时返回 404 错误
+func (s *webhookService) getDelivery(ctx context.Context, id uint, deliveryID uint64) (*models.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDelivery(ctx, deliveryID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("获取 webhook 投递记录失败: %w", err)
+	}
+	if delivery == nil || delivery.SubscriptionID != id {
+		return nil, errors.NewNotFoundError("webhook 投递记录不存在")
+	}
+	return delivery, nil
+}
+
+// deliveryDetail 投递记录详情
+func (s *webhookService) deliveryDetail(ctx context.Context, delivery *models.WebhookDelivery) (*models.WebhookDeliveryResponse, error) {
+	attempts, err := s.webhookRepo.ListAttempts(ctx, delivery.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取 webhook 投递日志失败: %w", err)
+	}
+	resp := delivery.ToResponse()
+	resp.Payload = delivery.Payload
+	resp.AttemptLogs = make([]*models.WebhookAttemptResponse, len(attempts))
+	for i := range attempts {
+		resp.AttemptLogs[i] = attempts[i].ToResponse()
+	}
+	return resp, nil
+}
+
+// validateWebhookURL 只允许 http 和 https 地址，且不能指向本机、内网或链路本地地址
+func (s *webhookService) validateWebhookURL(ctx context.Context, raw string) error {
+	if err := s.deliverer.CheckURL(ctx, raw); err != nil {
+		return errors.NewValidationError(err.Error())
+	}
+	return nil
+}
+
+// normalizeEventTypes 校验事件类型并去重，返回逗号分隔的字符串
+func normalizeEventTypes(eventTypes []string) (string, error) {
+	if len(eventTypes) == 0 {
+		return "", errors.NewValidationError("至少订阅一个事件类型")
+	}
+	var result []string
+	for _, t := range eventTypes {
+		t = strings.TrimSpace(t)
+		if t != events.AllEvents && !slices.Contains(events.Types, t) {
+			return "", errors.NewValidationError("不支持的事件类型: " + t)
+		}
+		if !slices.Contains(result, t) {
+			result = append(result, t)
+		}
+	}
+	return strings.Join(result, ","), nil
+}
+
+// generateWebhookSecret 生成32字节的随机签名密钥
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
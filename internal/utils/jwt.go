@@ -2,6 +2,7 @@ package utils
 
 import (
 	"errors"
+	"sync/atomic"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -9,10 +10,10 @@ import (
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	SecretKey     string `mapstructure:"secret_key" yaml:"secret_key"`
-	AccessExpire  int64  `mapstructure:"access_expire" yaml:"access_expire"`   // 访问token过期时间（秒）
-	RefreshExpire int64  `mapstructure:"refresh_expire" yaml:"refresh_expire"` // 刷新token过期时间（秒）
-	Issuer        string `mapstructure:"issuer" yaml:"issuer"`                 // 签发者
+	SecretKey     string `mapstructure:"secret_key" yaml:"secret_key" validate:"required"`
+	AccessExpire  int64  `mapstructure:"access_expire" yaml:"access_expire" validate:"gt=0"`   // 访问token过期时间（秒）
+	RefreshExpire int64  `mapstructure:"refresh_expire" yaml:"refresh_expire" validate:"gt=0"` // 刷新token过期时间（秒）
+	Issuer        string `mapstructure:"issuer" yaml:"issuer"`                                 // 签发者
 }
 
 // Claims JWT声明
@@ -213,49 +214,53 @@ func (j *JWTManager) GetTokenRemainingTime(tokenString string) (time.Duration, e
 	return remaining, nil
 }
 
-// 全局JWT管理器实例
-var jwtManager *JWTManager
+// 全局JWT管理器实例，配置热更新时整体替换
+var jwtManager atomic.Pointer[JWTManager]
 
-// InitJWT 初始化JWT管理器
+// InitJWT 初始化JWT管理器，可重复调用以应用新的配置
 func InitJWT(config JWTConfig) {
-	jwtManager = NewJWTManager(config)
+	jwtManager.Store(NewJWTManager(config))
 }
 
 // GetJWTManager 获取JWT管理器实例
 func GetJWTManager() *JWTManager {
-	return jwtManager
+	return jwtManager.Load()
 }
 
 // 以下是全局便捷函数，使用默认的JWT管理器
 
 // GenerateAccessToken 生成访问token
 func GenerateAccessToken(userID int64, username string) (string, error) {
-	if jwtManager == nil {
+	manager := jwtManager.Load()
+	if manager == nil {
 		return "", errors.New("JWT管理器未初始化")
 	}
-	return jwtManager.GenerateAccessToken(userID, username)
+	return manager.GenerateAccessToken(userID, username)
 }
 
 // GenerateRefreshToken 生成刷新token
 func GenerateRefreshToken(userID int64) (string, error) {
-	if jwtManager == nil {
+	manager := jwtManager.Load()
+	if manager == nil {
 		return "", errors.New("JWT管理器未初始化")
 	}
-	return jwtManager.GenerateRefreshToken(userID)
+	return manager.GenerateRefreshToken(userID)
 }
 
 // ValidateToken 验证token有效性
 func ValidateToken(tokenString string) (*Claims, error) {
-	if jwtManager == nil {
+	manager := jwtManager.Load()
+	if manager == nil {
 		return nil, errors.New("JWT管理器未初始化")
 	}
-	return jwtManager.ValidateToken(tokenString)
+	return manager.ValidateToken(tokenString)
 }
 
 // ValidateRefreshToken 验证刷新token有效性
 func ValidateRefreshToken(tokenString string) (*Claims, error) {
-	if jwtManager == nil {
+	manager := jwtManager.Load()
+	if manager == nil {
 		return nil, errors.New("JWT管理器未初始化")
 	}
-	return jwtManager.ValidateRefreshToken(tokenString)
+	return manager.ValidateRefreshToken(tokenString)
 }
@@ -0,0 +1,392 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_demo/internal/events"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"go_demo/pkg/logger"
+	"go_demo/pkg/metrics"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// webhookLoggerName Webhook 投递日志使用的logger名称
+const webhookLoggerName = "webhook"
+
+func init() {
+	logger.RegisterNames(webhookLoggerName)
+}
+
+// responseBodyLimit 投递日志保留的响应内容长度
+const responseBodyLimit = 1024
+
+// userAgent 请求的 User-Agent
+const userAgent = "go_demo-webhook/1.0"
+
+// Payload Webhook 请求体
+type Payload struct {
+	ID         uint64          `json:"id"`          // 事件ID，与 X-Webhook-Event-ID 相同
+	Type       string          `json:"type"`        // 事件类型
+	OccurredAt time.Time       `json:"occurred_at"` // 事件发生时间
+	Data       json.RawMessage `json:"data"`        // 事件内容，与领域事件的 JSON 相同
+}
+
+// Deliverer Webhook 投递器
+type Deliverer struct {
+	repo   repository.WebhookRepository
+	config Config
+	client *http.Client
+
+	runMu sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+	wake  chan struct{}
+}
+
+// NewDeliverer 创建 Webhook 投递器，未设置的配置项使用默认值
+func NewDeliverer(repo repository.WebhookRepository, config Config) *Deliverer {
+	defaults := DefaultConfig()
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.MaxRetryBackoff <= 0 {
+		config.MaxRetryBackoff = defaults.MaxRetryBackoff
+	}
+	return &Deliverer{
+		repo:   repo,
+		config: config,
+		client: newHTTPClient(config.AllowPrivateTargets),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// HandleEvent 事件订阅者，为每个订阅了该事件的启用的订阅生成投递记录
+// 事件重复投递时不会重复生成记录；返回错误时事件分发器稍后重试
+func (d *Deliverer) HandleEvent(ctx context.Context, msg events.Message) error {
+	subs, err := d.repo.EnabledSubscriptions(ctx)
+	if err != nil {
+		return fmt.Errorf("查询 webhook 订阅失败: %w", err)
+	}
+
+	var deliveries []*models.WebhookDelivery
+	var body []byte
+	now := time.Now()
+	for i := range subs {
+		if !subs[i].Subscribes(msg.Type) {
+			continue
+		}
+		if body == nil {
+			body, err = json.Marshal(Payload{ID: msg.ID, Type: msg.Type, OccurredAt: msg.OccurredAt, Data: msg.Payload})
+			if err != nil {
+				return fmt.Errorf("序列化 webhook 请求失败: %w", err)
+			}
+		}
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			SubscriptionID: subs[i].ID,
+			EventID:        msg.ID,
+			EventType:      msg.Type,
+			Payload:        string(body),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := d.repo.AddDeliveries(ctx, deliveries...); err != nil {
+		return fmt.Errorf("写入 webhook 投递记录失败: %w", err)
+	}
+
+	// 唤醒后台投递，不等待下一次轮询
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Start 在后台定期投递，重复调用无效
+func (d *Deliverer) Start() {
+	d.runMu.Lock()
+	defer d.runMu.Unlock()
+	if d.stop != nil {
+		return
+	}
+	d.stop = make(chan struct{})
+	d.done = make(chan struct{})
+	go d.run(d.stop, d.done)
+}
+
+// Stop 停止后台投递，等待正在发送的请求结束或 ctx 超时
+func (d *Deliverer) Stop(ctx context.Context) error {
+	d.runMu.Lock()
+	stop, done := d.stop, d.done
+	d.stop, d.done = nil, nil
+	d.runMu.Unlock()
+	if stop == nil {
+		return nil
+	}
+
+	close(stop)
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 轮询循环
+func (d *Deliverer) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+		// 一批投递完后立即读取下一批，直到没有到期的记录
+		for {
+			n, err := d.DeliverOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				webhookLogger().Error("投递 webhook 失败", logger.Err(err))
+			}
+			if err != nil || n < d.config.BatchSize {
+				break
+			}
+		}
+	}
+}
+
+// DeliverOnce 读取一批到期的投递记录并发送，返回本次发送的请求数
+func (d *Deliverer) DeliverOnce(ctx context.Context) (int, error) {
+	due, err := d.repo.DueDeliveries(ctx, time.Now(), d.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("读取 webhook 投递记录失败: %w", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		sent int
+		errs []error
+		sem  = make(chan struct{}, d.config.Workers)
+	)
+	for i := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(delivery *models.WebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			ok, err := d.deliver(ctx, delivery, models.WebhookTriggerAuto)
+			mu.Lock()
+			if ok {
+				sent++
+			}
+			if err != nil {
+				errs = append(errs, err)
+			}
+			mu.Unlock()
+		}(&due[i])
+	}
+	wg.Wait()
+	return sent, errors.Join(errs...)
+}
+
+// CheckURL 按投递配置校验订阅地址，见 CheckURL
+func (d *Deliverer) CheckURL(ctx context.Context, raw string) error {
+	return CheckURL(ctx, raw, d.config.AllowPrivateTargets)
+}
+
+// Redeliver 立即重新投递，清零投递次数后发送一次请求，失败时按正常的退避时间继续重试
+// 返回投递后的记录；记录正在被后台投递时不重复发送
+func (d *Deliverer) Redeliver(ctx context.Context, id uint64) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	if err := d.repo.ResetDelivery(ctx, id, now); err != nil {
+		return nil, fmt.Errorf("重置 webhook 投递记录失败: %w", err)
+	}
+	delivery, err := d.repo.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("获取 webhook 投递记录失败: %w", err)
+	}
+	if _, err := d.deliver(ctx, delivery, models.WebhookTriggerManual); err != nil {
+		return nil, err
+	}
+	return d.repo.GetDelivery(ctx, id)
+}
+
+// deliver 占用并发送一次投递，保存结果和投递日志；返回是否发送了请求
+func (d *Deliverer) deliver(ctx context.Context, delivery *models.WebhookDelivery, trigger string) (bool, error) {
+	// 占用期限覆盖请求超时，进程在发送过程中退出时，到期后重新投递
+	claimed, err := d.repo.ClaimDelivery(ctx, delivery.ID, delivery.Attempts, time.Now().Add(d.config.Timeout+time.Minute))
+	if err != nil {
+		return false, fmt.Errorf("占用 webhook 投递记录 %d 失败: %w", delivery.ID, err)
+	}
+	if !claimed {
+		return false, nil
+	}
+	attempt := delivery.Attempts + 1
+	delivery.Attempts = attempt
+
+	// 请求已发出后即使停止投递也要保存结果，避免丢失投递日志
+	saveCtx := context.WithoutCancel(ctx)
+	sub, err := d.repo.GetSubscription(saveCtx, delivery.SubscriptionID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("查询 webhook 订阅 %d 失败: %w", delivery.SubscriptionID, err)
+	}
+	if sub == nil || !sub.Enabled {
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = "订阅已删除或已停用"
+		if err := d.repo.SaveDeliveryResult(saveCtx, delivery); err != nil {
+			return false, fmt.Errorf("保存 webhook 投递结果失败: %w", err)
+		}
+		return false, nil
+	}
+
+	start := time.Now()
+	statusCode, responseBody, sendErr := d.send(ctx, sub, delivery)
+	duration := time.Since(start)
+	metrics.WebhookDeliveryDuration.WithLabelValues(delivery.EventType).Observe(duration.Seconds())
+
+	record := &models.WebhookAttempt{
+		DeliveryID:   delivery.ID,
+		Attempt:      attempt,
+		TriggeredBy:  trigger,
+		StatusCode:   statusCode,
+		ResponseBody: responseBody,
+		DurationMs:   duration.Milliseconds(),
+	}
+	delivery.LastStatusCode = statusCode
+	result := metrics.ResultSuccess
+	fields := []logger.Field{
+		logger.Int64("delivery_id", int64(delivery.ID)),
+		logger.Int64("subscription_id", int64(sub.ID)),
+		logger.String("event_type", delivery.EventType),
+		logger.Int("attempt", attempt),
+		logger.Int("status_code", statusCode),
+		logger.Duration("duration", duration),
+	}
+	switch {
+	case sendErr == nil:
+		deliveredAt := time.Now()
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &deliveredAt
+		delivery.LastError = ""
+		webhookLogger().Info("webhook 投递成功", fields...)
+	case attempt >= d.config.MaxAttempts:
+		record.Error = sendErr.Error()
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.LastError = record.Error
+		result = metrics.ResultDead
+		webhookLogger().Error("webhook 超过最大投递次数，已进入死信", append(fields, logger.Err(sendErr))...)
+	default:
+		record.Error = sendErr.Error()
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = time.Now().Add(d.backoff(attempt))
+		delivery.LastError = record.Error
+		result = metrics.ResultFailed
+		webhookLogger().Warn("webhook 投递失败，稍后重试",
+			append(fields, logger.Err(sendErr), logger.Any("next_attempt_at", delivery.NextAttemptAt))...)
+	}
+	metrics.WebhookDeliveriesTotal.WithLabelValues(delivery.EventType, result).Inc()
+
+	if err := d.repo.AddAttempt(saveCtx, record); err != nil {
+		webhookLogger().Warn("写入 webhook 投递日志失败", append(fields, logger.Err(err))...)
+	}
+	if err := d.repo.SaveDeliveryResult(saveCtx, delivery); err != nil {
+		return true, fmt.Errorf("保存 webhook 投递 %d 结果失败: %w", delivery.ID, err)
+	}
+	return true, nil
+}
+
+// send 发送签名后的请求，返回状态码和截断后的响应内容；非 2xx 响应返回错误
+func (d *Deliverer) send(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	body := []byte(delivery.Payload)
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderDeliveryID, strconv.FormatUint(delivery.ID, 10))
+	req.Header.Set(HeaderEventID, strconv.FormatUint(delivery.EventID, 10))
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	content, _ := io.ReadAll(io.LimitReader(resp.Body, responseBodyLimit))
+	// 读完剩余内容以复用连接，响应过大时直接关闭
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*responseBodyLimit))
+	responseBody := strings.ToValidUTF8(string(content), "")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, responseBody, fmt.Errorf("接收方返回状态码 %d", resp.StatusCode)
+	}
+	return resp.StatusCode, responseBody, nil
+}
+
+// backoff 第 attempt 次投递失败后的等待时间，指数增长，不超过 MaxRetryBackoff
+func (d *Deliverer) backoff(attempt int) time.Duration {
+	delay := d.config.RetryBackoff
+	for i := 1; i < attempt && delay < d.config.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxRetryBackoff {
+		delay = d.config.MaxRetryBackoff
+	}
+	return delay
+}
+
+// webhookLogger 获取 Webhook 投递的logger
+func webhookLogger() *zap.Logger {
+	return logger.Named(webhookLoggerName)
+}
@@ -0,0 +1,95 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenTarget 订阅地址指向本机、内网或链路本地地址
+var ErrForbiddenTarget = errors.New("webhook 地址不能指向本机、内网或链路本地地址")
+
+// sharedAddressSpace 运营商级 NAT 地址段（RFC 6598），与内网地址一样不允许访问
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// forbiddenAddr 是否为不允许投递的地址：本机、内网、链路本地（包括云厂商元数据地址 169.254.169.254）、未指定和组播地址
+func forbiddenAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return !addr.IsValid() ||
+		addr.IsLoopback() ||
+		addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() ||
+		addr.IsMulticast() ||
+		addr.IsUnspecified() ||
+		sharedAddressSpace.Contains(addr) ||
+		(addr.Is4() && addr.As4()[0] == 0)
+}
+
+// CheckURL 校验订阅地址：只允许 http 和 https，allowPrivate 为 false 时主机名解析出的任一地址不允许投递则返回 ErrForbiddenTarget
+// 解析结果可能在投递时变化（DNS 重绑定），投递时在建立连接前再次检查实际连接的地址
+func CheckURL(ctx context.Context, raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("webhook 地址必须是 http 或 https URL")
+	}
+	if allowPrivate {
+		return nil
+	}
+
+	host := u.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil {
+		if forbiddenAddr(addr) {
+			return ErrForbiddenTarget
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("解析 webhook 地址 %s 失败: %w", host, err)
+	}
+	for _, addr := range addrs {
+		if forbiddenAddr(addr) {
+			return ErrForbiddenTarget
+		}
+	}
+	return nil
+}
+
+// dialControl 建立连接前检查实际连接的地址，拒绝不允许投递的地址
+func dialControl(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("无效的连接地址 %s: %w", address, err)
+	}
+	if forbiddenAddr(addrPort.Addr()) {
+		return ErrForbiddenTarget
+	}
+	return nil
+}
+
+// newHTTPClient 创建投递使用的 HTTP 客户端
+// allowPrivate 为 false 时在建立连接前检查地址，不使用环境变量中的代理，避免检查的是代理地址而不是订阅地址
+func newHTTPClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = dialControl
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		// 不跟随重定向，3xx 视为投递失败，避免请求被转发到订阅以外的地址
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,94 @@
+// Package webhook 向外部系统推送领域事件
+//
+// Deliverer 订阅事件分发器，事件发生时为每个匹配的订阅生成一条投递记录（webhook_deliveries 表），
+// 再由后台轮询发送 HTTP POST 请求；接收方返回 2xx 视为成功，否则按退避时间重试，
+// 超过最大投递次数后进入死信，可以通过接口手动重新投递。每次请求的结果写入投递日志（webhook_attempts 表）。
+//
+// 请求使用订阅的密钥签名，接收方应校验签名和时间戳，并使用 X-Webhook-Event-ID 去重：
+//
+//	X-Webhook-Timestamp: 1700000000
+//	X-Webhook-Signature: sha256=hex(HMAC-SHA256(secret, timestamp + "." + body))
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 请求头
+const (
+	HeaderDeliveryID = "X-Webhook-ID"        // 投递记录ID，重新投递时不变
+	HeaderEventID    = "X-Webhook-Event-ID"  // 事件ID，同一事件投递给不同订阅时相同
+	HeaderEvent      = "X-Webhook-Event"     // 事件类型
+	HeaderTimestamp  = "X-Webhook-Timestamp" // 签名时间，Unix 秒
+	HeaderSignature  = "X-Webhook-Signature" // 签名，格式为 sha256=<hex>
+)
+
+// signaturePrefix 签名的算法前缀
+const signaturePrefix = "sha256="
+
+// 签名校验错误
+var (
+	ErrInvalidSignature = errors.New("webhook 签名无效")
+	ErrExpiredTimestamp = errors.New("webhook 时间戳超出允许范围")
+)
+
+// Config Webhook 投递配置
+type Config struct {
+	Enabled         bool          `mapstructure:"enabled" yaml:"enabled"`                                      // 是否在本实例发送 Webhook 请求
+	PollInterval    time.Duration `mapstructure:"poll_interval" yaml:"poll_interval" validate:"min=0"`         // 轮询待投递记录的间隔，默认 1s
+	BatchSize       int           `mapstructure:"batch_size" yaml:"batch_size" validate:"min=0"`               // 每次轮询读取的记录数，默认 50
+	Workers         int           `mapstructure:"workers" yaml:"workers" validate:"min=0"`                     // 并发发送的请求数，默认 4
+	Timeout         time.Duration `mapstructure:"timeout" yaml:"timeout" validate:"min=0"`                     // 单个请求的超时时间，默认 10s
+	MaxAttempts     int           `mapstructure:"max_attempts" yaml:"max_attempts" validate:"min=0"`           // 最大投递次数，超过后进入死信，默认 8
+	RetryBackoff    time.Duration `mapstructure:"retry_backoff" yaml:"retry_backoff" validate:"min=0"`         // 首次重试的等待时间，之后每次翻倍，默认 10s
+	MaxRetryBackoff time.Duration `mapstructure:"max_retry_backoff" yaml:"max_retry_backoff" validate:"min=0"` // 重试等待时间上限，默认 1h
+	// AllowPrivateTargets 是否允许订阅地址指向本机、内网和链路本地地址，仅用于开发和测试
+	// 开启后任何能管理订阅的用户都可以让服务请求内网地址（包括管理端口和云厂商元数据地址）并通过投递日志读取响应
+	AllowPrivateTargets bool `mapstructure:"allow_private_targets" yaml:"allow_private_targets"`
+}
+
+// DefaultConfig 默认 Webhook 投递配置
+func DefaultConfig() Config {
+	return Config{
+		Enabled:         true,
+		PollInterval:    time.Second,
+		BatchSize:       50,
+		Workers:         4,
+		Timeout:         10 * time.Second,
+		MaxAttempts:     8,
+		RetryBackoff:    10 * time.Second,
+		MaxRetryBackoff: time.Hour,
+	}
+}
+
+// Sign 计算签名，返回 X-Webhook-Signature 请求头的值
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify 校验签名，供接收方和测试使用；tolerance 大于0时拒绝与当前时间相差超过 tolerance 的请求，防止重放
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		if diff := time.Since(time.Unix(ts, 0)); diff > tolerance || diff < -tolerance {
+			return ErrExpiredTimestamp
+		}
+	}
+	if !strings.HasPrefix(signature, signaturePrefix) || !hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Package buildinfo 提供构建信息，版本、提交和构建时间由 scripts/build.sh 通过 ldflags 注入：
+//
+//	go build -ldflags "-X 'go_demo/pkg/buildinfo.Version=1.0.0' -X 'go_demo/pkg/buildinfo.GitCommit=abc123'"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// 构建时注入的信息
+var (
+	Version   = "dev" // 版本号
+	GitCommit = ""    // Git提交
+	BuildTime = ""    // 构建时间
+)
+
+// 进程启动时间
+var startTime = time.Now()
+
+// Info 构建信息
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	StartTime string `json:"start_time"`
+	Uptime    string `json:"uptime"`
+}
+
+// Get 获取构建信息，未通过 ldflags 注入提交和构建时间时使用 Go 工具链记录的 VCS 信息
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		StartTime: startTime.Format(time.RFC3339),
+		Uptime:    time.Since(startTime).Truncate(time.Second).String(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.GitCommit == "":
+				info.GitCommit = s.Value
+				if len(info.GitCommit) > 7 {
+					info.GitCommit = info.GitCommit[:7]
+				}
+			case s.Key == "vcs.time" && info.BuildTime == "":
+				info.BuildTime = s.Value
+			}
+		}
+	}
+	if info.GitCommit == "" {
+		info.GitCommit = "unknown"
+	}
+	return info
+}
@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Bus 二级缓存的失效广播通道
+type Bus interface {
+	// Publish 广播消息，所有订阅者（包括发送方）都会收到
+	Publish(ctx context.Context, payload []byte) error
+	// Subscribe 订阅广播，订阅成功后返回，之后在后台调用 handle
+	// 连接中断期间的消息可能丢失，重新订阅后以 nil 调用 handle，订阅者应丢弃全部本地状态
+	Subscribe(ctx context.Context, handle func(payload []byte)) (io.Closer, error)
+}
+
+// RedisBus 基于 Redis 发布订阅的广播通道，消息不持久化，只投递给当前在线的订阅者
+type RedisBus struct {
+	client  *redis.Client
+	channel string
+}
+
+// NewRedisBus 创建 Redis 广播通道
+func NewRedisBus(client *redis.Client, channel string) *RedisBus {
+	return &RedisBus{client: client, channel: channel}
+}
+
+// Publish 广播消息
+func (b *RedisBus) Publish(ctx context.Context, payload []byte) error {
+	return b.client.Publish(ctx, b.channel, payload).Err()
+}
+
+// Subscribe 订阅广播，连接中断后自动重连并重新订阅
+func (b *RedisBus) Subscribe(ctx context.Context, handle func(payload []byte)) (io.Closer, error) {
+	pubsub := b.client.Subscribe(ctx, b.channel)
+	// 等待订阅确认，确保返回后不会错过消息
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("订阅频道 %s 失败: %w", b.channel, err)
+	}
+	go func() {
+		for {
+			msg, err := pubsub.Receive(context.Background())
+			if err != nil {
+				if errors.Is(err, redis.ErrClosed) {
+					return
+				}
+				// 下一次 Receive 重新连接，等待一段时间避免 Redis 不可用时空转
+				time.Sleep(time.Second)
+				continue
+			}
+			switch msg := msg.(type) {
+			case *redis.Subscription:
+				// 重连后重新订阅，期间的消息可能丢失
+				if msg.Kind == "subscribe" {
+					handle(nil)
+				}
+			case *redis.Message:
+				handle([]byte(msg.Payload))
+			}
+		}
+	}()
+	return pubsub, nil
+}
+
+// MemoryBus 进程内的广播通道，Publish 同步调用全部订阅者，用于测试和单实例部署
+type MemoryBus struct {
+	mu       sync.RWMutex
+	handlers map[*memorySubscription]func(payload []byte)
+}
+
+// NewMemoryBus 创建进程内广播通道
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{handlers: make(map[*memorySubscription]func(payload []byte))}
+}
+
+// Publish 广播消息
+func (b *MemoryBus) Publish(ctx context.Context, payload []byte) error {
+	b.mu.RLock()
+	handlers := make([]func(payload []byte), 0, len(b.handlers))
+	for _, handle := range b.handlers {
+		handlers = append(handlers, handle)
+	}
+	b.mu.RUnlock()
+	for _, handle := range handlers {
+		handle(payload)
+	}
+	return nil
+}
+
+// Subscribe 订阅广播
+func (b *MemoryBus) Subscribe(ctx context.Context, handle func(payload []byte)) (io.Closer, error) {
+	sub := &memorySubscription{bus: b}
+	b.mu.Lock()
+	b.handlers[sub] = handle
+	b.mu.Unlock()
+	return sub, nil
+}
+
+// memorySubscription 进程内广播通道的订阅
+type memorySubscription struct {
+	bus *MemoryBus
+}
+
+// Close 取消订阅
+func (s *memorySubscription) Close() error {
+	s.bus.mu.Lock()
+	delete(s.bus.handlers, s)
+	s.bus.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec 缓存值的编码方式
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// 内置的编码方式
+var (
+	JSON    Codec = jsonCodec{}
+	Msgpack Codec = msgpackCodec{}
+	Gob     Codec = gobCodec{}
+)
+
+// CodecByName 根据名称获取编码方式，名称为空时使用 JSON
+func CodecByName(name string) (Codec, error) {
+	switch name {
+	case "", JSON.Name():
+		return JSON, nil
+	case Msgpack.Name():
+		return Msgpack, nil
+	case Gob.Name():
+		return Gob, nil
+	default:
+		return nil, fmt.Errorf("未知的缓存编码方式: %s", name)
+	}
+}
+
+// jsonCodec JSON 编码，可读性好，与其他语言的客户端兼容
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return "json" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// msgpackCodec MessagePack 编码，体积小、编解码快，字段名与 JSON 一致时优先使用 json 标签
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// gobCodec gob 编码，只能被 Go 程序读取，接口类型的值需要先 gob.Register
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// encode 使用编码方式编码值
+func encode(codec Codec, value interface{}) (string, error) {
+	data, err := codec.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("序列化失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// encodeAll 使用编码方式编码多个值
+func encodeAll(codec Codec, values []interface{}) ([]string, error) {
+	result := make([]string, len(values))
+	for i, v := range values {
+		s, err := encode(codec, v)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = s
+	}
+	return result, nil
+}
@@ -1,57 +1,78 @@
 package cache
 
 import (
+	"context"
+	"errors"
 	"time"
 )
 
+// ErrMiss 键、哈希字段或列表元素不存在
+var ErrMiss = errors.New("cache: miss")
+
 // CacheInterface 缓存接口
 // 定义了缓存操作的基本方法，支持多种缓存实现
+//
+// 每个方法以 context.Context 作为第一个参数，未命中时返回 ErrMiss。
+// Set、SetNX、MSet、HSet、LPush 和 RPush 写入的值由 Codec 编码，读取方法返回编码后的原始值，
+// 使用 GetObject 或 GetAs、HGetAs 等泛型函数解码；计数器、哈希字段、集合和有序集合的成员是原始字符串。
 type CacheInterface interface {
+	// Codec 值的编码方式
+	Codec() Codec
+
 	// 基本操作
-	Set(key string, value interface{}, expiration time.Duration) error
-	Get(key string) (string, error)
-	GetObject(key string, dest interface{}) error
-	Delete(keys ...string) error
-	Exists(key string) (bool, error)
+	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	GetObject(ctx context.Context, key string, dest interface{}) error
+	Delete(ctx context.Context, keys ...string) error
+	Exists(ctx context.Context, key string) (bool, error)
+
+	// 批量操作，MGet 的结果不包含不存在的键
+	MGet(ctx context.Context, keys ...string) (map[string]string, error)
+	MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error
 
 	// 过期时间操作
-	Expire(key string, expiration time.Duration) error
-	TTL(key string) (time.Duration, error)
+	Expire(ctx context.Context, key string, expiration time.Duration) error
+	TTL(ctx context.Context, key string) (time.Duration, error)
 
 	// 计数器操作
-	Increment(key string) (int64, error)
-	IncrementBy(key string, value int64) (int64, error)
-	Decrement(key string) (int64, error)
-	DecrementBy(key string, value int64) (int64, error)
+	Increment(ctx context.Context, key string) (int64, error)
+	IncrementBy(ctx context.Context, key string, value int64) (int64, error)
+	Decrement(ctx context.Context, key string) (int64, error)
+	DecrementBy(ctx context.Context, key string, value int64) (int64, error)
 
 	// 高级操作
-	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
+	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error)
 
 	// 哈希操作
-	HSet(key, field string, value interface{}) error
-	HGet(key, field string) (string, error)
-	HGetAll(key string) (map[string]string, error)
-	HDelete(key string, fields ...string) error
+	HSet(ctx context.Context, key, field string, value interface{}) error
+	HGet(ctx context.Context, key, field string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	HDelete(ctx context.Context, key string, fields ...string) error
 
 	// 列表操作
-	LPush(key string, values ...interface{}) error
-	RPush(key string, values ...interface{}) error
-	LPop(key string) (string, error)
-	RPop(key string) (string, error)
-	LRange(key string, start, stop int64) ([]string, error)
+	LPush(ctx context.Context, key string, values ...interface{}) error
+	RPush(ctx context.Context, key string, values ...interface{}) error
+	LPop(ctx context.Context, key string) (string, error)
+	RPop(ctx context.Context, key string) (string, error)
+	LRange(ctx context.Context, key string, start, stop int64) ([]string, error)
+
+	// 集合操作
+	SAdd(ctx context.Context, key string, members ...string) error
+	SMembers(ctx context.Context, key string) ([]string, error)
+	SIsMember(ctx context.Context, key, member string) (bool, error)
+	SRem(ctx context.Context, key string, members ...string) error
 
 	// 有序集合操作
-	ZAdd(key string, members ...*ZMember) error
-	ZRange(key string, start, stop int64) ([]string, error)
-	ZRangeWithScores(key string, start, stop int64) ([]*ZMember, error)
-	ZRem(key string, members ...string) error
-	ZRemRangeByScore(ctx interface{}, key, min, max string) (int64, error)
-	ZCard(key string) (int64, error)
-	SetExpire(ctx interface{}, key string, expiration time.Duration) error
+	ZAdd(ctx context.Context, key string, members ...*ZMember) error
+	ZRange(ctx context.Context, key string, start, stop int64) ([]string, error)
+	ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]*ZMember, error)
+	ZRem(ctx context.Context, key string, members ...string) error
+	ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error)
+	ZCard(ctx context.Context, key string) (int64, error)
 
 	// 管理操作
-	FlushDB() error
-	Ping() error
+	FlushDB(ctx context.Context) error
+	Ping(ctx context.Context) error
 }
 
 // ZMember 有序集合成员
@@ -59,3 +80,23 @@ type ZMember struct {
 	Score  float64
 	Member string
 }
+
+// Config 缓存配置
+type Config struct {
+	Backend    string        `mapstructure:"backend" yaml:"backend" validate:"omitempty,oneof=redis memory tiered"` // redis、memory（仅用于开发和测试）或 tiered（本地缓存 + Redis），默认 redis
+	Codec      string        `mapstructure:"codec" yaml:"codec" validate:"omitempty,oneof=json msgpack gob"`        // 值的编码方式，json、msgpack 或 gob，默认 json
+	MaxEntries int           `mapstructure:"max_entries" yaml:"max_entries" validate:"min=0"`                       // memory 缓存和 tiered 本地缓存的最大键数，默认 10000
+	LocalTTL   time.Duration `mapstructure:"local_ttl" yaml:"local_ttl" validate:"min=0"`                           // tiered 本地缓存时间，不超过键在 Redis 中的剩余时间，默认 1m
+	Channel    string        `mapstructure:"channel" yaml:"channel"`                                                // tiered 失效广播的 Redis 频道，默认 cache:invalidate
+}
+
+// DefaultConfig 默认缓存配置
+func DefaultConfig() Config {
+	return Config{
+		Backend:    "redis",
+		Codec:      JSON.Name(),
+		MaxEntries: DefaultMaxEntries,
+		LocalTTL:   time.Minute,
+		Channel:    "cache:invalidate",
+	}
+}
@@ -0,0 +1,742 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxEntries 内存缓存默认的最大键数
+const DefaultMaxEntries = 10000
+
+// errWrongType 键的类型与操作不匹配，与 Redis 的 WRONGTYPE 错误一致
+var errWrongType = errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+// errNotInteger 值不是整数
+var errNotInteger = errors.New("ERR value is not an integer or out of range")
+
+// entryKind 键的类型
+type entryKind int
+
+const (
+	kindString entryKind = iota
+	kindHash
+	kindList
+	kindSet
+	kindZSet
+)
+
+// memoryEntry 内存缓存中的一个键
+type memoryEntry struct {
+	key       string
+	kind      entryKind
+	str       string
+	hash      map[string]string
+	list      []string
+	set       map[string]struct{}
+	zset      map[string]float64
+	expiresAt time.Time // 零值表示不过期
+}
+
+// expired 键是否已过期
+func (e *memoryEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// MemoryCache 进程内缓存，实现 CacheInterface
+//
+// 支持字符串、哈希、列表、集合、有序集合、计数器和过期时间，语义与 RedisCache 一致。
+// 键数超过上限时淘汰最久未访问的键，过期的键在访问或淘汰时删除。数据只在当前进程内可见，用于开发、测试和二级缓存的本地层。
+type MemoryCache struct {
+	codec      Codec
+	mu         sync.Mutex
+	maxEntries int
+	ll         *list.List // 按访问时间排序，最近访问的在前
+	items      map[string]*list.Element
+}
+
+// NewMemoryCache 创建内存缓存，maxEntries 不大于 0 时使用 DefaultMaxEntries，codec 为 nil 时使用 JSON
+func NewMemoryCache(maxEntries int, codec Codec) *MemoryCache {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	if codec == nil {
+		codec = JSON
+	}
+	return &MemoryCache{
+		codec:      codec,
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+	}
+}
+
+// lookup 查找未过期的键并标记为最近访问，调用方持有锁
+func (c *MemoryCache) lookup(key string) *memoryEntry {
+	el, ok := c.items[key]
+	if !ok {
+		return nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if entry.expired(time.Now()) {
+		c.removeElement(el)
+		return nil
+	}
+	c.ll.MoveToFront(el)
+	return entry
+}
+
+// lookupKind 查找指定类型的键，类型不匹配时返回 errWrongType，调用方持有锁
+func (c *MemoryCache) lookupKind(key string, kind entryKind) (*memoryEntry, error) {
+	entry := c.lookup(key)
+	if entry != nil && entry.kind != kind {
+		return nil, errWrongType
+	}
+	return entry, nil
+}
+
+// lookupOrCreate 查找指定类型的键，不存在时创建，调用方持有锁
+func (c *MemoryCache) lookupOrCreate(key string, kind entryKind) (*memoryEntry, error) {
+	entry, err := c.lookupKind(key, kind)
+	if err != nil || entry != nil {
+		return entry, err
+	}
+	entry = &memoryEntry{key: key, kind: kind}
+	switch kind {
+	case kindHash:
+		entry.hash = make(map[string]string)
+	case kindSet:
+		entry.set = make(map[string]struct{})
+	case kindZSet:
+		entry.zset = make(map[string]float64)
+	}
+	c.insert(entry)
+	return entry, nil
+}
+
+// insert 写入键，替换同名的键，超过上限时淘汰最久未访问的键，调用方持有锁
+func (c *MemoryCache) insert(entry *memoryEntry) {
+	if el, ok := c.items[entry.key]; ok {
+		el.Value = entry
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[entry.key] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.maxEntries {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// removeElement 删除键，调用方持有锁
+func (c *MemoryCache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*memoryEntry).key)
+}
+
+// removeIfEmpty 删除没有成员的哈希、列表和集合，与 Redis 一致，调用方持有锁
+func (c *MemoryCache) removeIfEmpty(entry *memoryEntry) {
+	if len(entry.hash)+len(entry.list)+len(entry.set)+len(entry.zset) > 0 {
+		return
+	}
+	if el, ok := c.items[entry.key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// setString 写入原始字符串
+func (c *MemoryCache) setString(key, value string, expiration time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.insert(&memoryEntry{key: key, kind: kindString, str: value, expiresAt: expiresAt(expiration)})
+}
+
+// Codec 值的编码方式
+func (c *MemoryCache) Codec() Codec {
+	return c.codec
+}
+
+// Set 设置缓存
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := encode(c.codec, value)
+	if err != nil {
+		return err
+	}
+	c.setString(key, data, expiration)
+	return nil
+}
+
+// Get 获取缓存
+func (c *MemoryCache) Get(ctx context.Context, key string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindString)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return "", ErrMiss
+	}
+	return entry.str, nil
+}
+
+// GetObject 获取对象缓存
+func (c *MemoryCache) GetObject(ctx context.Context, key string, dest interface{}) error {
+	data, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.codec.Unmarshal([]byte(data), dest)
+}
+
+// Delete 删除缓存
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, key := range keys {
+		if el, ok := c.items[key]; ok {
+			c.removeElement(el)
+		}
+	}
+	return nil
+}
+
+// Exists 检查键是否存在
+func (c *MemoryCache) Exists(ctx context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lookup(key) != nil, nil
+}
+
+// MGet 批量获取字符串值，结果不包含不存在的键和其他类型的键
+func (c *MemoryCache) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if entry := c.lookup(key); entry != nil && entry.kind == kindString {
+			result[key] = entry.str
+		}
+	}
+	return result, nil
+}
+
+// MSet 批量设置缓存，使用相同的过期时间
+func (c *MemoryCache) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	encoded := make(map[string]string, len(values))
+	for key, value := range values {
+		data, err := encode(c.codec, value)
+		if err != nil {
+			return err
+		}
+		encoded[key] = data
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, data := range encoded {
+		c.insert(&memoryEntry{key: key, kind: kindString, str: data, expiresAt: expiresAt(expiration)})
+	}
+	return nil
+}
+
+// Expire 设置过期时间，不大于 0 时删除键
+func (c *MemoryCache) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.lookup(key)
+	if entry == nil {
+		return nil
+	}
+	if expiration <= 0 {
+		c.removeElement(c.items[key])
+		return nil
+	}
+	entry.expiresAt = time.Now().Add(expiration)
+	return nil
+}
+
+// TTL 获取剩余过期时间，与 RedisCache 一致，键不存在时返回 -2，没有过期时间时返回 -1
+func (c *MemoryCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry := c.lookup(key)
+	switch {
+	case entry == nil:
+		return -2, nil
+	case entry.expiresAt.IsZero():
+		return -1, nil
+	default:
+		return time.Until(entry.expiresAt).Round(time.Second), nil
+	}
+}
+
+// Increment 自增
+func (c *MemoryCache) Increment(ctx context.Context, key string) (int64, error) {
+	return c.IncrementBy(ctx, key, 1)
+}
+
+// IncrementBy 增加指定值，键不存在时从 0 开始，保留原有的过期时间
+func (c *MemoryCache) IncrementBy(ctx context.Context, key string, value int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindString)
+	if err != nil {
+		return 0, err
+	}
+	if entry == nil {
+		entry = &memoryEntry{key: key, kind: kindString, str: "0"}
+		c.insert(entry)
+	}
+	n, err := strconv.ParseInt(entry.str, 10, 64)
+	if err != nil {
+		return 0, errNotInteger
+	}
+	if (value > 0 && n > math.MaxInt64-value) || (value < 0 && n < math.MinInt64-value) {
+		return 0, errors.New("ERR increment or decrement would overflow")
+	}
+	n += value
+	entry.str = strconv.FormatInt(n, 10)
+	return n, nil
+}
+
+// Decrement 自减
+func (c *MemoryCache) Decrement(ctx context.Context, key string) (int64, error) {
+	return c.IncrementBy(ctx, key, -1)
+}
+
+// DecrementBy 减少指定值
+func (c *MemoryCache) DecrementBy(ctx context.Context, key string, value int64) (int64, error) {
+	if value == math.MinInt64 {
+		return 0, errors.New("ERR decrement would overflow")
+	}
+	return c.IncrementBy(ctx, key, -value)
+}
+
+// SetNX 设置键值（仅当键不存在时）
+func (c *MemoryCache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	data, err := encode(c.codec, value)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lookup(key) != nil {
+		return false, nil
+	}
+	c.insert(&memoryEntry{key: key, kind: kindString, str: data, expiresAt: expiresAt(expiration)})
+	return true, nil
+}
+
+// HSet 设置哈希字段
+func (c *MemoryCache) HSet(ctx context.Context, key, field string, value interface{}) error {
+	data, err := encode(c.codec, value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupOrCreate(key, kindHash)
+	if err != nil {
+		return err
+	}
+	entry.hash[field] = data
+	return nil
+}
+
+// HGet 获取哈希字段
+func (c *MemoryCache) HGet(ctx context.Context, key, field string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindHash)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return "", ErrMiss
+	}
+	value, ok := entry.hash[field]
+	if !ok {
+		return "", ErrMiss
+	}
+	return value, nil
+}
+
+// HGetAll 获取所有哈希字段，键不存在时返回空 map
+func (c *MemoryCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindHash)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]string)
+	if entry != nil {
+		for field, value := range entry.hash {
+			result[field] = value
+		}
+	}
+	return result, nil
+}
+
+// HDelete 删除哈希字段
+func (c *MemoryCache) HDelete(ctx context.Context, key string, fields ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindHash)
+	if err != nil || entry == nil {
+		return err
+	}
+	for _, field := range fields {
+		delete(entry.hash, field)
+	}
+	c.removeIfEmpty(entry)
+	return nil
+}
+
+// LPush 从列表左侧推入元素，与 Redis 一致，多个元素依次推入，最后一个在最左侧
+func (c *MemoryCache) LPush(ctx context.Context, key string, values ...interface{}) error {
+	items, err := encodeAll(c.codec, values)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupOrCreate(key, kindList)
+	if err != nil {
+		return err
+	}
+	pushed := make([]string, 0, len(items)+len(entry.list))
+	for i := len(items) - 1; i >= 0; i-- {
+		pushed = append(pushed, items[i])
+	}
+	entry.list = append(pushed, entry.list...)
+	return nil
+}
+
+// RPush 从列表右侧推入元素
+func (c *MemoryCache) RPush(ctx context.Context, key string, values ...interface{}) error {
+	items, err := encodeAll(c.codec, values)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupOrCreate(key, kindList)
+	if err != nil {
+		return err
+	}
+	entry.list = append(entry.list, items...)
+	return nil
+}
+
+// LPop 从列表左侧弹出元素
+func (c *MemoryCache) LPop(ctx context.Context, key string) (string, error) {
+	return c.pop(key, true)
+}
+
+// RPop 从列表右侧弹出元素
+func (c *MemoryCache) RPop(ctx context.Context, key string) (string, error) {
+	return c.pop(key, false)
+}
+
+// pop 从列表一侧弹出元素，列表为空时删除键
+func (c *MemoryCache) pop(key string, left bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindList)
+	if err != nil {
+		return "", err
+	}
+	if entry == nil {
+		return "", ErrMiss
+	}
+	var value string
+	if left {
+		value, entry.list = entry.list[0], entry.list[1:]
+	} else {
+		last := len(entry.list) - 1
+		value, entry.list = entry.list[last], entry.list[:last]
+	}
+	c.removeIfEmpty(entry)
+	return value, nil
+}
+
+// LRange 获取列表指定范围元素，负数下标从末尾计算
+func (c *MemoryCache) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindList)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return []string{}, nil
+	}
+	from, to := rangeBounds(int64(len(entry.list)), start, stop)
+	return append([]string{}, entry.list[from:to]...), nil
+}
+
+// SAdd 添加集合成员
+func (c *MemoryCache) SAdd(ctx context.Context, key string, members ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupOrCreate(key, kindSet)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		entry.set[member] = struct{}{}
+	}
+	return nil
+}
+
+// SMembers 获取集合所有成员，按字典序返回
+func (c *MemoryCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindSet)
+	if err != nil {
+		return nil, err
+	}
+	members := []string{}
+	if entry != nil {
+		for member := range entry.set {
+			members = append(members, member)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
+
+// SIsMember 判断是否是集合成员
+func (c *MemoryCache) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindSet)
+	if err != nil || entry == nil {
+		return false, err
+	}
+	_, ok := entry.set[member]
+	return ok, nil
+}
+
+// SRem 移除集合成员
+func (c *MemoryCache) SRem(ctx context.Context, key string, members ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindSet)
+	if err != nil || entry == nil {
+		return err
+	}
+	for _, member := range members {
+		delete(entry.set, member)
+	}
+	c.removeIfEmpty(entry)
+	return nil
+}
+
+// ZAdd 添加有序集合成员，成员已存在时更新分数
+func (c *MemoryCache) ZAdd(ctx context.Context, key string, members ...*ZMember) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupOrCreate(key, kindZSet)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		entry.zset[m.Member] = m.Score
+	}
+	return nil
+}
+
+// ZRange 获取有序集合指定范围成员
+func (c *MemoryCache) ZRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	members, err := c.ZRangeWithScores(ctx, key, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, len(members))
+	for i, m := range members {
+		result[i] = m.Member
+	}
+	return result, nil
+}
+
+// ZRangeWithScores 获取有序集合指定范围成员（带分数），按分数升序，分数相同时按成员字典序
+func (c *MemoryCache) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]*ZMember, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindZSet)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return []*ZMember{}, nil
+	}
+	sorted := sortedMembers(entry.zset)
+	from, to := rangeBounds(int64(len(sorted)), start, stop)
+	return sorted[from:to], nil
+}
+
+// ZRem 移除有序集合成员
+func (c *MemoryCache) ZRem(ctx context.Context, key string, members ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindZSet)
+	if err != nil || entry == nil {
+		return err
+	}
+	for _, m := range members {
+		delete(entry.zset, m)
+	}
+	c.removeIfEmpty(entry)
+	return nil
+}
+
+// ZRemRangeByScore 按分数范围移除有序集合成员，min 和 max 支持 -inf、+inf 和表示开区间的 ( 前缀
+func (c *MemoryCache) ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
+	lower, err := parseScoreBound(min)
+	if err != nil {
+		return 0, err
+	}
+	upper, err := parseScoreBound(max)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindZSet)
+	if err != nil || entry == nil {
+		return 0, err
+	}
+	var removed int64
+	for member, score := range entry.zset {
+		if lower.below(score) && upper.above(score) {
+			delete(entry.zset, member)
+			removed++
+		}
+	}
+	c.removeIfEmpty(entry)
+	return removed, nil
+}
+
+// ZCard 获取有序集合成员数量
+func (c *MemoryCache) ZCard(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, err := c.lookupKind(key, kindZSet)
+	if err != nil || entry == nil {
+		return 0, err
+	}
+	return int64(len(entry.zset)), nil
+}
+
+// FlushDB 清空缓存
+func (c *MemoryCache) FlushDB(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	return nil
+}
+
+// Ping 测试连接，内存缓存始终可用
+func (c *MemoryCache) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Len 当前的键数，包括已过期但尚未删除的键
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// expiresAt 过期时刻，expiration 不大于 0 时不过期
+func expiresAt(expiration time.Duration) time.Time {
+	if expiration <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(expiration)
+}
+
+// rangeBounds 把 Redis 风格的闭区间下标转换为切片的 [from, to)
+func rangeBounds(n, start, stop int64) (int64, int64) {
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	if stop >= n {
+		stop = n - 1
+	}
+	if start > stop || start >= n {
+		return 0, 0
+	}
+	return start, stop + 1
+}
+
+// sortedMembers 按分数升序排列有序集合成员，分数相同时按成员字典序
+func sortedMembers(zset map[string]float64) []*ZMember {
+	members := make([]*ZMember, 0, len(zset))
+	for member, score := range zset {
+		members = append(members, &ZMember{Score: score, Member: member})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].Score != members[j].Score {
+			return members[i].Score < members[j].Score
+		}
+		return members[i].Member < members[j].Member
+	})
+	return members
+}
+
+// scoreBound 分数范围的一端
+type scoreBound struct {
+	value     float64
+	exclusive bool
+}
+
+// below 分数是否不小于下界
+func (b scoreBound) below(score float64) bool {
+	if b.exclusive {
+		return score > b.value
+	}
+	return score >= b.value
+}
+
+// above 分数是否不大于上界
+func (b scoreBound) above(score float64) bool {
+	if b.exclusive {
+		return score < b.value
+	}
+	return score <= b.value
+}
+
+// parseScoreBound 解析 ZRemRangeByScore 的 min 和 max
+func parseScoreBound(s string) (scoreBound, error) {
+	var b scoreBound
+	if strings.HasPrefix(s, "(") {
+		b.exclusive = true
+		s = s[1:]
+	}
+	switch strings.ToLower(s) {
+	case "-inf":
+		b.value = math.Inf(-1)
+	case "+inf", "inf":
+		b.value = math.Inf(1)
+	default:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return b, errors.New("ERR min or max is not a float")
+		}
+		b.value = v
+	}
+	return b, nil
+}
@@ -2,7 +2,7 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -23,10 +23,11 @@ type RedisConfig struct {
 // RedisCache Redis缓存客户端
 type RedisCache struct {
 	client *redis.Client
+	codec  Codec
 }
 
-// NewRedisCache 创建Redis缓存客户端
-func NewRedisCache(config RedisConfig) (*RedisCache, error) {
+// NewRedisCache 创建Redis缓存客户端，codec 为 nil 时使用 JSON
+func NewRedisCache(config RedisConfig, codec Codec) (*RedisCache, error) {
 	client := redis.NewClient(&redis.Options{
 		Addr:         fmt.Sprintf("%s:%d", config.Host, config.Port),
 		Password:     config.Password,
@@ -44,202 +45,213 @@ func NewRedisCache(config RedisConfig) (*RedisCache, error) {
 		return nil, fmt.Errorf("Redis连接失败: %w", err)
 	}
 
-	return &RedisCache{client: client}, nil
+	if codec == nil {
+		codec = JSON
+	}
+	return &RedisCache{client: client, codec: codec}, nil
 }
 
-// Set 设置缓存
-func (c *RedisCache) Set(key string, value interface{}, expiration time.Duration) error {
-	ctx := context.Background()
+// Codec 值的编码方式
+func (c *RedisCache) Codec() Codec {
+	return c.codec
+}
 
-	// 序列化值
-	data, err := json.Marshal(value)
+// Set 设置缓存
+func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	data, err := encode(c.codec, value)
 	if err != nil {
-		return fmt.Errorf("序列化失败: %w", err)
+		return err
 	}
-
 	return c.client.Set(ctx, key, data, expiration).Err()
 }
 
 // Get 获取缓存
-func (c *RedisCache) Get(key string) (string, error) {
-	ctx := context.Background()
-	return c.client.Get(ctx, key).Result()
+func (c *RedisCache) Get(ctx context.Context, key string) (string, error) {
+	value, err := c.client.Get(ctx, key).Result()
+	return value, missing(err)
 }
 
 // GetObject 获取对象缓存
-func (c *RedisCache) GetObject(key string, dest interface{}) error {
-	ctx := context.Background()
-
-	data, err := c.client.Get(ctx, key).Result()
+func (c *RedisCache) GetObject(ctx context.Context, key string, dest interface{}) error {
+	data, err := c.Get(ctx, key)
 	if err != nil {
 		return err
 	}
-
-	return json.Unmarshal([]byte(data), dest)
+	return c.codec.Unmarshal([]byte(data), dest)
 }
 
 // Delete 删除缓存
-func (c *RedisCache) Delete(keys ...string) error {
-	ctx := context.Background()
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
 	return c.client.Del(ctx, keys...).Err()
 }
 
 // Exists 检查键是否存在
-func (c *RedisCache) Exists(key string) (bool, error) {
-	ctx := context.Background()
+func (c *RedisCache) Exists(ctx context.Context, key string) (bool, error) {
 	count, err := c.client.Exists(ctx, key).Result()
 	return count > 0, err
 }
 
+// MGet 批量获取字符串值，一次往返，结果不包含不存在的键
+func (c *RedisCache) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	result := make(map[string]string, len(keys))
+	if len(keys) == 0 {
+		return result, nil
+	}
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			result[keys[i]] = s
+		}
+	}
+	return result, nil
+}
+
+// MSet 批量设置缓存，使用相同的过期时间，通过管道一次往返写入
+func (c *RedisCache) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	if len(values) == 0 {
+		return nil
+	}
+	encoded := make(map[string]string, len(values))
+	for key, value := range values {
+		data, err := encode(c.codec, value)
+		if err != nil {
+			return err
+		}
+		encoded[key] = data
+	}
+	_, err := c.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, data := range encoded {
+			pipe.Set(ctx, key, data, expiration)
+		}
+		return nil
+	})
+	return err
+}
+
 // Expire 设置过期时间
-func (c *RedisCache) Expire(key string, expiration time.Duration) error {
-	ctx := context.Background()
+func (c *RedisCache) Expire(ctx context.Context, key string, expiration time.Duration) error {
 	return c.client.Expire(ctx, key, expiration).Err()
 }
 
-// TTL 获取剩余过期时间
-func (c *RedisCache) TTL(key string) (time.Duration, error) {
-	ctx := context.Background()
+// TTL 获取剩余过期时间，键不存在时返回 -2，没有过期时间时返回 -1
+func (c *RedisCache) TTL(ctx context.Context, key string) (time.Duration, error) {
 	return c.client.TTL(ctx, key).Result()
 }
 
-// Increment 自增（实现 CacheInterface）
-func (c *RedisCache) Increment(key string) (int64, error) {
-	ctx := context.Background()
+// Increment 自增
+func (c *RedisCache) Increment(ctx context.Context, key string) (int64, error) {
 	return c.client.Incr(ctx, key).Result()
 }
 
-// IncrementBy 增加指定值（实现 CacheInterface）
-func (c *RedisCache) IncrementBy(key string, value int64) (int64, error) {
-	ctx := context.Background()
+// IncrementBy 增加指定值
+func (c *RedisCache) IncrementBy(ctx context.Context, key string, value int64) (int64, error) {
 	return c.client.IncrBy(ctx, key, value).Result()
 }
 
-// Decrement 自减（实现 CacheInterface）
-func (c *RedisCache) Decrement(key string) (int64, error) {
-	ctx := context.Background()
+// Decrement 自减
+func (c *RedisCache) Decrement(ctx context.Context, key string) (int64, error) {
 	return c.client.Decr(ctx, key).Result()
 }
 
-// DecrementBy 减少指定值（实现 CacheInterface）
-func (c *RedisCache) DecrementBy(key string, value int64) (int64, error) {
-	ctx := context.Background()
+// DecrementBy 减少指定值
+func (c *RedisCache) DecrementBy(ctx context.Context, key string, value int64) (int64, error) {
 	return c.client.DecrBy(ctx, key, value).Result()
 }
 
-// Incr 自增（向后兼容）
-func (c *RedisCache) Incr(key string) (int64, error) {
-	return c.Increment(key)
-}
-
-// Decr 自减（向后兼容）
-func (c *RedisCache) Decr(key string) (int64, error) {
-	return c.Decrement(key)
-}
-
-// IncrBy 增加指定值（向后兼容）
-func (c *RedisCache) IncrBy(key string, value int64) (int64, error) {
-	return c.IncrementBy(key, value)
-}
-
 // SetNX 设置键值（仅当键不存在时）
-func (c *RedisCache) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
-	ctx := context.Background()
-
-	data, err := json.Marshal(value)
+func (c *RedisCache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	data, err := encode(c.codec, value)
 	if err != nil {
-		return false, fmt.Errorf("序列化失败: %w", err)
+		return false, err
 	}
-
 	return c.client.SetNX(ctx, key, data, expiration).Result()
 }
 
 // HSet 设置哈希字段
-func (c *RedisCache) HSet(key string, field string, value interface{}) error {
-	ctx := context.Background()
-	return c.client.HSet(ctx, key, field, value).Err()
+func (c *RedisCache) HSet(ctx context.Context, key, field string, value interface{}) error {
+	data, err := encode(c.codec, value)
+	if err != nil {
+		return err
+	}
+	return c.client.HSet(ctx, key, field, data).Err()
 }
 
 // HGet 获取哈希字段
-func (c *RedisCache) HGet(key string, field string) (string, error) {
-	ctx := context.Background()
-	return c.client.HGet(ctx, key, field).Result()
+func (c *RedisCache) HGet(ctx context.Context, key, field string) (string, error) {
+	value, err := c.client.HGet(ctx, key, field).Result()
+	return value, missing(err)
 }
 
 // HGetAll 获取所有哈希字段
-func (c *RedisCache) HGetAll(key string) (map[string]string, error) {
-	ctx := context.Background()
+func (c *RedisCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
 	return c.client.HGetAll(ctx, key).Result()
 }
 
-// HDel 删除哈希字段
-func (c *RedisCache) HDel(key string, fields ...string) error {
-	ctx := context.Background()
+// HDelete 删除哈希字段
+func (c *RedisCache) HDelete(ctx context.Context, key string, fields ...string) error {
 	return c.client.HDel(ctx, key, fields...).Err()
 }
 
-// HDelete 删除哈希字段（实现 CacheInterface）
-func (c *RedisCache) HDelete(key string, fields ...string) error {
-	return c.HDel(key, fields...)
-}
-
-// SAdd 添加集合成员
-func (c *RedisCache) SAdd(key string, members ...interface{}) error {
-	ctx := context.Background()
-	return c.client.SAdd(ctx, key, members...).Err()
+// LPush 从列表左侧推入元素
+func (c *RedisCache) LPush(ctx context.Context, key string, values ...interface{}) error {
+	items, err := encodeAll(c.codec, values)
+	if err != nil {
+		return err
+	}
+	return c.client.LPush(ctx, key, toArgs(items)...).Err()
 }
 
-// SMembers 获取集合所有成员
-func (c *RedisCache) SMembers(key string) ([]string, error) {
-	ctx := context.Background()
-	return c.client.SMembers(ctx, key).Result()
+// RPush 从列表右侧推入元素
+func (c *RedisCache) RPush(ctx context.Context, key string, values ...interface{}) error {
+	items, err := encodeAll(c.codec, values)
+	if err != nil {
+		return err
+	}
+	return c.client.RPush(ctx, key, toArgs(items)...).Err()
 }
 
-// SIsMember 判断是否是集合成员
-func (c *RedisCache) SIsMember(key string, member interface{}) (bool, error) {
-	ctx := context.Background()
-	return c.client.SIsMember(ctx, key, member).Result()
+// LPop 从列表左侧弹出元素
+func (c *RedisCache) LPop(ctx context.Context, key string) (string, error) {
+	value, err := c.client.LPop(ctx, key).Result()
+	return value, missing(err)
 }
 
-// SRem 移除集合成员
-func (c *RedisCache) SRem(key string, members ...interface{}) error {
-	ctx := context.Background()
-	return c.client.SRem(ctx, key, members...).Err()
+// RPop 从列表右侧弹出元素
+func (c *RedisCache) RPop(ctx context.Context, key string) (string, error) {
+	value, err := c.client.RPop(ctx, key).Result()
+	return value, missing(err)
 }
 
-// LPush 从列表左侧推入元素
-func (c *RedisCache) LPush(key string, values ...interface{}) error {
-	ctx := context.Background()
-	return c.client.LPush(ctx, key, values...).Err()
+// LRange 获取列表指定范围元素
+func (c *RedisCache) LRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
+	return c.client.LRange(ctx, key, start, stop).Result()
 }
 
-// RPush 从列表右侧推入元素
-func (c *RedisCache) RPush(key string, values ...interface{}) error {
-	ctx := context.Background()
-	return c.client.RPush(ctx, key, values...).Err()
+// SAdd 添加集合成员
+func (c *RedisCache) SAdd(ctx context.Context, key string, members ...string) error {
+	return c.client.SAdd(ctx, key, toArgs(members)...).Err()
 }
 
-// LPop 从列表左侧弹出元素
-func (c *RedisCache) LPop(key string) (string, error) {
-	ctx := context.Background()
-	return c.client.LPop(ctx, key).Result()
+// SMembers 获取集合所有成员
+func (c *RedisCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	return c.client.SMembers(ctx, key).Result()
 }
 
-// RPop 从列表右侧弹出元素
-func (c *RedisCache) RPop(key string) (string, error) {
-	ctx := context.Background()
-	return c.client.RPop(ctx, key).Result()
+// SIsMember 判断是否是集合成员
+func (c *RedisCache) SIsMember(ctx context.Context, key, member string) (bool, error) {
+	return c.client.SIsMember(ctx, key, member).Result()
 }
 
-// LRange 获取列表指定范围元素
-func (c *RedisCache) LRange(key string, start, stop int64) ([]string, error) {
-	ctx := context.Background()
-	return c.client.LRange(ctx, key, start, stop).Result()
+// SRem 移除集合成员
+func (c *RedisCache) SRem(ctx context.Context, key string, members ...string) error {
+	return c.client.SRem(ctx, key, toArgs(members)...).Err()
 }
 
-// ZAdd 添加有序集合成员（实现 CacheInterface）
-func (c *RedisCache) ZAdd(key string, members ...*ZMember) error {
-	ctx := context.Background()
+// ZAdd 添加有序集合成员
+func (c *RedisCache) ZAdd(ctx context.Context, key string, members ...*ZMember) error {
 	zMembers := make([]*redis.Z, len(members))
 	for i, m := range members {
 		zMembers[i] = &redis.Z{Score: m.Score, Member: m.Member}
@@ -248,14 +260,12 @@ func (c *RedisCache) ZAdd(key string, members ...*ZMember) error {
 }
 
 // ZRange 获取有序集合指定范围成员
-func (c *RedisCache) ZRange(key string, start, stop int64) ([]string, error) {
-	ctx := context.Background()
+func (c *RedisCache) ZRange(ctx context.Context, key string, start, stop int64) ([]string, error) {
 	return c.client.ZRange(ctx, key, start, stop).Result()
 }
 
-// ZRangeWithScores 获取有序集合指定范围成员（带分数）（实现 CacheInterface）
-func (c *RedisCache) ZRangeWithScores(key string, start, stop int64) ([]*ZMember, error) {
-	ctx := context.Background()
+// ZRangeWithScores 获取有序集合指定范围成员（带分数）
+func (c *RedisCache) ZRangeWithScores(ctx context.Context, key string, start, stop int64) ([]*ZMember, error) {
 	results, err := c.client.ZRangeWithScores(ctx, key, start, stop).Result()
 	if err != nil {
 		return nil, err
@@ -270,43 +280,28 @@ func (c *RedisCache) ZRangeWithScores(key string, start, stop int64) ([]*ZMember
 	return members, nil
 }
 
-// ZRem 移除有序集合成员（实现 CacheInterface）
-func (c *RedisCache) ZRem(key string, members ...string) error {
-	ctx := context.Background()
-	iMembers := make([]interface{}, len(members))
-	for i, m := range members {
-		iMembers[i] = m
-	}
-	return c.client.ZRem(ctx, key, iMembers...).Err()
+// ZRem 移除有序集合成员
+func (c *RedisCache) ZRem(ctx context.Context, key string, members ...string) error {
+	return c.client.ZRem(ctx, key, toArgs(members)...).Err()
 }
 
 // ZRemRangeByScore 按分数范围移除有序集合成员
-func (c *RedisCache) ZRemRangeByScore(ctx interface{}, key, min, max string) (int64, error) {
-	bgCtx := context.Background()
-	return c.client.ZRemRangeByScore(bgCtx, key, min, max).Result()
+func (c *RedisCache) ZRemRangeByScore(ctx context.Context, key, min, max string) (int64, error) {
+	return c.client.ZRemRangeByScore(ctx, key, min, max).Result()
 }
 
 // ZCard 获取有序集合成员数量
-func (c *RedisCache) ZCard(key string) (int64, error) {
-	ctx := context.Background()
+func (c *RedisCache) ZCard(ctx context.Context, key string) (int64, error) {
 	return c.client.ZCard(ctx, key).Result()
 }
 
-// SetExpire 设置键的过期时间
-func (c *RedisCache) SetExpire(ctx interface{}, key string, expiration time.Duration) error {
-	bgCtx := context.Background()
-	return c.client.Expire(bgCtx, key, expiration).Err()
-}
-
 // FlushDB 清空当前数据库
-func (c *RedisCache) FlushDB() error {
-	ctx := context.Background()
+func (c *RedisCache) FlushDB(ctx context.Context) error {
 	return c.client.FlushDB(ctx).Err()
 }
 
 // Ping 测试连接
-func (c *RedisCache) Ping() error {
-	ctx := context.Background()
+func (c *RedisCache) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
 }
 
@@ -331,9 +326,7 @@ func (c *RedisCache) GetClient() *redis.Client {
 }
 
 // GetStats 获取Redis统计信息
-func (c *RedisCache) GetStats() (map[string]interface{}, error) {
-	ctx := context.Background()
-
+func (c *RedisCache) GetStats(ctx context.Context) (map[string]interface{}, error) {
 	info, err := c.client.Info(ctx, "stats").Result()
 	if err != nil {
 		return nil, err
@@ -351,3 +344,20 @@ func (c *RedisCache) GetStats() (map[string]interface{}, error) {
 		"info":        info,
 	}, nil
 }
+
+// missing 把 redis.Nil 转换为 ErrMiss
+func missing(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return ErrMiss
+	}
+	return err
+}
+
+// toArgs 转换为 go-redis 的参数列表
+func toArgs(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	return args
+}
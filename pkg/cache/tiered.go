@@ -0,0 +1,309 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go_demo/pkg/logger"
+	"go_demo/pkg/metrics"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// tieredCacheName 缓存指标中的本地缓存名称
+const tieredCacheName = "local"
+
+// invalidation 失效广播消息
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys,omitempty"`
+	Flush  bool     `json:"flush,omitempty"`
+}
+
+// TieredCache 二级缓存，实现 CacheInterface
+//
+// 字符串值（Get、GetObject、MGet）读取时先查本地的 MemoryCache，未命中时读取二级缓存并写入本地，
+// 本地缓存时间为 LocalTTL 与键在二级缓存中剩余时间的较小值。
+// 修改字符串值的操作在写入二级缓存后删除本地的键，并通过 Bus 广播给其他实例；
+// 自增、自减和 Expire 只删除本实例的本地副本，不广播：限流等计数每个请求都会调用，计数应使用这些操作的返回值，
+// 其他实例通过 Get 读取计数键时最多读到 LocalTTL 内的旧值。
+// 哈希、列表、集合和有序集合不在本地缓存，直接读写二级缓存。
+// 广播不保证送达，Redis 重连后各实例清空本地缓存，其余情况下其他实例最多读到 LocalTTL 内的旧值。
+type TieredCache struct {
+	CacheInterface
+	local  *MemoryCache
+	bus    Bus
+	sub    io.Closer
+	origin string
+	ttl    time.Duration
+
+	mu  sync.Mutex
+	gen uint64 // 本地缓存的失效次数，读取二级缓存期间发生失效时不写入本地
+}
+
+// NewTieredCache 创建二级缓存并订阅失效广播，未设置的配置项使用默认值
+func NewTieredCache(remote CacheInterface, bus Bus, config Config) (*TieredCache, error) {
+	if config.LocalTTL <= 0 {
+		config.LocalTTL = DefaultConfig().LocalTTL
+	}
+	c := &TieredCache{
+		CacheInterface: remote,
+		local:          NewMemoryCache(config.MaxEntries, remote.Codec()),
+		bus:            bus,
+		origin:         uuid.NewString(),
+		ttl:            config.LocalTTL,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	sub, err := bus.Subscribe(ctx, c.handle)
+	if err != nil {
+		return nil, fmt.Errorf("订阅缓存失效广播失败: %w", err)
+	}
+	c.sub = sub
+	return c, nil
+}
+
+// Remote 二级缓存
+func (c *TieredCache) Remote() CacheInterface {
+	return c.CacheInterface
+}
+
+// Get 获取缓存，本地未命中时读取二级缓存并写入本地
+func (c *TieredCache) Get(ctx context.Context, key string) (string, error) {
+	if value, err := c.local.Get(ctx, key); err == nil {
+		metrics.CacheLookupsTotal.WithLabelValues(tieredCacheName, metrics.CacheHit).Inc()
+		return value, nil
+	}
+	metrics.CacheLookupsTotal.WithLabelValues(tieredCacheName, metrics.CacheMiss).Inc()
+
+	gen := c.generation()
+	value, err := c.CacheInterface.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	// 键没有过期时间时 TTL 返回 -1；已被删除（-2）或即将过期（0）时不写入本地
+	remaining, err := c.CacheInterface.TTL(ctx, key)
+	if err != nil || (remaining != -1 && remaining <= 0) {
+		return value, nil
+	}
+	ttl := c.ttl
+	if remaining > 0 && remaining < ttl {
+		ttl = remaining
+	}
+	c.mu.Lock()
+	if c.gen == gen {
+		c.local.setString(key, value, ttl)
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// GetObject 获取对象缓存
+func (c *TieredCache) GetObject(ctx context.Context, key string, dest interface{}) error {
+	data, err := c.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return c.Codec().Unmarshal([]byte(data), dest)
+}
+
+// Exists 检查键是否存在
+func (c *TieredCache) Exists(ctx context.Context, key string) (bool, error) {
+	if ok, _ := c.local.Exists(ctx, key); ok {
+		return true, nil
+	}
+	return c.CacheInterface.Exists(ctx, key)
+}
+
+// MGet 批量获取字符串值，本地未命中的键一次从二级缓存读取，不写入本地
+func (c *TieredCache) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	result, _ := c.local.MGet(ctx, keys...)
+	var rest []string
+	for _, key := range keys {
+		if _, ok := result[key]; !ok {
+			rest = append(rest, key)
+		}
+	}
+	metrics.CacheLookupsTotal.WithLabelValues(tieredCacheName, metrics.CacheHit).Add(float64(len(result)))
+	metrics.CacheLookupsTotal.WithLabelValues(tieredCacheName, metrics.CacheMiss).Add(float64(len(rest)))
+	if len(rest) == 0 {
+		return result, nil
+	}
+	remote, err := c.CacheInterface.MGet(ctx, rest...)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range remote {
+		result[key] = value
+	}
+	return result, nil
+}
+
+// Set 设置缓存
+func (c *TieredCache) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	err := c.CacheInterface.Set(ctx, key, value, expiration)
+	c.invalidate(ctx, key)
+	return err
+}
+
+// MSet 批量设置缓存
+func (c *TieredCache) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	err := c.CacheInterface.MSet(ctx, values, expiration)
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	c.invalidate(ctx, keys...)
+	return err
+}
+
+// SetNX 设置键值（仅当键不存在时）
+func (c *TieredCache) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	ok, err := c.CacheInterface.SetNX(ctx, key, value, expiration)
+	if ok {
+		c.invalidate(ctx, key)
+	}
+	return ok, err
+}
+
+// Delete 删除缓存
+func (c *TieredCache) Delete(ctx context.Context, keys ...string) error {
+	err := c.CacheInterface.Delete(ctx, keys...)
+	c.invalidate(ctx, keys...)
+	return err
+}
+
+// Expire 设置过期时间，只删除本实例的本地副本
+func (c *TieredCache) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	err := c.CacheInterface.Expire(ctx, key, expiration)
+	c.deleteLocal(key)
+	return err
+}
+
+// Increment 自增，只删除本实例的本地副本
+func (c *TieredCache) Increment(ctx context.Context, key string) (int64, error) {
+	n, err := c.CacheInterface.Increment(ctx, key)
+	c.deleteLocal(key)
+	return n, err
+}
+
+// IncrementBy 增加指定值
+func (c *TieredCache) IncrementBy(ctx context.Context, key string, value int64) (int64, error) {
+	n, err := c.CacheInterface.IncrementBy(ctx, key, value)
+	c.deleteLocal(key)
+	return n, err
+}
+
+// Decrement 自减
+func (c *TieredCache) Decrement(ctx context.Context, key string) (int64, error) {
+	n, err := c.CacheInterface.Decrement(ctx, key)
+	c.deleteLocal(key)
+	return n, err
+}
+
+// DecrementBy 减少指定值
+func (c *TieredCache) DecrementBy(ctx context.Context, key string, value int64) (int64, error) {
+	n, err := c.CacheInterface.DecrementBy(ctx, key, value)
+	c.deleteLocal(key)
+	return n, err
+}
+
+// FlushDB 清空二级缓存和全部实例的本地缓存
+func (c *TieredCache) FlushDB(ctx context.Context) error {
+	err := c.CacheInterface.FlushDB(ctx)
+	c.flushLocal()
+	c.publish(ctx, invalidation{Origin: c.origin, Flush: true})
+	return err
+}
+
+// Close 取消订阅失效广播并关闭二级缓存
+func (c *TieredCache) Close() error {
+	err := c.sub.Close()
+	if closer, ok := c.CacheInterface.(io.Closer); ok {
+		if cerr := closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// generation 当前的失效次数
+func (c *TieredCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// invalidate 删除本地的键并广播给其他实例，写入二级缓存失败时也执行，避免本地保留不确定的值
+func (c *TieredCache) invalidate(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+	c.deleteLocal(keys...)
+	c.publish(ctx, invalidation{Origin: c.origin, Keys: keys})
+}
+
+// deleteLocal 删除本地的键
+func (c *TieredCache) deleteLocal(keys ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	_ = c.local.Delete(context.Background(), keys...)
+}
+
+// flushLocal 清空本地缓存
+func (c *TieredCache) flushLocal() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	_ = c.local.FlushDB(context.Background())
+}
+
+// publish 广播失效消息，不受调用方取消的影响，失败时其他实例在 LocalTTL 后读到新值
+func (c *TieredCache) publish(ctx context.Context, msg invalidation) {
+	payload, err := json.Marshal(msg)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+		defer cancel()
+		err = c.bus.Publish(ctx, payload)
+	}
+	if err != nil {
+		cacheLogger().Warn("广播缓存失效失败", logger.Any("keys", msg.Keys), logger.Err(err))
+	}
+}
+
+// handle 处理失效广播，忽略本实例发出的消息
+func (c *TieredCache) handle(payload []byte) {
+	if payload == nil {
+		c.flushLocal()
+		return
+	}
+	var msg invalidation
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		cacheLogger().Warn("解析缓存失效广播失败", logger.Err(err))
+		return
+	}
+	switch {
+	case msg.Origin == c.origin:
+	case msg.Flush:
+		c.flushLocal()
+	default:
+		c.deleteLocal(msg.Keys...)
+	}
+}
+
+// cacheLoggerName 缓存日志使用的logger名称
+const cacheLoggerName = "cache"
+
+func init() {
+	logger.RegisterNames(cacheLoggerName)
+}
+
+// cacheLogger 缓存日志
+func cacheLogger() *zap.Logger {
+	return logger.Named(cacheLoggerName)
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Decode 使用缓存的编码方式把读取到的原始值解码为 T
+func Decode[T any](c CacheInterface, raw string) (T, error) {
+	var value T
+	if err := c.Codec().Unmarshal([]byte(raw), &value); err != nil {
+		return value, fmt.Errorf("反序列化失败: %w", err)
+	}
+	return value, nil
+}
+
+// GetAs 获取缓存并解码为 T，未命中时返回 ErrMiss
+func GetAs[T any](ctx context.Context, c CacheInterface, key string) (T, error) {
+	raw, err := c.Get(ctx, key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return Decode[T](c, raw)
+}
+
+// MGetAs 批量获取缓存并解码为 T，结果不包含不存在的键
+func MGetAs[T any](ctx context.Context, c CacheInterface, keys ...string) (map[string]T, error) {
+	raws, err := c.MGet(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]T, len(raws))
+	for key, raw := range raws {
+		value, err := Decode[T](c, raw)
+		if err != nil {
+			return nil, fmt.Errorf("键 %s: %w", key, err)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// HGetAs 获取哈希字段并解码为 T，未命中时返回 ErrMiss
+func HGetAs[T any](ctx context.Context, c CacheInterface, key, field string) (T, error) {
+	raw, err := c.HGet(ctx, key, field)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return Decode[T](c, raw)
+}
+
+// HGetAllAs 获取所有哈希字段并解码为 T
+func HGetAllAs[T any](ctx context.Context, c CacheInterface, key string) (map[string]T, error) {
+	raws, err := c.HGetAll(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]T, len(raws))
+	for field, raw := range raws {
+		value, err := Decode[T](c, raw)
+		if err != nil {
+			return nil, fmt.Errorf("字段 %s: %w", field, err)
+		}
+		result[field] = value
+	}
+	return result, nil
+}
+
+// LRangeAs 获取列表指定范围元素并解码为 T
+func LRangeAs[T any](ctx context.Context, c CacheInterface, key string, start, stop int64) ([]T, error) {
+	raws, err := c.LRange(ctx, key, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]T, len(raws))
+	for i, raw := range raws {
+		if result[i], err = Decode[T](c, raw); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// GetOrSet 获取缓存，未命中时调用 load 并写入缓存；读写缓存失败时返回 load 的结果，不影响调用方
+func GetOrSet[T any](ctx context.Context, c CacheInterface, key string, expiration time.Duration, load func(ctx context.Context) (T, error)) (T, error) {
+	value, err := GetAs[T](ctx, c, key)
+	if err == nil {
+		return value, nil
+	}
+	if value, err = load(ctx); err != nil {
+		return value, err
+	}
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return value, nil
+	}
+	_ = c.Set(ctx, key, value, expiration)
+	return value, nil
+}
@@ -0,0 +1,228 @@
+// Package cron 解析 cron 表达式并计算下次执行时间
+//
+// 支持标准的5个字段（分 时 日 月 周），字段中可以使用 *、逗号分隔的列表、范围 a-b 和步长 /n，
+// 月和星期可以使用英文缩写（JAN、MON），星期中 0 和 7 都表示星期日。
+// 日和星期同时指定时满足任意一个即可，与 crontab 一致。
+//
+// 也支持以下描述符：
+//
+//	@yearly（@annually）  0 0 1 1 *
+//	@monthly              0 0 1 * *
+//	@weekly               0 0 * * 0
+//	@daily（@midnight）    0 0 * * *
+//	@hourly               0 * * * *
+//	@every <duration>     固定间隔，如 @every 10m
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule 执行计划
+type Schedule interface {
+	// Next 返回 t 之后的下一次执行时间，没有时返回零值
+	Next(t time.Time) time.Time
+}
+
+// descriptors 描述符对应的表达式
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// 月份和星期的英文缩写
+var (
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	dayNames = map[string]int{"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6}
+)
+
+// field 字段的取值范围
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "分钟", min: 0, max: 59}
+	hourField   = field{name: "小时", min: 0, max: 23}
+	domField    = field{name: "日", min: 1, max: 31}
+	monthField  = field{name: "月", min: 1, max: 12, names: monthNames}
+	dowField    = field{name: "星期", min: 0, max: 7, names: dayNames}
+)
+
+// Parse 解析 cron 表达式
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || d < time.Second {
+			return nil, fmt.Errorf("无效的执行间隔 %q，至少为 1s", expr)
+		}
+		return every(d), nil
+	}
+	if spec, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = spec
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron 表达式 %q 应包含5个字段（分 时 日 月 周）", expr)
+	}
+	var s spec
+	var err error
+	if s.minute, err = parseField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if s.hour, err = parseField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if s.dom, err = parseField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if s.month, err = parseField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if s.dow, err = parseField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+	// 7 与 0 都表示星期日
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domAny = fields[2] == "*" || strings.HasPrefix(fields[2], "*/")
+	s.dowAny = fields[4] == "*" || strings.HasPrefix(fields[4], "*/")
+	return &s, nil
+}
+
+// Validate 校验 cron 表达式
+func Validate(expr string) error {
+	_, err := Parse(expr)
+	return err
+}
+
+// parseField 解析一个字段，返回取值的位图
+func parseField(expr string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangeExpr, stepExpr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepExpr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s字段的步长无效: %q", f.name, part)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rangeExpr == "*":
+			lo, hi = f.min, f.max
+		case strings.Contains(rangeExpr, "-"):
+			loExpr, hiExpr, _ := strings.Cut(rangeExpr, "-")
+			var err error
+			if lo, err = parseValue(loExpr, f); err != nil {
+				return 0, err
+			}
+			if hi, err = parseValue(hiExpr, f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s字段的范围无效: %q", f.name, part)
+			}
+		default:
+			v, err := parseValue(rangeExpr, f)
+			if err != nil {
+				return 0, err
+			}
+			lo, hi = v, v
+			// a/n 表示从 a 开始到最大值
+			if hasStep {
+				hi = f.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// parseValue 解析单个取值，支持英文缩写
+func parseValue(expr string, f field) (int, error) {
+	if v, ok := f.names[strings.ToLower(expr)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(expr)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s字段的取值 %q 超出范围 %d-%d", f.name, expr, f.min, f.max)
+	}
+	return v, nil
+}
+
+// spec 5个字段的执行计划，每个字段为取值的位图
+type spec struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// maxYears 查找下次执行时间的年数上限，如 2月30日永远不会执行
+const maxYears = 5
+
+// Next 返回 t 之后的下一次执行时间，精确到分钟
+func (s *spec) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 日和星期同时指定时满足任意一个即可
+func (s *spec) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// every 固定间隔的执行计划
+type every time.Duration
+
+// Next 返回 t 之后按间隔对齐的下一次执行时间
+func (e every) Next(t time.Time) time.Time {
+	d := time.Duration(e)
+	return t.Truncate(d).Add(d)
+}
@@ -0,0 +1,216 @@
+// Package database 数据库连接，支持 MySQL、PostgreSQL 和 SQLite
+//
+// SQLite 使用纯 Go 实现的驱动，无需 CGO；DSN 为 :memory: 时使用进程内数据库，
+// 适合测试和本地演示，无需启动 MySQL 容器。
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库驱动
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// sqliteBusyTimeout SQLite 文件数据库等待写锁的时间，避免并发写入时立即返回 database is locked
+const sqliteBusyTimeout = "_pragma=busy_timeout(5000)"
+
+// Config 数据库配置
+type Config struct {
+	Driver          string `mapstructure:"driver" yaml:"driver" validate:"oneof=mysql postgres sqlite"`
+	DSN             string `mapstructure:"dsn" yaml:"dsn" validate:"required"`
+	MaxOpenConns    int    `mapstructure:"max_open_conns" yaml:"max_open_conns" validate:"min=0"`
+	MaxIdleConns    int    `mapstructure:"max_idle_conns" yaml:"max_idle_conns" validate:"min=0"`
+	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime" yaml:"conn_max_lifetime" validate:"min=0"`   // 秒
+	ConnMaxIdleTime int    `mapstructure:"conn_max_idle_time" yaml:"conn_max_idle_time" validate:"min=0"` // 秒
+	LogMode         bool   `mapstructure:"log_mode" yaml:"log_mode"`
+	SlowThreshold   int    `mapstructure:"slow_threshold" yaml:"slow_threshold" validate:"min=0"` // 毫秒
+	AutoMigrate     bool   `mapstructure:"auto_migrate" yaml:"auto_migrate"`                      // 启动时执行待执行的迁移
+
+	QueryTimeout time.Duration `mapstructure:"query_timeout" yaml:"query_timeout" validate:"min=0"` // 单条语句的执行超时，0 表示只受请求上下文约束
+
+	Replication ReplicationConfig `mapstructure:"replication" yaml:"replication"` // 读写分离，未配置副本时不启用
+}
+
+// MySQLConfig 数据库配置
+//
+// Deprecated: 使用 Config
+type MySQLConfig = Config
+
+// Open 按 config.Driver 创建数据库连接，未设置时为 mysql
+func Open(config Config) (*gorm.DB, error) {
+	dialector, err := newDialector(config)
+	if err != nil {
+		return nil, err
+	}
+
+	// 配置GORM，SQL日志输出到名为 repository 的logger
+	gormConfig := &gorm.Config{
+		Logger: NewGormLogger(config.LogMode, time.Duration(config.SlowThreshold)*time.Millisecond),
+		NowFunc: func() time.Time {
+			return time.Now().Local()
+		},
+		DisableForeignKeyConstraintWhenMigrating: true,
+	}
+
+	// 连接数据库
+	db, err := gorm.Open(dialector, gormConfig)
+	if err != nil {
+		return nil, fmt.Errorf("连接数据库失败: %w", err)
+	}
+
+	// 获取底层的sql.DB
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库实例失败: %w", err)
+	}
+
+	// 设置连接池参数
+	configurePool(sqlDB, config)
+
+	// 测试连接
+	if err := sqlDB.Ping(); err != nil {
+		return nil, fmt.Errorf("数据库连接测试失败: %w", err)
+	}
+
+	// 查询超时：在请求上下文的基础上限制单条语句的执行时间
+	if err := db.Use(NewQueryTimeout(config.QueryTimeout)); err != nil {
+		_ = sqlDB.Close()
+		return nil, fmt.Errorf("注册查询超时插件失败: %w", err)
+	}
+
+	// 读写分离：读操作路由到副本
+	if len(config.Replication.Replicas) > 0 {
+		replication, err := newReplication(config, gormConfig)
+		if err != nil {
+			_ = sqlDB.Close()
+			return nil, err
+		}
+		if err := db.Use(replication); err != nil {
+			replication.close()
+			_ = sqlDB.Close()
+			return nil, fmt.Errorf("注册读写分离插件失败: %w", err)
+		}
+	}
+
+	return db, nil
+}
+
+// configurePool 设置连接池参数
+func configurePool(sqlDB *sql.DB, config Config) {
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(config.ConnMaxLifetime) * time.Second)
+	sqlDB.SetConnMaxIdleTime(time.Duration(config.ConnMaxIdleTime) * time.Second)
+	if config.Driver == DriverSQLite && isMemoryDSN(config.DSN) {
+		// 内存数据库的数据属于单个连接，固定使用一个永不过期的连接
+		sqlDB.SetMaxOpenConns(1)
+		sqlDB.SetMaxIdleConns(1)
+		sqlDB.SetConnMaxLifetime(0)
+		sqlDB.SetConnMaxIdleTime(0)
+	}
+}
+
+// NewMySQL 创建数据库连接
+//
+// Deprecated: 使用 Open，按 config.Driver 选择数据库
+func NewMySQL(config MySQLConfig) (*gorm.DB, error) {
+	return Open(config)
+}
+
+// newDialector 按驱动名称创建GORM方言
+func newDialector(config Config) (gorm.Dialector, error) {
+	switch config.Driver {
+	case DriverMySQL, "":
+		return mysql.Open(config.DSN), nil
+	case DriverPostgres:
+		return postgres.Open(config.DSN), nil
+	case DriverSQLite:
+		dsn := config.DSN
+		if !isMemoryDSN(dsn) && !strings.Contains(dsn, "busy_timeout") {
+			dsn = appendQuery(dsn, sqliteBusyTimeout)
+		}
+		return sqlite.Open(dsn), nil
+	default:
+		return nil, fmt.Errorf("不支持的数据库驱动: %s", config.Driver)
+	}
+}
+
+// isMemoryDSN 是否为 SQLite 内存数据库
+func isMemoryDSN(dsn string) bool {
+	return strings.Contains(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
+
+// appendQuery 向 DSN 追加查询参数
+func appendQuery(dsn, param string) string {
+	if strings.Contains(dsn, "?") {
+		return dsn + "&" + param
+	}
+	return dsn + "?" + param
+}
+
+// Close 关闭数据库连接，包括只读副本
+func Close(db *gorm.DB) error {
+	if db == nil {
+		return nil
+	}
+	if r := ReplicationOf(db); r != nil {
+		r.close()
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// HealthCheck 数据库健康检查，只检查主库，不可用的副本由读写分离自动摘除
+func HealthCheck(db *gorm.DB) error {
+	if db == nil {
+		return fmt.Errorf("数据库连接为空")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return fmt.Errorf("获取数据库实例失败: %w", err)
+	}
+	return sqlDB.Ping()
+}
+
+// GetStats 获取数据库连接池统计信息
+func GetStats(db *gorm.DB) (map[string]interface{}, error) {
+	if db == nil {
+		return nil, fmt.Errorf("数据库连接为空")
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := sqlDB.Stats()
+	result := map[string]interface{}{
+		"max_open_connections": stats.MaxOpenConnections,
+		"open_connections":     stats.OpenConnections,
+		"in_use":               stats.InUse,
+		"idle":                 stats.Idle,
+		"wait_count":           stats.WaitCount,
+		"wait_duration":        stats.WaitDuration.String(),
+		"max_idle_closed":      stats.MaxIdleClosed,
+		"max_lifetime_closed":  stats.MaxLifetimeClosed,
+	}
+	if r := ReplicationOf(db); r != nil {
+		result["replicas"] = r.Status()
+	}
+	return result, nil
+}
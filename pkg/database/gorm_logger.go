@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	applog "go_demo/pkg/logger"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// GormLoggerName GORM日志使用的logger名称，可通过 log.levels 单独设置级别，如 "repository=debug"
+const GormLoggerName = "repository"
+
+func init() {
+	applog.RegisterNames(GormLoggerName)
+}
+
+// gormLogger 基于zap的GORM日志实现
+// SQL语句默认以 debug 级别输出，开启 log_mode 时以 info 级别输出；慢查询输出 warn，错误输出 error
+type gormLogger struct {
+	level         logger.LogLevel
+	sqlLevel      zapcore.Level
+	slowThreshold time.Duration
+}
+
+// NewGormLogger 创建GORM日志
+func NewGormLogger(logMode bool, slowThreshold time.Duration) logger.Interface {
+	sqlLevel := zapcore.DebugLevel
+	if logMode {
+		sqlLevel = zapcore.InfoLevel
+	}
+	return &gormLogger{
+		level:         logger.Info,
+		sqlLevel:      sqlLevel,
+		slowThreshold: slowThreshold,
+	}
+}
+
+// log 获取带上下文字段的logger
+func (l *gormLogger) log(ctx context.Context) *zap.Logger {
+	return applog.Named(GormLoggerName).With(applog.ContextFields(ctx)...)
+}
+
+// LogMode 设置GORM日志级别
+func (l *gormLogger) LogMode(level logger.LogLevel) logger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Info {
+		l.log(ctx).Info(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Warn {
+		l.log(ctx).Warn(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= logger.Error {
+		l.log(ctx).Error(fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace 记录SQL执行情况
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= logger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	log := l.log(ctx)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= logger.Error:
+		sql, rows := fc()
+		log.Error("SQL执行错误", zap.String("sql", sql), zap.Int64("rows", rows),
+			zap.Duration("elapsed", elapsed), zap.Error(err))
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= logger.Warn:
+		sql, rows := fc()
+		log.Warn("慢查询", zap.String("sql", sql), zap.Int64("rows", rows),
+			zap.Duration("elapsed", elapsed), zap.Duration("threshold", l.slowThreshold))
+	case l.level >= logger.Info:
+		if ce := log.Check(l.sqlLevel, "SQL"); ce != nil {
+			sql, rows := fc()
+			ce.Write(zap.String("sql", sql), zap.Int64("rows", rows), zap.Duration("elapsed", elapsed))
+		}
+	}
+}
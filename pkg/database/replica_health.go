@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	applog "go_demo/pkg/logger"
+)
+
+// replicaCheckTimeout 单个副本健康检查的超时时间
+const replicaCheckTimeout = 3 * time.Second
+
+// LagProbe 查询副本的复制延迟
+type LagProbe func(ctx context.Context, db *sql.DB) (time.Duration, error)
+
+// postgresLagSQL PostgreSQL 备库的复制延迟，已回放全部 WAL 时为0，非备库为0
+const postgresLagSQL = `SELECT CASE
+	WHEN NOT pg_is_in_recovery() THEN 0
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END::float8`
+
+// defaultLagProbe 按驱动选择复制延迟查询，SQLite 没有复制，延迟视为0
+func defaultLagProbe(driver string) LagProbe {
+	switch driver {
+	case DriverMySQL, "":
+		return mysqlLag
+	case DriverPostgres:
+		return postgresLag
+	default:
+		return func(context.Context, *sql.DB) (time.Duration, error) { return 0, nil }
+	}
+}
+
+// SetLagProbe 替换复制延迟查询，用于自定义的复制拓扑
+func (r *Replication) SetLagProbe(probe LagProbe) {
+	r.probeMu.Lock()
+	r.probe = probe
+	r.probeMu.Unlock()
+}
+
+// run 定期检查副本状态，清理过期的粘滞记录
+func (r *Replication) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.Check(context.Background())
+			r.sticky.purge()
+		}
+	}
+}
+
+// Check 立即检查全部副本的连通性和复制延迟，更新副本是否参与读流量
+func (r *Replication) Check(ctx context.Context) {
+	r.probeMu.RLock()
+	probe := r.probe
+	r.probeMu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, rep := range r.replicas {
+		wg.Add(1)
+		go func(rep *replica) {
+			defer wg.Done()
+			r.checkReplica(ctx, rep, probe)
+		}(rep)
+	}
+	wg.Wait()
+}
+
+// checkReplica 检查单个副本，状态变化时记录日志
+func (r *Replication) checkReplica(ctx context.Context, rep *replica, probe LagProbe) {
+	ctx, cancel := context.WithTimeout(ctx, replicaCheckTimeout)
+	defer cancel()
+
+	var lag time.Duration
+	err := rep.db.PingContext(ctx)
+	if err == nil {
+		lag, err = probe(ctx, rep.db)
+	}
+	if err == nil && r.maxLag > 0 && lag > r.maxLag {
+		err = fmt.Errorf("复制延迟 %s 超过 %s", lag, r.maxLag)
+	}
+
+	rep.mu.Lock()
+	rep.lag, rep.err, rep.checkedAt = lag, err, time.Now()
+	rep.mu.Unlock()
+
+	healthy := err == nil
+	if was := rep.healthy.Swap(healthy); was != healthy {
+		if healthy {
+			applog.Info("只读副本已恢复", applog.String("replica", rep.name), applog.Duration("lag", lag))
+		} else {
+			applog.Warn("只读副本已摘除，读请求改由其他副本或主库处理", applog.String("replica", rep.name), applog.Err(err))
+		}
+	}
+}
+
+// mysqlLag 通过 SHOW REPLICA STATUS 查询 MySQL 副本的复制延迟，不是副本时为0
+func mysqlLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	rows, err := db.QueryContext(ctx, "SHOW REPLICA STATUS")
+	if err != nil {
+		// MySQL 8.0.22 之前的版本
+		if rows, err = db.QueryContext(ctx, "SHOW SLAVE STATUS"); err != nil {
+			return 0, fmt.Errorf("查询复制状态失败: %w", err)
+		}
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return 0, fmt.Errorf("读取复制状态失败: %w", err)
+	}
+
+	for i, column := range columns {
+		if column != "Seconds_Behind_Source" && column != "Seconds_Behind_Master" {
+			continue
+		}
+		if !values[i].Valid {
+			return 0, errors.New("复制线程未运行")
+		}
+		seconds, err := strconv.ParseInt(values[i].String, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("解析复制延迟失败: %w", err)
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return 0, errors.New("复制状态中没有延迟信息")
+}
+
+// postgresLag 查询 PostgreSQL 备库的复制延迟
+func postgresLag(ctx context.Context, db *sql.DB) (time.Duration, error) {
+	var seconds float64
+	if err := db.QueryRowContext(ctx, postgresLagSQL).Scan(&seconds); err != nil {
+		return 0, fmt.Errorf("查询复制延迟失败: %w", err)
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
@@ -0,0 +1,398 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// 副本选择策略
+const (
+	PolicyRoundRobin = "round_robin"
+	PolicyRandom     = "random"
+)
+
+// replicationPluginName 读写分离插件名称
+const replicationPluginName = "replication"
+
+// defaultHealthCheckInterval 默认的副本健康检查间隔
+const defaultHealthCheckInterval = 5 * time.Second
+
+// ReplicaConfig 只读副本配置，驱动和连接池参数与主库相同
+type ReplicaConfig struct {
+	Name string `mapstructure:"name" yaml:"name"` // 名称，用于日志和指标，默认 replica-<序号>
+	DSN  string `mapstructure:"dsn" yaml:"dsn" validate:"required"`
+}
+
+// ReplicationConfig 读写分离配置，未配置副本时全部请求使用主库
+type ReplicationConfig struct {
+	Replicas            []ReplicaConfig `mapstructure:"replicas" yaml:"replicas" validate:"dive"`
+	Policy              string          `mapstructure:"policy" yaml:"policy" validate:"omitempty,oneof=round_robin random"`  // 副本选择策略，默认 round_robin
+	StickyWindow        time.Duration   `mapstructure:"sticky_window" yaml:"sticky_window" validate:"min=0"`                 // 写入后同一用户的读请求在该时间内使用主库，0 表示不启用；只对本进程内携带粘滞键的请求生效
+	MaxLag              time.Duration   `mapstructure:"max_lag" yaml:"max_lag" validate:"min=0"`                             // 复制延迟超过该值的副本暂停读流量，0 表示不检查延迟
+	HealthCheckInterval time.Duration   `mapstructure:"health_check_interval" yaml:"health_check_interval" validate:"min=0"` // 副本健康检查间隔，默认 5s
+}
+
+// contextKey 上下文键
+type contextKey int
+
+const (
+	stickyContextKey contextKey = iota
+	primaryContextKey
+	timeoutContextKey
+)
+
+// WithStickyKey 设置读己之写的粘滞键，通常为用户ID
+// 携带该键的写操作成功后，sticky_window 内携带同一个键的读操作使用主库，避免读到副本上的旧数据
+// 键只在通过 db.WithContext 传入该上下文的语句上生效；写入记录保存在进程内，不在实例之间共享
+func WithStickyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, stickyContextKey, key)
+}
+
+// WithPrimary 强制读操作使用主库，用于对一致性要求高的读取，如写入前的检查
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey, true)
+}
+
+// stickyKey 获取上下文中的粘滞键
+func stickyKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	key, _ := ctx.Value(stickyContextKey).(string)
+	return key
+}
+
+// usePrimary 上下文是否要求使用主库
+func usePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	primary, _ := ctx.Value(primaryContextKey).(bool)
+	return primary
+}
+
+// Replication 读写分离插件
+// 读操作路由到健康的副本，写操作、事务和加锁读取使用主库；复制延迟过大或无法连接的副本暂停读流量，恢复后自动加回
+type Replication struct {
+	primary  gorm.ConnPool
+	replicas []*replica
+	policy   string
+	counter  atomic.Uint64
+	sticky   *stickyWindow
+	maxLag   time.Duration
+	interval time.Duration
+
+	probeMu sync.RWMutex
+	probe   LagProbe
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// replica 只读副本
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+
+	mu        sync.Mutex
+	lag       time.Duration
+	err       error
+	checkedAt time.Time
+}
+
+// ReplicaStatus 只读副本状态
+type ReplicaStatus struct {
+	Name      string        `json:"name"`
+	Healthy   bool          `json:"healthy"`
+	Lag       time.Duration `json:"lag"`
+	Error     string        `json:"error,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// newReplication 连接全部副本并完成首次健康检查
+func newReplication(config Config, gormConfig *gorm.Config) (*Replication, error) {
+	rc := config.Replication
+	r := &Replication{
+		policy:   rc.Policy,
+		maxLag:   rc.MaxLag,
+		interval: rc.HealthCheckInterval,
+		probe:    defaultLagProbe(config.Driver),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if r.interval <= 0 {
+		r.interval = defaultHealthCheckInterval
+	}
+	if rc.StickyWindow > 0 {
+		r.sticky = &stickyWindow{window: rc.StickyWindow, until: make(map[string]time.Time)}
+	}
+
+	for i, c := range rc.Replicas {
+		name := c.Name
+		if name == "" {
+			name = fmt.Sprintf("replica-%d", i+1)
+		}
+		sqlDB, err := openReplica(config, c.DSN, gormConfig)
+		if err != nil {
+			r.closeReplicas()
+			return nil, fmt.Errorf("连接只读副本 %s 失败: %w", name, err)
+		}
+		rep := &replica{name: name, db: sqlDB}
+		rep.healthy.Store(true)
+		r.replicas = append(r.replicas, rep)
+	}
+
+	// 启动时无法连接的副本直接摘除，不影响服务启动
+	r.Check(context.Background())
+	go r.run()
+	return r, nil
+}
+
+// openReplica 创建副本连接池，不在启动时测试连接，由健康检查决定是否使用
+func openReplica(config Config, dsn string, gormConfig *gorm.Config) (*sql.DB, error) {
+	var dialector gorm.Dialector
+	if config.Driver == DriverMySQL || config.Driver == "" {
+		// 跳过初始化时的版本查询，副本暂时不可用时也能创建连接池
+		dialector = mysql.New(mysql.Config{DSN: dsn, SkipInitializeWithVersion: true})
+	} else {
+		var err error
+		if dialector, err = newDialector(Config{Driver: config.Driver, DSN: dsn}); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:               gormConfig.Logger,
+		NowFunc:              gormConfig.NowFunc,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	configurePool(sqlDB, Config{
+		Driver:          config.Driver,
+		DSN:             dsn,
+		MaxOpenConns:    config.MaxOpenConns,
+		MaxIdleConns:    config.MaxIdleConns,
+		ConnMaxLifetime: config.ConnMaxLifetime,
+		ConnMaxIdleTime: config.ConnMaxIdleTime,
+	})
+	return sqlDB, nil
+}
+
+// Name 插件名称
+func (r *Replication) Name() string {
+	return replicationPluginName
+}
+
+// Initialize 注册回调：查询前选择副本，查询后恢复主库，写入后记录粘滞键
+func (r *Replication) Initialize(db *gorm.DB) error {
+	r.primary = db.ConnPool
+	cb := db.Callback()
+
+	if err := cb.Query().Before("gorm:query").Register("replication:route_query", r.route); err != nil {
+		return err
+	}
+	if err := cb.Query().After("gorm:query").Register("replication:restore_query", r.restore); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("replication:route_row", r.route); err != nil {
+		return err
+	}
+	if err := cb.Row().After("gorm:row").Register("replication:restore_row", r.restore); err != nil {
+		return err
+	}
+
+	writes := []struct {
+		operation string
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().After("gorm:create").Register},
+		{"update", cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().After("gorm:delete").Register},
+		{"raw", cb.Raw().After("gorm:raw").Register},
+	}
+	for _, w := range writes {
+		if err := w.after("replication:sticky_"+w.operation, r.markWrite); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// route 将读操作路由到副本
+// 只处理使用主库连接池的语句，事务（*sql.Tx）和固定连接（*sql.Conn）中的语句保持不变
+func (r *Replication) route(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.ConnPool != r.primary || !isReadStatement(stmt) {
+		return
+	}
+	if usePrimary(stmt.Context) || r.sticky.active(stickyKey(stmt.Context)) {
+		return
+	}
+	if rep := r.pick(); rep != nil {
+		stmt.ConnPool = rep.db
+	}
+}
+
+// restore 查询结束后恢复主库连接池，避免复用同一个语句的后续写操作落到副本
+func (r *Replication) restore(db *gorm.DB) {
+	for _, rep := range r.replicas {
+		if db.Statement.ConnPool == rep.db {
+			db.Statement.ConnPool = r.primary
+			return
+		}
+	}
+}
+
+// markWrite 写操作成功后记录粘滞键
+func (r *Replication) markWrite(db *gorm.DB) {
+	if db.Error == nil {
+		r.sticky.mark(stickyKey(db.Statement.Context))
+	}
+}
+
+// pick 按策略选择健康的副本，全部不可用时返回 nil，使用主库
+func (r *Replication) pick() *replica {
+	n := len(r.replicas)
+	var start int
+	if r.policy == PolicyRandom {
+		start = rand.IntN(n)
+	} else {
+		start = int(r.counter.Add(1) % uint64(n))
+	}
+	for i := 0; i < n; i++ {
+		if rep := r.replicas[(start+i)%n]; rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+// isReadStatement 是否为可以在副本执行的读操作，SELECT ... FOR UPDATE 等加锁读取使用主库
+func isReadStatement(stmt *gorm.Statement) bool {
+	if _, locking := stmt.Clauses["FOR"]; locking {
+		return false
+	}
+	// Raw 语句在执行前已经生成 SQL，按语句内容判断
+	if stmt.SQL.Len() == 0 {
+		return true
+	}
+	sql := strings.ToUpper(strings.TrimLeft(stmt.SQL.String(), " \t\r\n("))
+	if !strings.HasPrefix(sql, "SELECT") {
+		return false
+	}
+	return !strings.Contains(sql, "FOR UPDATE") && !strings.Contains(sql, "FOR SHARE") &&
+		!strings.Contains(sql, "LOCK IN SHARE MODE")
+}
+
+// Status 获取全部副本的状态
+func (r *Replication) Status() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		rep.mu.Lock()
+		s := ReplicaStatus{Name: rep.name, Healthy: rep.healthy.Load(), Lag: rep.lag, CheckedAt: rep.checkedAt}
+		if rep.err != nil {
+			s.Error = rep.err.Error()
+		}
+		rep.mu.Unlock()
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
+// DBs 获取副本连接池，按名称索引，用于注册连接池指标
+func (r *Replication) DBs() map[string]*sql.DB {
+	dbs := make(map[string]*sql.DB, len(r.replicas))
+	for _, rep := range r.replicas {
+		dbs[rep.name] = rep.db
+	}
+	return dbs
+}
+
+// close 停止健康检查并关闭副本连接
+func (r *Replication) close() {
+	close(r.stop)
+	<-r.done
+	r.closeReplicas()
+}
+
+// closeReplicas 关闭副本连接
+func (r *Replication) closeReplicas() {
+	for _, rep := range r.replicas {
+		_ = rep.db.Close()
+	}
+}
+
+// ReplicationOf 获取数据库连接上的读写分离插件，未配置副本时返回 nil
+func ReplicationOf(db *gorm.DB) *Replication {
+	if db == nil {
+		return nil
+	}
+	r, _ := db.Config.Plugins[replicationPluginName].(*Replication)
+	return r
+}
+
+// stickyWindow 记录每个粘滞键最近一次写入后使用主库的截止时间
+type stickyWindow struct {
+	window time.Duration
+	mu     sync.Mutex
+	until  map[string]time.Time
+}
+
+// mark 记录写入，未启用时为 nil
+func (s *stickyWindow) mark(key string) {
+	if s == nil || key == "" {
+		return
+	}
+	s.mu.Lock()
+	s.until[key] = time.Now().Add(s.window)
+	s.mu.Unlock()
+}
+
+// active 该键是否仍在粘滞窗口内
+func (s *stickyWindow) active(key string) bool {
+	if s == nil || key == "" {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.until[key]
+	if !ok {
+		return false
+	}
+	if time.Now().After(until) {
+		delete(s.until, key)
+		return false
+	}
+	return true
+}
+
+// purge 清理已过期的记录
+func (s *stickyWindow) purge() {
+	if s == nil {
+		return
+	}
+	now := time.Now()
+	s.mu.Lock()
+	for key, until := range s.until {
+		if now.After(until) {
+			delete(s.until, key)
+		}
+	}
+	s.mu.Unlock()
+}
@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// timeoutPluginName 查询超时插件名称
+const timeoutPluginName = "query_timeout"
+
+// timeoutStateKey 保存语句原上下文和取消函数的键
+const timeoutStateKey = "query_timeout:state"
+
+// WithQueryTimeout 覆盖单个请求的查询超时，用于报表导出等耗时较长的操作；0 表示不限制
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutContextKey, timeout)
+}
+
+// queryTimeoutOf 获取上下文中覆盖的查询超时
+func queryTimeoutOf(ctx context.Context) (time.Duration, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	timeout, ok := ctx.Value(timeoutContextKey).(time.Duration)
+	return timeout, ok
+}
+
+// timeoutState 语句执行前的上下文，执行结束后恢复
+type timeoutState struct {
+	parent context.Context
+	cancel context.CancelFunc
+}
+
+// QueryTimeout 为每条语句设置执行超时的插件
+// 超时基于 db.WithContext(ctx) 传入的请求上下文，请求取消或超时时语句同样终止。
+// Row/Rows/Scan 返回的结果集在回调结束后才由调用方读取，这类语句不设置超时，只受请求上下文约束。
+type QueryTimeout struct {
+	timeout time.Duration
+}
+
+// NewQueryTimeout 创建查询超时插件，timeout 为默认超时，0 表示只使用请求上下文的截止时间
+func NewQueryTimeout(timeout time.Duration) *QueryTimeout {
+	return &QueryTimeout{timeout: timeout}
+}
+
+// Name 插件名称
+func (p *QueryTimeout) Name() string {
+	return timeoutPluginName
+}
+
+// Initialize 注册回调，在全部回调之前设置超时，之后恢复原上下文
+func (p *QueryTimeout) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("*").Register, cb.Create().After("*").Register},
+		{"query", cb.Query().Before("*").Register, cb.Query().After("*").Register},
+		{"update", cb.Update().Before("*").Register, cb.Update().After("*").Register},
+		{"delete", cb.Delete().Before("*").Register, cb.Delete().After("*").Register},
+		{"raw", cb.Raw().Before("*").Register, cb.Raw().After("*").Register},
+	}
+
+	for _, h := range hooks {
+		if err := h.before("query_timeout:before_"+h.operation, p.before); err != nil {
+			return err
+		}
+		if err := h.after("query_timeout:after_"+h.operation, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// before 替换语句上下文为带超时的上下文
+func (p *QueryTimeout) before(db *gorm.DB) {
+	parent := db.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	timeout := p.timeout
+	if override, ok := queryTimeoutOf(parent); ok {
+		timeout = override
+	}
+	if timeout <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	db.Statement.Context = ctx
+	db.InstanceSet(timeoutStateKey, timeoutState{parent: parent, cancel: cancel})
+}
+
+// after 释放超时定时器并恢复原上下文，同一个链式查询的后续语句不受已结束的上下文影响
+func (p *QueryTimeout) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(timeoutStateKey)
+	if !ok {
+		return
+	}
+	state, ok := value.(timeoutState)
+	if !ok || state.cancel == nil {
+		return
+	}
+	state.cancel()
+	db.Statement.Context = state.parent
+	db.InstanceSet(timeoutStateKey, timeoutState{})
+}
@@ -0,0 +1,37 @@
+package health
+
+import (
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// GormCheck 数据库连接检查
+func GormCheck(db *gorm.DB) CheckFunc {
+	return func(ctx context.Context) error {
+		if db == nil {
+			return fmt.Errorf("数据库连接未初始化")
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("获取数据库实例失败: %w", err)
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// Pinger 支持 Ping 的组件，如 cache.CacheInterface
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck 通过 Ping 检查组件连接
+func PingCheck(p Pinger) CheckFunc {
+	return func(ctx context.Context) error {
+		if p == nil {
+			return fmt.Errorf("组件未初始化")
+		}
+		return p.Ping(ctx)
+	}
+}
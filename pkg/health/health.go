@@ -0,0 +1,242 @@
+// Package health 提供可插拔的健康检查注册表，支持存活、就绪和启动探针
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 检查状态
+const (
+	StatusUp       = "up"       // 全部检查通过
+	StatusDegraded = "degraded" // 仅非关键检查失败，仍可接收流量
+	StatusDown     = "down"     // 关键检查失败或服务正在关闭
+)
+
+// 默认检查超时
+const defaultTimeout = 2 * time.Second
+
+// CheckFunc 健康检查函数，返回错误表示不健康，需遵守 ctx 的超时
+type CheckFunc func(ctx context.Context) error
+
+// Check 健康检查定义
+type Check struct {
+	Name     string        // 名称，唯一
+	Check    CheckFunc     // 检查函数
+	Timeout  time.Duration // 超时时间，默认 2s
+	Critical bool          // 是否关键依赖，关键检查失败时就绪探针失败，非关键失败只标记为 degraded
+	CacheTTL time.Duration // 结果缓存时间，0 表示每次都执行检查
+	Liveness bool          // 是否参与存活探针，只应用于进程自身状态（如死锁检测），不应检查外部依赖
+}
+
+// CheckResult 单项检查结果
+type CheckResult struct {
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Critical  bool      `json:"critical"`
+	Duration  string    `json:"duration"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached,omitempty"`
+}
+
+// Report 探针结果
+type Report struct {
+	Status    string                 `json:"status"`
+	Reason    string                 `json:"reason,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+	Checks    map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// Healthy 探针是否通过（degraded 视为通过）
+func (r Report) Healthy() bool {
+	return r.Status != StatusDown
+}
+
+// Registry 健康检查注册表
+type Registry struct {
+	mu      sync.RWMutex
+	checks  map[string]Check
+	cacheMu sync.Mutex
+	cache   map[string]CheckResult
+
+	started      atomic.Bool // 启动流程是否完成
+	startupOK    atomic.Bool // 启动探针是否已通过，通过后不再重复检查
+	shuttingDown atomic.Bool // 是否正在关闭
+}
+
+// NewRegistry 创建健康检查注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		checks: make(map[string]Check),
+		cache:  make(map[string]CheckResult),
+	}
+}
+
+// Register 注册健康检查，名称重复时返回错误
+func (r *Registry) Register(check Check) error {
+	if check.Name == "" || check.Check == nil {
+		return fmt.Errorf("健康检查名称和检查函数不能为空")
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = defaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.checks[check.Name]; exists {
+		return fmt.Errorf("健康检查 %s 已存在", check.Name)
+	}
+	r.checks[check.Name] = check
+	return nil
+}
+
+// Unregister 注销健康检查
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	delete(r.checks, name)
+	r.mu.Unlock()
+
+	r.cacheMu.Lock()
+	delete(r.cache, name)
+	r.cacheMu.Unlock()
+}
+
+// MarkStarted 标记启动流程完成，此后启动探针才会执行检查
+func (r *Registry) MarkStarted() {
+	r.started.Store(true)
+}
+
+// SetShuttingDown 标记服务正在关闭，就绪探针立即失败以便负载均衡摘除流量
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown 是否正在关闭
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Live 存活探针，只执行标记为 Liveness 的检查
+func (r *Registry) Live(ctx context.Context) Report {
+	return r.run(ctx, func(c Check) bool { return c.Liveness })
+}
+
+// Ready 就绪探针，执行全部检查；关闭过程中直接返回失败
+func (r *Registry) Ready(ctx context.Context) Report {
+	if r.shuttingDown.Load() {
+		return Report{Status: StatusDown, Reason: "shutting down", Timestamp: time.Now()}
+	}
+	return r.run(ctx, func(Check) bool { return true })
+}
+
+// Startup 启动探针，启动流程完成且全部检查通过后保持成功
+func (r *Registry) Startup(ctx context.Context) Report {
+	if r.startupOK.Load() {
+		return Report{Status: StatusUp, Timestamp: time.Now()}
+	}
+	if !r.started.Load() {
+		return Report{Status: StatusDown, Reason: "starting", Timestamp: time.Now()}
+	}
+
+	report := r.run(ctx, func(Check) bool { return true })
+	if report.Healthy() {
+		r.startupOK.Store(true)
+	}
+	return report
+}
+
+// run 并发执行满足条件的检查并汇总结果
+func (r *Registry) run(ctx context.Context, include func(Check) bool) Report {
+	r.mu.RLock()
+	checks := make([]Check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if include(c) {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+	sort.Slice(checks, func(i, j int) bool { return checks[i].Name < checks[j].Name })
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c Check) {
+			defer wg.Done()
+			results[i] = r.execute(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Timestamp: time.Now(), Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.Name] = result
+		if result.Status == StatusUp {
+			continue
+		}
+		if c.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusUp {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// execute 执行单项检查，结果未过期时直接返回缓存
+func (r *Registry) execute(ctx context.Context, c Check) CheckResult {
+	if c.CacheTTL > 0 {
+		r.cacheMu.Lock()
+		cached, ok := r.cache[c.Name]
+		r.cacheMu.Unlock()
+		if ok && time.Since(cached.CheckedAt) < c.CacheTTL {
+			cached.Cached = true
+			return cached
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				errCh <- fmt.Errorf("检查发生panic: %v", p)
+			}
+		}()
+		errCh <- c.Check(ctx)
+	}()
+
+	// 检查函数未遵守 ctx 时也能按超时返回
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = fmt.Errorf("检查超时(%s)", c.Timeout)
+	}
+
+	result := CheckResult{
+		Status:    StatusUp,
+		Critical:  c.Critical,
+		Duration:  time.Since(start).String(),
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	if c.CacheTTL > 0 {
+		r.cacheMu.Lock()
+		r.cache[c.Name] = result
+		r.cacheMu.Unlock()
+	}
+	return result
+}
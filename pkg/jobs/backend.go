@@ -0,0 +1,254 @@
+package jobs
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Backend 作业队列存储
+//
+// 每个队列由四部分组成：就绪队列、延迟队列（按执行时间排序）、处理中列表（带占用期限）和死信队列。
+// Ack、Retry 和 Kill 只处理仍被占用的作业，占用已过期并被其他进程接管时返回 ErrLeaseLost。
+type Backend interface {
+	// Enqueue 放入作业，at 晚于当前时间时放入延迟队列
+	Enqueue(ctx context.Context, job *Job, at time.Time) error
+	// Reserve 取出一个就绪的作业并占用 visibility 时长；wait 大于0时最多等待 wait，没有作业时返回 nil
+	Reserve(ctx context.Context, queue string, visibility, wait time.Duration) (*Job, error)
+	// Ack 作业执行成功，从处理中列表删除
+	Ack(ctx context.Context, job *Job) error
+	// Retry 将处理中的作业放回延迟队列，在 at 时重新执行
+	Retry(ctx context.Context, job *Job, at time.Time) error
+	// Kill 将处理中的作业移入死信队列
+	Kill(ctx context.Context, job *Job) error
+	// Promote 将到期的延迟作业移入就绪队列，返回移动的数量
+	Promote(ctx context.Context, queue string, now time.Time) (int, error)
+	// Expired 返回占用已过期的作业，由调用方决定重试或进入死信
+	Expired(ctx context.Context, queue string, now time.Time, limit int) ([]*Job, error)
+	// Dead 返回死信队列中的作业，最新的在前
+	Dead(ctx context.Context, queue string, limit int) ([]*Job, error)
+	// Stats 返回队列中各状态的作业数
+	Stats(ctx context.Context, queue string) (Stats, error)
+}
+
+// MemoryBackend 内存作业队列，只在单个进程内有效，用于开发和测试
+type MemoryBackend struct {
+	mu        sync.Mutex
+	queues    map[string]*memoryQueue
+	signal    chan struct{} // 放入作业时关闭并替换，唤醒等待中的 Reserve
+	seq       uint64
+	deadLimit int
+}
+
+// memoryQueue 内存队列
+type memoryQueue struct {
+	ready      []*Job
+	delayed    []memoryDelayed
+	processing map[string]memoryLease
+	dead       []*Job
+}
+
+// memoryDelayed 延迟作业
+type memoryDelayed struct {
+	job *Job
+	at  time.Time
+}
+
+// memoryLease 处理中作业的占用
+type memoryLease struct {
+	job      *Job
+	deadline time.Time
+}
+
+// NewMemoryBackend 创建内存作业队列，deadLetterLimit 为每个队列保留的死信数量，0 使用默认值
+func NewMemoryBackend(deadLetterLimit int) *MemoryBackend {
+	if deadLetterLimit <= 0 {
+		deadLetterLimit = DefaultConfig().DeadLetterLimit
+	}
+	return &MemoryBackend{
+		queues:    make(map[string]*memoryQueue),
+		signal:    make(chan struct{}),
+		deadLimit: deadLetterLimit,
+	}
+}
+
+// queue 获取队列，不存在时创建，调用方需持有锁
+func (b *MemoryBackend) queue(name string) *memoryQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{processing: make(map[string]memoryLease)}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// notify 唤醒等待中的 Reserve，调用方需持有锁
+func (b *MemoryBackend) notify() {
+	close(b.signal)
+	b.signal = make(chan struct{})
+}
+
+// Enqueue 放入作业
+func (b *MemoryBackend) Enqueue(_ context.Context, job *Job, at time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.push(b.queue(job.Queue), job.clone(), at)
+	return nil
+}
+
+// push 放入就绪队列或延迟队列，调用方需持有锁
+func (b *MemoryBackend) push(q *memoryQueue, job *Job, at time.Time) {
+	job.token = ""
+	if at.After(time.Now()) {
+		q.delayed = append(q.delayed, memoryDelayed{job: job, at: at})
+		sort.SliceStable(q.delayed, func(i, j int) bool { return q.delayed[i].at.Before(q.delayed[j].at) })
+		return
+	}
+	q.ready = append(q.ready, job)
+	b.notify()
+}
+
+// Reserve 取出一个就绪的作业
+func (b *MemoryBackend) Reserve(ctx context.Context, queue string, visibility, wait time.Duration) (*Job, error) {
+	var timer <-chan time.Time
+	if wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		timer = t.C
+	}
+	for {
+		b.mu.Lock()
+		q := b.queue(queue)
+		if len(q.ready) > 0 {
+			job := q.ready[0]
+			q.ready = q.ready[1:]
+			b.seq++
+			job.token = strconv.FormatUint(b.seq, 10)
+			q.processing[job.ID] = memoryLease{job: job, deadline: time.Now().Add(visibility)}
+			b.mu.Unlock()
+			return job.clone(), nil
+		}
+		signal := b.signal
+		b.mu.Unlock()
+
+		if timer == nil {
+			return nil, nil
+		}
+		select {
+		case <-signal:
+		case <-timer:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// release 校验占用并从处理中列表删除，调用方需持有锁
+func (b *MemoryBackend) release(q *memoryQueue, job *Job) error {
+	lease, ok := q.processing[job.ID]
+	if !ok || lease.job.token != job.token {
+		return ErrLeaseLost
+	}
+	delete(q.processing, job.ID)
+	return nil
+}
+
+// Ack 作业执行成功
+func (b *MemoryBackend) Ack(_ context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.release(b.queue(job.Queue), job)
+}
+
+// Retry 将处理中的作业放回延迟队列
+func (b *MemoryBackend) Retry(_ context.Context, job *Job, at time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q := b.queue(job.Queue)
+	if err := b.release(q, job); err != nil {
+		return err
+	}
+	b.push(q, job.clone(), at)
+	return nil
+}
+
+// Kill 将处理中的作业移入死信队列
+func (b *MemoryBackend) Kill(_ context.Context, job *Job) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q := b.queue(job.Queue)
+	if err := b.release(q, job); err != nil {
+		return err
+	}
+	dead := job.clone()
+	dead.token = ""
+	q.dead = append([]*Job{dead}, q.dead...)
+	if len(q.dead) > b.deadLimit {
+		q.dead = q.dead[:b.deadLimit]
+	}
+	return nil
+}
+
+// Promote 将到期的延迟作业移入就绪队列
+func (b *MemoryBackend) Promote(_ context.Context, queue string, now time.Time) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q := b.queue(queue)
+	n := 0
+	for n < len(q.delayed) && !q.delayed[n].at.After(now) {
+		q.ready = append(q.ready, q.delayed[n].job)
+		n++
+	}
+	if n > 0 {
+		q.delayed = q.delayed[n:]
+		b.notify()
+	}
+	return n, nil
+}
+
+// Expired 返回占用已过期的作业
+func (b *MemoryBackend) Expired(_ context.Context, queue string, now time.Time, limit int) ([]*Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var expired []*Job
+	for _, lease := range b.queue(queue).processing {
+		if len(expired) >= limit {
+			break
+		}
+		if lease.deadline.Before(now) {
+			expired = append(expired, lease.job.clone())
+		}
+	}
+	return expired, nil
+}
+
+// Dead 返回死信队列中的作业
+func (b *MemoryBackend) Dead(_ context.Context, queue string, limit int) ([]*Job, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q := b.queue(queue)
+	if limit <= 0 || limit > len(q.dead) {
+		limit = len(q.dead)
+	}
+	dead := make([]*Job, limit)
+	for i := range dead {
+		dead[i] = q.dead[i].clone()
+	}
+	return dead, nil
+}
+
+// Stats 返回队列中各状态的作业数
+func (b *MemoryBackend) Stats(_ context.Context, queue string) (Stats, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	q := b.queue(queue)
+	return Stats{
+		Ready:      int64(len(q.ready)),
+		Delayed:    int64(len(q.delayed)),
+		Processing: int64(len(q.processing)),
+		Dead:       int64(len(q.dead)),
+	}, nil
+}
@@ -0,0 +1,102 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Client 作业提交客户端，只负责把作业放入队列，可以在不运行 Worker 的进程中使用
+type Client struct {
+	backend Backend
+	config  Config
+}
+
+// NewClient 创建作业提交客户端，未设置的配置项使用默认值
+func NewClient(backend Backend, config Config) *Client {
+	return &Client{backend: backend, config: config.withDefaults()}
+}
+
+// EnqueueOption 提交作业的选项
+type EnqueueOption func(*enqueueOptions)
+
+// enqueueOptions 提交作业的选项
+type enqueueOptions struct {
+	queue       string
+	at          time.Time
+	maxAttempts int
+}
+
+// Queue 指定作业所在的队列，默认为 DefaultQueue
+func Queue(name string) EnqueueOption {
+	return func(o *enqueueOptions) { o.queue = name }
+}
+
+// Delay 延迟 d 后执行
+func Delay(d time.Duration) EnqueueOption {
+	return func(o *enqueueOptions) { o.at = time.Now().Add(d) }
+}
+
+// At 在指定时间执行
+func At(t time.Time) EnqueueOption {
+	return func(o *enqueueOptions) { o.at = t }
+}
+
+// MaxAttempts 指定最大执行次数，默认使用配置的 max_attempts
+func MaxAttempts(n int) EnqueueOption {
+	return func(o *enqueueOptions) { o.maxAttempts = n }
+}
+
+// Enqueue 提交作业，payload 序列化为 JSON 后由注册了 jobType 的处理函数执行
+func (c *Client) Enqueue(ctx context.Context, jobType string, payload any, opts ...EnqueueOption) (*Job, error) {
+	options := enqueueOptions{queue: DefaultQueue, maxAttempts: c.config.MaxAttempts}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if _, ok := c.config.Queues[options.queue]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownQueue, options.queue)
+	}
+	if options.maxAttempts <= 0 {
+		options.maxAttempts = c.config.MaxAttempts
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化作业内容失败: %w", err)
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, fmt.Errorf("生成作业ID失败: %w", err)
+	}
+	job := &Job{
+		ID:          id,
+		Queue:       options.queue,
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: options.maxAttempts,
+		EnqueuedAt:  time.Now(),
+	}
+	if err := c.backend.Enqueue(ctx, job, options.at); err != nil {
+		return nil, fmt.Errorf("提交作业失败: %w", err)
+	}
+	return job, nil
+}
+
+// Stats 返回全部已配置队列的作业数
+func (c *Client) Stats(ctx context.Context) (map[string]Stats, error) {
+	stats := make(map[string]Stats, len(c.config.Queues))
+	for queue := range c.config.Queues {
+		s, err := c.backend.Stats(ctx, queue)
+		if err != nil {
+			return nil, fmt.Errorf("获取队列 %s 状态失败: %w", queue, err)
+		}
+		stats[queue] = s
+	}
+	return stats, nil
+}
+
+// Dead 返回队列中最近的死信
+func (c *Client) Dead(ctx context.Context, queue string, limit int) ([]*Job, error) {
+	return c.backend.Dead(ctx, queue, limit)
+}
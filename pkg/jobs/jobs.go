@@ -0,0 +1,159 @@
+// Package jobs 提供基于队列的后台作业
+//
+// 请求中不适合同步执行的工作（发送邮件、调用外部系统等）通过 Client.Enqueue 放入队列，
+// 由 Worker 在后台执行。作业按类型注册处理函数，处理函数返回错误时按退避时间重试，
+// 超过最大执行次数或返回 Permanent 错误后进入死信队列。
+//
+// 队列可靠性由 Backend 保证：取出的作业先移入处理中列表并记录占用期限（visibility timeout），
+// 处理成功后才删除；进程在处理过程中退出时，占用到期后作业重新入队，因此处理函数需要幂等。
+// 延迟执行和重试的作业保存在按执行时间排序的延迟队列中，到期后移入就绪队列。
+//
+// 生产环境使用 RedisBackend，测试使用 MemoryBackend。
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// DefaultQueue 未指定队列时使用的队列
+const DefaultQueue = "default"
+
+// 作业错误
+var (
+	ErrUnknownQueue = errors.New("作业队列未配置")
+	ErrLeaseLost    = errors.New("作业占用已过期")
+)
+
+// Config 后台作业配置
+type Config struct {
+	Backend           string         `mapstructure:"backend" yaml:"backend" validate:"omitempty,oneof=redis memory"` // 队列存储，redis 或 memory（仅用于开发和测试），默认 redis
+	Prefix            string         `mapstructure:"prefix" yaml:"prefix"`                                           // Redis 键前缀，默认 jobs
+	Embedded          bool           `mapstructure:"embedded" yaml:"embedded"`                                       // 是否在 server 进程内执行作业，默认由 go_demo worker 单独执行
+	Queues            map[string]int `mapstructure:"queues" yaml:"queues" validate:"dive,min=1"`                     // 队列名称及每个队列的并发数，默认 default: 4
+	PollInterval      time.Duration  `mapstructure:"poll_interval" yaml:"poll_interval" validate:"min=0"`            // 等待新作业和检查延迟作业的间隔，默认 1s
+	VisibilityTimeout time.Duration  `mapstructure:"visibility_timeout" yaml:"visibility_timeout" validate:"min=0"`  // 作业占用期限，也是单次执行的超时时间，默认 5m
+	MaxAttempts       int            `mapstructure:"max_attempts" yaml:"max_attempts" validate:"min=0"`              // 默认最大执行次数，超过后进入死信，默认 5
+	RetryBackoff      time.Duration  `mapstructure:"retry_backoff" yaml:"retry_backoff" validate:"min=0"`            // 首次重试的等待时间，之后每次翻倍，默认 5s
+	MaxRetryBackoff   time.Duration  `mapstructure:"max_retry_backoff" yaml:"max_retry_backoff" validate:"min=0"`    // 重试等待时间上限，默认 10m
+	DeadLetterLimit   int            `mapstructure:"dead_letter_limit" yaml:"dead_letter_limit" validate:"min=0"`    // 每个队列保留的死信数量，默认 1000
+	ShutdownTimeout   time.Duration  `mapstructure:"shutdown_timeout" yaml:"shutdown_timeout" validate:"min=0"`      // 关闭时等待正在执行的作业完成的时间，默认 30s
+}
+
+// DefaultConfig 默认后台作业配置
+func DefaultConfig() Config {
+	return Config{
+		Backend:           "redis",
+		Prefix:            "jobs",
+		Queues:            map[string]int{DefaultQueue: 4},
+		PollInterval:      time.Second,
+		VisibilityTimeout: 5 * time.Minute,
+		MaxAttempts:       5,
+		RetryBackoff:      5 * time.Second,
+		MaxRetryBackoff:   10 * time.Minute,
+		DeadLetterLimit:   1000,
+		ShutdownTimeout:   30 * time.Second,
+	}
+}
+
+// withDefaults 未设置的配置项使用默认值
+func (c Config) withDefaults() Config {
+	defaults := DefaultConfig()
+	if c.Backend == "" {
+		c.Backend = defaults.Backend
+	}
+	if c.Prefix == "" {
+		c.Prefix = defaults.Prefix
+	}
+	if len(c.Queues) == 0 {
+		c.Queues = defaults.Queues
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaults.PollInterval
+	}
+	if c.VisibilityTimeout <= 0 {
+		c.VisibilityTimeout = defaults.VisibilityTimeout
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = defaults.MaxAttempts
+	}
+	if c.MaxRetryBackoff <= 0 {
+		c.MaxRetryBackoff = defaults.MaxRetryBackoff
+	}
+	if c.DeadLetterLimit <= 0 {
+		c.DeadLetterLimit = defaults.DeadLetterLimit
+	}
+	if c.ShutdownTimeout <= 0 {
+		c.ShutdownTimeout = defaults.ShutdownTimeout
+	}
+	return c
+}
+
+// Job 作业，以 JSON 保存在队列中
+type Job struct {
+	ID          string          `json:"id"`
+	Queue       string          `json:"queue"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`             // 已执行次数，处理函数中为本次是第几次执行
+	MaxAttempts int             `json:"max_attempts"`         // 最大执行次数
+	LastError   string          `json:"last_error,omitempty"` // 最近一次失败的原因
+	EnqueuedAt  time.Time       `json:"enqueued_at"`
+
+	// token 占用凭证，由 Backend 在取出作业时设置，确认和重试时用于校验占用是否仍然有效
+	token string
+}
+
+// Decode 将作业内容解码到 v
+func (j *Job) Decode(v any) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// clone 复制作业，不共享 Payload
+func (j *Job) clone() *Job {
+	c := *j
+	c.Payload = append(json.RawMessage(nil), j.Payload...)
+	return &c
+}
+
+// Stats 队列中各状态的作业数
+type Stats struct {
+	Ready      int64 `json:"ready"`      // 等待执行
+	Delayed    int64 `json:"delayed"`    // 延迟执行或等待重试
+	Processing int64 `json:"processing"` // 正在执行
+	Dead       int64 `json:"dead"`       // 死信
+}
+
+// permanentError 不再重试的错误
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent 包装处理函数返回的错误，作业不再重试，直接进入死信队列
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent 判断错误是否为 Permanent 错误
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// newJobID 生成随机的作业ID
+func newJobID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,279 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// RedisBackend 基于 Redis 的作业队列
+//
+// 每个队列使用以下键，作业以 JSON 保存，JSON 本身即为占用凭证：
+//
+//	<prefix>:<queue>:ready       LIST  就绪作业，LPUSH 放入，BRPOPLPUSH 取出
+//	<prefix>:<queue>:processing  LIST  处理中作业
+//	<prefix>:<queue>:leases      ZSET  处理中作业的占用期限（毫秒时间戳）
+//	<prefix>:<queue>:delayed     ZSET  延迟作业，分数为执行时间（毫秒时间戳）
+//	<prefix>:<queue>:dead        LIST  死信，最新的在前
+type RedisBackend struct {
+	client    *redis.Client
+	prefix    string
+	deadLimit int
+}
+
+// NewRedisBackend 创建基于 Redis 的作业队列
+func NewRedisBackend(client *redis.Client, prefix string, deadLetterLimit int) *RedisBackend {
+	defaults := DefaultConfig()
+	if prefix == "" {
+		prefix = defaults.Prefix
+	}
+	if deadLetterLimit <= 0 {
+		deadLetterLimit = defaults.DeadLetterLimit
+	}
+	return &RedisBackend{client: client, prefix: prefix, deadLimit: deadLetterLimit}
+}
+
+// redisKeys 队列使用的键
+type redisKeys struct {
+	ready, processing, leases, delayed, dead string
+}
+
+// keys 返回队列使用的键
+func (b *RedisBackend) keys(queue string) redisKeys {
+	base := b.prefix + ":" + queue + ":"
+	return redisKeys{
+		ready:      base + "ready",
+		processing: base + "processing",
+		leases:     base + "leases",
+		delayed:    base + "delayed",
+		dead:       base + "dead",
+	}
+}
+
+// 原子操作脚本；占用记录不存在时返回0，表示占用已过期并被接管
+var (
+	// ackScript KEYS: processing, leases  ARGV: 占用凭证
+	ackScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[2], ARGV[1]) == 0 then return 0 end
+redis.call('LREM', KEYS[1], 1, ARGV[1])
+return 1`)
+
+	// retryScript KEYS: processing, leases, delayed  ARGV: 占用凭证, 新的作业内容, 执行时间
+	retryScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[2], ARGV[1]) == 0 then return 0 end
+redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('ZADD', KEYS[3], ARGV[3], ARGV[2])
+return 1`)
+
+	// killScript KEYS: processing, leases, dead  ARGV: 占用凭证, 新的作业内容, 保留数量
+	killScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[2], ARGV[1]) == 0 then return 0 end
+redis.call('LREM', KEYS[1], 1, ARGV[1])
+redis.call('LPUSH', KEYS[3], ARGV[2])
+redis.call('LTRIM', KEYS[3], 0, tonumber(ARGV[3]) - 1)
+return 1`)
+
+	// promoteScript KEYS: delayed, ready  ARGV: 当前时间, 单次数量上限
+	promoteScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, member in ipairs(due) do
+  redis.call('ZREM', KEYS[1], member)
+  redis.call('LPUSH', KEYS[2], member)
+end
+return #due`)
+
+	// adoptScript KEYS: processing, leases  ARGV: 占用期限
+	// 取出作业后、记录占用前进程退出时，处理中的作业没有占用记录，为其补充占用期限，到期后按过期处理；
+	// 正常取出的作业随后写入的占用期限会覆盖这里的值
+	adoptScript = redis.NewScript(`
+local n = 0
+for _, member in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+  n = n + redis.call('ZADD', KEYS[2], 'NX', ARGV[1], member)
+end
+return n`)
+)
+
+// promoteBatch 每次移动的延迟作业数量上限
+const promoteBatch = 100
+
+// Enqueue 放入作业
+func (b *RedisBackend) Enqueue(ctx context.Context, job *Job, at time.Time) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("序列化作业失败: %w", err)
+	}
+	keys := b.keys(job.Queue)
+	if at.After(time.Now()) {
+		return b.client.ZAdd(ctx, keys.delayed, &redis.Z{Score: float64(at.UnixMilli()), Member: string(raw)}).Err()
+	}
+	return b.client.LPush(ctx, keys.ready, raw).Err()
+}
+
+// Reserve 取出一个就绪的作业
+func (b *RedisBackend) Reserve(ctx context.Context, queue string, visibility, wait time.Duration) (*Job, error) {
+	keys := b.keys(queue)
+	var raw string
+	var err error
+	if wait > 0 {
+		raw, err = b.client.BRPopLPush(ctx, keys.ready, keys.processing, wait).Result()
+	} else {
+		raw, err = b.client.RPopLPush(ctx, keys.ready, keys.processing).Result()
+	}
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 占用记录写入失败时，作业留在处理中列表，由 Expired 补充占用期限后重新处理
+	deadline := time.Now().Add(visibility).UnixMilli()
+	if err := b.client.ZAdd(ctx, keys.leases, &redis.Z{Score: float64(deadline), Member: raw}).Err(); err != nil {
+		return nil, fmt.Errorf("记录作业占用失败: %w", err)
+	}
+
+	job, err := decodeJob(raw)
+	if err != nil {
+		// 无法解析的作业直接移入死信，保留原始内容
+		_ = b.moveToDead(ctx, keys, raw, raw)
+		return nil, err
+	}
+	return job, nil
+}
+
+// Ack 作业执行成功
+func (b *RedisBackend) Ack(ctx context.Context, job *Job) error {
+	keys := b.keys(job.Queue)
+	n, err := ackScript.Run(ctx, b.client, []string{keys.processing, keys.leases}, job.token).Int()
+	return leaseResult(n, err)
+}
+
+// Retry 将处理中的作业放回延迟队列
+func (b *RedisBackend) Retry(ctx context.Context, job *Job, at time.Time) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("序列化作业失败: %w", err)
+	}
+	keys := b.keys(job.Queue)
+	n, err := retryScript.Run(ctx, b.client, []string{keys.processing, keys.leases, keys.delayed},
+		job.token, raw, at.UnixMilli()).Int()
+	return leaseResult(n, err)
+}
+
+// Kill 将处理中的作业移入死信队列
+func (b *RedisBackend) Kill(ctx context.Context, job *Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("序列化作业失败: %w", err)
+	}
+	return b.moveToDead(ctx, b.keys(job.Queue), job.token, string(raw))
+}
+
+// moveToDead 将处理中的作业移入死信队列
+func (b *RedisBackend) moveToDead(ctx context.Context, keys redisKeys, token, raw string) error {
+	n, err := killScript.Run(ctx, b.client, []string{keys.processing, keys.leases, keys.dead},
+		token, raw, b.deadLimit).Int()
+	return leaseResult(n, err)
+}
+
+// Promote 将到期的延迟作业移入就绪队列
+func (b *RedisBackend) Promote(ctx context.Context, queue string, now time.Time) (int, error) {
+	keys := b.keys(queue)
+	return promoteScript.Run(ctx, b.client, []string{keys.delayed, keys.ready}, now.UnixMilli(), promoteBatch).Int()
+}
+
+// Expired 返回占用已过期的作业
+func (b *RedisBackend) Expired(ctx context.Context, queue string, now time.Time, limit int) ([]*Job, error) {
+	keys := b.keys(queue)
+	// 没有占用记录的作业在1分钟后按过期处理
+	grace := now.Add(time.Minute).UnixMilli()
+	if err := adoptScript.Run(ctx, b.client, []string{keys.processing, keys.leases}, grace).Err(); err != nil {
+		return nil, fmt.Errorf("检查处理中作业失败: %w", err)
+	}
+
+	raws, err := b.client.ZRangeByScore(ctx, keys.leases, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(now.UnixMilli(), 10),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	expired := make([]*Job, 0, len(raws))
+	for _, raw := range raws {
+		job, err := decodeJob(raw)
+		if err != nil {
+			_ = b.moveToDead(ctx, keys, raw, raw)
+			continue
+		}
+		expired = append(expired, job)
+	}
+	return expired, nil
+}
+
+// Dead 返回死信队列中的作业
+func (b *RedisBackend) Dead(ctx context.Context, queue string, limit int) ([]*Job, error) {
+	stop := int64(limit) - 1
+	if limit <= 0 {
+		stop = -1
+	}
+	raws, err := b.client.LRange(ctx, b.keys(queue).dead, 0, stop).Result()
+	if err != nil {
+		return nil, err
+	}
+	dead := make([]*Job, 0, len(raws))
+	for _, raw := range raws {
+		job, err := decodeJob(raw)
+		if err != nil {
+			job = &Job{Queue: queue, LastError: "无法解析的作业: " + err.Error()}
+		}
+		job.token = ""
+		dead = append(dead, job)
+	}
+	return dead, nil
+}
+
+// Stats 返回队列中各状态的作业数
+func (b *RedisBackend) Stats(ctx context.Context, queue string) (Stats, error) {
+	keys := b.keys(queue)
+	pipe := b.client.Pipeline()
+	ready := pipe.LLen(ctx, keys.ready)
+	delayed := pipe.ZCard(ctx, keys.delayed)
+	processing := pipe.LLen(ctx, keys.processing)
+	dead := pipe.LLen(ctx, keys.dead)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Stats{}, err
+	}
+	return Stats{
+		Ready:      ready.Val(),
+		Delayed:    delayed.Val(),
+		Processing: processing.Val(),
+		Dead:       dead.Val(),
+	}, nil
+}
+
+// decodeJob 解析作业，原始内容作为占用凭证
+func decodeJob(raw string) (*Job, error) {
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		return nil, fmt.Errorf("解析作业失败: %w", err)
+	}
+	job.token = raw
+	return &job, nil
+}
+
+// leaseResult 将脚本返回值转换为错误
+func leaseResult(n int, err error) error {
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_demo/pkg/logger"
+	"go_demo/pkg/metrics"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// jobsLoggerName 后台作业日志使用的logger名称
+const jobsLoggerName = "jobs"
+
+func init() {
+	logger.RegisterNames(jobsLoggerName)
+}
+
+// expiredBatch 每次检查的占用过期作业数量上限
+const expiredBatch = 100
+
+// interruptWait Stop 超时中断作业后，等待作业放回队列的时间
+const interruptWait = time.Second
+
+// Handler 作业处理函数，返回错误时按退避时间重试，返回 Permanent 错误时直接进入死信
+type Handler func(ctx context.Context, job *Job) error
+
+// jobContextKey 上下文中保存当前作业的键
+type jobContextKey struct{}
+
+// FromContext 返回处理函数正在执行的作业
+func FromContext(ctx context.Context) (*Job, bool) {
+	job, ok := ctx.Value(jobContextKey{}).(*Job)
+	return job, ok
+}
+
+// Worker 后台作业执行器，每个队列按配置的并发数执行作业
+type Worker struct {
+	backend Backend
+	config  Config
+	queues  map[string]int
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	runMu  sync.Mutex
+	stop   chan struct{}
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+// NewWorker 创建后台作业执行器，未设置的配置项使用默认值
+func NewWorker(backend Backend, config Config) *Worker {
+	config = config.withDefaults()
+	return &Worker{
+		backend:  backend,
+		config:   config,
+		queues:   config.Queues,
+		handlers: make(map[string]Handler),
+	}
+}
+
+// Register 注册作业类型的处理函数，重复注册时覆盖
+func (w *Worker) Register(jobType string, handler Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.handlers[jobType] = handler
+}
+
+// Handle 注册类型化的处理函数，作业内容解码为 T 后传入；解码失败的作业直接进入死信
+func Handle[T any](w *Worker, jobType string, fn func(ctx context.Context, payload T) error) {
+	w.Register(jobType, func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := job.Decode(&payload); err != nil {
+			return Permanent(fmt.Errorf("解析作业内容失败: %w", err))
+		}
+		return fn(ctx, payload)
+	})
+}
+
+// Types 返回已注册的作业类型
+func (w *Worker) Types() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	types := make([]string, 0, len(w.handlers))
+	for t := range w.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// LimitQueues 只执行指定队列中的作业，需要在 Start 之前调用
+func (w *Worker) LimitQueues(names ...string) error {
+	queues := make(map[string]int, len(names))
+	for _, name := range names {
+		concurrency, ok := w.config.Queues[name]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownQueue, name)
+		}
+		queues[name] = concurrency
+	}
+	w.queues = queues
+	return nil
+}
+
+// Queues 返回执行的队列及其并发数
+func (w *Worker) Queues() map[string]int {
+	queues := make(map[string]int, len(w.queues))
+	for name, concurrency := range w.queues {
+		queues[name] = concurrency
+	}
+	return queues
+}
+
+// Start 在后台执行作业，重复调用无效
+func (w *Worker) Start() {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+	if w.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	// ctx 只在 Stop 超时后取消，用于中断仍在执行的作业
+	ctx, cancel := context.WithCancel(context.Background())
+	w.stop, w.done, w.cancel = stop, done, cancel
+
+	var wg sync.WaitGroup
+	for queue, concurrency := range w.queues {
+		if concurrency <= 0 {
+			concurrency = 1
+		}
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func(queue string) {
+				defer wg.Done()
+				w.consume(ctx, queue, stop)
+			}(queue)
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		w.maintain(ctx, stop)
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+}
+
+// Stop 停止取出新作业并等待正在执行的作业完成；ctx 超时后中断剩余作业并放回队列
+func (w *Worker) Stop(ctx context.Context) error {
+	w.runMu.Lock()
+	stop, done, cancel := w.stop, w.done, w.cancel
+	w.stop, w.done, w.cancel = nil, nil, nil
+	w.runMu.Unlock()
+	if stop == nil {
+		return nil
+	}
+
+	close(stop)
+	select {
+	case <-done:
+		cancel()
+		return nil
+	case <-ctx.Done():
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(interruptWait):
+	}
+	return ctx.Err()
+}
+
+// consume 从队列取出并执行作业，直到停止
+func (w *Worker) consume(ctx context.Context, queue string, stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		job, err := w.backend.Reserve(ctx, queue, w.config.VisibilityTimeout, w.config.PollInterval)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			jobsLogger().Error("取出作业失败", logger.String("queue", queue), logger.Err(err))
+			select {
+			case <-stop:
+				return
+			case <-time.After(w.config.PollInterval):
+			}
+			continue
+		}
+		if job != nil {
+			w.process(ctx, job)
+		}
+	}
+}
+
+// maintain 定期将到期的延迟作业移入就绪队列，并重新处理占用过期的作业
+func (w *Worker) maintain(ctx context.Context, stop chan struct{}) {
+	ticker := time.NewTicker(w.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := w.Maintain(ctx); err != nil && ctx.Err() == nil {
+			jobsLogger().Error("维护作业队列失败", logger.Err(err))
+		}
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Maintain 对每个队列执行一次维护：移动到期的延迟作业，重试或放弃占用过期的作业
+func (w *Worker) Maintain(ctx context.Context) error {
+	var errs []error
+	now := time.Now()
+	for queue := range w.queues {
+		if _, err := w.backend.Promote(ctx, queue, now); err != nil {
+			errs = append(errs, fmt.Errorf("移动队列 %s 的延迟作业失败: %w", queue, err))
+		}
+		expired, err := w.backend.Expired(ctx, queue, now, expiredBatch)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("检查队列 %s 的过期作业失败: %w", queue, err))
+			continue
+		}
+		for _, job := range expired {
+			job.Attempts++
+			w.finish(ctx, job, errors.New("执行超时或进程退出，作业占用已过期"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// process 执行一个作业并保存结果
+func (w *Worker) process(ctx context.Context, job *Job) {
+	job.Attempts++
+
+	w.mu.RLock()
+	handler, ok := w.handlers[job.Type]
+	w.mu.RUnlock()
+
+	var err error
+	start := time.Now()
+	if ok {
+		err = w.call(ctx, handler, job)
+	} else {
+		err = Permanent(fmt.Errorf("未注册的作业类型: %s", job.Type))
+	}
+	metrics.JobDuration.WithLabelValues(job.Queue, job.Type).Observe(time.Since(start).Seconds())
+
+	// Stop 超时中断的作业不计入执行次数，立即放回队列
+	if err != nil && ctx.Err() != nil {
+		job.Attempts--
+		if err := w.backend.Retry(context.WithoutCancel(ctx), job, time.Now()); err != nil {
+			jobsLogger().Error("放回被中断的作业失败", append(jobFields(job), logger.Err(err))...)
+			return
+		}
+		jobsLogger().Warn("作业被中断，已放回队列", jobFields(job)...)
+		return
+	}
+	w.finish(ctx, job, err)
+}
+
+// call 调用处理函数，超时时间为占用期限，处理函数 panic 时返回错误
+func (w *Worker) call(ctx context.Context, handler Handler, job *Job) (err error) {
+	ctx, cancel := context.WithTimeout(context.WithValue(ctx, jobContextKey{}, job), w.config.VisibilityTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("作业处理函数 panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// finish 根据执行结果确认、重试作业或将作业移入死信
+func (w *Worker) finish(ctx context.Context, job *Job, err error) {
+	// 作业已执行，即使正在停止也要保存结果
+	ctx = context.WithoutCancel(ctx)
+	maxAttempts := job.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = w.config.MaxAttempts
+	}
+
+	fields := jobFields(job)
+	result := metrics.ResultSuccess
+	var saveErr error
+	switch {
+	case err == nil:
+		saveErr = w.backend.Ack(ctx, job)
+		jobsLogger().Debug("作业执行成功", fields...)
+	case IsPermanent(err) || job.Attempts >= maxAttempts:
+		job.LastError = err.Error()
+		saveErr = w.backend.Kill(ctx, job)
+		result = metrics.ResultDead
+		jobsLogger().Error("作业执行失败，已进入死信", append(fields, logger.Err(err))...)
+	default:
+		job.LastError = err.Error()
+		next := time.Now().Add(w.backoff(job.Attempts))
+		saveErr = w.backend.Retry(ctx, job, next)
+		result = metrics.ResultFailed
+		jobsLogger().Warn("作业执行失败，稍后重试", append(fields, logger.Err(err), logger.Any("next_attempt_at", next))...)
+	}
+	metrics.JobsProcessedTotal.WithLabelValues(job.Queue, job.Type, result).Inc()
+
+	switch {
+	case errors.Is(saveErr, ErrLeaseLost):
+		jobsLogger().Warn("作业占用已过期，可能已被重新执行", fields...)
+	case saveErr != nil:
+		jobsLogger().Error("保存作业结果失败，占用到期后重新执行", append(fields, logger.Err(saveErr))...)
+	}
+}
+
+// backoff 第 attempt 次执行失败后的等待时间，指数增长，不超过 MaxRetryBackoff
+func (w *Worker) backoff(attempt int) time.Duration {
+	delay := w.config.RetryBackoff
+	for i := 1; i < attempt && delay < w.config.MaxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > w.config.MaxRetryBackoff {
+		delay = w.config.MaxRetryBackoff
+	}
+	return delay
+}
+
+// jobFields 作业的日志字段
+func jobFields(job *Job) []logger.Field {
+	return []logger.Field{
+		logger.String("job_id", job.ID),
+		logger.String("queue", job.Queue),
+		logger.String("type", job.Type),
+		logger.Int("attempt", job.Attempts),
+	}
+}
+
+// jobsLogger 获取后台作业的logger
+func jobsLogger() *zap.Logger {
+	return logger.Named(jobsLoggerName)
+}
@@ -0,0 +1,181 @@
+// Package lock 提供基于 Redis SET NX 的分布式锁
+//
+// 锁的值为随机令牌，只有持有者可以续期和释放；持有者进程退出后锁在过期时间后自动释放。
+// 持有时间可能超过过期时间的任务需要定期调用 Refresh 续期，续期失败说明锁已丢失，应停止任务。
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// ErrNotHeld 锁已过期或被其他持有者获取
+var ErrNotHeld = errors.New("锁未持有或已过期")
+
+// Locker 分布式锁
+type Locker interface {
+	// TryLock 尝试获取锁，锁已被占用时返回 false，不等待
+	TryLock(ctx context.Context, key string, ttl time.Duration) (Lock, bool, error)
+}
+
+// Lock 已获取的锁
+type Lock interface {
+	// Refresh 将锁的过期时间重置为 ttl
+	Refresh(ctx context.Context, ttl time.Duration) error
+	// Release 释放锁，锁已过期时返回 ErrNotHeld
+	Release(ctx context.Context) error
+}
+
+// newToken 生成锁令牌
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// RedisLocker 基于 Redis 的分布式锁
+type RedisLocker struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLocker 创建基于 Redis 的分布式锁，prefix 为键前缀
+func NewRedisLocker(client *redis.Client, prefix string) *RedisLocker {
+	return &RedisLocker{client: client, prefix: prefix}
+}
+
+var (
+	// refreshScript 令牌一致时续期
+	refreshScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// releaseScript 令牌一致时删除
+	releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0`)
+)
+
+// TryLock 使用 SET NX 获取锁
+func (l *RedisLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (Lock, bool, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, false, err
+	}
+	key = l.prefix + key
+	ok, err := l.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return &redisLock{client: l.client, key: key, token: token}, true, nil
+}
+
+// redisLock 已获取的 Redis 锁
+type redisLock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+// Refresh 续期
+func (l *redisLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	n, err := refreshScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// Release 释放
+func (l *redisLock) Release(ctx context.Context) error {
+	n, err := releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotHeld
+	}
+	return nil
+}
+
+// MemoryLocker 进程内的锁，用于开发和测试
+type MemoryLocker struct {
+	mu    sync.Mutex
+	locks map[string]memoryEntry
+}
+
+// memoryEntry 进程内锁的令牌和过期时间
+type memoryEntry struct {
+	token     string
+	expiresAt time.Time
+}
+
+// NewMemoryLocker 创建进程内的锁
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{locks: make(map[string]memoryEntry)}
+}
+
+// TryLock 获取锁
+func (l *MemoryLocker) TryLock(_ context.Context, key string, ttl time.Duration) (Lock, bool, error) {
+	token, err := newToken()
+	if err != nil {
+		return nil, false, err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if entry, ok := l.locks[key]; ok && time.Now().Before(entry.expiresAt) {
+		return nil, false, nil
+	}
+	l.locks[key] = memoryEntry{token: token, expiresAt: time.Now().Add(ttl)}
+	return &memoryLock{locker: l, key: key, token: token}, true, nil
+}
+
+// memoryLock 已获取的进程内锁
+type memoryLock struct {
+	locker *MemoryLocker
+	key    string
+	token  string
+}
+
+// held 令牌一致且未过期，调用方需持有锁
+func (l *memoryLock) held() bool {
+	entry, ok := l.locker.locks[l.key]
+	return ok && entry.token == l.token && time.Now().Before(entry.expiresAt)
+}
+
+// Refresh 续期
+func (l *memoryLock) Refresh(_ context.Context, ttl time.Duration) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	if !l.held() {
+		return ErrNotHeld
+	}
+	l.locker.locks[l.key] = memoryEntry{token: l.token, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Release 释放
+func (l *memoryLock) Release(_ context.Context) error {
+	l.locker.mu.Lock()
+	defer l.locker.mu.Unlock()
+	if !l.held() {
+		return ErrNotHeld
+	}
+	delete(l.locker.locks, l.key)
+	return nil
+}
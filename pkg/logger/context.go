@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// ctxFieldsKey 上下文中保存日志字段的键
+type ctxFieldsKey struct{}
+
+// WithContext 返回携带日志字段的新上下文
+// 同名字段会覆盖上下文中已有的值，常用字段如 trace_id、request_id、user_id、route 由中间件写入
+func WithContext(ctx context.Context, fields ...Field) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, ctxFieldsKey{}, contextFields(ctx, fields))
+}
+
+// ContextFields 获取上下文中携带的日志字段
+func ContextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(ctxFieldsKey{}).([]Field)
+	return fields
+}
+
+// FromContext 返回带有上下文字段的logger，ctx 为空时返回全局logger
+func FromContext(ctx context.Context) *zap.Logger {
+	// 全局logger为包装函数设置了 CallerSkip(1)，直接使用时需要抵消
+	l := GetLogger().WithOptions(zap.AddCallerSkip(-1))
+	if fields := ContextFields(ctx); len(fields) > 0 {
+		return l.With(fields...)
+	}
+	return l
+}
+
+// hasFieldKey 判断字段列表中是否包含指定键
+func hasFieldKey(fields []Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// contextFields 合并上下文字段与调用时传入的字段，同名字段以传入的为准
+func contextFields(ctx context.Context, fields []Field) []Field {
+	existing := ContextFields(ctx)
+	if len(existing) == 0 {
+		return fields
+	}
+	merged := make([]Field, 0, len(existing)+len(fields))
+	for _, f := range existing {
+		if !hasFieldKey(fields, f.Key) {
+			merged = append(merged, f)
+		}
+	}
+	return append(merged, fields...)
+}
+
+// 带上下文的日志记录函数
+func DebugCtx(ctx context.Context, msg string, fields ...Field) {
+	GetLogger().Debug(msg, contextFields(ctx, fields)...)
+}
+
+func InfoCtx(ctx context.Context, msg string, fields ...Field) {
+	GetLogger().Info(msg, contextFields(ctx, fields)...)
+}
+
+func WarnCtx(ctx context.Context, msg string, fields ...Field) {
+	GetLogger().Warn(msg, contextFields(ctx, fields)...)
+}
+
+func ErrorCtx(ctx context.Context, msg string, fields ...Field) {
+	GetLogger().Error(msg, contextFields(ctx, fields)...)
+}
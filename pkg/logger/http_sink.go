@@ -0,0 +1,113 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// httpSink 以 gzip 压缩的 NDJSON 批量发送日志，需要由 Vector、Fluent Bit、Logstash 等支持 NDJSON 的采集器接收
+// Loki push API 和 Elasticsearch _bulk 使用各自的格式，不能直接作为接收端
+type httpSink struct {
+	url        string
+	headers    map[string]string
+	client     *http.Client
+	maxRetries int
+}
+
+// newHTTPSink 创建HTTP批量输出
+func newHTTPSink(config SinkConfig) (*httpSink, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("无效的 url %q", config.URL)
+	}
+
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	maxRetries := config.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	} else if maxRetries == 0 {
+		maxRetries = 2
+	}
+
+	return &httpSink{
+		url:        config.URL,
+		headers:    config.Headers,
+		client:     &http.Client{Timeout: timeout},
+		maxRetries: maxRetries,
+	}, nil
+}
+
+// Send 发送一批日志，失败时按指数退避重试
+func (s *httpSink) Send(records []sinkRecord) error {
+	body, err := encodeNDJSON(records)
+	if err != nil {
+		return err
+	}
+
+	backoff := 200 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		err = s.post(body)
+		if err == nil || attempt >= s.maxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post 发送请求，非2xx状态码视为失败
+func (s *httpSink) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Content-Encoding", "gzip")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("接收端返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encodeNDJSON 将日志编码为 gzip 压缩的 NDJSON
+func encodeNDJSON(records []sinkRecord) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	for _, r := range records {
+		if _, err := gz.Write(r.line); err != nil {
+			return nil, fmt.Errorf("压缩日志失败: %w", err)
+		}
+		if _, err := gz.Write([]byte{'\n'}); err != nil {
+			return nil, fmt.Errorf("压缩日志失败: %w", err)
+		}
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("压缩日志失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Close 关闭HTTP输出
+func (s *httpSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
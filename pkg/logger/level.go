@@ -0,0 +1,330 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LevelStatus 当前日志级别状态
+type LevelStatus struct {
+	Level    string            `json:"level"`               // 全局日志级别
+	Levels   map[string]string `json:"levels"`              // 按logger名称设置的级别
+	RevertAt *time.Time        `json:"revert_at,omitempty"` // 运行时修改自动恢复的时间
+}
+
+// levelController 日志级别控制器
+// 全局级别由 zap.AtomicLevel 控制，按名称的级别匹配 logger 名称及其子名称（如 service 匹配 service.auth）
+type levelController struct {
+	mu          sync.RWMutex
+	atomic      zap.AtomicLevel
+	names       map[string]zapcore.Level
+	baseLevel   zapcore.Level            // 配置文件中的全局级别，用于恢复
+	baseNames   map[string]zapcore.Level // 配置文件中的按名称级别，用于恢复
+	revertTimer *time.Timer
+	revertAt    time.Time
+	generation  uint64 // 每次修改递增，避免已过期的定时器覆盖新的设置
+}
+
+var levels = &levelController{
+	atomic:    zap.NewAtomicLevelAt(zapcore.InfoLevel),
+	names:     map[string]zapcore.Level{},
+	baseLevel: zapcore.InfoLevel,
+	baseNames: map[string]zapcore.Level{},
+}
+
+// reset 使用配置初始化级别，并取消未完成的自动恢复
+func (l *levelController) reset(level zapcore.Level, names map[string]zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stopRevertLocked()
+	l.baseLevel = level
+	l.baseNames = names
+	l.atomic.SetLevel(level)
+	l.names = copyLevels(names)
+}
+
+// rebase 更新配置文件中的级别，没有待自动恢复的运行时修改时立即生效
+func (l *levelController) rebase(level zapcore.Level, names map[string]zapcore.Level) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.baseLevel = level
+	l.baseNames = names
+	if l.revertTimer == nil {
+		l.atomic.SetLevel(level)
+		l.names = copyLevels(names)
+	}
+}
+
+// set 运行时修改级别，ttl>0 时到期自动恢复为配置文件中的级别
+func (l *levelController) set(level *zapcore.Level, names map[string]zapcore.Level, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if level != nil {
+		l.atomic.SetLevel(*level)
+	}
+	if names != nil {
+		l.names = copyLevels(names)
+	}
+
+	l.stopRevertLocked()
+	if ttl > 0 {
+		generation := l.generation
+		l.revertAt = time.Now().Add(ttl)
+		l.revertTimer = time.AfterFunc(ttl, func() {
+			if l.revert(generation) {
+				Info("日志级别已自动恢复", String("level", l.atomic.Level().String()))
+			}
+		})
+	}
+}
+
+// revert 恢复为配置文件中的级别，generation 不匹配时说明期间已有新的修改，不做处理
+func (l *levelController) revert(generation uint64) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if generation != l.generation {
+		return false
+	}
+	l.stopRevertLocked()
+	l.atomic.SetLevel(l.baseLevel)
+	l.names = copyLevels(l.baseNames)
+	return true
+}
+
+// stopRevertLocked 取消自动恢复，调用方需持有锁
+func (l *levelController) stopRevertLocked() {
+	l.generation++
+	if l.revertTimer != nil {
+		l.revertTimer.Stop()
+		l.revertTimer = nil
+	}
+	l.revertAt = time.Time{}
+}
+
+// levelFor 获取指定logger名称生效的级别，取最长匹配的名称
+func (l *levelController) levelFor(name string) zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	level := l.atomic.Level()
+	matched := -1
+	for n, lvl := range l.names {
+		if (name == n || strings.HasPrefix(name, n+".")) && len(n) > matched {
+			level = lvl
+			matched = len(n)
+		}
+	}
+	return level
+}
+
+// minLevel 获取所有级别中的最低级别，用于快速判断
+func (l *levelController) minLevel() zapcore.Level {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	level := l.atomic.Level()
+	for _, lvl := range l.names {
+		if lvl < level {
+			level = lvl
+		}
+	}
+	return level
+}
+
+// status 获取当前级别状态
+func (l *levelController) status() LevelStatus {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	status := LevelStatus{
+		Level:  l.atomic.Level().String(),
+		Levels: make(map[string]string, len(l.names)),
+	}
+	for n, lvl := range l.names {
+		status.Levels[n] = lvl.String()
+	}
+	if !l.revertAt.IsZero() {
+		revertAt := l.revertAt
+		status.RevertAt = &revertAt
+	}
+	return status
+}
+
+// levelFilterCore 按logger名称过滤日志级别的core
+type levelFilterCore struct {
+	zapcore.Core
+	ctl *levelController
+}
+
+// newLevelFilterCore 包装core，由控制器决定日志是否输出
+func newLevelFilterCore(core zapcore.Core, ctl *levelController) zapcore.Core {
+	return &levelFilterCore{Core: core, ctl: ctl}
+}
+
+func (c *levelFilterCore) Enabled(level zapcore.Level) bool {
+	return level >= c.ctl.minLevel()
+}
+
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{Core: c.Core.With(fields), ctl: c.ctl}
+}
+
+func (c *levelFilterCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if entry.Level < c.ctl.levelFor(entry.LoggerName) {
+		return ce
+	}
+	return c.Core.Check(entry, ce)
+}
+
+// ParseLevel 解析日志级别字符串
+func ParseLevel(level string) (zapcore.Level, error) {
+	lvl, err := zapcore.ParseLevel(strings.TrimSpace(level))
+	if err != nil {
+		return lvl, fmt.Errorf("无效的日志级别 %q", level)
+	}
+	return lvl, nil
+}
+
+// knownNames 各组件登记的logger名称，按名称设置级别时只接受这些名称及其子名称
+var (
+	knownNames   = map[string]bool{}
+	knownNamesMu sync.RWMutex
+)
+
+// RegisterNames 登记组件使用的logger名称，通常在包的 init 中调用
+func RegisterNames(names ...string) {
+	knownNamesMu.Lock()
+	defer knownNamesMu.Unlock()
+	for _, n := range names {
+		knownNames[n] = true
+	}
+}
+
+// KnownNames 获取已登记的logger名称
+func KnownNames() []string {
+	knownNamesMu.RLock()
+	defer knownNamesMu.RUnlock()
+	names := make([]string, 0, len(knownNames))
+	for n := range knownNames {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isKnownName 判断名称是否为已登记的logger名称或其子名称
+func isKnownName(name string) bool {
+	knownNamesMu.RLock()
+	defer knownNamesMu.RUnlock()
+	for n := range knownNames {
+		if name == n || strings.HasPrefix(name, n+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLevels 解析按名称设置的日志级别，格式如 "repository=debug, webhook=info"
+// 名称必须是已登记的logger名称或其子名称，避免配置了不存在的名称却静默不生效
+func ParseLevels(spec string) (map[string]zapcore.Level, error) {
+	result := make(map[string]zapcore.Level)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, level, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("无效的日志级别配置 %q，格式应为 name=level", item)
+		}
+		lvl, err := ParseLevel(level)
+		if err != nil {
+			return nil, fmt.Errorf("logger %s: %w", name, err)
+		}
+		if !isKnownName(name) {
+			return nil, fmt.Errorf("未知的logger名称 %q，可用: %s", name, strings.Join(KnownNames(), ", "))
+		}
+		result[name] = lvl
+	}
+	return result, nil
+}
+
+// FormatLevels 将按名称设置的日志级别格式化为 "name=level" 列表
+func FormatLevels(levels map[string]string) string {
+	items := make([]string, 0, len(levels))
+	for name, level := range levels {
+		items = append(items, name+"="+level)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// copyLevels 复制级别映射
+func copyLevels(src map[string]zapcore.Level) map[string]zapcore.Level {
+	dst := make(map[string]zapcore.Level, len(src))
+	for n, lvl := range src {
+		dst[n] = lvl
+	}
+	return dst
+}
+
+// GetLevelStatus 获取当前日志级别状态
+func GetLevelStatus() LevelStatus {
+	return levels.status()
+}
+
+// SetLevel 运行时修改全局日志级别，ttl>0 时到期自动恢复
+func SetLevel(level string, ttl time.Duration) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levels.set(&lvl, nil, ttl)
+	return nil
+}
+
+// SetLevels 运行时修改日志级别
+// level 为空时不修改全局级别；names 为 nil 时不修改按名称的级别，为空映射时清除所有按名称的级别
+func SetLevels(level string, names map[string]zapcore.Level, ttl time.Duration) error {
+	var lvl *zapcore.Level
+	if level != "" {
+		parsed, err := ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		lvl = &parsed
+	}
+	levels.set(lvl, names, ttl)
+	return nil
+}
+
+// ResetLevels 立即恢复为配置文件中的日志级别
+func ResetLevels() {
+	levels.mu.RLock()
+	generation := levels.generation
+	levels.mu.RUnlock()
+	levels.revert(generation)
+}
+
+// ApplyConfigLevels 配置热更新时更新配置文件中的日志级别
+// 存在带 ttl 的运行时修改时保留该修改，到期后恢复为新的配置级别
+func ApplyConfigLevels(level, spec string) error {
+	names, err := ParseLevels(spec)
+	if err != nil {
+		return err
+	}
+	levels.rebase(parseLevel(level, zapcore.InfoLevel), names)
+	return nil
+}
+
+// Named 获取指定名称的logger，可通过按名称的日志级别单独控制
+func Named(name string) *zap.Logger {
+	return GetLogger().WithOptions(zap.AddCallerSkip(-1)).Named(name)
+}
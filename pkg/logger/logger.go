@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"fmt"
 	"os"
 	"time"
 
@@ -16,53 +17,41 @@ var (
 
 // LogConfig 日志配置
 type LogConfig struct {
-	Level      string `mapstructure:"level" yaml:"level"`               // 日志级别: debug, info, warn, error
-	Format     string `mapstructure:"format" yaml:"format"`             // 日志格式: json, console
-	OutputPath string `mapstructure:"output_path" yaml:"output_path"`   // 日志输出路径
-	ReqLogPath string `mapstructure:"req_log_path" yaml:"req_log_path"` // 请求日志路径
-	MaxSize    int    `mapstructure:"max_size" yaml:"max_size"`         // 单个日志文件最大大小(MB)
-	MaxBackup  int    `mapstructure:"max_backup" yaml:"max_backup"`     // 保留的旧日志文件数量
-	MaxAge     int    `mapstructure:"max_age" yaml:"max_age"`           // 保留的旧日志文件天数
-	Compress   bool   `mapstructure:"compress" yaml:"compress"`         // 是否压缩旧日志文件
+	Level      string `mapstructure:"level" yaml:"level" validate:"omitempty,loglevel"`             // 日志级别: debug, info, warn, error
+	Levels     string `mapstructure:"levels" yaml:"levels" validate:"omitempty,loglevels"`          // 按logger名称设置级别，如 "repository=debug,webhook=info"
+	Format     string `mapstructure:"format" yaml:"format" validate:"omitempty,oneof=json console"` // 日志格式: json, console
+	OutputPath string `mapstructure:"output_path" yaml:"output_path" validate:"required"`           // 日志输出路径
+	ReqLogPath string `mapstructure:"req_log_path" yaml:"req_log_path"`                             // 请求日志路径
+	MaxSize    int    `mapstructure:"max_size" yaml:"max_size" validate:"min=0"`                    // 单个日志文件最大大小(MB)
+	MaxBackup  int    `mapstructure:"max_backup" yaml:"max_backup" validate:"min=0"`                // 保留的旧日志文件数量
+	MaxAge     int    `mapstructure:"max_age" yaml:"max_age" validate:"min=0"`                      // 保留的旧日志文件天数
+	Compress   bool   `mapstructure:"compress" yaml:"compress"`                                     // 是否压缩旧日志文件
+
+	Redact    RedactConfig    `mapstructure:"redact" yaml:"redact"`               // 请求日志脱敏规则
+	ReqLog    ReqLogConfig    `mapstructure:"req_log" yaml:"req_log"`             // 请求日志输出配置
+	AccessLog AccessLogConfig `mapstructure:"access_log" yaml:"access_log"`       // 访问日志配置
+	Sinks     []SinkConfig    `mapstructure:"sinks" yaml:"sinks" validate:"dive"` // 附加日志输出，如 syslog、HTTP
 }
 
 // Init 初始化日志系统
 func Init(config LogConfig) error {
-	// 设置日志级别
-	level := zapcore.InfoLevel
-	switch config.Level {
-	case "debug":
-		level = zapcore.DebugLevel
-	case "info":
-		level = zapcore.InfoLevel
-	case "warn":
-		level = zapcore.WarnLevel
-	case "error":
-		level = zapcore.ErrorLevel
+	// 初始化脱敏规则
+	redactor, err := NewRedactor(config.Redact)
+	if err != nil {
+		return fmt.Errorf("初始化日志脱敏规则失败: %w", err)
 	}
+	SetRedactor(redactor)
 
-	// 设置编码器配置
-	encoderConfig := zapcore.EncoderConfig{
-		TimeKey:        "time",
-		LevelKey:       "level",
-		NameKey:        "logger",
-		CallerKey:      "caller",
-		MessageKey:     "msg",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.LowercaseLevelEncoder,
-		EncodeTime:     customTimeEncoder,
-		EncodeDuration: zapcore.SecondsDurationEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
+	// 设置日志级别，实际输出由级别控制器决定，支持运行时修改
+	level := parseLevel(config.Level, zapcore.InfoLevel)
+	names, err := ParseLevels(config.Levels)
+	if err != nil {
+		return fmt.Errorf("解析日志级别配置失败: %w", err)
 	}
+	levels.reset(level, names)
 
 	// 设置编码器
-	var encoder zapcore.Encoder
-	if config.Format == "json" {
-		encoder = zapcore.NewJSONEncoder(encoderConfig)
-	} else {
-		encoder = zapcore.NewConsoleEncoder(encoderConfig)
-	}
+	encoder := newEncoder(config.Format)
 
 	// 设置日志输出
 	var cores []zapcore.Core
@@ -71,7 +60,7 @@ func Init(config LogConfig) error {
 	consoleCore := zapcore.NewCore(
 		encoder,
 		zapcore.AddSync(os.Stdout),
-		level,
+		zapcore.DebugLevel,
 	)
 	cores = append(cores, consoleCore)
 
@@ -87,19 +76,66 @@ func Init(config LogConfig) error {
 		fileCore := zapcore.NewCore(
 			encoder,
 			zapcore.AddSync(fileWriter),
-			level,
+			zapcore.DebugLevel,
 		)
 		cores = append(cores, fileCore)
 	}
 
+	// 附加日志输出
+	sinkCores, err := initSinks(config.Sinks)
+	if err != nil {
+		return fmt.Errorf("初始化日志输出失败: %w", err)
+	}
+	cores = append(cores, sinkCores...)
+
 	// 创建logger
-	core := zapcore.NewTee(cores...)
+	core := newLevelFilterCore(zapcore.NewTee(cores...), levels)
 	globalLogger = zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1))
 	sugarLogger = globalLogger.Sugar()
 
+	// 初始化请求日志和访问日志
+	initRequestLoggers(config)
+
 	return nil
 }
 
+// parseLevel 解析日志级别，无法识别时返回默认级别
+func parseLevel(level string, defaultLevel zapcore.Level) zapcore.Level {
+	switch level {
+	case "debug":
+		return zapcore.DebugLevel
+	case "info":
+		return zapcore.InfoLevel
+	case "warn":
+		return zapcore.WarnLevel
+	case "error":
+		return zapcore.ErrorLevel
+	}
+	return defaultLevel
+}
+
+// newEncoder 根据日志格式创建编码器
+func newEncoder(format string) zapcore.Encoder {
+	encoderConfig := zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     customTimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+
+	if format == "json" {
+		return zapcore.NewJSONEncoder(encoderConfig)
+	}
+	return zapcore.NewConsoleEncoder(encoderConfig)
+}
+
 // customTimeEncoder 自定义时间格式
 func customTimeEncoder(t time.Time, enc zapcore.PrimitiveArrayEncoder) {
 	enc.AppendString(t.Format("2006-01-02 15:04:05.000"))
@@ -204,13 +240,16 @@ func Fatalf(template string, args ...interface{}) {
 	GetSugarLogger().Fatalf(template, args...)
 }
 
-// ReqInfo 记录请求信息日志
+// ReqInfo 记录请求信息日志，写入独立的请求日志文件（未配置 req_log_path 时写入全局日志）
 func ReqInfo(msg string, fields ...Field) {
-	GetLogger().Info(msg, fields...)
+	GetReqLogger().Info(msg, fields...)
 }
 
 // Sync 同步日志缓冲区
 func Sync() error {
+	if reqLogger != nil && reqLogger != globalLogger {
+		_ = reqLogger.Sync()
+	}
 	if globalLogger != nil {
 		return globalLogger.Sync()
 	}
@@ -0,0 +1,277 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// RedactMask 敏感字段替换后的占位符
+const RedactMask = "******"
+
+// RedactConfig 请求日志脱敏配置
+type RedactConfig struct {
+	Fields       []string        `mapstructure:"fields" yaml:"fields"`                                // 需要脱敏的JSON/表单/查询参数字段名（不区分大小写）
+	Headers      []string        `mapstructure:"headers" yaml:"headers"`                              // 需要脱敏的请求头名称（不区分大小写）
+	Patterns     []RedactPattern `mapstructure:"patterns" yaml:"patterns" validate:"dive"`            // 对字符串值做正则掩码，如手机号、邮箱
+	MaxBodySize  int             `mapstructure:"max_body_size" yaml:"max_body_size" validate:"min=0"` // 记录的请求/响应体最大字节数，0表示不限制
+	ContentTypes []string        `mapstructure:"content_types" yaml:"content_types"`                  // 允许记录请求/响应体的Content-Type
+}
+
+// RedactPattern 正则掩码规则
+type RedactPattern struct {
+	Name    string `mapstructure:"name" yaml:"name"`                              // 规则名称，仅用于说明
+	Regex   string `mapstructure:"regex" yaml:"regex" validate:"required,regexp"` // 匹配正则
+	Replace string `mapstructure:"replace" yaml:"replace"`                        // 替换模板，支持 $1 等分组引用
+}
+
+// DefaultRedactConfig 默认脱敏配置
+func DefaultRedactConfig() RedactConfig {
+	return RedactConfig{
+		Fields: []string{
+			"password", "old_password", "new_password",
+			"token", "access_token", "refresh_token",
+			"captcha", "secret", "secret_key",
+		},
+		Headers: []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"},
+		Patterns: []RedactPattern{
+			{Name: "mobile", Regex: `\b(1[3-9]\d)\d{4}(\d{4})\b`, Replace: "${1}****${2}"},
+			{Name: "email", Regex: `\b([A-Za-z0-9._%+-])[A-Za-z0-9._%+-]*(@[A-Za-z0-9.-]+\.[A-Za-z]{2,})\b`, Replace: "${1}***${2}"},
+		},
+		MaxBodySize:  4096,
+		ContentTypes: []string{"application/json", "application/x-www-form-urlencoded", "text/plain"},
+	}
+}
+
+// compiledPattern 编译后的正则掩码规则
+type compiledPattern struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+// Redactor 日志脱敏器
+type Redactor struct {
+	fields       map[string]bool
+	headers      map[string]bool
+	patterns     []compiledPattern
+	maxBodySize  int
+	contentTypes map[string]bool
+}
+
+// NewRedactor 根据配置创建脱敏器，配置为空时使用默认规则
+func NewRedactor(config RedactConfig) (*Redactor, error) {
+	defaults := DefaultRedactConfig()
+	if config.Fields == nil {
+		config.Fields = defaults.Fields
+	}
+	if config.Headers == nil {
+		config.Headers = defaults.Headers
+	}
+	if config.Patterns == nil {
+		config.Patterns = defaults.Patterns
+	}
+	if config.ContentTypes == nil {
+		config.ContentTypes = defaults.ContentTypes
+	}
+
+	r := &Redactor{
+		fields:       make(map[string]bool, len(config.Fields)),
+		headers:      make(map[string]bool, len(config.Headers)),
+		maxBodySize:  config.MaxBodySize,
+		contentTypes: make(map[string]bool, len(config.ContentTypes)),
+	}
+	for _, f := range config.Fields {
+		r.fields[strings.ToLower(f)] = true
+	}
+	for _, h := range config.Headers {
+		r.headers[http.CanonicalHeaderKey(h)] = true
+	}
+	for _, ct := range config.ContentTypes {
+		r.contentTypes[strings.ToLower(ct)] = true
+	}
+	for _, p := range config.Patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("脱敏规则 %s 正则无效: %w", p.Name, err)
+		}
+		r.patterns = append(r.patterns, compiledPattern{re: re, replace: p.Replace})
+	}
+	return r, nil
+}
+
+var (
+	globalRedactor *Redactor
+	redactorMu     sync.RWMutex
+)
+
+// SetRedactor 设置全局脱敏器
+func SetRedactor(r *Redactor) {
+	redactorMu.Lock()
+	defer redactorMu.Unlock()
+	globalRedactor = r
+}
+
+// GetRedactor 获取全局脱敏器，未设置时返回默认规则的脱敏器
+func GetRedactor() *Redactor {
+	redactorMu.RLock()
+	r := globalRedactor
+	redactorMu.RUnlock()
+	if r != nil {
+		return r
+	}
+
+	r, _ = NewRedactor(DefaultRedactConfig())
+	SetRedactor(r)
+	return r
+}
+
+// IsSensitiveField 判断字段名是否需要脱敏
+func (r *Redactor) IsSensitiveField(name string) bool {
+	return r.fields[strings.ToLower(name)]
+}
+
+// RedactString 对字符串应用正则掩码
+func (r *Redactor) RedactString(s string) string {
+	for _, p := range r.patterns {
+		s = p.re.ReplaceAllString(s, p.replace)
+	}
+	return s
+}
+
+// RedactHeaders 返回脱敏后的请求头副本
+func (r *Redactor) RedactHeaders(header http.Header) map[string][]string {
+	result := make(map[string][]string, len(header))
+	for name, values := range header {
+		if r.headers[http.CanonicalHeaderKey(name)] {
+			result[name] = []string{RedactMask}
+			continue
+		}
+		redacted := make([]string, len(values))
+		for i, v := range values {
+			redacted[i] = r.RedactString(v)
+		}
+		result[name] = redacted
+	}
+	return result
+}
+
+// RedactValues 返回脱敏后的查询参数/表单副本
+func (r *Redactor) RedactValues(values url.Values) map[string][]string {
+	result := make(map[string][]string, len(values))
+	for key, vals := range values {
+		redacted := make([]string, len(vals))
+		for i, v := range vals {
+			if r.IsSensitiveField(key) {
+				redacted[i] = RedactMask
+			} else {
+				redacted[i] = r.RedactString(v)
+			}
+		}
+		result[key] = redacted
+	}
+	return result
+}
+
+// AllowContentType 判断该Content-Type的内容是否允许记录
+func (r *Redactor) AllowContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0])
+	}
+	return r.contentTypes[strings.ToLower(mediaType)]
+}
+
+// RedactBody 对请求/响应体脱敏，并按配置裁剪长度
+// 不在白名单内的Content-Type只记录占位信息，不输出原文
+func (r *Redactor) RedactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if !r.AllowContentType(contentType) {
+		return fmt.Sprintf("[omitted content-type=%s size=%d]", contentType, len(body))
+	}
+
+	var redacted string
+	switch {
+	case json.Valid(body):
+		redacted = r.redactJSON(body)
+	case strings.Contains(strings.ToLower(contentType), "x-www-form-urlencoded"):
+		redacted = r.redactForm(body)
+	default:
+		redacted = r.RedactString(string(body))
+	}
+
+	return r.truncate(redacted)
+}
+
+// redactJSON 对JSON内容按字段名和正则脱敏
+func (r *Redactor) redactJSON(body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var data interface{}
+	if err := decoder.Decode(&data); err != nil {
+		return r.RedactString(string(body))
+	}
+
+	out, err := json.Marshal(r.redactValue(data))
+	if err != nil {
+		return r.RedactString(string(body))
+	}
+	return string(out)
+}
+
+// redactValue 递归处理JSON值
+func (r *Redactor) redactValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for key, item := range val {
+			if r.IsSensitiveField(key) {
+				val[key] = RedactMask
+				continue
+			}
+			val[key] = r.redactValue(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = r.redactValue(item)
+		}
+		return val
+	case string:
+		return r.RedactString(val)
+	default:
+		return val
+	}
+}
+
+// redactForm 对表单内容脱敏
+func (r *Redactor) redactForm(body []byte) string {
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return r.RedactString(string(body))
+	}
+	return url.Values(r.RedactValues(values)).Encode()
+}
+
+// truncate 按最大长度裁剪，不截断多字节字符
+func (r *Redactor) truncate(s string) string {
+	if r.maxBodySize <= 0 || len(s) <= r.maxBodySize {
+		return s
+	}
+	n := r.maxBodySize
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return fmt.Sprintf("%s...[truncated %d bytes]", s[:n], len(s)-n)
+}
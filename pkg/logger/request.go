@@ -0,0 +1,271 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// ReqLogConfig 请求日志输出配置
+// 轮转参数为0时沿用主日志的配置
+type ReqLogConfig struct {
+	Level      string          `mapstructure:"level" yaml:"level" validate:"omitempty,loglevel"`             // 日志级别，默认 info
+	Format     string          `mapstructure:"format" yaml:"format" validate:"omitempty,oneof=json console"` // 日志格式: json, console，默认与主日志一致
+	Console    bool            `mapstructure:"console" yaml:"console"`                                       // 是否同时输出到控制台
+	MaxSize    int             `mapstructure:"max_size" yaml:"max_size" validate:"min=0"`                    // 单个日志文件最大大小(MB)
+	MaxBackup  int             `mapstructure:"max_backup" yaml:"max_backup" validate:"min=0"`                // 保留的旧日志文件数量
+	MaxAge     int             `mapstructure:"max_age" yaml:"max_age" validate:"min=0"`                      // 保留的旧日志文件天数
+	Compress   bool            `mapstructure:"compress" yaml:"compress"`                                     // 是否压缩旧日志文件
+	SampleRate float64         `mapstructure:"sample_rate" yaml:"sample_rate" validate:"min=0,max=1"`        // 默认采样率(0,1]，0表示全部记录
+	Sampling   []RouteSampling `mapstructure:"sampling" yaml:"sampling" validate:"dive"`                     // 按路由配置的采样率
+}
+
+// RouteSampling 路由采样规则
+type RouteSampling struct {
+	Method string  `mapstructure:"method" yaml:"method"`                    // HTTP方法，为空匹配所有方法
+	Route  string  `mapstructure:"route" yaml:"route" validate:"required"`  // 路由模板，如 /api/v1/users/:id，以 * 结尾表示前缀匹配
+	Rate   float64 `mapstructure:"rate" yaml:"rate" validate:"min=0,max=1"` // 采样率[0,1]
+}
+
+// AccessLogConfig 访问日志配置
+type AccessLogConfig struct {
+	Enabled   bool   `mapstructure:"enabled" yaml:"enabled"`                                        // 是否启用访问日志
+	Path      string `mapstructure:"path" yaml:"path"`                                              // 访问日志路径
+	Format    string `mapstructure:"format" yaml:"format" validate:"omitempty,oneof=json combined"` // 格式: json（JSON Lines）, combined（Apache combined）
+	MaxSize   int    `mapstructure:"max_size" yaml:"max_size" validate:"min=0"`                     // 单个日志文件最大大小(MB)
+	MaxBackup int    `mapstructure:"max_backup" yaml:"max_backup" validate:"min=0"`                 // 保留的旧日志文件数量
+	MaxAge    int    `mapstructure:"max_age" yaml:"max_age" validate:"min=0"`                       // 保留的旧日志文件天数
+	Compress  bool   `mapstructure:"compress" yaml:"compress"`                                      // 是否压缩旧日志文件
+}
+
+// AccessEntry 一条访问日志
+type AccessEntry struct {
+	Time      time.Time     `json:"-"`
+	RemoteIP  string        `json:"remote_ip"`
+	User      string        `json:"user,omitempty"`
+	Method    string        `json:"method"`
+	URI       string        `json:"uri"`
+	Route     string        `json:"route,omitempty"`
+	Proto     string        `json:"proto"`
+	Status    int           `json:"status"`
+	Bytes     int           `json:"bytes"`
+	Referer   string        `json:"referer,omitempty"`
+	UserAgent string        `json:"user_agent,omitempty"`
+	Duration  time.Duration `json:"-"`
+	RequestID string        `json:"request_id,omitempty"`
+	TraceID   string        `json:"trace_id,omitempty"`
+}
+
+var (
+	reqLogger    *zap.Logger
+	reqSampler   *requestSampler
+	accessWriter io.Writer
+	accessFormat string
+	accessMu     sync.Mutex
+	openedFiles  []*lumberjack.Logger
+)
+
+// initRequestLoggers 初始化请求日志和访问日志
+func initRequestLoggers(config LogConfig) {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+
+	// 关闭上一次初始化打开的文件
+	for _, f := range openedFiles {
+		_ = f.Close()
+	}
+	openedFiles = nil
+
+	reqSampler = newRequestSampler(config.ReqLog)
+
+	// 请求日志
+	if config.ReqLogPath == "" {
+		reqLogger = globalLogger
+	} else {
+		reqCfg := config.ReqLog
+		format := reqCfg.Format
+		if format == "" {
+			format = config.Format
+		}
+		encoder := newEncoder(format)
+		level := parseLevel(reqCfg.Level, zapcore.InfoLevel)
+
+		fileWriter := newRotateWriter(config.ReqLogPath, config,
+			reqCfg.MaxSize, reqCfg.MaxBackup, reqCfg.MaxAge, reqCfg.Compress)
+		cores := []zapcore.Core{zapcore.NewCore(encoder, zapcore.AddSync(fileWriter), level)}
+		if reqCfg.Console {
+			cores = append(cores, zapcore.NewCore(encoder, zapcore.AddSync(os.Stdout), level))
+		}
+		reqLogger = zap.New(zapcore.NewTee(cores...)).Named("request")
+	}
+
+	// 访问日志
+	accessWriter = nil
+	accessFormat = config.AccessLog.Format
+	if config.AccessLog.Enabled && config.AccessLog.Path != "" {
+		accessCfg := config.AccessLog
+		accessWriter = newRotateWriter(accessCfg.Path, config,
+			accessCfg.MaxSize, accessCfg.MaxBackup, accessCfg.MaxAge, accessCfg.Compress)
+	}
+}
+
+// newRotateWriter 创建轮转文件输出，未设置的轮转参数沿用主日志配置
+func newRotateWriter(path string, base LogConfig, maxSize, maxBackup, maxAge int, compress bool) *lumberjack.Logger {
+	if maxSize <= 0 {
+		maxSize = base.MaxSize
+	}
+	if maxBackup <= 0 {
+		maxBackup = base.MaxBackup
+	}
+	if maxAge <= 0 {
+		maxAge = base.MaxAge
+	}
+	w := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSize,
+		MaxBackups: maxBackup,
+		MaxAge:     maxAge,
+		Compress:   compress,
+	}
+	openedFiles = append(openedFiles, w)
+	return w
+}
+
+// GetReqLogger 获取请求日志logger
+func GetReqLogger() *zap.Logger {
+	if reqLogger == nil {
+		return GetLogger()
+	}
+	return reqLogger
+}
+
+// AccessLogEnabled 访问日志是否启用
+func AccessLogEnabled() bool {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+	return accessWriter != nil
+}
+
+// Access 写入一条访问日志
+func Access(entry AccessEntry) {
+	accessMu.Lock()
+	defer accessMu.Unlock()
+	if accessWriter == nil {
+		return
+	}
+
+	var line string
+	if accessFormat == "combined" {
+		line = formatCombined(entry)
+	} else {
+		line = formatJSONLine(entry)
+	}
+	_, _ = io.WriteString(accessWriter, line)
+}
+
+// formatCombined 按 Apache combined 格式输出
+func formatCombined(e AccessEntry) string {
+	remoteIP := e.RemoteIP
+	if remoteIP == "" {
+		remoteIP = "-"
+	}
+	user := e.User
+	if user == "" {
+		user = "-"
+	}
+	referer := e.Referer
+	if referer == "" {
+		referer = "-"
+	}
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %d \"%s\" \"%s\"\n",
+		remoteIP, user, e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		e.Method, e.URI, e.Proto, e.Status, e.Bytes,
+		escapeQuote(referer), escapeQuote(e.UserAgent))
+}
+
+// formatJSONLine 按 JSON Lines 格式输出，每行一个JSON对象
+func formatJSONLine(e AccessEntry) string {
+	type jsonEntry struct {
+		Time string `json:"time"`
+		AccessEntry
+		DurationMS float64 `json:"duration_ms"`
+	}
+	data, err := json.Marshal(jsonEntry{
+		Time:        e.Time.Format(time.RFC3339Nano),
+		AccessEntry: e,
+		DurationMS:  float64(e.Duration.Microseconds()) / 1000,
+	})
+	if err != nil {
+		return ""
+	}
+	return string(data) + "\n"
+}
+
+// escapeQuote 转义双引号，避免破坏 combined 格式
+func escapeQuote(s string) string {
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
+
+// requestSampler 按路由采样请求日志
+type requestSampler struct {
+	defaultRate float64
+	rules       []RouteSampling
+}
+
+// newRequestSampler 创建采样器
+func newRequestSampler(config ReqLogConfig) *requestSampler {
+	rate := config.SampleRate
+	if rate <= 0 || rate > 1 {
+		rate = 1
+	}
+	return &requestSampler{defaultRate: rate, rules: config.Sampling}
+}
+
+// rate 获取路由对应的采样率
+func (s *requestSampler) rate(method, route string) float64 {
+	for _, rule := range s.rules {
+		if rule.Method != "" && !strings.EqualFold(rule.Method, method) {
+			continue
+		}
+		if strings.HasSuffix(rule.Route, "*") {
+			if strings.HasPrefix(route, strings.TrimSuffix(rule.Route, "*")) {
+				return rule.Rate
+			}
+			continue
+		}
+		if rule.Route == route {
+			return rule.Rate
+		}
+	}
+	return s.defaultRate
+}
+
+// SampleRequest 判断请求是否需要记录日志
+// 状态码>=400的请求始终记录；其余按路由采样率决定。
+// 以 requestID 做哈希采样，同一请求在请求日志和访问日志中的采样结果一致。
+func SampleRequest(method, route string, status int, requestID string) bool {
+	if status >= 400 || reqSampler == nil {
+		return true
+	}
+
+	rate := reqSampler.rate(method, route)
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(requestID))
+	return float64(h.Sum32()%10000) < rate*10000
+}
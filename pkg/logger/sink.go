@@ -0,0 +1,375 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// 丢弃策略
+const (
+	DropNewest = "drop_newest" // 缓冲区满时丢弃新日志（默认）
+	DropOldest = "drop_oldest" // 缓冲区满时丢弃最早的日志
+)
+
+// SinkConfig 附加日志输出配置
+// 日志先写入有界缓冲区，由后台协程批量发送，慢速或不可用的输出不会阻塞请求处理
+type SinkConfig struct {
+	Name  string `mapstructure:"name" yaml:"name"`                                       // 名称，用于统计，默认为 类型-序号
+	Type  string `mapstructure:"type" yaml:"type" validate:"required,oneof=syslog http"` // 类型: syslog, http
+	Level string `mapstructure:"level" yaml:"level" validate:"omitempty,loglevel"`       // 最低输出级别，为空时与全局级别一致
+
+	// 缓冲与批量
+	BufferSize    int           `mapstructure:"buffer_size" yaml:"buffer_size" validate:"min=0"`                                   // 缓冲区可容纳的日志条数，默认 10000
+	DropPolicy    string        `mapstructure:"drop_policy" yaml:"drop_policy" validate:"omitempty,oneof=drop_newest drop_oldest"` // 缓冲区满时的丢弃策略: drop_newest, drop_oldest
+	BatchSize     int           `mapstructure:"batch_size" yaml:"batch_size" validate:"min=0"`                                     // 每批发送的最大条数，默认 500
+	FlushInterval time.Duration `mapstructure:"flush_interval" yaml:"flush_interval" validate:"min=0"`                             // 批量发送间隔，默认 1s
+
+	// syslog
+	Network  string `mapstructure:"network" yaml:"network" validate:"omitempty,oneof=udp tcp unix unixgram"` // 网络类型: udp, tcp, unix, unixgram
+	Address  string `mapstructure:"address" yaml:"address"`                                                  // 地址，如 127.0.0.1:514 或 /dev/log
+	Facility string `mapstructure:"facility" yaml:"facility"`                                                // syslog facility，如 user, daemon, local0，默认 local0
+	AppName  string `mapstructure:"app_name" yaml:"app_name"`                                                // syslog APP-NAME，默认 go_demo
+
+	// http
+	URL        string            `mapstructure:"url" yaml:"url" validate:"required_if=Type http"` // 接收地址，请求体为 gzip 压缩的 NDJSON
+	Headers    map[string]string `mapstructure:"headers" yaml:"headers"`                          // 附加请求头，如认证信息
+	Timeout    time.Duration     `mapstructure:"timeout" yaml:"timeout" validate:"min=0"`         // 请求超时，默认 5s
+	MaxRetries int               `mapstructure:"max_retries" yaml:"max_retries" validate:"min=0"` // 发送失败重试次数，默认 2
+}
+
+// SinkStat 附加日志输出的统计信息
+type SinkStat struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Buffered int    `json:"buffered"` // 当前缓冲区中的条数
+	Sent     uint64 `json:"sent"`     // 发送成功的条数
+	Dropped  uint64 `json:"dropped"`  // 缓冲区满被丢弃的条数
+	Failed   uint64 `json:"failed"`   // 发送失败（重试后仍失败）被丢弃的条数
+}
+
+// sinkRecord 一条待发送的日志
+type sinkRecord struct {
+	level zapcore.Level
+	time  time.Time
+	line  []byte // 编码后的日志内容，不含换行
+}
+
+// lineSink 日志发送目标
+type lineSink interface {
+	// Send 发送一批日志，返回错误时整批计为失败
+	Send(records []sinkRecord) error
+	Close() error
+}
+
+// sinkCounters 发送统计，按名称保留，重新初始化后继续累计
+type sinkCounters struct {
+	sent    atomic.Uint64
+	dropped atomic.Uint64
+	failed  atomic.Uint64
+}
+
+var (
+	sinksMu      sync.Mutex
+	activeSinks  []*asyncSink
+	sinkCounterM = map[string]*sinkCounters{}
+)
+
+// asyncSink 带有界缓冲区的异步发送器
+type asyncSink struct {
+	name          string
+	typ           string
+	sink          lineSink
+	queue         chan sinkRecord
+	dropOldest    bool
+	batchSize     int
+	flushInterval time.Duration
+	counters      *sinkCounters
+
+	flushReq chan chan struct{}
+	done     chan struct{}
+	stopped  chan struct{}
+	closeMu  sync.Once
+	failing  atomic.Bool
+}
+
+// newAsyncSink 创建异步发送器并启动后台协程
+func newAsyncSink(name, typ string, sink lineSink, config SinkConfig, counters *sinkCounters) *asyncSink {
+	bufferSize := config.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 10000
+	}
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	flushInterval := config.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+
+	a := &asyncSink{
+		name:          name,
+		typ:           typ,
+		sink:          sink,
+		queue:         make(chan sinkRecord, bufferSize),
+		dropOldest:    config.DropPolicy == DropOldest,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		counters:      counters,
+		flushReq:      make(chan chan struct{}),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	go a.run()
+	return a
+}
+
+// enqueue 写入缓冲区，缓冲区满时按丢弃策略处理，不会阻塞
+func (a *asyncSink) enqueue(r sinkRecord) {
+	select {
+	case a.queue <- r:
+		return
+	default:
+	}
+
+	if a.dropOldest {
+		select {
+		case <-a.queue:
+			a.counters.dropped.Add(1)
+		default:
+		}
+		select {
+		case a.queue <- r:
+			return
+		default:
+		}
+	}
+	a.counters.dropped.Add(1)
+}
+
+// run 后台批量发送
+func (a *asyncSink) run() {
+	defer close(a.stopped)
+
+	ticker := time.NewTicker(a.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]sinkRecord, 0, a.batchSize)
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := a.sink.Send(batch); err != nil {
+			a.counters.failed.Add(uint64(len(batch)))
+			// 日志系统自身的错误不能再写入日志，只在首次失败时输出到标准错误
+			if !a.failing.Swap(true) {
+				fmt.Fprintf(os.Stderr, "日志输出 %s 发送失败: %v\n", a.name, err)
+			}
+		} else {
+			a.counters.sent.Add(uint64(len(batch)))
+			a.failing.Store(false)
+		}
+		batch = batch[:0]
+	}
+	drain := func() {
+		for {
+			select {
+			case r := <-a.queue:
+				batch = append(batch, r)
+				if len(batch) >= a.batchSize {
+					send()
+				}
+			default:
+				send()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case r := <-a.queue:
+			batch = append(batch, r)
+			if len(batch) >= a.batchSize {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-a.flushReq:
+			drain()
+			close(ack)
+		case <-a.done:
+			drain()
+			_ = a.sink.Close()
+			return
+		}
+	}
+}
+
+// flush 发送缓冲区中的全部日志，超时返回错误
+func (a *asyncSink) flush(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	ack := make(chan struct{})
+	select {
+	case a.flushReq <- ack:
+	case <-a.stopped:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("日志输出 %s 刷新超时", a.name)
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("日志输出 %s 刷新超时", a.name)
+	}
+}
+
+// close 停止后台协程，发送剩余日志后关闭
+func (a *asyncSink) close(timeout time.Duration) {
+	a.closeMu.Do(func() { close(a.done) })
+	select {
+	case <-a.stopped:
+	case <-time.After(timeout):
+	}
+}
+
+// stat 获取统计信息
+func (a *asyncSink) stat() SinkStat {
+	return SinkStat{
+		Name:     a.name,
+		Type:     a.typ,
+		Buffered: len(a.queue),
+		Sent:     a.counters.sent.Load(),
+		Dropped:  a.counters.dropped.Load(),
+		Failed:   a.counters.failed.Load(),
+	}
+}
+
+// sinkCore 将日志编码后写入异步发送器的core
+type sinkCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	out *asyncSink
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return &sinkCore{LevelEnabler: c.LevelEnabler, enc: enc, out: c.out}
+}
+
+func (c *sinkCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *sinkCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.enc.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	line := make([]byte, len(strings.TrimRight(buf.String(), "\n")))
+	copy(line, buf.Bytes())
+	buf.Free()
+
+	c.out.enqueue(sinkRecord{level: entry.Level, time: entry.Time, line: line})
+	return nil
+}
+
+func (c *sinkCore) Sync() error {
+	return c.out.flush(5 * time.Second)
+}
+
+// initSinks 创建附加日志输出，并关闭上一次初始化创建的输出
+func initSinks(configs []SinkConfig) ([]zapcore.Core, error) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+
+	var (
+		cores []zapcore.Core
+		sinks []*asyncSink
+	)
+	for i, cfg := range configs {
+		typ := strings.ToLower(cfg.Type)
+		name := cfg.Name
+		if name == "" {
+			name = fmt.Sprintf("%s-%d", typ, i)
+		}
+
+		var (
+			sink lineSink
+			err  error
+		)
+		switch typ {
+		case "syslog":
+			sink, err = newSyslogSink(cfg)
+		case "http":
+			sink, err = newHTTPSink(cfg)
+		default:
+			err = fmt.Errorf("不支持的类型 %q", cfg.Type)
+		}
+		if err == nil && cfg.DropPolicy != "" && cfg.DropPolicy != DropNewest && cfg.DropPolicy != DropOldest {
+			err = fmt.Errorf("不支持的丢弃策略 %q", cfg.DropPolicy)
+		}
+		if err != nil {
+			for _, s := range sinks {
+				s.close(time.Second)
+			}
+			return nil, fmt.Errorf("日志输出 %s 配置错误: %w", name, err)
+		}
+
+		counters, ok := sinkCounterM[name]
+		if !ok {
+			counters = &sinkCounters{}
+			sinkCounterM[name] = counters
+		}
+		out := newAsyncSink(name, typ, sink, cfg, counters)
+		sinks = append(sinks, out)
+		cores = append(cores, &sinkCore{
+			LevelEnabler: parseLevel(cfg.Level, zapcore.DebugLevel),
+			enc:          newEncoder("json"),
+			out:          out,
+		})
+	}
+
+	for _, s := range activeSinks {
+		s.close(5 * time.Second)
+	}
+	activeSinks = sinks
+	return cores, nil
+}
+
+// SinkStats 获取附加日志输出的统计信息，包括丢弃的条数
+func SinkStats() []SinkStat {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+
+	stats := make([]SinkStat, 0, len(activeSinks))
+	for _, s := range activeSinks {
+		stats = append(stats, s.stat())
+	}
+	return stats
+}
+
+// CloseSinks 发送剩余日志并关闭附加日志输出，用于服务退出
+func CloseSinks() {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+
+	for _, s := range activeSinks {
+		s.close(5 * time.Second)
+	}
+}
@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogFacilities syslog facility 名称与编号
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSink 按 RFC 5424 格式发送日志
+// 流式连接（tcp、unix）使用 RFC 6587 的长度前缀分帧，数据报连接（udp、unixgram）每条日志一个数据报
+type syslogSink struct {
+	network  string
+	address  string
+	facility int
+	appName  string
+	hostname string
+	procID   string
+	stream   bool
+	conn     net.Conn
+}
+
+// newSyslogSink 创建syslog输出，连接在首次发送时建立
+func newSyslogSink(config SinkConfig) (*syslogSink, error) {
+	network := strings.ToLower(config.Network)
+	if network == "" {
+		network = "udp"
+	}
+	switch network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return nil, fmt.Errorf("不支持的网络类型 %q", config.Network)
+	}
+	if config.Address == "" {
+		return nil, fmt.Errorf("syslog 地址不能为空")
+	}
+
+	facility := "local0"
+	if config.Facility != "" {
+		facility = strings.ToLower(config.Facility)
+	}
+	code, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("不支持的 facility %q", config.Facility)
+	}
+
+	appName := config.AppName
+	if appName == "" {
+		appName = "go_demo"
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	return &syslogSink{
+		network:  network,
+		address:  config.Address,
+		facility: code,
+		appName:  appName,
+		hostname: hostname,
+		procID:   strconv.Itoa(os.Getpid()),
+		stream:   network == "tcp" || network == "unix",
+	}, nil
+}
+
+// syslogSeverity 将日志级别转换为 syslog severity
+func syslogSeverity(level zapcore.Level) int {
+	switch level {
+	case zapcore.DebugLevel:
+		return 7
+	case zapcore.InfoLevel:
+		return 6
+	case zapcore.WarnLevel:
+		return 4
+	case zapcore.ErrorLevel:
+		return 3
+	case zapcore.DPanicLevel:
+		return 2
+	case zapcore.PanicLevel:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// format 按 RFC 5424 格式化一条日志: <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID SD MSG
+func (s *syslogSink) format(r sinkRecord) []byte {
+	pri := s.facility*8 + syslogSeverity(r.level)
+	msg := fmt.Sprintf("<%d>1 %s %s %s %s - - %s", pri,
+		r.time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		s.hostname, s.appName, s.procID, r.line)
+	if s.stream {
+		msg = strconv.Itoa(len(msg)) + " " + msg
+	}
+	return []byte(msg)
+}
+
+// Send 发送一批日志，写入失败时重连一次
+func (s *syslogSink) Send(records []sinkRecord) error {
+	for _, r := range records {
+		msg := s.format(r)
+		if err := s.write(msg); err != nil {
+			s.closeConn()
+			if err := s.write(msg); err != nil {
+				s.closeConn()
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// write 写入一条消息，未连接时先建立连接
+func (s *syslogSink) write(msg []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.address, 5*time.Second)
+		if err != nil {
+			return fmt.Errorf("连接 syslog 失败: %w", err)
+		}
+		s.conn = conn
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+// closeConn 关闭连接
+func (s *syslogSink) closeConn() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// Close 关闭syslog输出
+func (s *syslogSink) Close() error {
+	s.closeConn()
+	return nil
+}
@@ -0,0 +1,100 @@
+package metrics
+
+import (
+	"go_demo/pkg/logger"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// RedisPoolStatsProvider 提供Redis连接池统计信息
+type RedisPoolStatsProvider interface {
+	PoolStats() *redis.PoolStats
+}
+
+// redisPoolCollector Redis连接池指标采集器
+type redisPoolCollector struct {
+	provider   RedisPoolStatsProvider
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+// RegisterRedisPoolStats 注册Redis连接池指标
+func RegisterRedisPoolStats(name string, provider RedisPoolStatsProvider) error {
+	labels := prometheus.Labels{"pool": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "redis_pool", metric), help, nil, labels)
+	}
+	return registerNamed("redis:"+name, &redisPoolCollector{
+		provider:   provider,
+		hits:       desc("hits_total", "连接池命中空闲连接的次数"),
+		misses:     desc("misses_total", "连接池未命中空闲连接的次数"),
+		timeouts:   desc("timeouts_total", "获取连接超时的次数"),
+		totalConns: desc("total_connections", "连接池中的连接总数"),
+		idleConns:  desc("idle_connections", "连接池中的空闲连接数"),
+		staleConns: desc("stale_connections_total", "被移除的过期连接数"),
+	})
+}
+
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.staleConns
+}
+
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.provider.PoolStats()
+	if stats == nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
+
+// logSinkCollector 附加日志输出的发送与丢弃指标采集器
+type logSinkCollector struct {
+	buffered *prometheus.Desc
+	sent     *prometheus.Desc
+	dropped  *prometheus.Desc
+	failed   *prometheus.Desc
+}
+
+// newLogSinkCollector 创建日志输出指标采集器
+func newLogSinkCollector() *logSinkCollector {
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "log_sink", metric), help, []string{"sink", "type"}, nil)
+	}
+	return &logSinkCollector{
+		buffered: desc("buffered_lines", "缓冲区中等待发送的日志条数"),
+		sent:     desc("sent_lines_total", "发送成功的日志条数"),
+		dropped:  desc("dropped_lines_total", "缓冲区满被丢弃的日志条数"),
+		failed:   desc("failed_lines_total", "发送失败被丢弃的日志条数"),
+	}
+}
+
+func (c *logSinkCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.buffered
+	ch <- c.sent
+	ch <- c.dropped
+	ch <- c.failed
+}
+
+func (c *logSinkCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range logger.SinkStats() {
+		ch <- prometheus.MustNewConstMetric(c.buffered, prometheus.GaugeValue, float64(s.Buffered), s.Name, s.Type)
+		ch <- prometheus.MustNewConstMetric(c.sent, prometheus.CounterValue, float64(s.Sent), s.Name, s.Type)
+		ch <- prometheus.MustNewConstMetric(c.dropped, prometheus.CounterValue, float64(s.Dropped), s.Name, s.Type)
+		ch <- prometheus.MustNewConstMetric(c.failed, prometheus.CounterValue, float64(s.Failed), s.Name, s.Type)
+	}
+}
@@ -0,0 +1,220 @@
+// Package metrics 提供 Prometheus 指标的定义、注册和暴露
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace 指标名称前缀
+const Namespace = "go_demo"
+
+// Registry 指标注册表，包含 Go 运行时和进程指标
+var Registry = prometheus.NewRegistry()
+
+// HTTP 指标
+var (
+	HTTPRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP请求总数",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP请求处理耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "requests_in_flight",
+		Help:      "正在处理的HTTP请求数",
+	})
+)
+
+// 数据库指标
+var (
+	DBQueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "数据库操作耗时",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	DBQueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "数据库操作错误数（不含记录不存在）",
+	}, []string{"operation", "table"})
+)
+
+// 业务指标
+var (
+	LoginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "auth",
+		Name:      "logins_total",
+		Help:      "登录次数，result 为 success 或 failed",
+	}, []string{"result"})
+
+	RegistrationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "auth",
+		Name:      "registrations_total",
+		Help:      "注册次数，result 为 success 或 failed",
+	}, []string{"result"})
+
+	CaptchaFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "auth",
+		Name:      "captcha_failures_total",
+		Help:      "验证码校验失败次数",
+	}, []string{"action"})
+
+	RateLimitTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "http",
+		Name:      "rate_limit_total",
+		Help:      "限流检查次数，result 为 allowed 或 rejected",
+	}, []string{"result"})
+
+	EventDeliveriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "events",
+		Name:      "deliveries_total",
+		Help:      "领域事件投递次数，result 为 success、failed 或 dead",
+	}, []string{"event_type", "result"})
+
+	WebhookDeliveriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "webhook",
+		Name:      "deliveries_total",
+		Help:      "Webhook 请求次数，result 为 success、failed 或 dead",
+	}, []string{"event_type", "result"})
+
+	WebhookDeliveryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "webhook",
+		Name:      "delivery_duration_seconds",
+		Help:      "Webhook 请求耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"event_type"})
+
+	JobsProcessedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "jobs",
+		Name:      "processed_total",
+		Help:      "后台作业执行次数，result 为 success、failed 或 dead",
+	}, []string{"queue", "type", "result"})
+
+	JobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "jobs",
+		Name:      "duration_seconds",
+		Help:      "后台作业执行耗时",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"queue", "type"})
+
+	SchedulerRunsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "scheduler",
+		Name:      "runs_total",
+		Help:      "定时任务执行次数，result 为 success 或 failed",
+	}, []string{"task", "result"})
+
+	SchedulerRunDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: Namespace,
+		Subsystem: "scheduler",
+		Name:      "run_duration_seconds",
+		Help:      "定时任务执行耗时",
+		Buckets:   []float64{.01, .1, 1, 5, 15, 60, 300, 900, 3600},
+	}, []string{"task"})
+
+	CacheLookupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: Namespace,
+		Subsystem: "cache",
+		Name:      "lookups_total",
+		Help:      "缓存查询次数，result 为 hit、negative_hit 或 miss",
+	}, []string{"cache", "result"})
+)
+
+// 缓存查询结果标签值
+const (
+	CacheHit         = "hit"
+	CacheNegativeHit = "negative_hit" // 命中了记录不存在的缓存
+	CacheMiss        = "miss"
+)
+
+// 业务结果标签值
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+	ResultDead    = "dead"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestsTotal,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		DBQueryDuration,
+		DBQueryErrors,
+		LoginsTotal,
+		RegistrationsTotal,
+		CaptchaFailuresTotal,
+		RateLimitTotal,
+		EventDeliveriesTotal,
+		WebhookDeliveriesTotal,
+		WebhookDeliveryDuration,
+		JobsProcessedTotal,
+		JobDuration,
+		SchedulerRunsTotal,
+		SchedulerRunDuration,
+		CacheLookupsTotal,
+		newLogSinkCollector(),
+	)
+}
+
+// Handler 返回 Prometheus 文本格式的指标处理器
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+var (
+	namedMu         sync.Mutex
+	namedCollectors = map[string]prometheus.Collector{}
+)
+
+// registerNamed 按名称注册采集器，同名采集器已存在时先注销，便于重新初始化
+func registerNamed(name string, c prometheus.Collector) error {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	if old, ok := namedCollectors[name]; ok {
+		Registry.Unregister(old)
+	}
+	if err := Registry.Register(c); err != nil {
+		return err
+	}
+	namedCollectors[name] = c
+	return nil
+}
+
+// RegisterDBStats 注册 sql.DB 连接池指标
+func RegisterDBStats(dbName string, db *sql.DB) error {
+	return registerNamed("db:"+dbName, collectors.NewDBStatsCollector(db, dbName))
+}
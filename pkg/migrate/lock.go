@@ -0,0 +1,80 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrLockTimeout 等待迁移锁超时，通常是其他副本正在执行迁移
+var ErrLockTimeout = errors.New("等待迁移锁超时")
+
+// lockRetryInterval 不支持阻塞等待的数据库重试获取锁的间隔
+const lockRetryInterval = 500 * time.Millisecond
+
+// acquireLock 获取会话级的数据库锁，conn 必须固定为同一个连接
+// MySQL 使用 GET_LOCK，PostgreSQL 使用 advisory lock；SQLite 写操作本身串行，不加锁
+func acquireLock(ctx context.Context, conn *gorm.DB, name string, timeout time.Duration) error {
+	switch conn.Dialector.Name() {
+	case "mysql":
+		var got sql.NullInt64
+		seconds := int(timeout.Seconds())
+		if seconds < 1 {
+			seconds = 1
+		}
+		if err := conn.Raw("SELECT GET_LOCK(?, ?)", name, seconds).Scan(&got).Error; err != nil {
+			return fmt.Errorf("获取迁移锁失败: %w", err)
+		}
+		if !got.Valid || got.Int64 != 1 {
+			return fmt.Errorf("%w: %s", ErrLockTimeout, name)
+		}
+		return nil
+	case "postgres":
+		deadline := time.Now().Add(timeout)
+		for {
+			var got bool
+			if err := conn.Raw("SELECT pg_try_advisory_lock(?)", lockKey(name)).Scan(&got).Error; err != nil {
+				return fmt.Errorf("获取迁移锁失败: %w", err)
+			}
+			if got {
+				return nil
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%w: %s", ErrLockTimeout, name)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(lockRetryInterval):
+			}
+		}
+	default:
+		return nil
+	}
+}
+
+// releaseLock 释放 acquireLock 获取的锁
+func releaseLock(conn *gorm.DB, name string) error {
+	// 使用独立的 context，调用方取消后仍然释放锁
+	conn = conn.WithContext(context.Background())
+	switch conn.Dialector.Name() {
+	case "mysql":
+		return conn.Exec("SELECT RELEASE_LOCK(?)", name).Error
+	case "postgres":
+		return conn.Exec("SELECT pg_advisory_unlock(?)", lockKey(name)).Error
+	default:
+		return nil
+	}
+}
+
+// lockKey PostgreSQL advisory lock 的整数键
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
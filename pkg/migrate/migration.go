@@ -0,0 +1,242 @@
+// Package migrate 提供版本化的数据库迁移：
+//
+//   - SQL 迁移：文件名为 <版本号>_<名称>.up.sql / .down.sql，通常通过 go:embed 打包进二进制
+//   - Go 迁移：通过 Go 函数实现，适用于需要判断现状或迁移数据的场景
+//
+// 已执行的版本记录在 schema_migrations 表中，并保存迁移内容的校验和，
+// 已执行的 SQL 被修改时拒绝继续迁移；执行期间持有数据库锁，多个副本同时启动时不会重复执行。
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrIrreversible 迁移没有提供回滚
+var ErrIrreversible = errors.New("迁移不支持回滚")
+
+// Func 迁移函数，tx 为迁移所在的事务
+type Func func(tx *gorm.DB) error
+
+// Migration 单个版本的迁移
+type Migration struct {
+	Version  int64  // 版本号，按从小到大的顺序执行
+	Name     string // 名称
+	Up       Func   // 升级
+	Down     Func   // 回滚，为 nil 表示不支持回滚
+	Checksum string // 迁移内容的校验和，SQL 迁移为 up/down 文件内容的 SHA-256
+	Source   string // 来源: sql, go
+}
+
+// Go 创建 Go 函数实现的迁移，down 为 nil 表示不支持回滚
+// Go 迁移的校验和只包含版本号和名称，修改函数实现不会被发现，已发布的迁移不应再修改
+func Go(version int64, name string, up, down Func) *Migration {
+	return &Migration{
+		Version:  version,
+		Name:     name,
+		Up:       up,
+		Down:     down,
+		Checksum: checksum(fmt.Sprintf("go:%d:%s", version, name)),
+		Source:   "go",
+	}
+}
+
+// LoadFS 从文件系统加载 SQL 迁移，dir 下的文件名格式为 <版本号>_<名称>.up.sql 和 <版本号>_<名称>.down.sql
+// 每个版本必须有 up 文件，down 文件可选
+func LoadFS(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移目录失败: %w", err)
+	}
+
+	type files struct {
+		name     string
+		up, down string
+		hasUp    bool
+	}
+	byVersion := make(map[int64]*files)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+		version, name, direction, err := parseFileName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取迁移文件 %s 失败: %w", entry.Name(), err)
+		}
+
+		f := byVersion[version]
+		if f == nil {
+			f = &files{name: name}
+			byVersion[version] = f
+		}
+		if f.name != name {
+			return nil, fmt.Errorf("版本 %d 的迁移文件名称不一致: %s, %s", version, f.name, name)
+		}
+		if direction == "up" {
+			f.up, f.hasUp = string(data), true
+		} else {
+			f.down = string(data)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for version, f := range byVersion {
+		if !f.hasUp {
+			return nil, fmt.Errorf("版本 %d 缺少 up 迁移文件", version)
+		}
+		m := &Migration{
+			Version:  version,
+			Name:     f.name,
+			Up:       execSQL(f.up),
+			Checksum: checksum(f.up + "\x00" + f.down),
+			Source:   "sql",
+		}
+		if strings.TrimSpace(f.down) != "" {
+			m.Down = execSQL(f.down)
+		}
+		migrations = append(migrations, m)
+	}
+	sortMigrations(migrations)
+	return migrations, nil
+}
+
+// parseFileName 解析迁移文件名 <版本号>_<名称>.<up|down>.sql
+func parseFileName(file string) (version int64, name, direction string, err error) {
+	base := strings.TrimSuffix(file, ".sql")
+	switch {
+	case strings.HasSuffix(base, ".up"):
+		direction = "up"
+	case strings.HasSuffix(base, ".down"):
+		direction = "down"
+	default:
+		return 0, "", "", fmt.Errorf("迁移文件 %s 必须以 .up.sql 或 .down.sql 结尾", file)
+	}
+	base = strings.TrimSuffix(base, "."+direction)
+
+	prefix, name, ok := strings.Cut(base, "_")
+	version, err = strconv.ParseInt(prefix, 10, 64)
+	if !ok || err != nil || version <= 0 || name == "" {
+		return 0, "", "", fmt.Errorf("迁移文件 %s 的名称格式应为 <版本号>_<名称>.%s.sql", file, direction)
+	}
+	return version, name, direction, nil
+}
+
+// execSQL 按语句依次执行 SQL 文件内容
+func execSQL(content string) Func {
+	statements := SplitStatements(content)
+	return func(tx *gorm.DB) error {
+		for i, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("执行第 %d 条语句失败: %w", i+1, err)
+			}
+		}
+		return nil
+	}
+}
+
+// SplitStatements 按分号拆分 SQL 语句，忽略引号和注释中的分号，去掉仅包含注释的语句
+// 数据库驱动默认不支持一次执行多条语句，因此逐条执行
+func SplitStatements(content string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		hasCode    bool // 当前语句是否包含注释以外的内容
+	)
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); hasCode && stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case c == '-' && i+1 < len(content) && content[i+1] == '-':
+			// 单行注释
+			end := strings.IndexByte(content[i:], '\n')
+			if end < 0 {
+				end = len(content) - i
+			}
+			i += end - 1
+			continue
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			// 多行注释
+			end := strings.Index(content[i+2:], "*/")
+			if end < 0 {
+				i = len(content)
+				continue
+			}
+			i += end + 3
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			// 引号内的内容原样保留
+			end := i + 1
+			for end < len(content) && content[end] != c {
+				if content[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(content) {
+				end = len(content) - 1
+			}
+			current.WriteString(content[i : end+1])
+			hasCode = true
+			i = end
+			continue
+		case c == ';':
+			flush()
+			continue
+		}
+		current.WriteByte(c)
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			hasCode = true
+		}
+	}
+	flush()
+	return statements
+}
+
+// Validate 检查迁移列表：版本号大于0且不重复，必须提供 Up
+func Validate(migrations []*Migration) error {
+	seen := make(map[int64]string, len(migrations))
+	for _, m := range migrations {
+		if m.Version <= 0 {
+			return fmt.Errorf("迁移 %s 的版本号必须大于0", m.Name)
+		}
+		if m.Up == nil {
+			return fmt.Errorf("迁移 %d_%s 缺少 Up", m.Version, m.Name)
+		}
+		if name, ok := seen[m.Version]; ok {
+			return fmt.Errorf("迁移版本号 %d 重复: %s, %s", m.Version, name, m.Name)
+		}
+		seen[m.Version] = m.Name
+	}
+	return nil
+}
+
+// sortMigrations 按版本号排序
+func sortMigrations(migrations []*Migration) {
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+}
+
+// checksum 计算 SHA-256 校验和
+func checksum(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
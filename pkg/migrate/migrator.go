@@ -0,0 +1,363 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"go_demo/pkg/logger"
+)
+
+// ErrChecksumMismatch 已执行的迁移内容被修改
+var ErrChecksumMismatch = errors.New("已执行的迁移内容被修改")
+
+// 迁移状态
+const (
+	StateApplied  = "applied"  // 已执行
+	StatePending  = "pending"  // 待执行
+	StateModified = "modified" // 已执行，但迁移内容已被修改
+	StateMissing  = "missing"  // 已执行，但当前版本的程序中没有该迁移（通常是数据库由更新的版本迁移过）
+)
+
+// Config 迁移配置
+type Config struct {
+	Table       string        // 迁移记录表，默认 schema_migrations
+	LockName    string        // 数据库锁名称，默认 go_demo_migrations
+	LockTimeout time.Duration // 等待数据库锁的超时时间，默认1分钟
+}
+
+// DefaultConfig 默认迁移配置
+func DefaultConfig() Config {
+	return Config{
+		Table:       "schema_migrations",
+		LockName:    "go_demo_migrations",
+		LockTimeout: time.Minute,
+	}
+}
+
+// record 迁移记录
+type record struct {
+	Version    int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name       string    `gorm:"size:255;not null"`
+	Checksum   string    `gorm:"size:64;not null"`
+	AppliedAt  time.Time `gorm:"not null"`
+	DurationMs int64     `gorm:"not null"`
+}
+
+// Status 迁移状态
+type Status struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	Source    string     `json:"source,omitempty"`
+	State     string     `json:"state"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator 迁移执行器
+type Migrator struct {
+	db         *gorm.DB
+	config     Config
+	migrations []*Migration
+	byVersion  map[int64]*Migration
+}
+
+// New 创建迁移执行器
+func New(db *gorm.DB, migrations []*Migration, config Config) (*Migrator, error) {
+	defaults := DefaultConfig()
+	if config.Table == "" {
+		config.Table = defaults.Table
+	}
+	if config.LockName == "" {
+		config.LockName = defaults.LockName
+	}
+	if config.LockTimeout <= 0 {
+		config.LockTimeout = defaults.LockTimeout
+	}
+	if err := Validate(migrations); err != nil {
+		return nil, err
+	}
+
+	sorted := append([]*Migration(nil), migrations...)
+	sortMigrations(sorted)
+	byVersion := make(map[int64]*Migration, len(sorted))
+	for _, m := range sorted {
+		byVersion[m.Version] = m
+	}
+	return &Migrator{db: db, config: config, migrations: sorted, byVersion: byVersion}, nil
+}
+
+// Status 获取全部迁移的状态，按版本号排序
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	db := m.db.WithContext(ctx)
+	if err := m.ensureTable(db); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(db)
+	if err != nil {
+		return nil, err
+	}
+	return m.status(applied), nil
+}
+
+// Up 执行全部待执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var done []*Migration
+	err := m.locked(ctx, false, func(db *gorm.DB, applied map[int64]record) error {
+		var err error
+		done, err = m.applyPending(db, applied, m.latest())
+		return err
+	})
+	return done, err
+}
+
+// Down 回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) ([]*Migration, error) {
+	var done []*Migration
+	err := m.locked(ctx, false, func(db *gorm.DB, applied map[int64]record) error {
+		for _, version := range appliedDesc(applied) {
+			if len(done) >= steps {
+				break
+			}
+			migration, err := m.rollback(db, version)
+			if err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// To 迁移到指定版本：执行不超过该版本的待执行迁移，回滚高于该版本的已执行迁移，version 为0时回滚全部
+func (m *Migrator) To(ctx context.Context, version int64) ([]*Migration, error) {
+	var done []*Migration
+	err := m.locked(ctx, false, func(db *gorm.DB, applied map[int64]record) error {
+		for _, v := range appliedDesc(applied) {
+			if v <= version {
+				break
+			}
+			migration, err := m.rollback(db, v)
+			if err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		pending, err := m.applyPending(db, applied, version)
+		done = append(done, pending...)
+		return err
+	})
+	return done, err
+}
+
+// Redo 回滚并重新执行最近一次执行的迁移，用于开发时调整迁移内容
+// 与其他操作不同，不检查该迁移的校验和，重新执行后记录新的校验和
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var redone *Migration
+	err := m.locked(ctx, true, func(db *gorm.DB, applied map[int64]record) error {
+		versions := appliedDesc(applied)
+		if len(versions) == 0 {
+			return fmt.Errorf("没有已执行的迁移")
+		}
+		migration, err := m.rollback(db, versions[0])
+		if err != nil {
+			return err
+		}
+		if err := m.apply(db, migration); err != nil {
+			return err
+		}
+		redone = migration
+		return nil
+	})
+	return redone, err
+}
+
+// applyPending 按版本号顺序执行不超过 version 的待执行迁移
+func (m *Migrator) applyPending(db *gorm.DB, applied map[int64]record, version int64) ([]*Migration, error) {
+	var done []*Migration
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.apply(db, migration); err != nil {
+			return done, err
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// latest 获取最新的迁移版本号
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// locked 持有数据库锁执行迁移操作，执行前检查已执行迁移的校验和
+// skipLatest 为 true 时不检查最近一次执行的迁移
+func (m *Migrator) locked(ctx context.Context, skipLatest bool, fn func(db *gorm.DB, applied map[int64]record) error) error {
+	// 会话级的数据库锁与连接绑定，整个过程使用同一个连接
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := acquireLock(ctx, conn, m.config.LockName, m.config.LockTimeout); err != nil {
+			return err
+		}
+		defer func() {
+			if err := releaseLock(conn, m.config.LockName); err != nil {
+				logger.Warn("释放迁移锁失败", logger.Err(err))
+			}
+		}()
+
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+		// 获取锁之后读取迁移记录，其他副本可能已经完成了迁移
+		applied, err := m.applied(conn)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(applied, skipLatest); err != nil {
+			return err
+		}
+		return fn(conn, applied)
+	})
+}
+
+// verify 检查已执行迁移的校验和
+func (m *Migrator) verify(applied map[int64]record, skipLatest bool) error {
+	versions := appliedDesc(applied)
+	if skipLatest && len(versions) > 0 {
+		versions = versions[1:]
+	}
+
+	var modified []string
+	for _, v := range versions {
+		migration, ok := m.byVersion[v]
+		if ok && migration.Checksum != applied[v].Checksum {
+			modified = append(modified, fmt.Sprintf("%d_%s", v, migration.Name))
+		}
+	}
+	if len(modified) > 0 {
+		sort.Strings(modified)
+		return fmt.Errorf("%w: %s，请新增迁移而不是修改已执行的迁移", ErrChecksumMismatch, strings.Join(modified, ", "))
+	}
+	return nil
+}
+
+// apply 在事务中执行迁移并写入迁移记录
+func (m *Migrator) apply(db *gorm.DB, migration *Migration) error {
+	start := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Up(tx); err != nil {
+			return err
+		}
+		return tx.Table(m.config.Table).Create(&record{
+			Version:    migration.Version,
+			Name:       migration.Name,
+			Checksum:   migration.Checksum,
+			AppliedAt:  time.Now(),
+			DurationMs: time.Since(start).Milliseconds(),
+		}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("执行迁移 %d_%s 失败: %w", migration.Version, migration.Name, err)
+	}
+	logger.Info("迁移已执行",
+		logger.Any("version", migration.Version),
+		logger.String("name", migration.Name),
+		logger.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// rollback 在事务中回滚迁移并删除迁移记录
+func (m *Migrator) rollback(db *gorm.DB, version int64) (*Migration, error) {
+	migration, ok := m.byVersion[version]
+	if !ok {
+		return nil, fmt.Errorf("无法回滚版本 %d: 当前程序中没有该迁移", version)
+	}
+	if migration.Down == nil {
+		return nil, fmt.Errorf("无法回滚 %d_%s: %w", version, migration.Name, ErrIrreversible)
+	}
+
+	start := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Down(tx); err != nil {
+			return err
+		}
+		return tx.Table(m.config.Table).Where("version = ?", version).Delete(&record{}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("回滚迁移 %d_%s 失败: %w", version, migration.Name, err)
+	}
+	logger.Info("迁移已回滚",
+		logger.Any("version", version),
+		logger.String("name", migration.Name),
+		logger.Duration("duration", time.Since(start)))
+	return migration, nil
+}
+
+// ensureTable 创建迁移记录表
+func (m *Migrator) ensureTable(db *gorm.DB) error {
+	if err := db.Table(m.config.Table).AutoMigrate(&record{}); err != nil {
+		return fmt.Errorf("创建迁移记录表失败: %w", err)
+	}
+	return nil
+}
+
+// applied 读取已执行的迁移记录
+func (m *Migrator) applied(db *gorm.DB) (map[int64]record, error) {
+	var records []record
+	if err := db.Table(m.config.Table).Find(&records).Error; err != nil {
+		return nil, fmt.Errorf("读取迁移记录失败: %w", err)
+	}
+	applied := make(map[int64]record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// status 合并迁移列表和迁移记录
+func (m *Migrator) status(applied map[int64]record) []Status {
+	result := make([]Status, 0, len(m.migrations)+len(applied))
+	for _, migration := range m.migrations {
+		s := Status{Version: migration.Version, Name: migration.Name, Source: migration.Source, State: StatePending}
+		if r, ok := applied[migration.Version]; ok {
+			appliedAt := r.AppliedAt
+			s.AppliedAt = &appliedAt
+			s.State = StateApplied
+			if r.Checksum != migration.Checksum {
+				s.State = StateModified
+			}
+		}
+		result = append(result, s)
+	}
+	for version, r := range applied {
+		if _, ok := m.byVersion[version]; ok {
+			continue
+		}
+		appliedAt := r.AppliedAt
+		result = append(result, Status{Version: version, Name: r.Name, State: StateMissing, AppliedAt: &appliedAt})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result
+}
+
+// appliedDesc 已执行的版本号，从大到小
+func appliedDesc(applied map[int64]record) []int64 {
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+	return versions
+}
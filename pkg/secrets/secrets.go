@@ -0,0 +1,127 @@
+// Package secrets 解析配置中的密钥引用，支持环境变量、文件和本地加密密钥库：
+//
+//	env://GO_DEMO_JWT_SECRET        读取环境变量
+//	file:///run/secrets/db_password 读取文件内容（去掉末尾换行），适用于 Docker/Kubernetes secrets
+//	vault://jwt_secret_key          读取本地加密密钥库中的条目
+//
+// 兼容旧的 ${VAR} 写法：整个值为 ${VAR} 时读取环境变量，变量未设置时保留原值。
+package secrets
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Provider 密钥提供者，ref 为去掉 scheme:// 前缀后的部分
+type Provider interface {
+	Resolve(ref string) (string, error)
+}
+
+// ProviderFunc 函数形式的密钥提供者
+type ProviderFunc func(ref string) (string, error)
+
+// Resolve 实现 Provider
+func (f ProviderFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// legacyEnvPattern 旧的整值环境变量占位符，如 ${DB_PASSWORD}
+// 不匹配 ${1} 这类正则替换模板
+var legacyEnvPattern = regexp.MustCompile(`^\$\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// Resolver 密钥引用解析器
+type Resolver struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewResolver 创建解析器，默认注册 env 和 file 提供者
+func NewResolver() *Resolver {
+	r := &Resolver{providers: make(map[string]Provider)}
+	r.Register("env", ProviderFunc(resolveEnv))
+	r.Register("file", ProviderFunc(resolveFile))
+	return r
+}
+
+// Register 注册指定 scheme 的提供者，已存在时替换
+func (r *Resolver) Register(scheme string, p Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[scheme] = p
+}
+
+// IsReference 判断值是否为已注册 scheme 的密钥引用
+func (r *Resolver) IsReference(value string) bool {
+	scheme, _, ok := splitReference(value)
+	if !ok {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, exists := r.providers[scheme]
+	return exists
+}
+
+// Resolve 解析密钥引用，resolved 表示值是否为引用并已被替换
+// 非引用的值原样返回；错误信息只包含引用本身，不包含解析出的密钥
+func (r *Resolver) Resolve(value string) (result string, resolved bool, err error) {
+	if m := legacyEnvPattern.FindStringSubmatch(value); m != nil {
+		if env, ok := os.LookupEnv(m[1]); ok && env != "" {
+			return env, true, nil
+		}
+		return value, false, nil
+	}
+
+	scheme, ref, ok := splitReference(value)
+	if !ok {
+		return value, false, nil
+	}
+	r.mu.RLock()
+	p, exists := r.providers[scheme]
+	r.mu.RUnlock()
+	if !exists {
+		return value, false, nil
+	}
+
+	secret, err := p.Resolve(ref)
+	if err != nil {
+		return "", false, fmt.Errorf("解析密钥引用 %s 失败: %w", value, err)
+	}
+	return secret, true, nil
+}
+
+// splitReference 拆分 scheme://ref
+func splitReference(value string) (scheme, ref string, ok bool) {
+	i := strings.Index(value, "://")
+	if i <= 0 {
+		return "", "", false
+	}
+	scheme = value[:i]
+	for _, c := range scheme {
+		if c < 'a' || c > 'z' {
+			return "", "", false
+		}
+	}
+	return scheme, value[i+3:], true
+}
+
+// resolveEnv 读取环境变量，未设置时返回错误
+func resolveEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("环境变量 %s 未设置", name)
+	}
+	return value, nil
+}
+
+// resolveFile 读取文件内容，去掉末尾换行
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
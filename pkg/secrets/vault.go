@@ -0,0 +1,309 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyEnv 密钥库主密钥的环境变量，优先于密钥文件，值为 base64 编码的32字节密钥
+const KeyEnv = "GO_DEMO_VAULT_KEY"
+
+// keySize AES-256 密钥长度
+const keySize = 32
+
+// vaultVersion 密钥库文件格式版本
+const vaultVersion = 1
+
+// ErrSecretNotFound 密钥库中不存在指定条目
+var ErrSecretNotFound = errors.New("密钥不存在")
+
+// vaultFile 密钥库文件内容，每个条目使用 AES-256-GCM 单独加密，条目名称作为附加数据防止条目互换
+type vaultFile struct {
+	Version   int               `json:"version"`
+	KeyID     string            `json:"key_id"` // 主密钥指纹，用于发现密钥不匹配
+	UpdatedAt time.Time         `json:"updated_at"`
+	Secrets   map[string]string `json:"secrets"` // 名称 -> base64(nonce + 密文)
+}
+
+// Vault 本地加密密钥库
+type Vault struct {
+	mu   sync.RWMutex
+	path string
+	key  []byte
+	file vaultFile
+}
+
+// GenerateKey 生成随机主密钥
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("生成密钥失败: %w", err)
+	}
+	return key, nil
+}
+
+// LoadKey 加载主密钥，环境变量 GO_DEMO_VAULT_KEY 优先，其次读取密钥文件
+func LoadKey(keyFile string) ([]byte, error) {
+	if encoded := os.Getenv(KeyEnv); encoded != "" {
+		return decodeKey(encoded)
+	}
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥文件失败: %w", err)
+	}
+	return decodeKey(string(data))
+}
+
+// WriteKeyFile 写入主密钥文件，权限为0600
+func WriteKeyFile(keyFile string, key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(keyFile), 0o700); err != nil {
+		return fmt.Errorf("创建密钥目录失败: %w", err)
+	}
+	return writeFileAtomic(keyFile, []byte(base64.StdEncoding.EncodeToString(key)+"\n"))
+}
+
+// decodeKey 解码 base64 主密钥
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("密钥格式错误: %w", err)
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("密钥长度必须为%d字节", keySize)
+	}
+	return key, nil
+}
+
+// keyID 主密钥指纹
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// OpenVault 打开密钥库，文件不存在时返回空密钥库，首次 Save 时创建
+func OpenVault(path string, key []byte) (*Vault, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("密钥长度必须为%d字节", keySize)
+	}
+	v := &Vault{
+		path: path,
+		key:  key,
+		file: vaultFile{Version: vaultVersion, KeyID: keyID(key), Secrets: map[string]string{}},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取密钥库失败: %w", err)
+	}
+	if err := json.Unmarshal(data, &v.file); err != nil {
+		return nil, fmt.Errorf("解析密钥库失败: %w", err)
+	}
+	if v.file.Version != vaultVersion {
+		return nil, fmt.Errorf("不支持的密钥库版本: %d", v.file.Version)
+	}
+	if v.file.KeyID != keyID(key) {
+		return nil, fmt.Errorf("主密钥与密钥库不匹配")
+	}
+	if v.file.Secrets == nil {
+		v.file.Secrets = map[string]string{}
+	}
+	return v, nil
+}
+
+// Get 读取并解密条目
+func (v *Vault) Get(name string) (string, error) {
+	v.mu.RLock()
+	encoded, ok := v.file.Secrets[name]
+	v.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	plain, err := decrypt(v.key, name, encoded)
+	if err != nil {
+		return "", fmt.Errorf("解密 %s 失败: %w", name, err)
+	}
+	return plain, nil
+}
+
+// Set 加密并保存条目，需调用 Save 写入文件
+func (v *Vault) Set(name, value string) error {
+	if name == "" {
+		return fmt.Errorf("密钥名称不能为空")
+	}
+	encoded, err := encrypt(v.key, name, value)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.file.Secrets[name] = encoded
+	v.mu.Unlock()
+	return nil
+}
+
+// Delete 删除条目，需调用 Save 写入文件
+func (v *Vault) Delete(name string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.file.Secrets[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	delete(v.file.Secrets, name)
+	return nil
+}
+
+// Names 获取全部条目名称
+func (v *Vault) Names() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	names := make([]string, 0, len(v.file.Secrets))
+	for name := range v.file.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Rotate 使用新的主密钥重新加密全部条目，需调用 Save 写入文件
+func (v *Vault) Rotate(newKey []byte) error {
+	if len(newKey) != keySize {
+		return fmt.Errorf("密钥长度必须为%d字节", keySize)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	rotated := make(map[string]string, len(v.file.Secrets))
+	for name, encoded := range v.file.Secrets {
+		plain, err := decrypt(v.key, name, encoded)
+		if err != nil {
+			return fmt.Errorf("解密 %s 失败: %w", name, err)
+		}
+		if rotated[name], err = encrypt(newKey, name, plain); err != nil {
+			return err
+		}
+	}
+	v.key = newKey
+	v.file.KeyID = keyID(newKey)
+	v.file.Secrets = rotated
+	return nil
+}
+
+// Save 写入密钥库文件，权限为0600
+func (v *Vault) Save() error {
+	v.mu.Lock()
+	v.file.UpdatedAt = time.Now().UTC()
+	data, err := json.MarshalIndent(v.file, "", "  ")
+	v.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("序列化密钥库失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), 0o700); err != nil {
+		return fmt.Errorf("创建密钥库目录失败: %w", err)
+	}
+	return writeFileAtomic(v.path, append(data, '\n'))
+}
+
+// encrypt 使用 AES-256-GCM 加密，条目名称作为附加数据
+func encrypt(key []byte, name, plain string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("生成随机数失败: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), []byte(name))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt 解密 encrypt 的结果
+func decrypt(key []byte, name, encoded string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("密文格式错误: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("密文长度错误")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], []byte(name))
+	if err != nil {
+		return "", fmt.Errorf("密文校验失败: %w", err)
+	}
+	return string(plain), nil
+}
+
+// newGCM 创建 AES-GCM
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("创建加密器失败: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免写入中断损坏原文件
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("设置文件权限失败: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("写入临时文件失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("替换文件失败: %w", err)
+	}
+	return nil
+}
+
+// VaultProvider 创建 vault:// 提供者，首次使用时才加载主密钥和密钥库
+func VaultProvider(path, keyFile string) Provider {
+	var (
+		once  sync.Once
+		vault *Vault
+		err   error
+	)
+	return ProviderFunc(func(name string) (string, error) {
+		once.Do(func() {
+			var key []byte
+			if key, err = LoadKey(keyFile); err != nil {
+				return
+			}
+			vault, err = OpenVault(path, key)
+		})
+		if err != nil {
+			return "", err
+		}
+		return vault.Get(name)
+	})
+}
@@ -0,0 +1,189 @@
+// Package tracing 基于 OpenTelemetry 的分布式链路追踪
+// 使用 W3C traceparent/tracestate 传播链路上下文，支持 OTLP/HTTP、stdout 和文件导出
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// InstrumentationName 埋点库名称
+const InstrumentationName = "go_demo"
+
+// Config 链路追踪配置
+type Config struct {
+	Enabled     bool              `mapstructure:"enabled" yaml:"enabled"`                                               // 是否启用，未启用时仍会透传上游的 traceparent
+	ServiceName string            `mapstructure:"service_name" yaml:"service_name"`                                     // 服务名称，默认 go_demo
+	Exporter    string            `mapstructure:"exporter" yaml:"exporter" validate:"omitempty,oneof=otlp stdout file"` // 导出方式: otlp, stdout, file
+	Endpoint    string            `mapstructure:"endpoint" yaml:"endpoint"`                                             // OTLP/HTTP 地址，如 localhost:4318
+	URLPath     string            `mapstructure:"url_path" yaml:"url_path"`                                             // OTLP/HTTP 路径，默认 /v1/traces
+	Insecure    bool              `mapstructure:"insecure" yaml:"insecure"`                                             // OTLP 是否使用 HTTP 而非 HTTPS
+	Headers     map[string]string `mapstructure:"headers" yaml:"headers"`                                               // OTLP 附加请求头
+	FilePath    string            `mapstructure:"file_path" yaml:"file_path"`                                           // file 导出的文件路径
+	SampleRatio float64           `mapstructure:"sample_ratio" yaml:"sample_ratio" validate:"min=0,max=1"`              // 采样率(0,1]，上游已采样时跟随上游
+}
+
+var (
+	mu       sync.Mutex
+	provider *sdktrace.TracerProvider
+	closers  []io.Closer
+)
+
+func init() {
+	// 默认使用 W3C 传播器，未启用追踪时也能透传上游链路
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+}
+
+// Init 初始化链路追踪，未启用时使用空实现
+func Init(config Config) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := shutdownLocked(context.Background()); err != nil {
+		return fmt.Errorf("关闭旧的链路追踪失败: %w", err)
+	}
+	if !config.Enabled {
+		otel.SetTracerProvider(noop.NewTracerProvider())
+		return nil
+	}
+
+	exporter, err := newExporter(config)
+	if err != nil {
+		return fmt.Errorf("创建链路导出器失败: %w", err)
+	}
+
+	serviceName := config.ServiceName
+	if serviceName == "" {
+		serviceName = "go_demo"
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL, semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return fmt.Errorf("创建链路资源失败: %w", err)
+	}
+
+	ratio := config.SampleRatio
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(provider)
+	return nil
+}
+
+// newExporter 根据配置创建导出器
+func newExporter(config Config) (sdktrace.SpanExporter, error) {
+	switch config.Exporter {
+	case "", "otlp":
+		opts := []otlptracehttp.Option{}
+		if config.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(config.Endpoint))
+		}
+		if config.URLPath != "" {
+			opts = append(opts, otlptracehttp.WithURLPath(config.URLPath))
+		}
+		if config.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		if len(config.Headers) > 0 {
+			opts = append(opts, otlptracehttp.WithHeaders(config.Headers))
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "file":
+		if config.FilePath == "" {
+			return nil, fmt.Errorf("file 导出需要配置 file_path")
+		}
+		if err := os.MkdirAll(filepath.Dir(config.FilePath), 0o755); err != nil {
+			return nil, fmt.Errorf("创建链路文件目录失败: %w", err)
+		}
+		f, err := os.OpenFile(config.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("打开链路文件失败: %w", err)
+		}
+		closers = append(closers, f)
+		return stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, fmt.Errorf("不支持的导出方式 %q", config.Exporter)
+	}
+}
+
+// Shutdown 导出剩余的span并关闭链路追踪
+func Shutdown(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	return shutdownLocked(ctx)
+}
+
+// shutdownLocked 关闭当前的 TracerProvider，调用方需持有锁
+func shutdownLocked(ctx context.Context) error {
+	var err error
+	if provider != nil {
+		err = provider.Shutdown(ctx)
+		provider = nil
+	}
+	for _, c := range closers {
+		_ = c.Close()
+	}
+	closers = nil
+	return err
+}
+
+// Tracer 获取埋点使用的 tracer
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// ForceFlush 立即导出已结束的span，主要用于测试
+func ForceFlush(ctx context.Context) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if provider == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return provider.ForceFlush(ctx)
+}
+
+// TraceID 获取上下文中的 trace ID，不存在时返回空字符串
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
+
+// SpanID 获取上下文中的 span ID，不存在时返回空字符串
+func SpanID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasSpanID() {
+		return ""
+	}
+	return sc.SpanID().String()
+}
@@ -77,7 +77,7 @@ APP_PORT=8080
 CONFIG_PATH=/app/configs/config.yaml
 
 # JWT 配置
-JWT_SECRET=your-secret-key-change-in-production
+GO_DEMO_JWT_SECRET=$(openssl rand -base64 48 2>/dev/null || head -c 48 /dev/urandom | base64)
 
 # 日志配置
 LOG_LEVEL=info
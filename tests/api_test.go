@@ -3,11 +3,13 @@ package tests
 import (
 	"bytes"
 	"encoding/json"
+	"go_demo/internal/events"
 	"go_demo/internal/handler"
 	"go_demo/internal/models"
 	"go_demo/internal/repository"
 	"go_demo/internal/router"
 	"go_demo/internal/service"
+	"go_demo/pkg/captcha"
 	"go_demo/pkg/logger"
 	"go_demo/pkg/validator"
 	"net/http"
@@ -40,17 +42,21 @@ func setupAPITestRouter(t *testing.T) *gin.Engine {
 
 	// 初始化仓储层
 	userRepo := repository.NewUserRepository(db)
+	txManager := repository.NewTxManager(db)
+	publisher := events.NewOutboxPublisher(repository.NewOutboxRepository(db))
 
 	// 初始化服务层
-	authService := service.NewAuthService(userRepo)
-	userService := service.NewUserService(userRepo)
+	authService := service.NewAuthService(userRepo, txManager, publisher)
+	userService := service.NewUserService(userRepo, txManager, publisher)
 
 	// 初始化处理器
-	authHandler := handler.NewAuthHandler(authService, userService)
+	captchaService := captcha.NewDefaultCaptchaService()
+	authHandler := handler.NewAuthHandler(authService, userService, captchaService)
 	userHandler := handler.NewUserHandler(userService)
+	captchaHandler := handler.NewCaptchaHandler(captchaService)
 
 	// 设置路由
-	r := router.NewRouter(authHandler, userHandler)
+	r := router.NewRouter(authHandler, userHandler, captchaHandler, nil, nil, nil)
 	engine := r.Setup()
 
 	return engine
@@ -0,0 +1,491 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_demo/pkg/cache"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheStrings(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(0, nil)
+
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("未命中应返回 cache.ErrMiss, got %v", err)
+	}
+	var missing string
+	if err := c.GetObject(ctx, "missing", &missing); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("GetObject 未命中应返回 cache.ErrMiss, got %v", err)
+	}
+
+	// 默认使用 JSON 编码
+	type profile struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	if err := c.Set(ctx, "profile", profile{Name: "alice", Age: 30}, 0); err != nil {
+		t.Fatalf("Set 失败: %v", err)
+	}
+	if raw, _ := c.Get(ctx, "profile"); raw != `{"name":"alice","age":30}` {
+		t.Errorf("Get 应返回 JSON, got %s", raw)
+	}
+	var got profile
+	if err := c.GetObject(ctx, "profile", &got); err != nil || got.Name != "alice" || got.Age != 30 {
+		t.Errorf("GetObject 失败: %+v, %v", got, err)
+	}
+
+	if ok, _ := c.SetNX(ctx, "profile", "other", 0); ok {
+		t.Error("键已存在时 SetNX 应返回 false")
+	}
+	if ok, _ := c.SetNX(ctx, "lock", "token", 0); !ok {
+		t.Error("键不存在时 SetNX 应返回 true")
+	}
+
+	if err := c.Delete(ctx, "profile", "lock"); err != nil {
+		t.Fatalf("Delete 失败: %v", err)
+	}
+	if ok, _ := c.Exists(ctx, "profile"); ok {
+		t.Error("删除后键不应存在")
+	}
+}
+
+func TestMemoryCacheCountersAndTTL(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(0, nil)
+
+	if n, _ := c.Increment(ctx, "counter"); n != 1 {
+		t.Errorf("不存在的键自增应从 0 开始, got %d", n)
+	}
+	if n, _ := c.IncrementBy(ctx, "counter", 10); n != 11 {
+		t.Errorf("IncrementBy 结果不正确, got %d", n)
+	}
+	if n, _ := c.DecrementBy(ctx, "counter", 5); n != 6 {
+		t.Errorf("DecrementBy 结果不正确, got %d", n)
+	}
+	if n, _ := c.Decrement(ctx, "counter"); n != 5 {
+		t.Errorf("Decrement 结果不正确, got %d", n)
+	}
+	_ = c.Set(ctx, "text", "abc", 0)
+	if _, err := c.Increment(ctx, "text"); err == nil {
+		t.Error("非整数的值自增应返回错误")
+	}
+
+	if ttl, _ := c.TTL(ctx, "missing"); ttl != -2 {
+		t.Errorf("不存在的键 TTL 应为 -2, got %v", ttl)
+	}
+	if ttl, _ := c.TTL(ctx, "counter"); ttl != -1 {
+		t.Errorf("没有过期时间的键 TTL 应为 -1, got %v", ttl)
+	}
+	if err := c.Expire(ctx, "counter", time.Minute); err != nil {
+		t.Fatalf("Expire 失败: %v", err)
+	}
+	if ttl, _ := c.TTL(ctx, "counter"); ttl != time.Minute {
+		t.Errorf("TTL 应为 1m, got %v", ttl)
+	}
+	// 自增保留过期时间
+	_, _ = c.Increment(ctx, "counter")
+	if ttl, _ := c.TTL(ctx, "counter"); ttl != time.Minute {
+		t.Errorf("自增后应保留过期时间, got %v", ttl)
+	}
+
+	_ = c.Set(ctx, "short", "v", 20*time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.Get(ctx, "short"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("过期的键应返回 cache.ErrMiss, got %v", err)
+	}
+	if err := c.Expire(ctx, "counter", 0); err != nil {
+		t.Fatalf("Expire 失败: %v", err)
+	}
+	if ok, _ := c.Exists(ctx, "counter"); ok {
+		t.Error("过期时间不大于 0 时应删除键")
+	}
+}
+
+func TestMemoryCacheBatch(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(0, nil)
+
+	if err := c.MSet(ctx, map[string]interface{}{"a": 1, "b": "two"}, time.Minute); err != nil {
+		t.Fatalf("MSet 失败: %v", err)
+	}
+	if ttl, _ := c.TTL(ctx, "b"); ttl != time.Minute {
+		t.Errorf("MSet 应设置过期时间, got %v", ttl)
+	}
+	_ = c.RPush(ctx, "list", "v")
+
+	// 不存在的键和非字符串的键不出现在结果中
+	got, err := c.MGet(ctx, "a", "b", "missing", "list")
+	if err != nil {
+		t.Fatalf("MGet 失败: %v", err)
+	}
+	if want := map[string]string{"a": "1", "b": `"two"`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MGet = %v, want %v", got, want)
+	}
+	if got, _ := c.MGet(ctx); len(got) != 0 {
+		t.Errorf("没有键时 MGet 应返回空结果, got %v", got)
+	}
+
+	if err := c.MSet(ctx, map[string]interface{}{"bad": make(chan int)}, 0); err == nil {
+		t.Error("无法编码的值应返回错误")
+	}
+}
+
+func TestMemoryCacheCollections(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(0, nil)
+
+	t.Run("哈希", func(t *testing.T) {
+		_ = c.HSet(ctx, "h", "name", "alice")
+		_ = c.HSet(ctx, "h", "age", 30)
+		_ = c.HSet(ctx, "h", "admin", true)
+		if v, _ := c.HGet(ctx, "h", "age"); v != "30" {
+			t.Errorf("HGet 应返回编码后的值, got %q", v)
+		}
+		if _, err := c.HGet(ctx, "h", "missing"); !errors.Is(err, cache.ErrMiss) {
+			t.Errorf("字段不存在应返回 cache.ErrMiss, got %v", err)
+		}
+		all, _ := c.HGetAll(ctx, "h")
+		if want := map[string]string{"name": `"alice"`, "age": "30", "admin": "true"}; !reflect.DeepEqual(all, want) {
+			t.Errorf("HGetAll = %v, want %v", all, want)
+		}
+		_ = c.HDelete(ctx, "h", "name", "age", "admin")
+		if ok, _ := c.Exists(ctx, "h"); ok {
+			t.Error("删除全部字段后应删除键")
+		}
+		if err := c.HSet(ctx, "h", "x", make(chan int)); err == nil {
+			t.Error("无法编码的值应返回错误")
+		}
+	})
+
+	t.Run("列表", func(t *testing.T) {
+		_ = c.RPush(ctx, "l", "b", "c")
+		_ = c.LPush(ctx, "l", "a", "z")
+		if got, _ := cache.LRangeAs[string](ctx, c, "l", 0, -1); !reflect.DeepEqual(got, []string{"z", "a", "b", "c"}) {
+			t.Errorf("LRange = %v", got)
+		}
+		if got, _ := cache.LRangeAs[string](ctx, c, "l", -2, 10); !reflect.DeepEqual(got, []string{"b", "c"}) {
+			t.Errorf("负数下标 LRange = %v", got)
+		}
+		if got, _ := c.LRange(ctx, "l", 3, 1); len(got) != 0 {
+			t.Errorf("空范围应返回空列表, got %v", got)
+		}
+		if v, _ := c.LPop(ctx, "l"); v != `"z"` {
+			t.Errorf("LPop = %q", v)
+		}
+		if v, _ := c.RPop(ctx, "l"); v != `"c"` {
+			t.Errorf("RPop = %q", v)
+		}
+		_, _ = c.LPop(ctx, "l")
+		_, _ = c.LPop(ctx, "l")
+		if _, err := c.LPop(ctx, "l"); !errors.Is(err, cache.ErrMiss) {
+			t.Errorf("空列表弹出应返回 cache.ErrMiss, got %v", err)
+		}
+	})
+
+	t.Run("集合", func(t *testing.T) {
+		_ = c.SAdd(ctx, "s", "b", "a", "1")
+		if got, _ := c.SMembers(ctx, "s"); !reflect.DeepEqual(got, []string{"1", "a", "b"}) {
+			t.Errorf("SMembers = %v", got)
+		}
+		if ok, _ := c.SIsMember(ctx, "s", "1"); !ok {
+			t.Error("SIsMember 应返回 true")
+		}
+		if ok, _ := c.SIsMember(ctx, "s", "c"); ok {
+			t.Error("SIsMember 应返回 false")
+		}
+		_ = c.SRem(ctx, "s", "a", "b", "1")
+		if ok, _ := c.Exists(ctx, "s"); ok {
+			t.Error("删除全部成员后应删除键")
+		}
+	})
+
+	t.Run("有序集合", func(t *testing.T) {
+		_ = c.ZAdd(ctx, "z", &cache.ZMember{Score: 3, Member: "c"}, &cache.ZMember{Score: 1, Member: "a"},
+			&cache.ZMember{Score: 2, Member: "b"}, &cache.ZMember{Score: 2, Member: "bb"})
+		if got, _ := c.ZRange(ctx, "z", 0, -1); !reflect.DeepEqual(got, []string{"a", "b", "bb", "c"}) {
+			t.Errorf("ZRange = %v", got)
+		}
+		withScores, _ := c.ZRangeWithScores(ctx, "z", -1, -1)
+		if len(withScores) != 1 || withScores[0].Member != "c" || withScores[0].Score != 3 {
+			t.Errorf("ZRangeWithScores = %+v", withScores)
+		}
+		// 更新已有成员的分数
+		_ = c.ZAdd(ctx, "z", &cache.ZMember{Score: 0, Member: "c"})
+		if got, _ := c.ZRange(ctx, "z", 0, 0); !reflect.DeepEqual(got, []string{"c"}) {
+			t.Errorf("更新分数后 ZRange = %v", got)
+		}
+		if n, _ := c.ZRemRangeByScore(ctx, "z", "(1", "+inf"); n != 2 {
+			t.Errorf("ZRemRangeByScore 开区间应删除 2 个成员, got %d", n)
+		}
+		if n, _ := c.ZCard(ctx, "z"); n != 2 {
+			t.Errorf("ZCard = %d", n)
+		}
+		_ = c.ZRem(ctx, "z", "a")
+		if n, _ := c.ZRemRangeByScore(ctx, "z", "-inf", "0"); n != 1 {
+			t.Errorf("ZRemRangeByScore 闭区间应删除 1 个成员, got %d", n)
+		}
+		if _, err := c.ZRemRangeByScore(ctx, "z", "low", "1"); err == nil {
+			t.Error("无效的分数范围应返回错误")
+		}
+	})
+
+	t.Run("类型不匹配", func(t *testing.T) {
+		_ = c.Set(ctx, "str", "v", 0)
+		if err := c.HSet(ctx, "str", "f", "v"); err == nil {
+			t.Error("对字符串执行哈希操作应返回错误")
+		}
+		_ = c.RPush(ctx, "list", "v")
+		if _, err := c.Get(ctx, "list"); err == nil || errors.Is(err, cache.ErrMiss) {
+			t.Errorf("对列表执行 Get 应返回类型错误, got %v", err)
+		}
+	})
+}
+
+func TestMemoryCacheLRU(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(3, nil)
+	for i := 1; i <= 3; i++ {
+		_ = c.Set(ctx, fmt.Sprintf("k%d", i), i, 0)
+	}
+	// 访问 k1 后 k2 成为最久未访问的键
+	_, _ = c.Get(ctx, "k1")
+	_ = c.Set(ctx, "k4", 4, 0)
+
+	if ok, _ := c.Exists(ctx, "k2"); ok {
+		t.Error("超过上限时应淘汰最久未访问的键")
+	}
+	for _, key := range []string{"k1", "k3", "k4"} {
+		if ok, _ := c.Exists(ctx, key); !ok {
+			t.Errorf("%s 不应被淘汰", key)
+		}
+	}
+	if c.Len() != 3 {
+		t.Errorf("键数应为 3, got %d", c.Len())
+	}
+
+	_ = c.FlushDB(ctx)
+	if c.Len() != 0 {
+		t.Errorf("FlushDB 后键数应为 0, got %d", c.Len())
+	}
+}
+
+func TestCacheCodecs(t *testing.T) {
+	ctx := context.Background()
+
+	type address struct {
+		City string `json:"city"`
+	}
+	type profile struct {
+		Name     string    `json:"name"`
+		Age      int       `json:"age"`
+		Tags     []string  `json:"tags"`
+		Address  *address  `json:"address"`
+		JoinedAt time.Time `json:"joined_at"`
+	}
+	want := profile{
+		Name:     "alice",
+		Age:      30,
+		Tags:     []string{"admin", "beta"},
+		Address:  &address{City: "Shanghai"},
+		JoinedAt: time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+	}
+	// msgpack 解码的时间使用本地时区，时间只比较时刻
+	same := func(got profile) bool {
+		if !got.JoinedAt.Equal(want.JoinedAt) {
+			return false
+		}
+		got.JoinedAt = want.JoinedAt
+		return reflect.DeepEqual(got, want)
+	}
+
+	for _, codec := range []cache.Codec{cache.JSON, cache.Msgpack, cache.Gob} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			c := cache.NewMemoryCache(0, codec)
+			if c.Codec() != codec {
+				t.Fatalf("Codec = %s", c.Codec().Name())
+			}
+
+			if err := c.Set(ctx, "profile", want, 0); err != nil {
+				t.Fatalf("Set 失败: %v", err)
+			}
+			var got profile
+			if err := c.GetObject(ctx, "profile", &got); err != nil || !same(got) {
+				t.Errorf("GetObject = %+v, %v", got, err)
+			}
+			if got, err := cache.GetAs[profile](ctx, c, "profile"); err != nil || !same(got) {
+				t.Errorf("GetAs = %+v, %v", got, err)
+			}
+			if _, err := cache.GetAs[profile](ctx, c, "missing"); !errors.Is(err, cache.ErrMiss) {
+				t.Errorf("GetAs 未命中应返回 cache.ErrMiss, got %v", err)
+			}
+
+			_ = c.MSet(ctx, map[string]interface{}{"a": uint(1), "b": uint(0)}, 0)
+			ids, err := cache.MGetAs[uint](ctx, c, "a", "b", "missing")
+			if want := map[string]uint{"a": 1, "b": 0}; err != nil || !reflect.DeepEqual(ids, want) {
+				t.Errorf("MGetAs = %v, %v, want %v", ids, err, want)
+			}
+
+			_ = c.HSet(ctx, "h", "alice", want)
+			if got, err := cache.HGetAs[profile](ctx, c, "h", "alice"); err != nil || !same(got) {
+				t.Errorf("HGetAs = %+v, %v", got, err)
+			}
+			if all, err := cache.HGetAllAs[profile](ctx, c, "h"); err != nil || !same(all["alice"]) {
+				t.Errorf("HGetAllAs = %+v, %v", all, err)
+			}
+
+			_ = c.RPush(ctx, "l", 1, 2, 3)
+			if got, err := cache.LRangeAs[int](ctx, c, "l", 0, -1); err != nil || !reflect.DeepEqual(got, []int{1, 2, 3}) {
+				t.Errorf("LRangeAs = %v, %v", got, err)
+			}
+
+			// 计数器不经过编码，与编码方式无关
+			if n, err := c.IncrementBy(ctx, "counter", 5); err != nil || n != 5 {
+				t.Errorf("IncrementBy = %d, %v", n, err)
+			}
+		})
+	}
+
+	if codec, err := cache.CodecByName(""); err != nil || codec != cache.JSON {
+		t.Errorf("名称为空时应使用 JSON, got %v, %v", codec, err)
+	}
+	if _, err := cache.CodecByName("xml"); err == nil {
+		t.Error("未知的编码方式应返回错误")
+	}
+
+	// 解码失败时返回错误，而不是未命中
+	if _, err := cache.Decode[string](cache.NewMemoryCache(0, cache.Gob), "not gob"); err == nil || errors.Is(err, cache.ErrMiss) {
+		t.Errorf("解码失败应返回错误, got %v", err)
+	}
+}
+
+func TestCacheGetOrSet(t *testing.T) {
+	ctx := context.Background()
+	c := cache.NewMemoryCache(0, nil)
+
+	loads := 0
+	load := func(context.Context) (int, error) {
+		loads++
+		return 42, nil
+	}
+	for i := 0; i < 2; i++ {
+		if v, err := cache.GetOrSet(ctx, c, "answer", time.Minute, load); err != nil || v != 42 {
+			t.Fatalf("GetOrSet = %d, %v", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("命中后不应再加载, loads = %d", loads)
+	}
+	if ttl, _ := c.TTL(ctx, "answer"); ttl != time.Minute {
+		t.Errorf("加载结果应按过期时间写入缓存, got %v", ttl)
+	}
+
+	loadErr := errors.New("load failed")
+	if _, err := cache.GetOrSet(ctx, c, "broken", time.Minute, func(context.Context) (int, error) {
+		return 0, loadErr
+	}); !errors.Is(err, loadErr) {
+		t.Errorf("应返回加载错误, got %v", err)
+	}
+	if ok, _ := c.Exists(ctx, "broken"); ok {
+		t.Error("加载失败时不应写入缓存")
+	}
+}
+
+func TestTieredCache(t *testing.T) {
+	ctx := context.Background()
+	remote := cache.NewMemoryCache(0, nil)
+	bus := cache.NewMemoryBus()
+	cfg := cache.DefaultConfig()
+	newTiered := func() *cache.TieredCache {
+		t.Helper()
+		c, err := cache.NewTieredCache(remote, bus, cfg)
+		if err != nil {
+			t.Fatalf("创建二级缓存失败: %v", err)
+		}
+		return c
+	}
+	a, b := newTiered(), newTiered()
+	defer a.Close()
+	defer b.Close()
+
+	_ = a.Set(ctx, "greeting", "hello", time.Hour)
+	if v, _ := b.Get(ctx, "greeting"); v != `"hello"` {
+		t.Fatalf("应读取二级缓存的值, got %s", v)
+	}
+
+	// 绕过二级缓存直接修改时，本地缓存仍返回旧值
+	_ = remote.Set(ctx, "greeting", "changed", time.Hour)
+	if v, _ := b.Get(ctx, "greeting"); v != `"hello"` {
+		t.Errorf("本地缓存命中时不应读取二级缓存, got %s", v)
+	}
+
+	// 通过任一实例修改时广播失效，其他实例读到新值
+	_ = a.Set(ctx, "greeting", "hi", time.Hour)
+	if v, _ := b.Get(ctx, "greeting"); v != `"hi"` {
+		t.Errorf("失效广播后应读到新值, got %s", v)
+	}
+	_ = a.Delete(ctx, "greeting")
+	if _, err := b.Get(ctx, "greeting"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("删除后应返回 cache.ErrMiss, got %v", err)
+	}
+
+	// 计数器操作只删除本实例的本地副本，不广播，限流等计数不会每个请求都发布消息
+	var published atomic.Int32
+	sub, _ := bus.Subscribe(ctx, func([]byte) { published.Add(1) })
+	if n, _ := a.Increment(ctx, "hits"); n != 1 {
+		t.Errorf("计数器 = %d", n)
+	}
+	if v, _ := a.Get(ctx, "hits"); v != "1" {
+		t.Errorf("计数器 = %s", v)
+	}
+	_, _ = a.Increment(ctx, "hits")
+	_ = a.Expire(ctx, "hits", time.Minute)
+	if v, _ := a.Get(ctx, "hits"); v != "2" {
+		t.Errorf("自增后应读到新值, got %s", v)
+	}
+	_, _ = a.DecrementBy(ctx, "hits", 2)
+	if v, _ := a.Get(ctx, "hits"); v != "0" {
+		t.Errorf("自减后应读到新值, got %s", v)
+	}
+	if n := published.Load(); n != 0 {
+		t.Errorf("计数器操作不应广播失效, 广播了 %d 次", n)
+	}
+	_ = sub.Close()
+
+	// 批量读取合并本地缓存和二级缓存，批量写入广播失效
+	_ = a.Set(ctx, "x", 1, time.Hour)
+	_, _ = b.Get(ctx, "x")
+	_ = remote.Set(ctx, "y", 2, time.Hour)
+	if got, _ := cache.MGetAs[int](ctx, b, "x", "y", "missing"); !reflect.DeepEqual(got, map[string]int{"x": 1, "y": 2}) {
+		t.Errorf("MGet = %v", got)
+	}
+	_ = a.MSet(ctx, map[string]interface{}{"x": 10, "y": 20}, time.Hour)
+	if got, _ := cache.MGetAs[int](ctx, b, "x", "y"); !reflect.DeepEqual(got, map[string]int{"x": 10, "y": 20}) {
+		t.Errorf("MSet 后应读到新值, got %v", got)
+	}
+
+	// 本地缓存时间不超过二级缓存的剩余时间
+	_ = a.Set(ctx, "short", "v", 1100*time.Millisecond)
+	_, _ = b.Get(ctx, "short")
+	time.Sleep(1200 * time.Millisecond)
+	if _, err := b.Get(ctx, "short"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("二级缓存过期后本地也应过期, got %v", err)
+	}
+
+	// 哈希等结构直接读写二级缓存
+	_ = a.HSet(ctx, "h", "f", "v")
+	if v, _ := cache.HGetAs[string](ctx, remote, "h", "f"); v != "v" {
+		t.Errorf("HSet 应写入二级缓存, got %q", v)
+	}
+
+	_ = b.Set(ctx, "k", "v", 0)
+	_, _ = a.Get(ctx, "k")
+	_ = b.FlushDB(ctx)
+	if _, err := a.Get(ctx, "k"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("FlushDB 应清空全部实例的本地缓存, got %v", err)
+	}
+}
@@ -0,0 +1,240 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"go_demo/internal/config"
+	"go_demo/internal/middleware"
+	"go_demo/pkg/cache"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reloadConfigTemplate 热更新测试使用的配置模板，参数依次为端口、日志级别、访问token有效期、允许的来源
+const reloadConfigTemplate = `
+server:
+  port: %d
+  mode: test
+database:
+  dsn: "test:test@tcp(localhost:3306)/test_db"
+jwt:
+  secret_key: "test-secret-key"
+  access_expire: %d
+log:
+  level: %s
+  output_path: "%s"
+cors:
+  allow_origins: ["%s"]
+`
+
+// writeReloadConfig 写入热更新测试配置
+func writeReloadConfig(t *testing.T, path string, port int, level string, accessExpire int, origin string) {
+	t.Helper()
+	content := fmt.Sprintf(reloadConfigTemplate, port, accessExpire, level, filepath.Join(filepath.Dir(path), "app.log"), origin)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeReloadConfig(t, path, 9090, "info", 1800, "*")
+	if _, err := config.Load(path); err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+
+	var notified atomic.Int32
+	var got *config.Config
+	config.Subscribe("test", func(old, new *config.Config) error {
+		notified.Add(1)
+		got = new
+		return nil
+	})
+	defer config.Unsubscribe("test")
+
+	// 端口不支持热更新，保持原值；日志级别和JWT有效期立即生效
+	writeReloadConfig(t, path, 9191, "debug", 600, "*")
+	changes, err := config.Reload(path)
+	if err != nil {
+		t.Fatalf("热更新失败: %v", err)
+	}
+	keys := map[string]bool{}
+	for _, c := range changes {
+		keys[c.Key] = true
+	}
+	if !keys["log.level"] || !keys["jwt.access_expire"] || keys["server.port"] {
+		t.Fatalf("变更项错误: %+v", changes)
+	}
+
+	current := config.GetConfig()
+	if current.Server.Port != 9090 || current.Log.Level != "debug" || current.JWT.AccessExpire != 600 {
+		t.Fatalf("生效的配置错误: %+v", current.Server)
+	}
+	if notified.Load() != 1 || got != current {
+		t.Fatalf("订阅者应收到新配置，实际通知 %d 次", notified.Load())
+	}
+
+	// 无效配置不替换当前配置
+	if err := os.WriteFile(path, []byte("server: [invalid"), 0o644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+	if _, err := config.Reload(path); err == nil {
+		t.Fatal("无效配置应返回错误")
+	}
+	if config.GetConfig() != current {
+		t.Fatal("无效配置不应替换当前配置")
+	}
+}
+
+func TestConfigWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeReloadConfig(t, path, 9090, "info", 1800, "*")
+	if _, err := config.Load(path); err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	defer middleware.SetCORSConfig(middleware.DefaultCORSConfig())
+
+	changed := make(chan struct{}, 1)
+	config.Subscribe("test_watch", func(_, new *config.Config) error {
+		middleware.SetCORSConfig(middleware.CORSConfig{AllowOrigins: new.CORS.AllowOrigins, AllowCredentials: new.CORS.AllowCredentials})
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+		return nil
+	})
+	defer config.Unsubscribe("test_watch")
+
+	watcher, err := config.Watch(path)
+	if err != nil {
+		t.Fatalf("启动监听失败: %v", err)
+	}
+	defer watcher.Close()
+
+	writeReloadConfig(t, path, 9090, "info", 1800, "https://app.example.com")
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("修改配置文件后未触发热更新")
+	}
+
+	// 跨域配置立即生效
+	r := gin.New()
+	r.Use(middleware.CORS())
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+	for origin, want := range map[string]string{
+		"https://app.example.com":  "https://app.example.com",
+		"https://evil.example.org": "",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set("Origin", origin)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != want {
+			t.Errorf("来源 %s 的 Access-Control-Allow-Origin 为 %q，期望 %q", origin, got, want)
+		}
+	}
+}
+
+// fakeCounterCache 仅实现限流中间件用到的计数方法
+type fakeCounterCache struct {
+	cache.CacheInterface
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (f *fakeCounterCache) Increment(_ context.Context, key string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.counts[key]++
+	return f.counts[key], nil
+}
+
+func (f *fakeCounterCache) Expire(context.Context, string, time.Duration) error {
+	return nil
+}
+
+func TestRateLimitReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer middleware.SetRateLimitConfig(middleware.DefaultRateLimitConfig())
+
+	store := &fakeCounterCache{counts: map[string]int64{}}
+	r := gin.New()
+	r.Use(middleware.RateLimit(store))
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func() int {
+		req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 默认未启用
+	for i := 0; i < 5; i++ {
+		if code := request(); code != http.StatusOK {
+			t.Fatalf("未启用限流时应放行: %d", code)
+		}
+	}
+
+	middleware.SetRateLimitConfig(middleware.RateLimitConfig{Enabled: true, Requests: 2, Window: 3600})
+	codes := []int{request(), request(), request()}
+	if codes[0] != http.StatusOK || codes[1] != http.StatusOK || codes[2] != http.StatusTooManyRequests {
+		t.Fatalf("限流结果错误: %v", codes)
+	}
+
+	// 调高限额后立即生效
+	middleware.SetRateLimitConfig(middleware.RateLimitConfig{Enabled: true, Requests: 10, Window: 3600})
+	if code := request(); code != http.StatusOK {
+		t.Fatalf("调高限额后应放行: %d", code)
+	}
+}
+
+func TestRateLimitIgnoresUntrustedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer middleware.SetRateLimitConfig(middleware.DefaultRateLimitConfig())
+	middleware.SetRateLimitConfig(middleware.RateLimitConfig{Enabled: true, Requests: 2, Window: 3600})
+
+	newEngine := func(trustedProxies []string) *gin.Engine {
+		r := gin.New()
+		if err := r.SetTrustedProxies(trustedProxies); err != nil {
+			t.Fatalf("设置信任代理失败: %v", err)
+		}
+		r.Use(middleware.RateLimit(&fakeCounterCache{counts: map[string]int64{}}))
+		r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return r
+	}
+	request := func(r *gin.Engine, forwardedFor string) int {
+		req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 未信任代理时按连接地址计数，伪造 X-Forwarded-For 不能绕过限流
+	r := newEngine(nil)
+	codes := []int{request(r, "1.1.1.1"), request(r, "2.2.2.2"), request(r, "3.3.3.3")}
+	if codes[2] != http.StatusTooManyRequests {
+		t.Errorf("伪造 X-Forwarded-For 不应绕过限流: %v", codes)
+	}
+
+	// 来自信任代理的请求按 X-Forwarded-For 中的客户端IP计数
+	r = newEngine([]string{"10.0.0.0/8"})
+	codes = []int{request(r, "1.1.1.1"), request(r, "2.2.2.2"), request(r, "3.3.3.3")}
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("信任代理后第 %d 个客户端应放行: %d", i+1, code)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"go_demo/internal/config"
+	"go_demo/pkg/logger"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+// invalidConfig 包含多处错误的配置
+const invalidConfig = `
+server:
+  port: 70000
+  mode: prod
+  prot: 1
+database:
+  dsn: ""
+jwt:
+  secret_key: "test-secret-key"
+  access_expire: 0
+log:
+  level: verbose
+  sinks:
+    - type: kafka
+      levle: info
+  redact:
+    patterns:
+      - regex: "(["
+`
+
+func TestConfigValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(invalidConfig), 0o644); err != nil {
+		t.Fatalf("写入配置失败: %v", err)
+	}
+
+	collect := func(opts config.ParseOptions) map[string]bool {
+		_, err := config.Parse(path, opts)
+		if !errors.Is(err, config.ErrInvalidConfig) {
+			t.Fatalf("配置错误应包装 ErrInvalidConfig: %v", err)
+		}
+		var verr *config.ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("应返回 ValidationError: %v", err)
+		}
+		paths := map[string]bool{}
+		for _, fe := range verr.Errors {
+			paths[fe.Path] = true
+		}
+		return paths
+	}
+
+	// 一次报告全部错误，路径与配置文件一致
+	paths := collect(config.ParseOptions{})
+	for _, want := range []string{
+		"server.port", "server.mode", "database.dsn", "jwt.access_expire",
+		"log.level", "log.sinks[0].type", "log.redact.patterns[0].regex",
+	} {
+		if !paths[want] {
+			t.Errorf("缺少配置项 %s 的错误，实际: %v", want, paths)
+		}
+	}
+	if paths["server.prot"] {
+		t.Error("非严格模式不应报告未知配置项")
+	}
+
+	// 严格模式报告拼写错误的配置项
+	paths = collect(config.ParseOptions{Strict: true})
+	if !paths["server.prot"] || !paths["log.sinks[0].levle"] {
+		t.Errorf("严格模式应报告未知配置项，实际: %v", paths)
+	}
+
+	// 无效配置不替换当前配置
+	if _, err := config.Load(path); !errors.Is(err, config.ErrInvalidConfig) {
+		t.Fatalf("加载无效配置应返回 ErrInvalidConfig: %v", err)
+	}
+}
+
+func TestShippedConfigsValid(t *testing.T) {
+	files, _ := filepath.Glob("../configs/*.yaml")
+	if len(files) == 0 {
+		t.Skip("未找到配置文件")
+	}
+	for _, file := range files {
+		if _, err := config.Parse(file, config.ParseOptions{Strict: true, SkipSecrets: true}); err != nil {
+			t.Errorf("%s 校验失败: %v", file, err)
+		}
+	}
+}
+
+// TestShippedConfigsRedactDefaults 配置文件中的脱敏字段和请求头会替换内置规则，需包含全部内置项
+func TestShippedConfigsRedactDefaults(t *testing.T) {
+	files, _ := filepath.Glob("../configs/*.yaml")
+	if len(files) == 0 {
+		t.Skip("未找到配置文件")
+	}
+	defaults := logger.DefaultRedactConfig()
+	for _, file := range files {
+		cfg, err := config.Parse(file, config.ParseOptions{SkipSecrets: true})
+		if err != nil {
+			t.Fatalf("%s 解析失败: %v", file, err)
+		}
+		redact := cfg.Log.Redact
+		for _, field := range defaults.Fields {
+			if redact.Fields != nil && !slices.Contains(redact.Fields, field) {
+				t.Errorf("%s 的 log.redact.fields 缺少内置字段 %s", file, field)
+			}
+		}
+		for _, header := range defaults.Headers {
+			if redact.Headers != nil && !slices.Contains(redact.Headers, header) {
+				t.Errorf("%s 的 log.redact.headers 缺少内置请求头 %s", file, header)
+			}
+		}
+	}
+}
+
+func TestConfigSchemaUpToDate(t *testing.T) {
+	data, err := os.ReadFile("../configs/config.schema.json")
+	if err != nil {
+		t.Skipf("未找到 JSON Schema 文件: %v", err)
+	}
+
+	var committed, generated interface{}
+	if err := json.Unmarshal(data, &committed); err != nil {
+		t.Fatalf("解析 JSON Schema 失败: %v", err)
+	}
+	raw, _ := json.Marshal(config.Schema())
+	_ = json.Unmarshal(raw, &generated)
+	if !reflect.DeepEqual(committed, generated) {
+		t.Fatal("configs/config.schema.json 已过期，请执行 make config-schema 重新生成")
+	}
+}
@@ -1,18 +1,24 @@
 package tests
 
 import (
+	"context"
+	"errors"
 	"go_demo/internal/models"
+	"go_demo/internal/repository"
 	"go_demo/pkg/database"
 	"go_demo/pkg/logger"
+	"os"
 	"testing"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// setupTestDB 创建测试数据库，默认连接本地 MySQL，无法连接时跳过测试
+// 设置 TEST_DB_DRIVER 和 TEST_DB_DSN 可以切换数据库，如 TEST_DB_DRIVER=sqlite TEST_DB_DSN=:memory: 使用进程内数据库
 func setupTestDB(t *testing.T) *gorm.DB {
-	// 使用内存数据库进行测试
-	cfg := database.MySQLConfig{
-		Driver:          "mysql",
+	cfg := database.Config{
+		Driver:          database.DriverMySQL,
 		DSN:             "root:@tcp(localhost:3306)/go_demo_test?charset=utf8mb4&parseTime=True&loc=Local",
 		MaxOpenConns:    10,
 		MaxIdleConns:    5,
@@ -21,7 +27,19 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		LogMode:         false,
 		SlowThreshold:   200,
 	}
+	if driver := os.Getenv("TEST_DB_DRIVER"); driver != "" {
+		cfg.Driver, cfg.DSN = driver, os.Getenv("TEST_DB_DSN")
+	}
+	return openTestDB(t, cfg)
+}
+
+// setupSQLiteDB 创建进程内的 SQLite 内存数据库，不依赖外部服务，每次调用都是独立的空数据库
+func setupSQLiteDB(t *testing.T) *gorm.DB {
+	return openTestDB(t, database.Config{Driver: database.DriverSQLite, DSN: ":memory:"})
+}
 
+// openTestDB 连接测试数据库并创建表
+func openTestDB(t *testing.T, cfg database.Config) *gorm.DB {
 	// 初始化日志（测试模式）
 	logCfg := logger.LogConfig{
 		Level:      "error",
@@ -34,13 +52,13 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	}
 	logger.Init(logCfg)
 
-	db, err := database.NewMySQL(cfg)
+	db, err := database.Open(cfg)
 	if err != nil {
 		t.Skipf("跳过数据库测试，无法连接到测试数据库: %v", err)
 	}
 
 	// 自动迁移测试表
-	err = db.AutoMigrate(&models.User{})
+	err = db.AutoMigrate(&models.User{}, &models.OutboxMessage{}, &models.WebhookSubscription{}, &models.WebhookDelivery{}, &models.WebhookAttempt{}, &models.TaskRun{})
 	if err != nil {
 		t.Fatalf("数据库迁移失败: %v", err)
 	}
@@ -51,6 +69,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 func cleanupTestDB(t *testing.T, db *gorm.DB) {
 	// 清理测试数据
 	db.Exec("DELETE FROM users")
+	db.Exec("DELETE FROM outbox_messages")
 	database.Close(db)
 }
 
@@ -173,7 +192,7 @@ func TestUserDatabase(t *testing.T) {
 		if err != nil {
 			t.Fatalf("使用Unscoped查询删除的用户失败: %v", err)
 		}
-		if deletedUser.DeletedAt.IsZero() {
+		if !deletedUser.DeletedAt.Valid {
 			t.Error("删除时间应该被设置")
 		}
 	})
@@ -230,6 +249,71 @@ func TestUserDatabase(t *testing.T) {
 	})
 }
 
+func TestUserRepositoryOnSQLite(t *testing.T) {
+	db := setupSQLiteDB(t)
+	defer cleanupTestDB(t, db)
+	repo := repository.NewUserRepository(db)
+	ctx := context.Background()
+
+	user := &models.User{Username: "sqliteuser", Email: "sqlite@example.com", Password: "hashed", Mobile: "13800138000", Status: 1}
+	if err := repo.Create(ctx, user); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	if exists, err := repo.ExistsByUsername(ctx, "sqliteuser"); err != nil || !exists {
+		t.Fatalf("用户应存在: %v", err)
+	}
+
+	// 最后登录时间不依赖数据库函数
+	if err := repo.UpdateLastLogin(ctx, user.ID); err != nil {
+		t.Fatalf("更新最后登录时间失败: %v", err)
+	}
+	found, err := repo.GetByUsername(ctx, "sqliteuser")
+	if err != nil {
+		t.Fatalf("查询用户失败: %v", err)
+	}
+	if found.LastLogin == nil || time.Since(*found.LastLogin) > time.Minute {
+		t.Errorf("最后登录时间未更新: %v", found.LastLogin)
+	}
+}
+
+// sequenceSQL 返回 1..n 的 SQLite 查询，n 较大时耗时较长，用于验证查询超时
+const sequenceSQL = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < ?) SELECT x FROM c"
+
+func TestQueryTimeout(t *testing.T) {
+	db := openTestDB(t, database.Config{Driver: database.DriverSQLite, DSN: ":memory:", QueryTimeout: 50 * time.Millisecond})
+	defer cleanupTestDB(t, db)
+	ctx := context.Background()
+
+	var rows []int64
+	err := db.WithContext(ctx).Raw(sequenceSQL, 1000000000).Find(&rows).Error
+	if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+		t.Fatalf("超过 query_timeout 的查询应被取消: %v", err)
+	}
+
+	// 单个请求可以覆盖默认超时
+	err = db.WithContext(database.WithQueryTimeout(ctx, time.Minute)).Raw(sequenceSQL, 100000).Find(&rows).Error
+	if err != nil || len(rows) != 100000 {
+		t.Fatalf("覆盖超时后查询应成功: %v, %d", err, len(rows))
+	}
+
+	// 请求上下文取消时查询同样终止
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := db.WithContext(cancelled).Raw(sequenceSQL, 10).Find(&rows).Error; !errors.Is(err, context.Canceled) {
+		t.Fatalf("请求取消后查询应失败: %v", err)
+	}
+
+	// 同一个链式查询的多条语句互不影响
+	repo := repository.NewUserRepository(db)
+	if err := repo.Create(ctx, &models.User{Username: "timeout", Email: "timeout@example.com", Password: "hashed", Mobile: "13800138002", Status: 1}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	users, total, err := repo.List(ctx, &models.UserQuery{Page: 1, Size: 10})
+	if err != nil || total != 1 || len(users) != 1 {
+		t.Fatalf("Count 之后的 Find 不应使用已结束的上下文: %v, %d, %d", err, total, len(users))
+	}
+}
+
 func TestUserValidation(t *testing.T) {
 	t.Run("用户查询参数验证", func(t *testing.T) {
 		query := &models.UserQuery{
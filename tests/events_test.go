@@ -0,0 +1,299 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"go_demo/internal/events"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"go_demo/internal/service"
+	"go_demo/pkg/lock"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// outboxMessages 按写入顺序返回发件箱中的全部消息
+func outboxMessages(t *testing.T, db *gorm.DB) []models.OutboxMessage {
+	t.Helper()
+	var messages []models.OutboxMessage
+	if err := db.Order("id").Find(&messages).Error; err != nil {
+		t.Fatalf("查询发件箱失败: %v", err)
+	}
+	return messages
+}
+
+func TestDomainEventsOutbox(t *testing.T) {
+	db := setupSQLiteDB(t)
+	defer cleanupTestDB(t, db)
+	ctx := context.Background()
+	txManager := repository.NewTxManager(db)
+	publisher := events.NewOutboxPublisher(repository.NewOutboxRepository(db))
+	userService := service.NewUserService(repository.NewUserRepository(db), txManager, publisher)
+
+	t.Run("业务操作在同一事务中写入事件", func(t *testing.T) {
+		user, err := userService.CreateUser(ctx, models.UserCreateRequest{Username: "eventuser", Password: "123456", Email: "event@example.com"})
+		if err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		if err := userService.UpdateUserStatus(ctx, int(user.ID), 0); err != nil {
+			t.Fatalf("更新用户状态失败: %v", err)
+		}
+		// 状态未变化时不发布事件
+		if err := userService.UpdateUserStatus(ctx, int(user.ID), 0); err != nil {
+			t.Fatalf("更新用户状态失败: %v", err)
+		}
+		if err := userService.DeleteUser(ctx, int(user.ID)); err != nil {
+			t.Fatalf("删除用户失败: %v", err)
+		}
+
+		messages := outboxMessages(t, db)
+		want := []string{events.TypeUserRegistered, events.TypeUserStatusChanged, events.TypeUserDeleted}
+		if len(messages) != len(want) {
+			t.Fatalf("期望 %d 条事件, 实际 %d", len(want), len(messages))
+		}
+		for i, msg := range messages {
+			if msg.EventType != want[i] || msg.AggregateType != events.AggregateUser || msg.Status != models.OutboxStatusPending {
+				t.Errorf("第 %d 条事件不正确: %+v", i, msg)
+			}
+		}
+		var changed events.UserStatusChanged
+		if err := (events.Message{Payload: []byte(messages[1].Payload)}).Decode(&changed); err != nil || changed.UserID != user.ID || changed.OldStatus != 1 || changed.NewStatus != 0 {
+			t.Errorf("状态变更事件内容不正确: %+v, %v", changed, err)
+		}
+	})
+
+	t.Run("事务回滚时事件不写入", func(t *testing.T) {
+		db.Exec("DELETE FROM outbox_messages")
+		errRollback := errors.New("rollback")
+		err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := publisher.Publish(ctx, events.PasswordChanged{UserEvent: events.UserEvent{UserID: 1}}); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Fatalf("期望回滚错误, 实际 %v", err)
+		}
+		if messages := outboxMessages(t, db); len(messages) != 0 {
+			t.Errorf("回滚后不应有事件, 实际 %d 条", len(messages))
+		}
+	})
+}
+
+func TestEventDispatcher(t *testing.T) {
+	db := setupSQLiteDB(t)
+	defer cleanupTestDB(t, db)
+	ctx := context.Background()
+	outbox := repository.NewOutboxRepository(db)
+	publisher := events.NewOutboxPublisher(outbox)
+	config := events.DefaultConfig()
+	config.RetryBackoff = 0
+	config.MaxAttempts = 3
+
+	publish := func(t *testing.T, evts ...events.Event) {
+		t.Helper()
+		db.Exec("DELETE FROM outbox_messages")
+		if err := publisher.Publish(ctx, evts...); err != nil {
+			t.Fatalf("发布事件失败: %v", err)
+		}
+	}
+
+	t.Run("投递给类型订阅者和全部事件订阅者", func(t *testing.T) {
+		publish(t, events.UserLoggedIn{UserEvent: events.UserEvent{UserID: 1}, Username: "alice"})
+		dispatcher := events.NewDispatcher(outbox, nil, config)
+		var typed, all int
+		dispatcher.Subscribe(events.TypeUserLoggedIn, "typed", func(_ context.Context, msg events.Message) error {
+			var event events.UserLoggedIn
+			if err := msg.Decode(&event); err != nil || event.Username != "alice" || msg.Attempt != 1 {
+				t.Errorf("消息内容不正确: %+v, %v", msg, err)
+			}
+			typed++
+			return nil
+		})
+		dispatcher.Subscribe(events.AllEvents, "all", func(context.Context, events.Message) error {
+			all++
+			return nil
+		})
+		dispatcher.Subscribe(events.TypeUserDeleted, "other", func(context.Context, events.Message) error {
+			t.Error("不应收到未订阅的事件")
+			return nil
+		})
+
+		if n, err := dispatcher.DispatchOnce(ctx); err != nil || n != 1 {
+			t.Fatalf("投递失败: %d, %v", n, err)
+		}
+		if typed != 1 || all != 1 {
+			t.Errorf("期望两个订阅者各收到一次, 实际 %d, %d", typed, all)
+		}
+		if msg := outboxMessages(t, db)[0]; msg.Status != models.OutboxStatusProcessed || msg.ProcessedAt == nil || msg.Attempts != 1 {
+			t.Errorf("消息应标记为已投递: %+v", msg)
+		}
+		// 已投递的消息不再投递
+		if n, _ := dispatcher.DispatchOnce(ctx); n != 0 || typed != 1 {
+			t.Errorf("已投递的消息不应重复投递")
+		}
+	})
+
+	t.Run("失败后重试且保持同一聚合的顺序", func(t *testing.T) {
+		publish(t,
+			events.UserLoggedIn{UserEvent: events.UserEvent{UserID: 1}},
+			events.PasswordChanged{UserEvent: events.UserEvent{UserID: 1}},
+			events.UserLoggedIn{UserEvent: events.UserEvent{UserID: 2}},
+		)
+		dispatcher := events.NewDispatcher(outbox, nil, config)
+		var (
+			mu        sync.Mutex
+			delivered []string
+			failed    bool
+		)
+		dispatcher.Subscribe(events.AllEvents, "recorder", func(_ context.Context, msg events.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if msg.AggregateID == "1" && msg.Type == events.TypeUserLoggedIn && !failed {
+				failed = true
+				return errors.New("temporary failure")
+			}
+			delivered = append(delivered, msg.AggregateID+":"+msg.Type)
+			return nil
+		})
+
+		// 用户1的第一条事件失败，后续事件等待；用户2的事件不受影响
+		if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+			t.Fatalf("投递失败: %v", err)
+		}
+		if len(delivered) != 1 || delivered[0] != "2:"+events.TypeUserLoggedIn {
+			t.Fatalf("失败的聚合不应继续投递: %v", delivered)
+		}
+		if msg := outboxMessages(t, db)[0]; msg.Status != models.OutboxStatusPending || msg.Attempts != 1 || msg.LastError == "" {
+			t.Errorf("失败的消息应等待重试: %+v", msg)
+		}
+
+		if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+			t.Fatalf("投递失败: %v", err)
+		}
+		want := []string{"2:" + events.TypeUserLoggedIn, "1:" + events.TypeUserLoggedIn, "1:" + events.TypePasswordChanged}
+		if len(delivered) != len(want) {
+			t.Fatalf("期望投递 %v, 实际 %v", want, delivered)
+		}
+		for i := range want {
+			if delivered[i] != want[i] {
+				t.Errorf("期望投递 %v, 实际 %v", want, delivered)
+				break
+			}
+		}
+	})
+
+	t.Run("超过最大投递次数进入死信", func(t *testing.T) {
+		publish(t,
+			events.UserDeleted{UserEvent: events.UserEvent{UserID: 3}},
+			events.PasswordChanged{UserEvent: events.UserEvent{UserID: 3}},
+		)
+		dispatcher := events.NewDispatcher(outbox, nil, config)
+		var delivered int
+		dispatcher.Subscribe(events.TypeUserDeleted, "panicking", func(context.Context, events.Message) error {
+			panic("boom")
+		})
+		dispatcher.Subscribe(events.TypePasswordChanged, "recorder", func(context.Context, events.Message) error {
+			delivered++
+			return nil
+		})
+
+		for i := 0; i < config.MaxAttempts; i++ {
+			if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+				t.Fatalf("投递失败: %v", err)
+			}
+		}
+		messages := outboxMessages(t, db)
+		if messages[0].Status != models.OutboxStatusDead || messages[0].Attempts != config.MaxAttempts {
+			t.Errorf("消息应进入死信: %+v", messages[0])
+		}
+		// 死信消息不再阻塞同一聚合的后续事件
+		if messages[1].Status != models.OutboxStatusProcessed || delivered != 1 {
+			t.Errorf("后续事件应被投递: %+v, %d", messages[1], delivered)
+		}
+	})
+
+	t.Run("退避中的消息不占用批次", func(t *testing.T) {
+		publish(t,
+			events.UserLoggedIn{UserEvent: events.UserEvent{UserID: 10}},
+			events.UserLoggedIn{UserEvent: events.UserEvent{UserID: 11}},
+			events.UserLoggedIn{UserEvent: events.UserEvent{UserID: 12}},
+		)
+		backoffConfig := config
+		backoffConfig.BatchSize = 2
+		backoffConfig.RetryBackoff = time.Hour
+		dispatcher := events.NewDispatcher(outbox, nil, backoffConfig)
+		var delivered []string
+		dispatcher.Subscribe(events.AllEvents, "recorder", func(_ context.Context, msg events.Message) error {
+			if msg.AggregateID != "13" {
+				return errors.New("temporary failure")
+			}
+			delivered = append(delivered, msg.AggregateID)
+			return nil
+		})
+
+		// 退避中的消息数超过批次大小
+		for i := 0; i < 2; i++ {
+			if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+				t.Fatalf("投递失败: %v", err)
+			}
+		}
+		if err := publisher.Publish(ctx, events.UserLoggedIn{UserEvent: events.UserEvent{UserID: 13}}); err != nil {
+			t.Fatalf("发布事件失败: %v", err)
+		}
+
+		n, err := dispatcher.DispatchOnce(ctx)
+		if err != nil {
+			t.Fatalf("投递失败: %v", err)
+		}
+		if n != 1 || len(delivered) != 1 || delivered[0] != "13" {
+			t.Fatalf("新的聚合应被投递: n=%d, %v", n, delivered)
+		}
+		for _, msg := range outboxMessages(t, db)[:3] {
+			if msg.Status != models.OutboxStatusPending || msg.Attempts != 1 {
+				t.Errorf("退避中的消息不应再次投递: %+v", msg)
+			}
+		}
+	})
+
+	t.Run("其他实例持有投递锁时不投递", func(t *testing.T) {
+		publish(t, events.UserLoggedIn{UserEvent: events.UserEvent{UserID: 4}})
+		locker := lock.NewMemoryLocker()
+		held, ok, err := locker.TryLock(ctx, "events:dispatcher", time.Minute)
+		if err != nil || !ok {
+			t.Fatalf("获取锁失败: %v", err)
+		}
+
+		lockedConfig := config
+		lockedConfig.PollInterval = 10 * time.Millisecond
+		dispatcher := events.NewDispatcher(outbox, locker, lockedConfig)
+		var delivered atomic.Int32
+		dispatcher.Subscribe(events.AllEvents, "recorder", func(context.Context, events.Message) error {
+			delivered.Add(1)
+			return nil
+		})
+		dispatcher.Start()
+		defer dispatcher.Stop(ctx)
+
+		time.Sleep(100 * time.Millisecond)
+		if delivered.Load() != 0 {
+			t.Fatal("投递锁被其他实例持有时不应投递")
+		}
+
+		// 锁释放后由本实例投递
+		if err := held.Release(ctx); err != nil {
+			t.Fatalf("释放锁失败: %v", err)
+		}
+		deadline := time.Now().Add(2 * time.Second)
+		for delivered.Load() == 0 && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if delivered.Load() != 1 {
+			t.Fatalf("锁释放后应投递一次, 实际 %d", delivered.Load())
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go_demo/internal/handler"
+	"go_demo/pkg/health"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthRegistryStatus(t *testing.T) {
+	registry := health.NewRegistry()
+	var optionalErr atomic.Value
+	optionalErr.Store(errors.New("不可用"))
+
+	_ = registry.Register(health.Check{Name: "db", Critical: true, Check: func(ctx context.Context) error { return nil }})
+	_ = registry.Register(health.Check{Name: "optional", Check: func(ctx context.Context) error {
+		if err, ok := optionalErr.Load().(error); ok {
+			return err
+		}
+		return nil
+	}})
+
+	if err := registry.Register(health.Check{Name: "db", Check: func(ctx context.Context) error { return nil }}); err == nil {
+		t.Fatal("重复注册应返回错误")
+	}
+
+	report := registry.Ready(context.Background())
+	if report.Status != health.StatusDegraded || !report.Healthy() {
+		t.Fatalf("非关键检查失败时应为 degraded: %+v", report)
+	}
+	if report.Checks["optional"].Error == "" {
+		t.Fatalf("应返回检查错误: %+v", report.Checks)
+	}
+
+	_ = registry.Register(health.Check{Name: "critical", Critical: true, Check: func(ctx context.Context) error {
+		return errors.New("连接失败")
+	}})
+	if report := registry.Ready(context.Background()); report.Status != health.StatusDown {
+		t.Fatalf("关键检查失败时应为 down: %+v", report)
+	}
+
+	// 存活探针不检查外部依赖
+	if report := registry.Live(context.Background()); report.Status != health.StatusUp || len(report.Checks) != 0 {
+		t.Fatalf("存活探针不应执行依赖检查: %+v", report)
+	}
+}
+
+func TestHealthCheckTimeoutAndCache(t *testing.T) {
+	registry := health.NewRegistry()
+	var calls atomic.Int32
+
+	_ = registry.Register(health.Check{
+		Name:     "slow",
+		Critical: true,
+		Timeout:  50 * time.Millisecond,
+		Check: func(ctx context.Context) error {
+			// 不遵守 ctx 的检查也应按超时返回
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+	_ = registry.Register(health.Check{
+		Name:     "cached",
+		CacheTTL: time.Minute,
+		Check: func(ctx context.Context) error {
+			calls.Add(1)
+			return nil
+		},
+	})
+
+	start := time.Now()
+	report := registry.Ready(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("检查超时未生效，耗时 %s", elapsed)
+	}
+	if report.Checks["slow"].Status != health.StatusDown {
+		t.Fatalf("超时检查应失败: %+v", report.Checks["slow"])
+	}
+
+	report = registry.Ready(context.Background())
+	if calls.Load() != 1 {
+		t.Fatalf("缓存期内不应重复执行检查，实际执行 %d 次", calls.Load())
+	}
+	if !report.Checks["cached"].Cached {
+		t.Fatalf("应标记为缓存结果: %+v", report.Checks["cached"])
+	}
+}
+
+func TestHealthProbeEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := health.NewRegistry()
+	_ = registry.Register(health.Check{Name: "db", Critical: true, Check: func(ctx context.Context) error { return nil }})
+	h := handler.NewHealthHandler(registry)
+
+	r := gin.New()
+	r.GET("/health/live", h.Liveness)
+	r.GET("/health/ready", h.Readiness)
+	r.GET("/health/startup", h.Startup)
+
+	get := func(path string) (int, health.Report) {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var report health.Report
+		_ = json.Unmarshal(w.Body.Bytes(), &report)
+		return w.Code, report
+	}
+
+	if code, _ := get("/health/startup"); code != http.StatusServiceUnavailable {
+		t.Fatalf("启动完成前启动探针应失败: %d", code)
+	}
+	registry.MarkStarted()
+	if code, _ := get("/health/startup"); code != http.StatusOK {
+		t.Fatalf("启动完成后启动探针应成功: %d", code)
+	}
+	if code, report := get("/health/ready"); code != http.StatusOK || report.Checks["db"].Status != health.StatusUp {
+		t.Fatalf("就绪探针应成功: %d %+v", code, report)
+	}
+
+	// 优雅关闭期间就绪探针失败，存活探针不受影响
+	registry.SetShuttingDown()
+	if code, report := get("/health/ready"); code != http.StatusServiceUnavailable || report.Reason == "" {
+		t.Fatalf("关闭期间就绪探针应失败: %d %+v", code, report)
+	}
+	if code, _ := get("/health/live"); code != http.StatusOK {
+		t.Fatalf("关闭期间存活探针应成功: %d", code)
+	}
+}
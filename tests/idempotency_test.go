@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"go_demo/internal/middleware"
+	"go_demo/pkg/cache"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeKVCache 仅实现幂等中间件和用户缓存用到的键值方法，值使用 JSON 编码，其余方法未实现
+type fakeKVCache struct {
+	cache.CacheInterface
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func newFakeKVCache() *fakeKVCache {
+	return &fakeKVCache{data: make(map[string][]byte)}
+}
+
+func (f *fakeKVCache) Codec() cache.Codec {
+	return cache.JSON
+}
+
+func (f *fakeKVCache) Set(_ context.Context, key string, value interface{}, _ time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.data[key] = data
+	return nil
+}
+
+func (f *fakeKVCache) MSet(ctx context.Context, values map[string]interface{}, expiration time.Duration) error {
+	for key, value := range values {
+		if err := f.Set(ctx, key, value, expiration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeKVCache) SetNX(_ context.Context, key string, value interface{}, _ time.Duration) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.data[key]; ok {
+		return false, nil
+	}
+	f.data[key] = data
+	return true, nil
+}
+
+func (f *fakeKVCache) Get(_ context.Context, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, ok := f.data[key]
+	if !ok {
+		return "", cache.ErrMiss
+	}
+	return string(data), nil
+}
+
+func (f *fakeKVCache) GetObject(ctx context.Context, key string, dest interface{}) error {
+	raw, err := f.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(raw), dest)
+}
+
+func (f *fakeKVCache) Delete(_ context.Context, keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, key := range keys {
+		delete(f.data, key)
+	}
+	return nil
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newEngine := func(store cache.CacheInterface, handler gin.HandlerFunc) *gin.Engine {
+		r := gin.New()
+		r.POST("/users", middleware.Idempotency(store), handler)
+		return r
+	}
+
+	doRequest := func(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPost, "/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("重复请求回放首次响应", func(t *testing.T) {
+		var calls int32
+		r := newEngine(newFakeKVCache(), func(c *gin.Context) {
+			n := atomic.AddInt32(&calls, 1)
+			c.Header("X-Custom", "value")
+			c.JSON(http.StatusCreated, gin.H{"call": n})
+		})
+
+		first := doRequest(r, "key-1", `{"username":"a"}`)
+		second := doRequest(r, "key-1", `{"username":"a"}`)
+
+		if calls != 1 {
+			t.Fatalf("期望处理器只执行1次，实际执行%d次", calls)
+		}
+		if second.Code != http.StatusCreated {
+			t.Errorf("期望回放状态码201，得到%d", second.Code)
+		}
+		if second.Body.String() != first.Body.String() {
+			t.Errorf("回放响应体不一致: %s != %s", second.Body.String(), first.Body.String())
+		}
+		if second.Header().Get("X-Custom") != "value" {
+			t.Errorf("期望回放响应头 X-Custom")
+		}
+		if second.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("期望回放响应带有 Idempotent-Replayed 头")
+		}
+	})
+
+	t.Run("相同键不同请求体返回422", func(t *testing.T) {
+		r := newEngine(newFakeKVCache(), func(c *gin.Context) {
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		})
+
+		doRequest(r, "key-2", `{"username":"a"}`)
+		w := doRequest(r, "key-2", `{"username":"b"}`)
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("期望状态码422，得到%d", w.Code)
+		}
+	})
+
+	t.Run("并发重复请求返回409", func(t *testing.T) {
+		started := make(chan struct{})
+		release := make(chan struct{})
+		r := newEngine(newFakeKVCache(), func(c *gin.Context) {
+			close(started)
+			<-release
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		})
+
+		done := make(chan *httptest.ResponseRecorder)
+		go func() {
+			done <- doRequest(r, "key-3", `{}`)
+		}()
+		<-started
+
+		w := doRequest(r, "key-3", `{}`)
+		close(release)
+		first := <-done
+
+		if w.Code != http.StatusConflict {
+			t.Errorf("期望状态码409，得到%d", w.Code)
+		}
+		if first.Code != http.StatusOK {
+			t.Errorf("期望首个请求状态码200，得到%d", first.Code)
+		}
+	})
+
+	t.Run("服务端错误不缓存", func(t *testing.T) {
+		var calls int32
+		r := newEngine(newFakeKVCache(), func(c *gin.Context) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				c.JSON(http.StatusInternalServerError, gin.H{"ok": false})
+				return
+			}
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		})
+
+		doRequest(r, "key-4", `{}`)
+		w := doRequest(r, "key-4", `{}`)
+		if calls != 2 || w.Code != http.StatusOK {
+			t.Errorf("期望重试成功，调用次数=%d 状态码=%d", calls, w.Code)
+		}
+	})
+
+	t.Run("未提供幂等键或缓存为空时直接放行", func(t *testing.T) {
+		var calls int32
+		handler := func(c *gin.Context) {
+			atomic.AddInt32(&calls, 1)
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		}
+
+		r := newEngine(newFakeKVCache(), handler)
+		doRequest(r, "", `{}`)
+		doRequest(r, "", `{}`)
+
+		r = newEngine(nil, handler)
+		doRequest(r, "key-5", `{}`)
+		doRequest(r, "key-5", `{}`)
+
+		if calls != 4 {
+			t.Errorf("期望处理器执行4次，实际执行%d次", calls)
+		}
+	})
+}
@@ -0,0 +1,295 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"go_demo/pkg/jobs"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testJobsConfig 测试用的后台作业配置，轮询和重试间隔都很短
+func testJobsConfig() jobs.Config {
+	cfg := jobs.DefaultConfig()
+	cfg.Backend = "memory"
+	cfg.Queues = map[string]int{jobs.DefaultQueue: 2, "mail": 1}
+	cfg.PollInterval = 10 * time.Millisecond
+	cfg.RetryBackoff = 10 * time.Millisecond
+	cfg.MaxRetryBackoff = 50 * time.Millisecond
+	cfg.MaxAttempts = 3
+	return cfg
+}
+
+// waitUntil 轮询直到条件成立或超时
+func waitUntil(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待超时")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// stopWorker 停止执行器，测试结束前调用
+func stopWorker(t *testing.T, worker *jobs.Worker) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := worker.Stop(ctx); err != nil {
+		t.Errorf("停止作业执行失败: %v", err)
+	}
+}
+
+type welcomeMail struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func TestJobsWorker(t *testing.T) {
+	ctx := context.Background()
+	cfg := testJobsConfig()
+	backend := jobs.NewMemoryBackend(0)
+	client := jobs.NewClient(backend, cfg)
+	worker := jobs.NewWorker(backend, cfg)
+
+	var (
+		mu       sync.Mutex
+		mails    []welcomeMail
+		attempts = map[string]int{}
+	)
+	jobs.Handle(worker, "mail.welcome", func(ctx context.Context, m welcomeMail) error {
+		job, _ := jobs.FromContext(ctx)
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[job.ID] = job.Attempts
+		mails = append(mails, m)
+		return nil
+	})
+	worker.Register("flaky", func(ctx context.Context, job *jobs.Job) error {
+		mu.Lock()
+		attempts[job.ID] = job.Attempts
+		mu.Unlock()
+		if job.Attempts < 3 {
+			return errors.New("暂时失败")
+		}
+		return nil
+	})
+	worker.Register("broken", func(ctx context.Context, job *jobs.Job) error {
+		return jobs.Permanent(errors.New("参数错误"))
+	})
+	worker.Register("panics", func(ctx context.Context, job *jobs.Job) error {
+		panic("boom")
+	})
+
+	if _, err := client.Enqueue(ctx, "mail.welcome", welcomeMail{}, jobs.Queue("unknown")); !errors.Is(err, jobs.ErrUnknownQueue) {
+		t.Fatalf("提交到未配置的队列应失败: %v", err)
+	}
+
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	mail, err := client.Enqueue(ctx, "mail.welcome", welcomeMail{UserID: 1, Email: "a@example.com"}, jobs.Queue("mail"))
+	if err != nil {
+		t.Fatalf("提交作业失败: %v", err)
+	}
+	flaky, err := client.Enqueue(ctx, "flaky", nil)
+	if err != nil {
+		t.Fatalf("提交作业失败: %v", err)
+	}
+	for _, jobType := range []string{"broken", "panics", "unregistered"} {
+		if _, err := client.Enqueue(ctx, jobType, nil, jobs.MaxAttempts(2)); err != nil {
+			t.Fatalf("提交作业失败: %v", err)
+		}
+	}
+
+	waitUntil(t, 5*time.Second, func() bool {
+		mu.Lock()
+		done := len(mails) == 1 && attempts[flaky.ID] == 3
+		mu.Unlock()
+		stats, _ := client.Stats(ctx)
+		return done && stats[jobs.DefaultQueue].Dead == 3 && stats[jobs.DefaultQueue].Processing == 0 &&
+			stats[jobs.DefaultQueue].Delayed == 0
+	})
+
+	mu.Lock()
+	if mails[0].Email != "a@example.com" || attempts[mail.ID] != 1 {
+		t.Errorf("类型化处理函数收到的内容不正确: %+v, attempts=%d", mails[0], attempts[mail.ID])
+	}
+	mu.Unlock()
+
+	dead, err := client.Dead(ctx, jobs.DefaultQueue, 10)
+	if err != nil {
+		t.Fatalf("获取死信失败: %v", err)
+	}
+	byType := map[string]*jobs.Job{}
+	for _, job := range dead {
+		byType[job.Type] = job
+	}
+	if job := byType["broken"]; job == nil || job.Attempts != 1 || job.LastError != "参数错误" {
+		t.Errorf("Permanent 错误应直接进入死信: %+v", job)
+	}
+	if job := byType["panics"]; job == nil || job.Attempts != 2 {
+		t.Errorf("panic 的作业应重试到最大次数后进入死信: %+v", job)
+	}
+	if job := byType["unregistered"]; job == nil || job.Attempts != 1 {
+		t.Errorf("未注册类型的作业应直接进入死信: %+v", job)
+	}
+}
+
+func TestJobsDelayed(t *testing.T) {
+	ctx := context.Background()
+	cfg := testJobsConfig()
+	backend := jobs.NewMemoryBackend(0)
+	client := jobs.NewClient(backend, cfg)
+	worker := jobs.NewWorker(backend, cfg)
+
+	ran := make(chan time.Time, 1)
+	worker.Register("later", func(ctx context.Context, job *jobs.Job) error {
+		ran <- time.Now()
+		return nil
+	})
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	start := time.Now()
+	if _, err := client.Enqueue(ctx, "later", nil, jobs.Delay(150*time.Millisecond)); err != nil {
+		t.Fatalf("提交作业失败: %v", err)
+	}
+	stats, _ := client.Stats(ctx)
+	if stats[jobs.DefaultQueue].Delayed != 1 {
+		t.Errorf("延迟作业应在延迟队列中: %+v", stats[jobs.DefaultQueue])
+	}
+
+	select {
+	case at := <-ran:
+		if at.Sub(start) < 150*time.Millisecond {
+			t.Errorf("延迟作业提前执行: %v", at.Sub(start))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("延迟作业未执行")
+	}
+}
+
+func TestJobsVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	cfg := testJobsConfig()
+	backend := jobs.NewMemoryBackend(0)
+	client := jobs.NewClient(backend, cfg)
+	worker := jobs.NewWorker(backend, cfg)
+
+	if _, err := client.Enqueue(ctx, "report", nil); err != nil {
+		t.Fatalf("提交作业失败: %v", err)
+	}
+
+	// 模拟进程取出作业后退出：占用到期前不会被重新取出
+	crashed, err := backend.Reserve(ctx, jobs.DefaultQueue, 50*time.Millisecond, 0)
+	if err != nil || crashed == nil {
+		t.Fatalf("取出作业失败: %v", err)
+	}
+	if job, _ := backend.Reserve(ctx, jobs.DefaultQueue, time.Minute, 0); job != nil {
+		t.Fatal("占用中的作业不应被重复取出")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	cfg.RetryBackoff = 0
+	if err := jobs.NewWorker(backend, cfg).Maintain(ctx); err != nil {
+		t.Fatalf("维护作业队列失败: %v", err)
+	}
+	if err := backend.Ack(ctx, crashed); !errors.Is(err, jobs.ErrLeaseLost) {
+		t.Errorf("占用过期后确认应返回 ErrLeaseLost: %v", err)
+	}
+
+	ran := make(chan *jobs.Job, 1)
+	worker.Register("report", func(ctx context.Context, job *jobs.Job) error {
+		ran <- job
+		return nil
+	})
+	worker.Start()
+	defer stopWorker(t, worker)
+
+	select {
+	case job := <-ran:
+		if job.ID != crashed.ID || job.Attempts != 2 {
+			t.Errorf("占用过期的作业应重新执行并计入执行次数: id=%s attempts=%d", job.ID, job.Attempts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("占用过期的作业未重新执行")
+	}
+}
+
+func TestJobsGracefulDrain(t *testing.T) {
+	ctx := context.Background()
+	cfg := testJobsConfig()
+	backend := jobs.NewMemoryBackend(0)
+	client := jobs.NewClient(backend, cfg)
+
+	t.Run("等待正在执行的作业完成", func(t *testing.T) {
+		worker := jobs.NewWorker(backend, cfg)
+		started := make(chan struct{})
+		release := make(chan struct{})
+		finished := make(chan struct{})
+		worker.Register("slow", func(ctx context.Context, job *jobs.Job) error {
+			close(started)
+			<-release
+			close(finished)
+			return nil
+		})
+		worker.Start()
+		if _, err := client.Enqueue(ctx, "slow", nil); err != nil {
+			t.Fatalf("提交作业失败: %v", err)
+		}
+		<-started
+
+		stopped := make(chan error, 1)
+		go func() {
+			stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			defer cancel()
+			stopped <- worker.Stop(stopCtx)
+		}()
+		select {
+		case <-stopped:
+			t.Fatal("作业完成前不应停止")
+		case <-time.After(50 * time.Millisecond):
+		}
+		close(release)
+		if err := <-stopped; err != nil {
+			t.Errorf("停止作业执行失败: %v", err)
+		}
+		<-finished
+		if stats, _ := backend.Stats(ctx, jobs.DefaultQueue); stats != (jobs.Stats{}) {
+			t.Errorf("作业完成后队列应为空: %+v", stats)
+		}
+	})
+
+	t.Run("超时后中断作业并放回队列", func(t *testing.T) {
+		worker := jobs.NewWorker(backend, cfg)
+		started := make(chan struct{})
+		worker.Register("stuck", func(ctx context.Context, job *jobs.Job) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		worker.Start()
+		if _, err := client.Enqueue(ctx, "stuck", nil); err != nil {
+			t.Fatalf("提交作业失败: %v", err)
+		}
+		<-started
+
+		stopCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if err := worker.Stop(stopCtx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("超时停止应返回 DeadlineExceeded: %v", err)
+		}
+		stats, _ := backend.Stats(ctx, jobs.DefaultQueue)
+		if stats.Ready != 1 || stats.Processing != 0 || stats.Dead != 0 {
+			t.Fatalf("被中断的作业应放回就绪队列: %+v", stats)
+		}
+		job, _ := backend.Reserve(ctx, jobs.DefaultQueue, time.Minute, 0)
+		if job == nil || job.Attempts != 0 {
+			t.Errorf("被中断的执行不应计入执行次数: %+v", job)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"go_demo/internal/middleware"
+	"go_demo/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLogContextFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	if err := logger.Init(logger.LogConfig{Level: "info", Format: "json", OutputPath: logFile}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(middleware.RequestID(), middleware.Trace(), middleware.LogContext())
+	r.GET("/users/:id", func(c *gin.Context) {
+		// 模拟认证中间件补充用户ID
+		c.Request = c.Request.WithContext(logger.WithContext(c.Request.Context(), logger.Int64("user_id", 42)))
+		logger.InfoCtx(c, "通过gin上下文记录")
+		logger.FromContext(c.Request.Context()).Info("通过请求上下文记录")
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-Trace-ID", "trace-abc")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	logger.Sync()
+
+	lines := readLines(t, logFile)
+	if len(lines) != 2 {
+		t.Fatalf("期望2条日志，得到%d条: %v", len(lines), lines)
+	}
+	for _, line := range lines {
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("日志不是合法JSON: %v", err)
+		}
+		if entry["trace_id"] != "trace-abc" || entry["route"] != "/users/:id" || entry["user_id"] != float64(42) {
+			t.Errorf("日志缺少上下文字段: %s", line)
+		}
+		if id, _ := entry["request_id"].(string); id == "" || id != w.Header().Get("X-Request-ID") {
+			t.Errorf("request_id 不正确: %s", line)
+		}
+	}
+}
+
+func TestWithContextOverride(t *testing.T) {
+	ctx := logger.WithContext(context.Background(), logger.String("route", "/a"), logger.String("request_id", "r1"))
+	ctx = logger.WithContext(ctx, logger.String("route", "/b"))
+
+	fields := logger.ContextFields(ctx)
+	if len(fields) != 2 {
+		t.Fatalf("同名字段应被覆盖，得到%d个字段", len(fields))
+	}
+	for _, f := range fields {
+		if f.Key == "route" && f.String != "/b" {
+			t.Errorf("期望 route=/b，得到 %s", f.String)
+		}
+	}
+	if logger.ContextFields(nil) != nil {
+		t.Error("nil 上下文应返回空字段")
+	}
+	// nil 上下文也能拿到可用的logger
+	logger.FromContext(nil).Debug("nil ctx")
+}
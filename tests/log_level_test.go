@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"encoding/json"
+	"go_demo/internal/admin"
+	"go_demo/internal/config"
+	"go_demo/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNamedLogLevels(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	if err := logger.Init(logger.LogConfig{
+		Level:      "info",
+		Levels:     "webhook=debug, repository=error",
+		Format:     "json",
+		OutputPath: logFile,
+	}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	logger.Named("webhook").Debug("webhook-debug")
+	logger.Named("webhook.delivery").Debug("webhook-delivery-debug")
+	logger.Named("handler").Debug("handler-debug")
+	logger.Named("repository").Warn("repository-warn")
+	logger.Info("global-info")
+	logger.Sync()
+
+	content := strings.Join(readLines(t, logFile), "\n")
+	for _, want := range []string{"webhook-debug", "webhook-delivery-debug", "global-info"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("期望输出 %s: %s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"handler-debug", "repository-warn"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("不应输出 %s: %s", unwanted, content)
+		}
+	}
+
+	if _, err := logger.ParseLevels("webhook"); err == nil {
+		t.Error("期望格式错误返回错误")
+	}
+	if _, err := logger.ParseLevels("webhook=verbose"); err == nil {
+		t.Error("期望无效级别返回错误")
+	}
+	if _, err := logger.ParseLevels("service=info"); err == nil {
+		t.Error("期望未登记的logger名称返回错误")
+	}
+}
+
+func TestSetLevelWithTTL(t *testing.T) {
+	if err := logger.Init(logger.LogConfig{Level: "warn", Format: "console"}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	names, _ := logger.ParseLevels("webhook=debug")
+	if err := logger.SetLevels("debug", names, 50*time.Millisecond); err != nil {
+		t.Fatalf("修改日志级别失败: %v", err)
+	}
+	status := logger.GetLevelStatus()
+	if status.Level != "debug" || status.Levels["webhook"] != "debug" || status.RevertAt == nil {
+		t.Fatalf("修改后的级别不正确: %+v", status)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if logger.GetLevelStatus().Level == "warn" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	status = logger.GetLevelStatus()
+	if status.Level != "warn" || len(status.Levels) != 0 || status.RevertAt != nil {
+		t.Errorf("期望到期后恢复为配置级别，得到: %+v", status)
+	}
+
+	if err := logger.SetLevels("verbose", nil, 0); err == nil {
+		t.Error("期望无效级别返回错误")
+	}
+}
+
+func TestLogLevelAdminAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := logger.Init(logger.LogConfig{Level: "info", Format: "console"}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	// 日志级别管理接口挂载在运维管理端口上
+	h := admin.NewHandler(func() *config.Config { return &config.Config{} }, nil)
+
+	doRequest := func(method, body, remoteAddr string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/log/level", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("查看级别", func(t *testing.T) {
+		w := doRequest(http.MethodGet, "", "127.0.0.1:12345")
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"level":"info"`) {
+			t.Errorf("查看级别失败: %d %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("本机修改和恢复级别", func(t *testing.T) {
+		w := doRequest(http.MethodPut, `{"level":"debug","levels":"repository=debug","ttl":"1h"}`, "127.0.0.1:12345")
+		if w.Code != http.StatusOK {
+			t.Fatalf("期望状态码200，得到%d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data logger.LevelStatus `json:"data"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("解析响应失败: %v", err)
+		}
+		if resp.Data.Level != "debug" || resp.Data.Levels["repository"] != "debug" || resp.Data.RevertAt == nil {
+			t.Errorf("响应级别不正确: %+v", resp.Data)
+		}
+
+		w = doRequest(http.MethodDelete, "", "127.0.0.1:12345")
+		if w.Code != http.StatusOK || logger.GetLevelStatus().Level != "info" {
+			t.Errorf("恢复级别失败: %d %+v", w.Code, logger.GetLevelStatus())
+		}
+	})
+
+	t.Run("参数错误", func(t *testing.T) {
+		for _, body := range []string{`{"level":"verbose"}`, `{"levels":"repository"}`, `{"ttl":"abc"}`} {
+			if w := doRequest(http.MethodPut, body, "127.0.0.1:12345"); w.Code != http.StatusBadRequest {
+				t.Errorf("%s 期望状态码400，得到%d", body, w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package tests
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"go_demo/pkg/logger"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// findSinkStat 按名称获取日志输出统计
+func findSinkStat(t *testing.T, name string) logger.SinkStat {
+	t.Helper()
+	for _, s := range logger.SinkStats() {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("未找到日志输出 %s", name)
+	return logger.SinkStat{}
+}
+
+func TestHTTPSink(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		lines []map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "gzip" || r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("请求头不正确: %v", r.Header)
+		}
+		if r.Header.Get("Authorization") != "Bearer test" {
+			t.Errorf("缺少自定义请求头")
+		}
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			t.Errorf("请求体不是gzip: %v", err)
+			return
+		}
+		scanner := bufio.NewScanner(gz)
+		mu.Lock()
+		defer mu.Unlock()
+		for scanner.Scan() {
+			var entry map[string]interface{}
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Errorf("不是合法的NDJSON: %s", scanner.Text())
+				continue
+			}
+			lines = append(lines, entry)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	if err := logger.Init(logger.LogConfig{
+		Level:  "info",
+		Format: "console",
+		Sinks: []logger.SinkConfig{{
+			Name:          "http-test",
+			Type:          "http",
+			URL:           server.URL,
+			Headers:       map[string]string{"Authorization": "Bearer test"},
+			Level:         "warn",
+			FlushInterval: time.Hour,
+		}},
+	}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	logger.Info("info 不发送")
+	logger.Warn("warn-1", logger.String("k", "v"))
+	logger.Error("error-1")
+	logger.Sync()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lines) != 2 {
+		t.Fatalf("期望接收2条日志，得到%d条: %v", len(lines), lines)
+	}
+	if lines[0]["msg"] != "warn-1" || lines[0]["k"] != "v" || lines[1]["level"] != "error" {
+		t.Errorf("日志内容不正确: %v", lines)
+	}
+	if stat := findSinkStat(t, "http-test"); stat.Sent != 2 || stat.Dropped != 0 {
+		t.Errorf("统计不正确: %+v", stat)
+	}
+}
+
+func TestSinkDropPolicy(t *testing.T) {
+	block := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-block
+	}))
+	defer server.Close()
+
+	if err := logger.Init(logger.LogConfig{
+		Level:  "info",
+		Format: "console",
+		Sinks: []logger.SinkConfig{{
+			Name:       "slow",
+			Type:       "http",
+			URL:        server.URL,
+			BufferSize: 10,
+			BatchSize:  1,
+			DropPolicy: logger.DropOldest,
+			Timeout:    time.Second,
+			MaxRetries: -1,
+		}},
+	}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+	defer close(block)
+
+	// 接收端阻塞时写日志也不应阻塞
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		logger.Info("slow sink")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("慢速输出阻塞了日志写入: %v", elapsed)
+	}
+
+	stat := findSinkStat(t, "slow")
+	if stat.Dropped == 0 || stat.Buffered > 10 {
+		t.Errorf("期望缓冲区满时丢弃日志: %+v", stat)
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("无法监听UDP: %v", err)
+	}
+	defer conn.Close()
+
+	if err := logger.Init(logger.LogConfig{
+		Level:  "info",
+		Format: "console",
+		Sinks: []logger.SinkConfig{{
+			Type:     "syslog",
+			Network:  "udp",
+			Address:  conn.LocalAddr().String(),
+			Facility: "local3",
+			AppName:  "demo",
+		}},
+	}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	logger.Warn("syslog message")
+	logger.Sync()
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("未收到syslog消息: %v", err)
+	}
+	msg := string(buf[:n])
+	// local3(19)*8 + warning(4) = 156
+	pattern := regexp.MustCompile(`^<156>1 \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}\S+ \S+ demo \d+ - - \{.*\}$`)
+	if !pattern.MatchString(msg) || !strings.Contains(msg, "syslog message") {
+		t.Errorf("syslog 格式不正确: %s", msg)
+	}
+}
+
+func TestSinkConfigValidation(t *testing.T) {
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	for _, cfg := range []logger.SinkConfig{
+		{Type: "kafka"},
+		{Type: "http", URL: "ftp://example.com"},
+		{Type: "syslog", Network: "udp"},
+		{Type: "syslog", Address: "127.0.0.1:514", Facility: "unknown"},
+		{Type: "http", URL: "http://127.0.0.1", DropPolicy: "block"},
+	} {
+		if err := logger.Init(logger.LogConfig{Level: "error", Format: "console", Sinks: []logger.SinkConfig{cfg}}); err == nil {
+			t.Errorf("期望配置 %+v 返回错误", cfg)
+		}
+	}
+}
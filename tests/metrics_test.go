@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"go_demo/internal/middleware"
+	"go_demo/pkg/metrics"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// fakePoolStats 固定返回的Redis连接池统计
+type fakePoolStats struct{}
+
+func (fakePoolStats) PoolStats() *redis.PoolStats {
+	return &redis.PoolStats{Hits: 5, Misses: 2, TotalConns: 3, IdleConns: 1}
+}
+
+// scrapeMetrics 抓取指标文本
+func scrapeMetrics(t *testing.T, r http.Handler) string {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("抓取指标失败: %d", w.Code)
+	}
+	body, _ := io.ReadAll(w.Body)
+	return string(body)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.Metrics())
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	r.GET("/items/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/items/1", "/items/2", "/not-found"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if err := metrics.RegisterRedisPoolStats("test", fakePoolStats{}); err != nil {
+		t.Fatalf("注册Redis指标失败: %v", err)
+	}
+	// 重复注册同名采集器不应报错
+	if err := metrics.RegisterRedisPoolStats("test", fakePoolStats{}); err != nil {
+		t.Fatalf("重复注册Redis指标失败: %v", err)
+	}
+	metrics.CaptchaFailuresTotal.WithLabelValues("login").Inc()
+
+	body := scrapeMetrics(t, r)
+	for _, want := range []string{
+		`go_demo_http_requests_total{method="GET",route="/items/:id",status="200"} 2`,
+		`go_demo_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`go_demo_http_request_duration_seconds_bucket{method="GET",route="/items/:id",status="200",le="+Inf"} 2`,
+		`go_demo_redis_pool_total_connections{pool="test"} 3`,
+		`go_demo_redis_pool_hits_total{pool="test"} 5`,
+		`go_demo_auth_captcha_failures_total{action="login"}`,
+		`go_goroutines`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("指标中缺少 %s", want)
+		}
+	}
+	if strings.Contains(body, "/not-found") {
+		t.Error("未匹配的路径不应作为标签值")
+	}
+}
@@ -0,0 +1,209 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go_demo/internal/migrations"
+	"go_demo/pkg/migrate"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"gorm.io/gorm"
+)
+
+func TestMigrateLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0002_add_orders.up.sql":     {Data: []byte("CREATE TABLE orders (id INT);")},
+		"sql/0002_add_orders.down.sql":   {Data: []byte("DROP TABLE orders;")},
+		"sql/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INT);")},
+		"sql/0010_no_down.up.sql":        {Data: []byte("SELECT 1;")},
+		"sql/README.md":                  {Data: []byte("忽略非 SQL 文件")},
+		"sql/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	}
+
+	loaded, err := migrate.LoadFS(fsys, "sql")
+	if err != nil {
+		t.Fatalf("加载迁移失败: %v", err)
+	}
+	var versions []int64
+	for _, m := range loaded {
+		versions = append(versions, m.Version)
+	}
+	if !reflect.DeepEqual(versions, []int64{1, 2, 10}) {
+		t.Fatalf("迁移应按版本号排序，实际: %v", versions)
+	}
+	if loaded[0].Name != "create_users" || loaded[0].Source != "sql" || loaded[0].Down == nil {
+		t.Errorf("迁移信息错误: %+v", loaded[0])
+	}
+	if loaded[2].Down != nil {
+		t.Error("没有 down 文件的迁移不应支持回滚")
+	}
+
+	// 校验和随内容变化
+	again, _ := migrate.LoadFS(fsys, "sql")
+	if again[0].Checksum != loaded[0].Checksum {
+		t.Error("相同内容的校验和应一致")
+	}
+	fsys["sql/0001_create_users.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE IF EXISTS users;")}
+	changed, _ := migrate.LoadFS(fsys, "sql")
+	if changed[0].Checksum == loaded[0].Checksum {
+		t.Error("修改 down 文件后校验和应变化")
+	}
+
+	for name, files := range map[string]fstest.MapFS{
+		"缺少 up":   {"sql/0001_a.down.sql": {Data: []byte("SELECT 1;")}},
+		"版本号无效":   {"sql/v1_a.up.sql": {Data: []byte("SELECT 1;")}},
+		"缺少方向":    {"sql/0001_a.sql": {Data: []byte("SELECT 1;")}},
+		"同版本名称不同": {"sql/0001_a.up.sql": {Data: []byte("SELECT 1;")}, "sql/0001_b.down.sql": {Data: []byte("SELECT 1;")}},
+	} {
+		if _, err := migrate.LoadFS(files, "sql"); err == nil {
+			t.Errorf("%s: 应返回错误", name)
+		}
+	}
+}
+
+func TestMigrateSplitStatements(t *testing.T) {
+	content := `
+-- 注释中的分号; 不拆分
+CREATE TABLE t (name VARCHAR(10) DEFAULT 'a;b', note TEXT COMMENT "x;y");
+/* 多行注释;
+   仍然是注释 */
+INSERT INTO t (name) VALUES ('it\'s; ok');
+-- 只有注释的语句被忽略
+;
+UPDATE ` + "`t`" + ` SET name = 'c'`
+
+	got := migrate.SplitStatements(content)
+	want := []string{
+		`CREATE TABLE t (name VARCHAR(10) DEFAULT 'a;b', note TEXT COMMENT "x;y")`,
+		`INSERT INTO t (name) VALUES ('it\'s; ok')`,
+		"UPDATE `t` SET name = 'c'",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("拆分结果错误:\n实际: %q\n期望: %q", got, want)
+	}
+}
+
+func TestAppMigrations(t *testing.T) {
+	// 各数据库的迁移版本号和名称必须一致
+	var expected []string
+	for _, dialect := range migrations.Dialects {
+		all, err := migrations.All(dialect)
+		if err != nil {
+			t.Fatalf("加载 %s 迁移失败: %v", dialect, err)
+		}
+		var names []string
+		for _, m := range all {
+			names = append(names, fmt.Sprintf("%d_%s", m.Version, m.Name))
+		}
+		if expected == nil {
+			expected = names
+		} else if !reflect.DeepEqual(names, expected) {
+			t.Errorf("%s 的迁移与 %s 不一致: %v, %v", dialect, migrations.Dialects[0], names, expected)
+		}
+	}
+	if len(expected) == 0 || expected[0] != "1_create_users" {
+		t.Fatalf("应包含版本1的建表迁移: %v", expected)
+	}
+	if _, err := migrations.All("oracle"); err == nil {
+		t.Error("不支持的数据库应返回错误")
+	}
+
+	all, _ := migrations.All(migrations.Dialects[0])
+	if err := migrate.Validate(append(all, migrate.Go(1, "dup", func(*gorm.DB) error { return nil }, nil))); err == nil {
+		t.Error("重复的版本号应校验失败")
+	}
+}
+
+func TestAppMigrationsUpDown(t *testing.T) {
+	db := setupSQLiteDB(t)
+	defer cleanupTestDB(t, db)
+	ctx := context.Background()
+
+	// setupTestDB 已通过 AutoMigrate 建表，迁移应兼容已存在的表
+	all, err := migrations.All(db.Dialector.Name())
+	if err != nil {
+		t.Fatalf("加载迁移失败: %v", err)
+	}
+	cfg := migrate.Config{Table: "schema_migrations_app_test"}
+	t.Cleanup(func() { _ = db.Migrator().DropTable(cfg.Table) })
+	m, err := migrate.New(db, all, cfg)
+	if err != nil {
+		t.Fatalf("创建迁移执行器失败: %v", err)
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("执行迁移失败: %v", err)
+	}
+	if !db.Migrator().HasIndex("users", "idx_users_mobile") {
+		t.Error("迁移后应存在 idx_users_mobile 索引")
+	}
+	if _, err := m.To(ctx, 0); err != nil {
+		t.Fatalf("回滚全部迁移失败: %v", err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("回滚后 users 表应被删除")
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("重新执行迁移失败: %v", err)
+	}
+	if err := migrations.Seed(db); err != nil {
+		t.Fatalf("创建种子数据失败: %v", err)
+	}
+	if err := migrations.Seed(db); err != nil {
+		t.Fatalf("重复创建种子数据失败: %v", err)
+	}
+}
+
+func TestMigratorWithDatabase(t *testing.T) {
+	db := setupSQLiteDB(t)
+	ctx := context.Background()
+
+	var calls []string
+	step := func(name string) migrate.Func {
+		return func(tx *gorm.DB) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	list := []*migrate.Migration{
+		migrate.Go(1, "first", step("up1"), step("down1")),
+		migrate.Go(2, "second", step("up2"), nil),
+	}
+	cfg := migrate.Config{Table: "schema_migrations_test", LockName: "go_demo_migrations_test"}
+	t.Cleanup(func() { _ = db.Migrator().DropTable(cfg.Table) })
+	_ = db.Migrator().DropTable(cfg.Table)
+
+	m, err := migrate.New(db, list, cfg)
+	if err != nil {
+		t.Fatalf("创建迁移执行器失败: %v", err)
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 2 {
+		t.Fatalf("执行迁移失败: %v, %d", err, len(done))
+	}
+	if done, err := m.Up(ctx); err != nil || len(done) != 0 {
+		t.Fatalf("重复执行不应再执行迁移: %v, %d", err, len(done))
+	}
+
+	// 版本2不支持回滚
+	if _, err := m.Down(ctx, 1); !errors.Is(err, migrate.ErrIrreversible) {
+		t.Fatalf("回滚不可逆迁移应返回 ErrIrreversible: %v", err)
+	}
+
+	// 已执行的迁移被修改时拒绝执行
+	list[0] = migrate.Go(1, "renamed", step("up1"), step("down1"))
+	m, _ = migrate.New(db, list, cfg)
+	if _, err := m.Up(ctx); !errors.Is(err, migrate.ErrChecksumMismatch) {
+		t.Fatalf("校验和不一致应返回 ErrChecksumMismatch: %v", err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil || statuses[0].State != migrate.StateModified || statuses[1].State != migrate.StateApplied {
+		t.Fatalf("迁移状态错误: %v, %+v", err, statuses)
+	}
+
+	if !reflect.DeepEqual(calls, []string{"up1", "up2"}) {
+		t.Errorf("迁移执行顺序错误: %v", calls)
+	}
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"go_demo/internal/middleware"
+	"go_demo/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRedactor(t *testing.T) {
+	redactor, err := logger.NewRedactor(logger.DefaultRedactConfig())
+	if err != nil {
+		t.Fatalf("创建脱敏器失败: %v", err)
+	}
+
+	t.Run("JSON字段脱敏", func(t *testing.T) {
+		body := `{"username":"alice","password":"secret123","data":{"token":"abc","refresh_token":"def"}}`
+		out := redactor.RedactBody("application/json", []byte(body))
+		for _, leaked := range []string{"secret123", `"abc"`, `"def"`} {
+			if strings.Contains(out, leaked) {
+				t.Errorf("脱敏结果泄露了 %s: %s", leaked, out)
+			}
+		}
+		if !strings.Contains(out, "alice") {
+			t.Errorf("非敏感字段不应被脱敏: %s", out)
+		}
+	})
+
+	t.Run("手机号和邮箱掩码", func(t *testing.T) {
+		out := redactor.RedactString("mobile=13812345678 email=alice@example.com")
+		if strings.Contains(out, "13812345678") || !strings.Contains(out, "138****5678") {
+			t.Errorf("手机号掩码错误: %s", out)
+		}
+		if strings.Contains(out, "alice@") || !strings.Contains(out, "a***@example.com") {
+			t.Errorf("邮箱掩码错误: %s", out)
+		}
+	})
+
+	t.Run("请求头和查询参数脱敏", func(t *testing.T) {
+		headers := redactor.RedactHeaders(http.Header{"Authorization": {"Bearer xyz"}, "Accept": {"*/*"}})
+		if headers["Authorization"][0] != logger.RedactMask || headers["Accept"][0] != "*/*" {
+			t.Errorf("请求头脱敏错误: %v", headers)
+		}
+		query := redactor.RedactValues(url.Values{"token": {"xyz"}, "page": {"1"}})
+		if query["token"][0] != logger.RedactMask || query["page"][0] != "1" {
+			t.Errorf("查询参数脱敏错误: %v", query)
+		}
+	})
+
+	t.Run("Content-Type白名单与长度限制", func(t *testing.T) {
+		out := redactor.RedactBody("multipart/form-data; boundary=x", []byte("binary"))
+		if strings.Contains(out, "binary") {
+			t.Errorf("非白名单内容不应被记录: %s", out)
+		}
+
+		limited, err := logger.NewRedactor(logger.RedactConfig{MaxBodySize: 10})
+		if err != nil {
+			t.Fatalf("创建脱敏器失败: %v", err)
+		}
+		out = limited.RedactBody("text/plain", []byte(strings.Repeat("a", 50)))
+		if !strings.HasPrefix(out, strings.Repeat("a", 10)+"...[truncated") {
+			t.Errorf("长度限制错误: %s", out)
+		}
+
+		// 多字节字符不在中间截断，每个汉字3字节，10字节只保留3个汉字
+		out = limited.RedactBody("text/plain", []byte(strings.Repeat("中", 20)))
+		if !utf8.ValidString(out) || !strings.HasPrefix(out, strings.Repeat("中", 3)+"...[truncated 51 bytes]") {
+			t.Errorf("多字节内容截断错误: %q", out)
+		}
+	})
+
+	t.Run("无效正则返回错误", func(t *testing.T) {
+		_, err := logger.NewRedactor(logger.RedactConfig{Patterns: []logger.RedactPattern{{Name: "bad", Regex: "("}}})
+		if err == nil {
+			t.Error("期望无效正则返回错误")
+		}
+	})
+}
+
+func TestRequestLogRedaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logFile := filepath.Join(t.TempDir(), "request.log")
+	if err := logger.Init(logger.LogConfig{Level: "info", Format: "json", OutputPath: logFile}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	r := gin.New()
+	r.Use(middleware.RequestLog())
+	r.POST("/login", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"data": gin.H{"token": "jwt-access-token", "refresh_token": "jwt-refresh-token"}})
+	})
+
+	body := `{"username":"alice","password":"plain-password","captcha":"1234"}`
+	req, _ := http.NewRequest(http.MethodPost, "/login?token=query-token", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	logger.Sync()
+
+	if !strings.Contains(w.Body.String(), "jwt-access-token") {
+		t.Fatalf("脱敏不应影响实际响应: %s", w.Body.String())
+	}
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("读取日志失败: %v", err)
+	}
+	for _, leaked := range []string{"plain-password", "jwt-access-token", "jwt-refresh-token", "query-token"} {
+		if strings.Contains(string(content), leaked) {
+			t.Errorf("请求日志泄露了 %s", leaked)
+		}
+	}
+	if !strings.Contains(string(content), "alice") {
+		t.Errorf("请求日志缺少非敏感字段: %s", content)
+	}
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"context"
+	"database/sql"
+	"go_demo/pkg/database"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// setupReplicatedDB 创建一个主库和两个副本，均为独立的 SQLite 内存数据库
+// 每个库的 items 表中有一行以库名命名的数据，通过读到的数据判断查询落在哪个库
+func setupReplicatedDB(t *testing.T, policy string) (*gorm.DB, *database.Replication) {
+	db, err := database.Open(database.Config{
+		Driver: database.DriverSQLite,
+		DSN:    ":memory:",
+		Replication: database.ReplicationConfig{
+			Replicas: []database.ReplicaConfig{
+				{Name: "replica-1", DSN: ":memory:"},
+				{Name: "replica-2", DSN: ":memory:"},
+			},
+			Policy:              policy,
+			StickyWindow:        time.Minute,
+			MaxLag:              time.Second,
+			HealthCheckInterval: time.Hour,
+		},
+	})
+	if err != nil {
+		t.Fatalf("连接数据库失败: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close(db) })
+
+	replication := database.ReplicationOf(db)
+	if replication == nil {
+		t.Fatal("配置副本后应启用读写分离")
+	}
+
+	const ddl = "CREATE TABLE items (name TEXT)"
+	if err := db.Exec(ddl).Error; err != nil {
+		t.Fatalf("主库建表失败: %v", err)
+	}
+	db.Exec("INSERT INTO items (name) VALUES ('primary')")
+	for name, replica := range replication.DBs() {
+		if _, err := replica.Exec(ddl); err != nil {
+			t.Fatalf("副本建表失败: %v", err)
+		}
+		_, _ = replica.Exec("INSERT INTO items (name) VALUES (?)", name)
+	}
+	return db, replication
+}
+
+// servedBy 查询 items 表，返回处理查询的库名
+func servedBy(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	var name string
+	if err := db.Table("items").Select("name").Limit(1).Scan(&name).Error; err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	return name
+}
+
+func TestReplicationRouting(t *testing.T) {
+	db, _ := setupReplicatedDB(t, database.PolicyRoundRobin)
+
+	// 轮询使用两个副本
+	seen := map[string]int{}
+	for i := 0; i < 4; i++ {
+		seen[servedBy(t, db)]++
+	}
+	if seen["replica-1"] != 2 || seen["replica-2"] != 2 {
+		t.Fatalf("读操作应轮询两个副本，实际: %v", seen)
+	}
+
+	// Raw 查询同样路由到副本，写操作使用主库
+	var name string
+	db.Raw("SELECT name FROM items").Scan(&name)
+	if name == "primary" {
+		t.Error("Raw 查询应路由到副本")
+	}
+	if err := db.Exec("INSERT INTO items (name) VALUES ('written')").Error; err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	var count int64
+	db.WithContext(database.WithPrimary(context.Background())).Table("items").Where("name = ?", "written").Count(&count)
+	if count != 1 {
+		t.Error("写操作应落在主库")
+	}
+
+	// 事务内的读操作使用主库
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		if got := servedBy(t, tx); got != "primary" {
+			t.Errorf("事务内的读操作应使用主库，实际: %s", got)
+		}
+		return nil
+	})
+
+	// 强制主库
+	if got := servedBy(t, db.WithContext(database.WithPrimary(context.Background()))); got != "primary" {
+		t.Errorf("WithPrimary 应使用主库，实际: %s", got)
+	}
+}
+
+func TestReplicationStickyWindow(t *testing.T) {
+	db, _ := setupReplicatedDB(t, database.PolicyRandom)
+
+	alice := database.WithStickyKey(context.Background(), "user:1")
+	bob := database.WithStickyKey(context.Background(), "user:2")
+
+	if got := servedBy(t, db.WithContext(alice)); got == "primary" {
+		t.Fatal("写入前的读操作应使用副本")
+	}
+	if err := db.WithContext(alice).Exec("INSERT INTO items (name) VALUES ('alice')").Error; err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+
+	// 写入后同一用户读主库，其他用户不受影响
+	if got := servedBy(t, db.WithContext(alice)); got != "primary" {
+		t.Errorf("写入后同一用户应读主库，实际: %s", got)
+	}
+	if got := servedBy(t, db.WithContext(bob)); got == "primary" {
+		t.Error("其他用户应继续使用副本")
+	}
+}
+
+func TestReplicationLagEjection(t *testing.T) {
+	db, replication := setupReplicatedDB(t, database.PolicyRoundRobin)
+	dbs := replication.DBs()
+
+	lagging := map[*sql.DB]bool{dbs["replica-1"]: true}
+	replication.SetLagProbe(func(ctx context.Context, db *sql.DB) (time.Duration, error) {
+		if lagging[db] {
+			return time.Hour, nil
+		}
+		return 0, nil
+	})
+	replication.Check(context.Background())
+
+	// 延迟过大的副本被摘除
+	for i := 0; i < 3; i++ {
+		if got := servedBy(t, db); got != "replica-2" {
+			t.Fatalf("延迟过大的副本应被摘除，实际: %s", got)
+		}
+	}
+	for _, s := range replication.Status() {
+		if s.Name == "replica-1" && (s.Healthy || s.Error == "") {
+			t.Errorf("副本状态应为不健康: %+v", s)
+		}
+	}
+
+	// 全部副本不可用时使用主库
+	lagging[dbs["replica-2"]] = true
+	replication.Check(context.Background())
+	if got := servedBy(t, db); got != "primary" {
+		t.Fatalf("全部副本不可用时应使用主库，实际: %s", got)
+	}
+
+	// 延迟恢复后重新加入
+	lagging = map[*sql.DB]bool{}
+	replication.Check(context.Background())
+	if got := servedBy(t, db); got == "primary" {
+		t.Fatal("恢复后的副本应重新参与读流量")
+	}
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"go_demo/internal/middleware"
+	"go_demo/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setupRequestLogEngine 使用指定的日志配置初始化日志并创建测试路由
+func setupRequestLogEngine(t *testing.T, cfg logger.LogConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	if err := logger.Init(cfg); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	t.Cleanup(func() {
+		logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+	})
+
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.AccessLog(), middleware.RequestLog())
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	r.GET("/users/:id", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"id": c.Param("id")})
+	})
+	r.GET("/fail", func(c *gin.Context) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+	})
+	return r
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		t.Fatalf("打开日志文件失败: %v", err)
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines
+}
+
+func TestRequestLogSink(t *testing.T) {
+	dir := t.TempDir()
+	appLog := filepath.Join(dir, "app.log")
+	reqLog := filepath.Join(dir, "request.log")
+	accessLog := filepath.Join(dir, "access.log")
+
+	r := setupRequestLogEngine(t, logger.LogConfig{
+		Level:      "info",
+		Format:     "json",
+		OutputPath: appLog,
+		ReqLogPath: reqLog,
+		ReqLog: logger.ReqLogConfig{
+			Sampling: []logger.RouteSampling{{Route: "/health", Rate: 0}},
+		},
+		AccessLog: logger.AccessLogConfig{Enabled: true, Path: accessLog, Format: "json"},
+	})
+
+	for _, path := range []string{"/users/1", "/health", "/fail"} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	logger.Info("应用日志")
+	logger.Sync()
+
+	t.Run("请求日志与应用日志分离", func(t *testing.T) {
+		for _, line := range readLines(t, appLog) {
+			if strings.Contains(line, `"msg":"req"`) {
+				t.Errorf("应用日志中不应包含请求日志: %s", line)
+			}
+		}
+		lines := readLines(t, reqLog)
+		if len(lines) != 2 {
+			t.Fatalf("期望请求日志2条（/health被采样丢弃），得到%d条: %v", len(lines), lines)
+		}
+		if !strings.Contains(lines[0], "/users/1") || !strings.Contains(lines[1], "/fail") {
+			t.Errorf("请求日志内容不符合预期: %v", lines)
+		}
+	})
+
+	t.Run("访问日志为JSON Lines", func(t *testing.T) {
+		lines := readLines(t, accessLog)
+		if len(lines) != 2 {
+			t.Fatalf("期望访问日志2条，得到%d条: %v", len(lines), lines)
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+			t.Fatalf("访问日志不是合法JSON: %v", err)
+		}
+		if entry["route"] != "/users/:id" || entry["status"].(float64) != 200 {
+			t.Errorf("访问日志字段不符合预期: %v", entry)
+		}
+	})
+}
+
+func TestAccessLogCombinedFormat(t *testing.T) {
+	accessLog := filepath.Join(t.TempDir(), "access.log")
+	r := setupRequestLogEngine(t, logger.LogConfig{
+		Level:     "error",
+		Format:    "console",
+		AccessLog: logger.AccessLogConfig{Enabled: true, Path: accessLog, Format: "combined"},
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/7?token=secret", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	req.Header.Set("Referer", "http://example.com")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := readLines(t, accessLog)
+	if len(lines) != 1 {
+		t.Fatalf("期望访问日志1条，得到%d条", len(lines))
+	}
+	pattern := regexp.MustCompile(`^\S+ - - \[[^\]]+\] "GET /users/7\?token=\S+ HTTP/1\.1" 200 \d+ "http://example.com" "test-agent"$`)
+	if !pattern.MatchString(lines[0]) {
+		t.Errorf("combined 格式不正确: %s", lines[0])
+	}
+	if strings.Contains(lines[0], "secret") {
+		t.Errorf("访问日志泄露了查询参数: %s", lines[0])
+	}
+}
+
+func TestSampleRequest(t *testing.T) {
+	if err := logger.Init(logger.LogConfig{
+		Level:  "error",
+		Format: "console",
+		ReqLog: logger.ReqLogConfig{
+			SampleRate: 0.5,
+			Sampling: []logger.RouteSampling{
+				{Method: "GET", Route: "/health", Rate: 0},
+				{Route: "/api/v1/*", Rate: 1},
+			},
+		},
+	}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	if logger.SampleRequest("GET", "/health", 200, "id") {
+		t.Error("期望 GET /health 不被采样")
+	}
+	if !logger.SampleRequest("HEAD", "/health", 200, "id") {
+		t.Error("方法不匹配时应使用默认采样率")
+	}
+	if !logger.SampleRequest("GET", "/health", 503, "id") {
+		t.Error("错误请求应始终记录")
+	}
+	if !logger.SampleRequest("POST", "/api/v1/users", 200, "id") {
+		t.Error("前缀匹配规则未生效")
+	}
+
+	// 默认采样率0.5，采样结果应大致过半，且同一请求ID结果稳定
+	sampled := 0
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("req-%d", i)
+		first := logger.SampleRequest("GET", "/other", 200, id)
+		if first != logger.SampleRequest("GET", "/other", 200, id) {
+			t.Fatal("同一请求ID的采样结果应一致")
+		}
+		if first {
+			sampled++
+		}
+	}
+	if sampled < 400 || sampled > 600 {
+		t.Errorf("采样比例偏差过大: %d/1000", sampled)
+	}
+}
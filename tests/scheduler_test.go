@@ -0,0 +1,361 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"go_demo/internal/events"
+	"go_demo/internal/maintenance"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"go_demo/internal/scheduler"
+	"go_demo/pkg/cron"
+	"go_demo/pkg/lock"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCronSchedule(t *testing.T) {
+	from := time.Date(2024, 1, 31, 10, 17, 30, 0, time.UTC) // 星期三
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 30, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2024, 2, 1, 3, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2024, 1, 31, 13, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * SUN", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * MON", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}, // 日和星期满足任意一个
+		{"0 12 * MAR,APR *", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 10m", time.Date(2024, 1, 31, 10, 20, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		schedule, err := cron.Parse(tt.expr)
+		if err != nil {
+			t.Errorf("解析 %q 失败: %v", tt.expr, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(tt.want) {
+			t.Errorf("%q 的下次执行时间为 %v，期望 %v", tt.expr, got, tt.want)
+		}
+	}
+
+	if next := mustParseCron(t, "0 0 30 2 *").Next(from); !next.IsZero() {
+		t.Errorf("2月30日不应有执行时间: %v", next)
+	}
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "5-1 * * * *", "*/0 * * * *", "@every 10ms", "0 0 * * FOO"} {
+		if err := cron.Validate(expr); err == nil {
+			t.Errorf("%q 应校验失败", expr)
+		}
+	}
+}
+
+// mustParseCron 解析 cron 表达式，失败时结束测试
+func mustParseCron(t *testing.T, expr string) cron.Schedule {
+	t.Helper()
+	schedule, err := cron.Parse(expr)
+	if err != nil {
+		t.Fatalf("解析 %q 失败: %v", expr, err)
+	}
+	return schedule
+}
+
+func TestMemoryLocker(t *testing.T) {
+	ctx := context.Background()
+	locker := lock.NewMemoryLocker()
+
+	held, ok, err := locker.TryLock(ctx, "task", 50*time.Millisecond)
+	if err != nil || !ok {
+		t.Fatalf("获取锁失败: ok=%v err=%v", ok, err)
+	}
+	if _, ok, _ := locker.TryLock(ctx, "task", time.Minute); ok {
+		t.Fatal("锁被占用时不应获取成功")
+	}
+	if err := held.Refresh(ctx, 50*time.Millisecond); err != nil {
+		t.Errorf("续期失败: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	other, ok, _ := locker.TryLock(ctx, "task", time.Minute)
+	if !ok {
+		t.Fatal("锁过期后应能获取")
+	}
+	if err := held.Refresh(ctx, time.Minute); !errors.Is(err, lock.ErrNotHeld) {
+		t.Errorf("锁被其他持有者获取后续期应返回 ErrNotHeld: %v", err)
+	}
+	if err := held.Release(ctx); !errors.Is(err, lock.ErrNotHeld) {
+		t.Errorf("不应释放其他持有者的锁: %v", err)
+	}
+	if err := other.Release(ctx); err != nil {
+		t.Errorf("释放锁失败: %v", err)
+	}
+}
+
+func TestSchedulerRunNow(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLiteDB(t)
+	locker := lock.NewMemoryLocker()
+	s := scheduler.New(repository.NewTaskRunRepository(db), locker, scheduler.Config{LockTTL: 30 * time.Millisecond})
+
+	if err := s.Register(scheduler.Task{Name: "ok", Run: func(ctx context.Context) (int64, error) {
+		// 执行时间超过锁的过期时间，依靠续期保持持有
+		time.Sleep(80 * time.Millisecond)
+		if _, ok, _ := locker.TryLock(ctx, "scheduler:ok", time.Minute); ok {
+			return 0, errors.New("执行期间锁已过期")
+		}
+		return 3, nil
+	}}); err != nil {
+		t.Fatalf("注册任务失败: %v", err)
+	}
+	if err := s.Register(scheduler.Task{Name: "fails", Schedule: "@daily", Run: func(ctx context.Context) (int64, error) {
+		return 1, errors.New("清理失败")
+	}}); err != nil {
+		t.Fatalf("注册任务失败: %v", err)
+	}
+	if err := s.Register(scheduler.Task{Name: "ok", Run: func(ctx context.Context) (int64, error) { return 0, nil }}); !errors.Is(err, scheduler.ErrDuplicateTask) {
+		t.Errorf("重复注册应失败: %v", err)
+	}
+	if err := s.Register(scheduler.Task{Name: "bad", Schedule: "* * *", Run: func(ctx context.Context) (int64, error) { return 0, nil }}); err == nil {
+		t.Error("无效的 cron 表达式应注册失败")
+	}
+
+	run, err := s.RunNow(ctx, "ok")
+	if err != nil {
+		t.Fatalf("执行任务失败: %v", err)
+	}
+	if run.Status != models.TaskRunSucceeded || run.Affected != 3 || run.TriggeredBy != models.TaskTriggerManual ||
+		run.DurationMs < 80 || run.FinishedAt == nil {
+		t.Errorf("执行记录不正确: %+v", run)
+	}
+
+	run, err = s.RunNow(ctx, "fails")
+	if err == nil || run == nil || run.Status != models.TaskRunFailed || run.Error != "清理失败" {
+		t.Errorf("任务失败时应返回错误和失败的执行记录: run=%+v err=%v", run, err)
+	}
+
+	if _, err := s.RunNow(ctx, "missing"); !errors.Is(err, scheduler.ErrUnknownTask) {
+		t.Errorf("执行未注册的任务应返回 ErrUnknownTask: %v", err)
+	}
+
+	held, _, _ := locker.TryLock(ctx, "scheduler:ok", time.Minute)
+	if _, err := s.RunNow(ctx, "ok"); !errors.Is(err, scheduler.ErrLocked) {
+		t.Errorf("任务正在其他实例执行时应返回 ErrLocked: %v", err)
+	}
+	_ = held.Release(ctx)
+
+	history, err := s.History(ctx, "", 10)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("查询执行记录失败: %d 条, err=%v", len(history), err)
+	}
+	if history[0].Task != "fails" || history[1].Task != "ok" {
+		t.Errorf("执行记录应按开始时间倒序: %s, %s", history[0].Task, history[1].Task)
+	}
+
+	tasks := s.Tasks()
+	if len(tasks) != 2 || tasks[0].Name != "ok" || !tasks[0].Next.IsZero() || tasks[1].Next.IsZero() {
+		t.Errorf("任务列表不正确: %+v", tasks)
+	}
+}
+
+func TestSchedulerSingleRunAcrossReplicas(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLiteDB(t)
+	repo := repository.NewTaskRunRepository(db)
+	locker := lock.NewMemoryLocker()
+
+	// 两个实例共享锁和执行记录，同一计划时间只执行一次
+	var calls atomic.Int32
+	replicas := make([]*scheduler.Scheduler, 2)
+	for i := range replicas {
+		replicas[i] = scheduler.New(repo, locker, scheduler.DefaultConfig())
+		if err := replicas[i].Register(scheduler.Task{Name: "tick", Schedule: "@every 1s", Run: func(ctx context.Context) (int64, error) {
+			calls.Add(1)
+			time.Sleep(20 * time.Millisecond)
+			return 0, nil
+		}}); err != nil {
+			t.Fatalf("注册任务失败: %v", err)
+		}
+		replicas[i].Start()
+	}
+
+	waitUntil(t, 5*time.Second, func() bool {
+		runs, _ := repo.List(ctx, "tick", 10)
+		return len(runs) > 0 && runs[0].Status == models.TaskRunSucceeded
+	})
+	time.Sleep(100 * time.Millisecond)
+	for _, s := range replicas {
+		stopCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if err := s.Stop(stopCtx); err != nil {
+			t.Errorf("停止调度失败: %v", err)
+		}
+		cancel()
+	}
+
+	runs, err := repo.List(ctx, "tick", 10)
+	if err != nil {
+		t.Fatalf("查询执行记录失败: %v", err)
+	}
+	seen := map[time.Time]bool{}
+	for _, run := range runs {
+		if run.ScheduledAt == nil || run.TriggeredBy != models.TaskTriggerSchedule {
+			t.Errorf("按计划执行的记录应包含计划时间: %+v", run)
+			continue
+		}
+		if seen[run.ScheduledAt.UTC()] {
+			t.Errorf("同一计划时间重复执行: %v", run.ScheduledAt)
+		}
+		seen[run.ScheduledAt.UTC()] = true
+	}
+	if int(calls.Load()) != len(runs) {
+		t.Errorf("任务执行 %d 次，执行记录 %d 条", calls.Load(), len(runs))
+	}
+}
+
+func TestMaintenanceTasks(t *testing.T) {
+	ctx := context.Background()
+	db := setupSQLiteDB(t)
+	users := repository.NewUserRepository(db)
+	outbox := repository.NewOutboxRepository(db)
+	webhooks := repository.NewWebhookRepository(db)
+	taskRuns := repository.NewTaskRunRepository(db)
+	cfg := maintenance.DefaultConfig()
+	cfg.BatchSize = 2 // 覆盖分批处理
+	m := maintenance.New(users, outbox, webhooks, taskRuns, repository.NewTxManager(db), cfg)
+
+	now := time.Now()
+	longAgo := now.AddDate(0, 0, -100)
+	recent := now.Add(-time.Hour)
+	create := func(name string, createdAt time.Time, lastLogin *time.Time, activated int) *models.User {
+		user := &models.User{Username: name, Email: name + "@example.com", Password: "hashed", Status: 1,
+			LastLogin: lastLogin, CreatedAt: createdAt}
+		if err := db.Create(user).Error; err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		// is_activated 的零值会被默认值覆盖，创建后再更新
+		db.Model(user).UpdateColumn("is_activated", activated)
+		return user
+	}
+	exists := func(user *models.User) bool {
+		var count int64
+		db.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Count(&count)
+		return count > 0
+	}
+
+	t.Run("永久删除保留期外的软删除用户", func(t *testing.T) {
+		var old []*models.User
+		for _, name := range []string{"deleted1", "deleted2", "deleted3"} {
+			user := create(name, longAgo, &recent, 1)
+			db.Model(user).UpdateColumn("deleted_at", longAgo)
+			old = append(old, user)
+		}
+		kept := create("deleted_recently", longAgo, &recent, 1)
+		if err := db.Delete(kept).Error; err != nil {
+			t.Fatalf("软删除用户失败: %v", err)
+		}
+
+		n, err := m.PurgeDeletedUsers(ctx)
+		if err != nil || n != 3 {
+			t.Fatalf("永久删除 %d 个用户，期望 3: %v", n, err)
+		}
+		for _, user := range old {
+			if exists(user) {
+				t.Errorf("用户 %s 应被永久删除", user.Username)
+			}
+		}
+		if !exists(kept) {
+			t.Error("保留期内的软删除用户不应被永久删除")
+		}
+		if taken, _ := users.ExistsByUsername(ctx, kept.Username); !taken {
+			t.Error("永久删除前软删除用户的用户名仍应被占用")
+		}
+	})
+
+	t.Run("禁用长期未登录的用户", func(t *testing.T) {
+		stale := create("stale", longAgo, &longAgo, 1)
+		never := create("never_logged_in", longAgo, nil, 1)
+		active := create("active", longAgo, &recent, 1)
+		fresh := create("fresh", recent, nil, 1)
+
+		n, err := m.DisableInactiveUsers(ctx)
+		if err != nil || n != 2 {
+			t.Fatalf("禁用 %d 个用户，期望 2: %v", n, err)
+		}
+		for _, tc := range []struct {
+			user   *models.User
+			status int
+		}{{stale, 0}, {never, 0}, {active, 1}, {fresh, 1}} {
+			got, _ := users.GetByID(ctx, int(tc.user.ID))
+			if got.Status != tc.status {
+				t.Errorf("用户 %s 的状态为 %d，期望 %d", tc.user.Username, got.Status, tc.status)
+			}
+		}
+
+		var changed int64
+		db.Model(&models.OutboxMessage{}).Where("event_type = ?", events.TypeUserStatusChanged).Count(&changed)
+		if changed != 2 {
+			t.Errorf("应为每个禁用的用户发布状态变更事件，实际 %d 条", changed)
+		}
+	})
+
+	t.Run("删除超过有效期未激活的用户", func(t *testing.T) {
+		expired := create("unactivated_old", longAgo, nil, 0)
+		pending := create("unactivated_new", recent, nil, 0)
+
+		n, err := m.ExpireUnactivatedUsers(ctx)
+		if err != nil || n != 1 {
+			t.Fatalf("删除 %d 个用户，期望 1: %v", n, err)
+		}
+		if exists(expired) || !exists(pending) {
+			t.Error("只应删除超过有效期的未激活用户")
+		}
+		if taken, _ := users.ExistsByUsername(ctx, expired.Username); taken {
+			t.Error("删除未激活用户后应释放用户名")
+		}
+		var deleted int64
+		db.Model(&models.OutboxMessage{}).Where("event_type = ? AND aggregate_id = ?", events.TypeUserDeleted, expired.ID).Count(&deleted)
+		if deleted != 1 {
+			t.Errorf("应发布用户删除事件，实际 %d 条", deleted)
+		}
+	})
+
+	t.Run("清理历史事件和投递记录", func(t *testing.T) {
+		old := now.AddDate(0, 0, -120)
+		sub := &models.WebhookSubscription{URL: "https://example.com/hook", Secret: "s", EventTypes: "*", Enabled: true}
+		db.Create(sub)
+		for i, status := range []string{models.WebhookDeliverySucceeded, models.WebhookDeliveryDead, models.WebhookDeliveryDead, models.WebhookDeliveryPending} {
+			delivery := &models.WebhookDelivery{SubscriptionID: sub.ID, EventID: uint64(i + 1), EventType: "user.registered",
+				Payload: "{}", Status: status, NextAttemptAt: old, CreatedAt: old, UpdatedAt: old}
+			db.Create(delivery)
+			db.Create(&models.WebhookAttempt{DeliveryID: delivery.ID, Attempt: 1, TriggeredBy: models.WebhookTriggerAuto})
+		}
+		db.Create(&models.WebhookDelivery{SubscriptionID: sub.ID, EventID: 99, EventType: "user.registered",
+			Payload: "{}", Status: models.WebhookDeliverySucceeded, NextAttemptAt: now})
+		db.Model(&models.OutboxMessage{}).Where("1 = 1").Updates(map[string]interface{}{
+			"status": models.OutboxStatusProcessed, "processed_at": old,
+		})
+		db.Create(&models.TaskRun{Task: "old", TriggeredBy: models.TaskTriggerSchedule, Status: models.TaskRunSucceeded, StartedAt: old})
+		db.Create(&models.TaskRun{Task: "new", TriggeredBy: models.TaskTriggerSchedule, Status: models.TaskRunSucceeded, StartedAt: now})
+
+		if _, err := m.CompactAuditLogs(ctx); err != nil {
+			t.Fatalf("清理失败: %v", err)
+		}
+		var deliveries, attempts, messages int64
+		db.Model(&models.WebhookDelivery{}).Count(&deliveries)
+		db.Model(&models.WebhookAttempt{}).Count(&attempts)
+		db.Model(&models.OutboxMessage{}).Count(&messages)
+		if deliveries != 2 || attempts != 1 {
+			t.Errorf("应只保留待投递和保留期内的投递记录: deliveries=%d attempts=%d", deliveries, attempts)
+		}
+		if messages != 0 {
+			t.Errorf("保留期外已投递的事件应被删除: %d", messages)
+		}
+		runs, _ := taskRuns.List(ctx, "", 10)
+		if len(runs) != 1 || runs[0].Task != "new" {
+			t.Errorf("应只保留保留期内的执行记录: %+v", runs)
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go_demo/internal/config"
+	"go_demo/pkg/secrets"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretResolver(t *testing.T) {
+	r := secrets.NewResolver()
+	t.Setenv("GO_DEMO_TEST_SECRET", "from-env")
+
+	secretFile := filepath.Join(t.TempDir(), "db_password")
+	if err := os.WriteFile(secretFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatalf("写入密钥文件失败: %v", err)
+	}
+
+	tests := []struct {
+		value    string
+		want     string
+		resolved bool
+	}{
+		{"env://GO_DEMO_TEST_SECRET", "from-env", true},
+		{"file://" + secretFile, "from-file", true},
+		{"${GO_DEMO_TEST_SECRET}", "from-env", true},
+		{"${GO_DEMO_TEST_UNSET}", "${GO_DEMO_TEST_UNSET}", false},
+		{"${1}****${2}", "${1}****${2}", false},
+		{"https://example.com", "https://example.com", false},
+		{"plain", "plain", false},
+	}
+	for _, tt := range tests {
+		got, resolved, err := r.Resolve(tt.value)
+		if err != nil {
+			t.Fatalf("解析 %s 失败: %v", tt.value, err)
+		}
+		if got != tt.want || resolved != tt.resolved {
+			t.Errorf("解析 %s 得到 (%q, %v)，期望 (%q, %v)", tt.value, got, resolved, tt.want, tt.resolved)
+		}
+	}
+
+	if _, _, err := r.Resolve("env://GO_DEMO_TEST_UNSET"); err == nil {
+		t.Error("环境变量未设置时应返回错误")
+	}
+}
+
+func TestVault(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets.vault")
+	key, _ := secrets.GenerateKey()
+
+	vault, err := secrets.OpenVault(path, key)
+	if err != nil {
+		t.Fatalf("打开密钥库失败: %v", err)
+	}
+	if err := vault.Set("jwt_secret_key", "top-secret"); err != nil {
+		t.Fatalf("设置密钥失败: %v", err)
+	}
+	if err := vault.Save(); err != nil {
+		t.Fatalf("保存密钥库失败: %v", err)
+	}
+
+	// 文件中只有密文，权限为0600
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "top-secret") {
+		t.Fatal("密钥库文件不应包含明文")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("密钥库文件权限为 %v，期望 0600", info.Mode().Perm())
+	}
+
+	// 主密钥不匹配时拒绝打开
+	otherKey, _ := secrets.GenerateKey()
+	if _, err := secrets.OpenVault(path, otherKey); err == nil {
+		t.Fatal("主密钥不匹配时应返回错误")
+	}
+
+	// 条目名称参与校验，密文不能挪用到其他条目
+	var file struct {
+		Version int               `json:"version"`
+		KeyID   string            `json:"key_id"`
+		Secrets map[string]string `json:"secrets"`
+	}
+	_ = json.Unmarshal(data, &file)
+	file.Secrets["other"] = file.Secrets["jwt_secret_key"]
+	swapped, _ := json.Marshal(file)
+	_ = os.WriteFile(path, swapped, 0o600)
+	reopened, err := secrets.OpenVault(path, key)
+	if err != nil {
+		t.Fatalf("重新打开密钥库失败: %v", err)
+	}
+	if _, err := reopened.Get("other"); err == nil {
+		t.Fatal("挪用的密文应解密失败")
+	}
+	if _, err := reopened.Get("missing"); !errors.Is(err, secrets.ErrSecretNotFound) {
+		t.Fatalf("不存在的条目应返回 ErrSecretNotFound: %v", err)
+	}
+
+	// 更换主密钥后旧密钥失效，条目内容不变
+	_ = reopened.Delete("other")
+	newKey, _ := secrets.GenerateKey()
+	if err := reopened.Rotate(newKey); err != nil {
+		t.Fatalf("更换主密钥失败: %v", err)
+	}
+	if err := reopened.Save(); err != nil {
+		t.Fatalf("保存密钥库失败: %v", err)
+	}
+	if _, err := secrets.OpenVault(path, key); err == nil {
+		t.Fatal("更换主密钥后旧密钥应失效")
+	}
+	rotated, err := secrets.OpenVault(path, newKey)
+	if err != nil {
+		t.Fatalf("使用新主密钥打开失败: %v", err)
+	}
+	if got, _ := rotated.Get("jwt_secret_key"); got != "top-secret" {
+		t.Fatalf("更换主密钥后读取到 %q", got)
+	}
+}
+
+// secretsConfigTemplate 密钥引用测试配置，参数依次为 DSN、JWT密钥、Redis密码、密钥库路径、主密钥文件路径、日志路径
+const secretsConfigTemplate = `
+server:
+  port: 9090
+  mode: test
+database:
+  dsn: "%s"
+jwt:
+  secret_key: "%s"
+redis:
+  Password: "%s"
+log:
+  output_path: "%s"
+secrets:
+  vault_path: "%s"
+  key_file: "%s"
+`
+
+func TestConfigSecretReferences(t *testing.T) {
+	dir := t.TempDir()
+	vaultPath := filepath.Join(dir, "secrets.vault")
+	keyFile := filepath.Join(dir, "vault.key")
+	passwordFile := filepath.Join(dir, "redis_password")
+
+	key, _ := secrets.GenerateKey()
+	if err := secrets.WriteKeyFile(keyFile, key); err != nil {
+		t.Fatalf("写入主密钥失败: %v", err)
+	}
+	vault, _ := secrets.OpenVault(vaultPath, key)
+	_ = vault.Set("jwt_secret_key", "jwt-from-vault")
+	if err := vault.Save(); err != nil {
+		t.Fatalf("保存密钥库失败: %v", err)
+	}
+	_ = os.WriteFile(passwordFile, []byte("redis-from-file\n"), 0o600)
+	t.Setenv("GO_DEMO_TEST_DSN", "app:db-from-env@tcp(localhost:3306)/app")
+
+	writeConfig := func(jwtRef string) string {
+		path := filepath.Join(dir, "config.yaml")
+		content := fmt.Sprintf(secretsConfigTemplate, "env://GO_DEMO_TEST_DSN", jwtRef, "file://"+passwordFile,
+			filepath.Join(dir, "app.log"), vaultPath, keyFile)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("写入配置失败: %v", err)
+		}
+		return path
+	}
+
+	cfg, err := config.Load(writeConfig("vault://jwt_secret_key"))
+	if err != nil {
+		t.Fatalf("加载配置失败: %v", err)
+	}
+	if cfg.Database.DSN != "app:db-from-env@tcp(localhost:3306)/app" ||
+		cfg.JWT.SecretKey != "jwt-from-vault" || cfg.Redis.Password != "redis-from-file" {
+		t.Fatalf("密钥引用解析结果错误: dsn=%q jwt=%q redis=%q", cfg.Database.DSN, cfg.JWT.SecretKey, cfg.Redis.Password)
+	}
+
+	// 脱敏结果中不出现任何解析出的密钥
+	out, _ := json.Marshal(config.Redacted(cfg))
+	for _, secret := range []string{"db-from-env", "jwt-from-vault", "redis-from-file"} {
+		if strings.Contains(string(out), secret) {
+			t.Errorf("脱敏配置中包含密钥 %s: %s", secret, out)
+		}
+	}
+
+	// 引用不存在的条目时加载失败，错误信息包含配置项名称
+	_, err = config.Load(writeConfig("vault://missing"))
+	if err == nil || !strings.Contains(err.Error(), "jwt.secret_key") {
+		t.Fatalf("引用不存在的条目应返回包含配置项名称的错误: %v", err)
+	}
+	if strings.Contains(err.Error(), "db-from-env") {
+		t.Fatalf("错误信息不应包含密钥: %v", err)
+	}
+}
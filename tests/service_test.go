@@ -1,8 +1,10 @@
 package tests
 
 import (
+	"context"
 	"testing"
 
+	"go_demo/internal/events"
 	"go_demo/internal/models"
 	"go_demo/internal/repository"
 	"go_demo/internal/service"
@@ -28,7 +30,8 @@ func TestAuthServiceLayer(t *testing.T) {
 	// 设置测试数据库
 	db := setupTestDB(t)
 	userRepo := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepo)
+	authService := service.NewAuthService(userRepo, repository.NewTxManager(db), events.NewOutboxPublisher(repository.NewOutboxRepository(db)))
+	ctx := context.Background()
 
 	t.Run("用户注册", func(t *testing.T) {
 		req := models.RegisterRequest{
@@ -39,7 +42,7 @@ func TestAuthServiceLayer(t *testing.T) {
 			Mobile:   "13812345678",
 		}
 
-		user, err := authService.Register(nil, req)
+		user, err := authService.Register(ctx, req)
 		if err != nil {
 			t.Fatalf("注册失败: %v", err)
 		}
@@ -67,13 +70,13 @@ func TestAuthServiceLayer(t *testing.T) {
 		}
 
 		// 第一次注册
-		_, err := authService.Register(nil, req)
+		_, err := authService.Register(ctx, req)
 		if err != nil {
 			t.Fatalf("第一次注册失败: %v", err)
 		}
 
 		// 第二次注册应该失败
-		_, err = authService.Register(nil, req)
+		_, err = authService.Register(ctx, req)
 		if err == nil {
 			t.Error("重复注册应该失败")
 		}
@@ -93,7 +96,7 @@ func TestAuthServiceLayer(t *testing.T) {
 			Mobile:   "18666666666",
 		}
 
-		_, err := authService.Register(nil, registerReq)
+		_, err := authService.Register(ctx, registerReq)
 		if err != nil {
 			t.Fatalf("注册失败: %v", err)
 		}
@@ -104,7 +107,7 @@ func TestAuthServiceLayer(t *testing.T) {
 			Password: "123456",
 		}
 
-		response, err := authService.Login(nil, loginReq)
+		response, err := authService.Login(ctx, loginReq)
 		if err != nil {
 			t.Fatalf("登录失败: %v", err)
 		}
@@ -128,7 +131,7 @@ func TestAuthServiceLayer(t *testing.T) {
 			Password: "wrongpassword",
 		}
 
-		_, err := authService.Login(nil, loginReq)
+		_, err := authService.Login(ctx, loginReq)
 		if err == nil {
 			t.Error("错误的登录信息应该失败")
 		}
@@ -157,7 +160,8 @@ func TestUserService(t *testing.T) {
 	// 设置测试数据库
 	db := setupTestDB(t)
 	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
+	userService := service.NewUserService(userRepo, repository.NewTxManager(db), events.NewOutboxPublisher(repository.NewOutboxRepository(db)))
+	ctx := context.Background()
 
 	// 创建测试用户
 	testUser := &models.User{
@@ -171,7 +175,7 @@ func TestUserService(t *testing.T) {
 	db.Create(testUser)
 
 	t.Run("获取用户列表", func(t *testing.T) {
-		users, total, err := userService.GetUsers(1, 10)
+		users, total, err := userService.GetUsers(ctx, 1, 10)
 		if err != nil {
 			t.Fatalf("获取用户列表失败: %v", err)
 		}
@@ -186,7 +190,7 @@ func TestUserService(t *testing.T) {
 	})
 
 	t.Run("根据ID获取用户", func(t *testing.T) {
-		user, err := userService.GetUserByID(int(testUser.ID))
+		user, err := userService.GetUserByID(ctx, int(testUser.ID))
 		if err != nil {
 			t.Fatalf("获取用户失败: %v", err)
 		}
@@ -201,7 +205,7 @@ func TestUserService(t *testing.T) {
 	})
 
 	t.Run("获取不存在的用户", func(t *testing.T) {
-		_, err := userService.GetUserByID(99999)
+		_, err := userService.GetUserByID(ctx, 99999)
 		if err == nil {
 			t.Error("获取不存在的用户应该失败")
 		}
@@ -218,7 +222,7 @@ func TestUserService(t *testing.T) {
 			Email:    "newuser@example.com",
 		}
 
-		user, err := userService.CreateUser(req)
+		user, err := userService.CreateUser(ctx, req)
 		if err != nil {
 			t.Fatalf("创建用户失败: %v", err)
 		}
@@ -243,7 +247,7 @@ func TestUserService(t *testing.T) {
 			Status: &status,
 		}
 
-		user, err := userService.UpdateUser(int(testUser.ID), req)
+		user, err := userService.UpdateUser(ctx, int(testUser.ID), req)
 		if err != nil {
 			t.Fatalf("更新用户失败: %v", err)
 		}
@@ -273,20 +277,20 @@ func TestUserService(t *testing.T) {
 		}
 		db.Create(deleteUser)
 
-		err := userService.DeleteUser(int(deleteUser.ID))
+		err := userService.DeleteUser(ctx, int(deleteUser.ID))
 		if err != nil {
 			t.Fatalf("删除用户失败: %v", err)
 		}
 
 		// 验证用户已被软删除
-		_, err = userService.GetUserByID(int(deleteUser.ID))
+		_, err = userService.GetUserByID(ctx, int(deleteUser.ID))
 		if err == nil {
 			t.Error("删除的用户不应该被找到")
 		}
 	})
 
 	t.Run("获取用户统计", func(t *testing.T) {
-		stats, err := userService.GetUserStats()
+		stats, err := userService.GetUserStats(ctx)
 		if err != nil {
 			t.Fatalf("获取用户统计失败: %v", err)
 		}
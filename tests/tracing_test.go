@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"context"
+	"go_demo/internal/middleware"
+	"go_demo/pkg/logger"
+	"go_demo/pkg/tracing"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// setupSpanRecorder 使用内存记录器替换全局 TracerProvider
+func setupSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	old := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(old)
+		_ = provider.Shutdown(context.Background())
+	})
+	return recorder
+}
+
+func TestTraceparentPropagation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := setupSpanRecorder(t)
+
+	logFile := filepath.Join(t.TempDir(), "app.log")
+	if err := logger.Init(logger.LogConfig{Level: "info", Format: "json", OutputPath: logFile}); err != nil {
+		t.Fatalf("日志初始化失败: %v", err)
+	}
+	defer logger.Init(logger.LogConfig{Level: "error", Format: "console"})
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(middleware.RequestID(), middleware.Trace(), middleware.LogContext())
+	r.GET("/orders/:id", func(c *gin.Context) {
+		// 模拟下游Redis调用
+		hook := tracing.NewRedisHook()
+		cmd := redis.NewStringCmd(c.Request.Context(), "get", "order")
+		ctx, _ := hook.BeforeProcess(c.Request.Context(), cmd)
+		_ = hook.AfterProcess(ctx, cmd)
+
+		logger.InfoCtx(c, "处理订单")
+		c.Status(http.StatusOK)
+	})
+
+	const (
+		traceID  = "4bf92f3577b34da6a3ce929d0e0e4736"
+		parentID = "00f067aa0ba902b7"
+	)
+	req, _ := http.NewRequest(http.MethodGet, "/orders/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	logger.Sync()
+
+	if got := w.Header().Get("X-Trace-ID"); got != traceID {
+		t.Errorf("期望 X-Trace-ID=%s，得到 %s", traceID, got)
+	}
+	if tp := w.Header().Get("traceparent"); !strings.Contains(tp, traceID) {
+		t.Errorf("响应头 traceparent 不正确: %s", tp)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("期望2个span，得到%d个", len(spans))
+	}
+	var server, child sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		if s.SpanKind() == trace.SpanKindServer {
+			server = s
+		} else {
+			child = s
+		}
+	}
+	if server == nil || child == nil {
+		t.Fatalf("缺少服务端span或子span")
+	}
+	if server.Name() != "GET /orders/:id" || server.Parent().SpanID().String() != parentID {
+		t.Errorf("服务端span不正确: name=%s parent=%s", server.Name(), server.Parent().SpanID())
+	}
+	if child.Name() != "redis.get" || child.Parent().SpanID() != server.SpanContext().SpanID() {
+		t.Errorf("Redis子span不正确: name=%s", child.Name())
+	}
+
+	lines := readLines(t, logFile)
+	if len(lines) != 1 || !strings.Contains(lines[0], `"trace_id":"`+traceID+`"`) ||
+		!strings.Contains(lines[0], `"span_id":"`+server.SpanContext().SpanID().String()+`"`) {
+		t.Errorf("日志缺少链路信息: %v", lines)
+	}
+}
+
+func TestTraceFallbackWithoutTraceparent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	if err := tracing.Init(tracing.Config{Enabled: false}); err != nil {
+		t.Fatalf("初始化链路追踪失败: %v", err)
+	}
+
+	r := gin.New()
+	r.Use(middleware.Trace())
+	r.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req, _ := http.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("X-Trace-ID", "legacy-trace")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if got := w.Header().Get("X-Trace-ID"); got != "legacy-trace" {
+		t.Errorf("未启用追踪时应沿用 X-Trace-ID，得到 %s", got)
+	}
+}
+
+func TestTracingFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+	if err := tracing.Init(tracing.Config{Enabled: true, Exporter: "file", FilePath: path}); err != nil {
+		t.Fatalf("初始化链路追踪失败: %v", err)
+	}
+	defer tracing.Init(tracing.Config{Enabled: false})
+
+	_, span := tracing.Tracer().Start(context.Background(), "file-span")
+	traceID := span.SpanContext().TraceID().String()
+	span.End()
+	if err := tracing.Shutdown(context.Background()); err != nil {
+		t.Fatalf("关闭链路追踪失败: %v", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取链路文件失败: %v", err)
+	}
+	if !strings.Contains(string(content), "file-span") || !strings.Contains(string(content), traceID) {
+		t.Errorf("链路文件内容不正确: %s", content)
+	}
+
+	if err := tracing.Init(tracing.Config{Enabled: true, Exporter: "zipkin"}); err == nil {
+		t.Error("期望不支持的导出方式返回错误")
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"testing"
+)
+
+func TestTxManager(t *testing.T) {
+	db := setupSQLiteDB(t)
+	defer cleanupTestDB(t, db)
+	repo := repository.NewUserRepository(db)
+	txManager := repository.NewTxManager(db)
+	ctx := context.Background()
+
+	newUser := func(name, mobile string) *models.User {
+		return &models.User{Username: name, Email: name + "@example.com", Password: "hashed", Mobile: mobile, Status: 1}
+	}
+	exists := func(name string) bool {
+		t.Helper()
+		ok, err := repo.ExistsByUsername(ctx, name)
+		if err != nil {
+			t.Fatalf("查询用户失败: %v", err)
+		}
+		return ok
+	}
+
+	// 提交：事务内的读写使用同一个事务
+	err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, newUser("committed", "13800000001")); err != nil {
+			return err
+		}
+		if _, err := repo.GetByUsername(ctx, "committed"); err != nil {
+			t.Errorf("事务内应能读到未提交的数据: %v", err)
+		}
+		return nil
+	})
+	if err != nil || !exists("committed") {
+		t.Fatalf("事务应提交: %v", err)
+	}
+
+	// 返回错误时回滚
+	errAbort := errors.New("abort")
+	err = txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, newUser("rolledback", "13800000002")); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) || exists("rolledback") {
+		t.Fatalf("返回错误时应回滚: %v", err)
+	}
+
+	// panic 时回滚并继续抛出
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic 应继续抛出")
+			}
+		}()
+		_ = txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			_ = repo.Create(ctx, newUser("panicked", "13800000003"))
+			panic("boom")
+		})
+	}()
+	if exists("panicked") {
+		t.Fatal("panic 时应回滚")
+	}
+
+	// 嵌套事务使用保存点：内层失败只回滚内层
+	err = txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := repo.Create(ctx, newUser("outer", "13800000004")); err != nil {
+			return err
+		}
+		innerErr := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.Create(ctx, newUser("inner", "13800000005")); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		if !errors.Is(innerErr, errAbort) {
+			t.Errorf("内层事务应返回错误: %v", innerErr)
+		}
+		return nil
+	})
+	if err != nil || !exists("outer") || exists("inner") {
+		t.Fatalf("内层失败应只回滚到保存点: %v", err)
+	}
+
+	// 外层失败时内层已完成的写入一起回滚
+	err = txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			return repo.Create(ctx, newUser("nested", "13800000006"))
+		}); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) || exists("nested") {
+		t.Fatalf("外层失败时应回滚全部写入: %v", err)
+	}
+}
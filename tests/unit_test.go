@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"go_demo/internal/models"
 	"go_demo/internal/service"
 	"go_demo/internal/utils"
@@ -48,10 +49,11 @@ func TestAuthService(t *testing.T) {
 		t.Fatalf("Failed to generate test token: %v", err)
 	}
 
-	authService := service.NewAuthService(nil)
+	authService := service.NewAuthService(nil, nil, nil)
+	ctx := context.Background()
 
 	// 测试token验证
-	claims, err := authService.ValidateToken(token)
+	claims, err := authService.ValidateToken(ctx, token)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
@@ -0,0 +1,334 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"go_demo/internal/events"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"go_demo/internal/service"
+	"go_demo/pkg/cache"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// countingUserRepository 统计按 ID 和用户名查询数据库的次数，hold 后按 ID 查询阻塞到 release
+type countingUserRepository struct {
+	repository.UserRepository
+	queries atomic.Int64
+	mu      sync.Mutex
+	gate    chan struct{}
+}
+
+// hold 阻塞之后的按 ID 查询，返回的 release 放行
+func (r *countingUserRepository) hold() (release func()) {
+	gate := make(chan struct{})
+	r.mu.Lock()
+	r.gate = gate
+	r.mu.Unlock()
+	return func() {
+		r.mu.Lock()
+		r.gate = nil
+		r.mu.Unlock()
+		close(gate)
+	}
+}
+
+func (r *countingUserRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
+	r.queries.Add(1)
+	r.mu.Lock()
+	gate := r.gate
+	r.mu.Unlock()
+	if gate != nil {
+		<-gate
+	}
+	return r.UserRepository.GetByID(ctx, id)
+}
+
+func (r *countingUserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
+	r.queries.Add(1)
+	return r.UserRepository.GetByUsername(ctx, username)
+}
+
+func TestCachedUserRepository(t *testing.T) {
+	db := setupSQLiteDB(t)
+	defer cleanupTestDB(t, db)
+	ctx := context.Background()
+
+	inner := &countingUserRepository{UserRepository: repository.NewUserRepository(db)}
+	store := newFakeKVCache()
+	repo := repository.NewCachedUserRepository(inner, store, repository.DefaultUserCacheConfig())
+	txManager := repository.NewTxManager(db)
+
+	create := func(name string) *models.User {
+		t.Helper()
+		user := &models.User{Username: name, Email: name + "@example.com", Password: "hashed", Status: 1}
+		if err := repo.Create(ctx, user); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+		return user
+	}
+	// queried 执行 fn 并返回查询数据库的次数
+	queried := func(fn func()) int64 {
+		before := inner.queries.Load()
+		fn()
+		return inner.queries.Load() - before
+	}
+
+	t.Run("按ID和索引命中缓存", func(t *testing.T) {
+		user := create("cached")
+		if n := queried(func() { _, _ = repo.GetByID(ctx, int(user.ID)) }); n != 1 {
+			t.Fatalf("首次读取应查询数据库一次, got %d", n)
+		}
+		n := queried(func() {
+			got, err := repo.GetByID(ctx, int(user.ID))
+			if err != nil || got.Username != "cached" || got.Password != "" {
+				t.Errorf("缓存的用户不正确，不应包含密码哈希: %+v, %v", got, err)
+			}
+			if got, err := repo.GetByUsername(ctx, "cached"); err != nil || got.ID != user.ID {
+				t.Errorf("按用户名读取失败: %+v, %v", got, err)
+			}
+			if got, err := repo.GetByEmail(ctx, "cached@example.com"); err != nil || got.ID != user.ID {
+				t.Errorf("按邮箱读取失败: %+v, %v", got, err)
+			}
+		})
+		if n != 0 {
+			t.Errorf("缓存命中时不应查询数据库, got %d", n)
+		}
+	})
+
+	t.Run("密码哈希不写入缓存", func(t *testing.T) {
+		user := create("credentials")
+		if got, err := repo.GetByUsername(ctx, "credentials"); err != nil || got.Password != "" {
+			t.Errorf("首次读取也不应返回密码哈希: %+v, %v", got, err)
+		}
+		if got, err := repo.GetByUsername(repository.WithCredentials(ctx), "credentials"); err != nil || got.Password != "hashed" {
+			t.Errorf("WithCredentials 应读取数据库中的密码哈希: %+v, %v", got, err)
+		}
+
+		// 保存从缓存读取的用户时不清空密码
+		cached, err := repo.GetByID(ctx, int(user.ID))
+		if err != nil {
+			t.Fatalf("读取用户失败: %v", err)
+		}
+		cached.Name = "改名"
+		if err := repo.Update(ctx, cached); err != nil {
+			t.Fatalf("更新用户失败: %v", err)
+		}
+		if got, err := repo.GetByID(repository.WithCredentials(ctx), int(user.ID)); err != nil || got.Password != "hashed" || got.Name != "改名" {
+			t.Errorf("更新后密码哈希应保持不变: %+v, %v", got, err)
+		}
+	})
+
+	t.Run("缓存不存在的用户", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			n := queried(func() {
+				if _, err := repo.GetByUsername(ctx, "ghost"); err != gorm.ErrRecordNotFound {
+					t.Errorf("不存在的用户应返回 gorm.ErrRecordNotFound, got %v", err)
+				}
+			})
+			if want := int64(1 - i); n != want {
+				t.Errorf("第 %d 次读取查询数据库 %d 次, want %d", i+1, n, want)
+			}
+		}
+
+		// 创建后删除用户名上的不存在缓存，注册后立即登录能读到用户
+		user := create("ghost")
+		if got, err := repo.GetByUsername(ctx, "ghost"); err != nil || got.ID != user.ID {
+			t.Errorf("创建后应能读到用户: %+v, %v", got, err)
+		}
+	})
+
+	t.Run("写操作后缓存失效", func(t *testing.T) {
+		user := create("writer")
+		if _, err := repo.GetByUsername(ctx, "writer"); err != nil {
+			t.Fatalf("读取用户失败: %v", err)
+		}
+
+		user.Name = "新名字"
+		user.Username = "renamed"
+		if err := repo.Update(ctx, user); err != nil {
+			t.Fatalf("更新用户失败: %v", err)
+		}
+		if got, err := repo.GetByID(ctx, int(user.ID)); err != nil || got.Name != "新名字" {
+			t.Errorf("Update 后应读到新值: %+v, %v", got, err)
+		}
+		if _, err := repo.GetByUsername(ctx, "writer"); err != gorm.ErrRecordNotFound {
+			t.Errorf("旧用户名的索引应失效, got %v", err)
+		}
+		if got, err := repo.GetByUsername(ctx, "renamed"); err != nil || got.ID != user.ID {
+			t.Errorf("应能按新用户名读取: %+v, %v", got, err)
+		}
+
+		if err := repo.UpdateStatus(ctx, int(user.ID), 0); err != nil {
+			t.Fatalf("更新状态失败: %v", err)
+		}
+		if got, _ := repo.GetByID(ctx, int(user.ID)); got == nil || got.Status != 0 {
+			t.Errorf("UpdateStatus 后应读到新状态: %+v", got)
+		}
+
+		other := create("batch")
+		_, _ = repo.GetByID(ctx, int(other.ID))
+		if err := repo.BatchUpdateStatus(ctx, []int{int(user.ID), int(other.ID)}, 1); err != nil {
+			t.Fatalf("批量更新状态失败: %v", err)
+		}
+		for _, id := range []uint{user.ID, other.ID} {
+			if got, _ := repo.GetByID(ctx, int(id)); got == nil || got.Status != 1 {
+				t.Errorf("BatchUpdateStatus 后应读到新状态: %+v", got)
+			}
+		}
+
+		if err := repo.Delete(ctx, int(user.ID)); err != nil {
+			t.Fatalf("删除用户失败: %v", err)
+		}
+		if _, err := repo.GetByID(ctx, int(user.ID)); err != gorm.ErrRecordNotFound {
+			t.Errorf("删除后按ID读取应返回 gorm.ErrRecordNotFound, got %v", err)
+		}
+		if _, err := repo.GetByUsername(ctx, "renamed"); err != gorm.ErrRecordNotFound {
+			t.Errorf("删除后按用户名读取应返回 gorm.ErrRecordNotFound, got %v", err)
+		}
+	})
+
+	t.Run("事务内不使用缓存并在提交后失效", func(t *testing.T) {
+		user := create("tx")
+		_, _ = repo.GetByID(ctx, int(user.ID))
+
+		err := txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.UpdateStatus(ctx, int(user.ID), 0); err != nil {
+				return err
+			}
+			n := queried(func() {
+				if got, err := repo.GetByID(ctx, int(user.ID)); err != nil || got.Status != 0 {
+					t.Errorf("事务内应读到未提交的状态: %+v, %v", got, err)
+				}
+			})
+			if n != 1 {
+				t.Errorf("事务内读取应查询数据库, got %d", n)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("事务执行失败: %v", err)
+		}
+		if got, _ := repo.GetByID(ctx, int(user.ID)); got == nil || got.Status != 0 {
+			t.Errorf("提交后应读到新状态: %+v", got)
+		}
+
+		rollback := errors.New("rollback")
+		err = txManager.WithinTransaction(ctx, func(ctx context.Context) error {
+			if err := repo.UpdateStatus(ctx, int(user.ID), 1); err != nil {
+				return err
+			}
+			return rollback
+		})
+		if !errors.Is(err, rollback) {
+			t.Fatalf("应返回回调的错误, got %v", err)
+		}
+		if got, _ := repo.GetByID(ctx, int(user.ID)); got == nil || got.Status != 0 {
+			t.Errorf("回滚后应读到原状态: %+v", got)
+		}
+	})
+
+	t.Run("更新用户不使用缓存中的旧数据", func(t *testing.T) {
+		user := create("stale")
+		_, _ = repo.GetByID(ctx, int(user.ID))
+		// 绕过缓存修改状态，模拟缓存中的数据已过期
+		if err := db.Model(&models.User{}).Where("id = ?", user.ID).Update("status", 0).Error; err != nil {
+			t.Fatalf("修改状态失败: %v", err)
+		}
+
+		userService := service.NewUserService(repo, txManager, events.NewOutboxPublisher(repository.NewOutboxRepository(db)))
+		if _, err := userService.UpdateUserProfile(ctx, int(user.ID), models.UserProfileUpdateRequest{Name: "profile"}); err != nil {
+			t.Fatalf("更新用户资料失败: %v", err)
+		}
+		if _, err := userService.UpdateUser(ctx, int(user.ID), models.UpdateUserRequest{Name: "renamed"}); err != nil {
+			t.Fatalf("更新用户失败: %v", err)
+		}
+
+		var got models.User
+		if err := db.First(&got, user.ID).Error; err != nil {
+			t.Fatalf("查询用户失败: %v", err)
+		}
+		if got.Status != 0 || got.Name != "renamed" {
+			t.Errorf("不应用缓存中的旧状态覆盖数据库: %+v", got)
+		}
+	})
+
+	t.Run("并发未命中只查询一次数据库", func(t *testing.T) {
+		user := create("stampede")
+		release := inner.hold()
+
+		var wg sync.WaitGroup
+		n := queried(func() {
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if got, err := repo.GetByID(ctx, int(user.ID)); err != nil || got.ID != user.ID {
+						t.Errorf("并发读取失败: %+v, %v", got, err)
+					}
+				}()
+			}
+			// 等待并发请求都在等待同一次查询
+			time.Sleep(50 * time.Millisecond)
+			release()
+			wg.Wait()
+		})
+		if n != 1 {
+			t.Errorf("并发未命中应只查询一次数据库, got %d", n)
+		}
+	})
+
+	t.Run("调用方取消时返回", func(t *testing.T) {
+		user := create("cancelled")
+		release := inner.hold()
+		defer release()
+
+		cctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+		defer cancel()
+		if _, err := repo.GetByID(cctx, int(user.ID)); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("调用方超时应返回 context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
+
+func TestCachedUserRepositoryCodecs(t *testing.T) {
+	for _, codec := range []cache.Codec{cache.JSON, cache.Msgpack, cache.Gob} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			db := setupSQLiteDB(t)
+			defer cleanupTestDB(t, db)
+			ctx := context.Background()
+
+			inner := &countingUserRepository{UserRepository: repository.NewUserRepository(db)}
+			repo := repository.NewCachedUserRepository(inner, cache.NewMemoryCache(0, codec), repository.DefaultUserCacheConfig())
+
+			now := time.Now().Truncate(time.Second)
+			user := &models.User{Username: "codec", Email: "codec@example.com", Password: "hashed", Status: 1, LastLogin: &now}
+			if err := repo.Create(ctx, user); err != nil {
+				t.Fatalf("创建用户失败: %v", err)
+			}
+			_, _ = repo.GetByUsername(ctx, "codec")
+			_, _ = repo.GetByID(ctx, 999)
+			_, _ = repo.GetByEmail(ctx, "ghost@example.com")
+
+			before := inner.queries.Load()
+			got, err := repo.GetByUsername(ctx, "codec")
+			if err != nil || got.ID != user.ID || got.Password != "" || got.LastLogin == nil || !got.LastLogin.Equal(now) {
+				t.Errorf("缓存的用户不正确: %+v, %v", got, err)
+			}
+			if _, err := repo.GetByID(ctx, 999); err != gorm.ErrRecordNotFound {
+				t.Errorf("不存在的用户应返回 gorm.ErrRecordNotFound, got %v", err)
+			}
+			if _, err := repo.GetByEmail(ctx, "ghost@example.com"); err != gorm.ErrRecordNotFound {
+				t.Errorf("不存在的邮箱应返回 gorm.ErrRecordNotFound, got %v", err)
+			}
+			if n := inner.queries.Load() - before; n != 0 {
+				t.Errorf("缓存命中时不应查询数据库, got %d", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"go_demo/internal/events"
+	"go_demo/internal/middleware"
+	"go_demo/internal/models"
+	"go_demo/internal/repository"
+	"go_demo/internal/service"
+	"go_demo/internal/utils"
+	"go_demo/internal/webhook"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":1,"type":"user.registered"}`)
+	now := time.Now().Unix()
+	ts := strconv.FormatInt(now, 10)
+	signature := webhook.Sign("secret-0123456789", now, body)
+
+	if err := webhook.Verify("secret-0123456789", ts, signature, body, 5*time.Minute); err != nil {
+		t.Fatalf("签名应校验通过: %v", err)
+	}
+	if err := webhook.Verify("other-secret-0123", ts, signature, body, 5*time.Minute); !errors.Is(err, webhook.ErrInvalidSignature) {
+		t.Errorf("密钥不同时应校验失败: %v", err)
+	}
+	if err := webhook.Verify("secret-0123456789", ts, signature, []byte(`{"id":2}`), 5*time.Minute); !errors.Is(err, webhook.ErrInvalidSignature) {
+		t.Errorf("请求体被修改时应校验失败: %v", err)
+	}
+	old := now - 3600
+	if err := webhook.Verify("secret-0123456789", strconv.FormatInt(old, 10), webhook.Sign("secret-0123456789", old, body), body, 5*time.Minute); !errors.Is(err, webhook.ErrExpiredTimestamp) {
+		t.Errorf("时间戳过期时应校验失败: %v", err)
+	}
+}
+
+// webhookReceiver 本地 Webhook 接收方，校验签名并记录收到的请求
+type webhookReceiver struct {
+	*httptest.Server
+	mu       sync.Mutex
+	secret   string
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	r := &webhookReceiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if err := webhook.Verify(r.secret, req.Header.Get(webhook.HeaderTimestamp), req.Header.Get(webhook.HeaderSignature), body, time.Minute); err != nil {
+			t.Errorf("签名校验失败: %v", err)
+		}
+		r.requests = append(r.requests, req)
+		r.bodies = append(r.bodies, body)
+		w.WriteHeader(r.status)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// setStatus 设置接收方返回的状态码
+func (r *webhookReceiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+// count 收到的请求数
+func (r *webhookReceiver) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	db := setupSQLiteDB(t)
+	defer cleanupTestDB(t, db)
+	defer db.Exec("DELETE FROM webhook_subscriptions")
+	defer db.Exec("DELETE FROM webhook_deliveries")
+	defer db.Exec("DELETE FROM webhook_attempts")
+	ctx := context.Background()
+
+	repo := repository.NewWebhookRepository(db)
+	config := webhook.DefaultConfig()
+	config.RetryBackoff = 0
+	config.MaxAttempts = 3
+	config.AllowPrivateTargets = true // 接收方监听在 127.0.0.1
+	deliverer := webhook.NewDeliverer(repo, config)
+	webhookService := service.NewWebhookService(repo, deliverer)
+
+	outbox := repository.NewOutboxRepository(db)
+	dispatcher := events.NewDispatcher(outbox, nil, events.DefaultConfig())
+	dispatcher.Subscribe(events.AllEvents, "webhooks", deliverer.HandleEvent)
+	publish := func(t *testing.T, event events.Event) {
+		t.Helper()
+		if err := events.NewOutboxPublisher(outbox).Publish(ctx, event); err != nil {
+			t.Fatalf("发布事件失败: %v", err)
+		}
+		if _, err := dispatcher.DispatchOnce(ctx); err != nil {
+			t.Fatalf("分发事件失败: %v", err)
+		}
+	}
+
+	receiver := newWebhookReceiver(t)
+	created, err := webhookService.CreateWebhook(ctx, models.WebhookCreateRequest{
+		URL:        receiver.URL + "/hooks",
+		EventTypes: []string{events.TypeUserRegistered, events.TypeUserDeleted},
+	})
+	if err != nil {
+		t.Fatalf("创建订阅失败: %v", err)
+	}
+	if created.Secret == "" {
+		t.Fatal("未指定密钥时应生成并返回密钥")
+	}
+	receiver.secret = created.Secret
+	if got, _ := webhookService.GetWebhook(ctx, created.ID); got.Secret != "" {
+		t.Error("查询订阅时不应返回密钥")
+	}
+
+	// 停用的订阅和未订阅该事件的订阅不生成投递
+	disabled := false
+	if _, err := webhookService.CreateWebhook(ctx, models.WebhookCreateRequest{URL: receiver.URL, EventTypes: []string{"*"}, Enabled: &disabled}); err != nil {
+		t.Fatalf("创建订阅失败: %v", err)
+	}
+	if _, err := webhookService.CreateWebhook(ctx, models.WebhookCreateRequest{URL: receiver.URL, EventTypes: []string{events.TypeUserLoggedIn}}); err != nil {
+		t.Fatalf("创建订阅失败: %v", err)
+	}
+
+	t.Run("校验订阅参数", func(t *testing.T) {
+		if _, err := webhookService.CreateWebhook(ctx, models.WebhookCreateRequest{URL: "ftp://example.com", EventTypes: []string{"*"}}); err == nil {
+			t.Error("非 http 地址应被拒绝")
+		}
+		if _, err := webhookService.CreateWebhook(ctx, models.WebhookCreateRequest{URL: receiver.URL, EventTypes: []string{"user.unknown"}}); err == nil {
+			t.Error("未知的事件类型应被拒绝")
+		}
+	})
+
+	t.Run("签名投递", func(t *testing.T) {
+		publish(t, events.UserRegistered{UserEvent: events.UserEvent{UserID: 7}, Username: "hook"})
+		// 事件重复投递给订阅者时不重复生成投递记录
+		if err := deliverer.HandleEvent(ctx, events.Message{ID: 1, Type: events.TypeUserRegistered}); err != nil {
+			t.Fatalf("处理重复事件失败: %v", err)
+		}
+
+		if n, err := deliverer.DeliverOnce(ctx); err != nil || n != 1 {
+			t.Fatalf("期望发送1个请求: %d, %v", n, err)
+		}
+		if receiver.count() != 1 {
+			t.Fatalf("接收方应收到1个请求, 实际 %d", receiver.count())
+		}
+		req := receiver.requests[0]
+		if req.URL.Path != "/hooks" || req.Header.Get(webhook.HeaderEvent) != events.TypeUserRegistered || req.Header.Get(webhook.HeaderEventID) != "1" {
+			t.Errorf("请求不正确: %s %v", req.URL.Path, req.Header)
+		}
+
+		deliveries, total, err := webhookService.ListDeliveries(ctx, created.ID, "", 1, 10)
+		if err != nil || total != 1 {
+			t.Fatalf("期望1条投递记录: %d, %v", total, err)
+		}
+		detail, err := webhookService.GetDelivery(ctx, created.ID, deliveries[0].ID)
+		if err != nil {
+			t.Fatalf("获取投递详情失败: %v", err)
+		}
+		if detail.Status != models.WebhookDeliverySucceeded || detail.LastStatusCode != http.StatusOK || len(detail.AttemptLogs) != 1 {
+			t.Errorf("投递应成功并记录日志: %+v", detail)
+		}
+		if detail.Payload != string(receiver.bodies[0]) || detail.AttemptLogs[0].ResponseBody != "ok" {
+			t.Errorf("投递日志内容不正确: %+v", detail)
+		}
+		// 已成功的投递不再发送
+		if n, _ := deliverer.DeliverOnce(ctx); n != 0 {
+			t.Errorf("已成功的投递不应重复发送")
+		}
+	})
+
+	t.Run("失败重试后进入死信并手动重新投递", func(t *testing.T) {
+		receiver.setStatus(http.StatusInternalServerError)
+		before := receiver.count()
+		publish(t, events.UserDeleted{UserEvent: events.UserEvent{UserID: 7}, Username: "hook"})
+
+		for i := 0; i < config.MaxAttempts+1; i++ {
+			if _, err := deliverer.DeliverOnce(ctx); err != nil {
+				t.Fatalf("投递失败: %v", err)
+			}
+		}
+		if got := receiver.count() - before; got != config.MaxAttempts {
+			t.Fatalf("期望请求 %d 次后停止, 实际 %d", config.MaxAttempts, got)
+		}
+		dead, total, err := webhookService.ListDeliveries(ctx, created.ID, models.WebhookDeliveryDead, 1, 10)
+		if err != nil || total != 1 {
+			t.Fatalf("期望1条死信: %d, %v", total, err)
+		}
+		if dead[0].Attempts != config.MaxAttempts || dead[0].LastStatusCode != http.StatusInternalServerError || dead[0].LastError == "" {
+			t.Errorf("死信记录不正确: %+v", dead[0])
+		}
+
+		receiver.setStatus(http.StatusNoContent)
+		detail, err := webhookService.Redeliver(ctx, created.ID, dead[0].ID)
+		if err != nil {
+			t.Fatalf("重新投递失败: %v", err)
+		}
+		if detail.Status != models.WebhookDeliverySucceeded || detail.Attempts != 1 {
+			t.Errorf("重新投递应成功: %+v", detail)
+		}
+		logs := detail.AttemptLogs
+		if len(logs) != config.MaxAttempts+1 || logs[len(logs)-1].TriggeredBy != models.WebhookTriggerManual || logs[0].TriggeredBy != models.WebhookTriggerAuto {
+			t.Errorf("投递日志应记录每次请求: %+v", logs)
+		}
+
+		if _, err := webhookService.Redeliver(ctx, created.ID, 99999); err == nil {
+			t.Error("不存在的投递记录应返回错误")
+		}
+	})
+
+	t.Run("删除订阅后未完成的投递进入死信", func(t *testing.T) {
+		receiver.setStatus(http.StatusBadGateway)
+		publish(t, events.UserRegistered{UserEvent: events.UserEvent{UserID: 8}})
+		if _, err := deliverer.DeliverOnce(ctx); err != nil {
+			t.Fatalf("投递失败: %v", err)
+		}
+		if err := webhookService.DeleteWebhook(ctx, created.ID); err != nil {
+			t.Fatalf("删除订阅失败: %v", err)
+		}
+		before := receiver.count()
+		if _, err := deliverer.DeliverOnce(ctx); err != nil {
+			t.Fatalf("投递失败: %v", err)
+		}
+		if receiver.count() != before {
+			t.Error("订阅删除后不应继续发送")
+		}
+		var pending int64
+		db.Model(&models.WebhookDelivery{}).Where("status = ?", models.WebhookDeliveryPending).Count(&pending)
+		if pending != 0 {
+			t.Errorf("不应留下待投递记录, 实际 %d", pending)
+		}
+	})
+}
+
+func TestWebhookPrivateTargets(t *testing.T) {
+	db := setupSQLiteDB(t)
+	defer cleanupTestDB(t, db)
+	defer db.Exec("DELETE FROM webhook_subscriptions")
+	defer db.Exec("DELETE FROM webhook_deliveries")
+	defer db.Exec("DELETE FROM webhook_attempts")
+	ctx := context.Background()
+
+	repo := repository.NewWebhookRepository(db)
+	deliverer := webhook.NewDeliverer(repo, webhook.DefaultConfig())
+	webhookService := service.NewWebhookService(repo, deliverer)
+
+	t.Run("创建订阅时拒绝内网地址", func(t *testing.T) {
+		for _, u := range []string{
+			"http://127.0.0.1:6060/config",
+			"http://localhost/hooks",
+			"http://[::1]/hooks",
+			"http://10.0.0.1/hooks",
+			"http://192.168.1.1/hooks",
+			"http://169.254.169.254/latest/meta-data/",
+			"http://0.0.0.0/hooks",
+			"http://[::ffff:127.0.0.1]/hooks",
+		} {
+			if err := deliverer.CheckURL(ctx, u); !errors.Is(err, webhook.ErrForbiddenTarget) {
+				t.Errorf("%s 应被拒绝: %v", u, err)
+			}
+			if _, err := webhookService.CreateWebhook(ctx, models.WebhookCreateRequest{URL: u, EventTypes: []string{"*"}}); err == nil {
+				t.Errorf("%s 不应创建成功", u)
+			}
+		}
+		if err := deliverer.CheckURL(ctx, "https://93.184.216.34/hooks"); err != nil {
+			t.Errorf("公网地址应允许: %v", err)
+		}
+	})
+
+	t.Run("投递时拒绝连接内网地址", func(t *testing.T) {
+		// 模拟校验后域名被解析到内网地址（DNS 重绑定）
+		receiver := newWebhookReceiver(t)
+		sub := &models.WebhookSubscription{URL: receiver.URL, Secret: "secret-0123456789", EventTypes: "*", Enabled: true}
+		if err := repo.CreateSubscription(ctx, sub); err != nil {
+			t.Fatalf("创建订阅失败: %v", err)
+		}
+		if err := deliverer.HandleEvent(ctx, events.Message{ID: 1, Type: events.TypeUserRegistered, Payload: []byte(`{}`)}); err != nil {
+			t.Fatalf("生成投递记录失败: %v", err)
+		}
+		if _, err := deliverer.DeliverOnce(ctx); err != nil {
+			t.Fatalf("投递失败: %v", err)
+		}
+		if receiver.count() != 0 {
+			t.Fatal("不应向内网地址发送请求")
+		}
+		deliveries, _, err := webhookService.ListDeliveries(ctx, sub.ID, "", 1, 10)
+		if err != nil || len(deliveries) != 1 {
+			t.Fatalf("期望1条投递记录: %v", err)
+		}
+		if !strings.Contains(deliveries[0].LastError, webhook.ErrForbiddenTarget.Error()) {
+			t.Errorf("投递失败原因不正确: %q", deliveries[0].LastError)
+		}
+	})
+}
+
+func TestWebhookRoutesRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	utils.InitJWT(utils.JWTConfig{SecretKey: "test-secret-key", AccessExpire: 3600, RefreshExpire: 3600, Issuer: "go_demo_test"})
+	defer middleware.SetAdminUsers(nil)
+
+	r := gin.New()
+	r.GET("/webhooks", middleware.JWTAuthMiddleware(), middleware.AdminOnly(), func(c *gin.Context) { c.Status(http.StatusOK) })
+	request := func(username string) int {
+		token, err := utils.GenerateAccessToken(1, username)
+		if err != nil {
+			t.Fatalf("生成token失败: %v", err)
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/webhooks", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// 未配置管理员时任何用户都不能管理订阅
+	if code := request("admin"); code != http.StatusForbidden {
+		t.Errorf("未配置管理员时应拒绝访问: %d", code)
+	}
+
+	middleware.SetAdminUsers([]string{"admin"})
+	if code := request("admin"); code != http.StatusOK {
+		t.Errorf("管理员应可以访问: %d", code)
+	}
+	if code := request("someone"); code != http.StatusForbidden {
+		t.Errorf("普通用户应被拒绝: %d", code)
+	}
+}